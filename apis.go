@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"os"
+)
+
+// APIs reports, for each type given by -from, what a value of it takes to be passed to each of
+// the common standard library APIs in conversions.APIs, e.g. that an int64 has to be narrowed
+// to int for make([]byte, n), but not converted at all for strconv.FormatInt.
+func APIs(ctx context.Context, args []string) error {
+	var fromList string
+	var asJSON bool
+	fs := flag.NewFlagSet("apis", flag.ContinueOnError)
+	fs.StringVar(&fromList, "from", "", "comma separated primitives to pass to the APIs, defaults to every type analyzed")
+	fs.BoolVar(&asJSON, "json", false, "write the conversions as JSON rather than logging them")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+	froms := opts.WithDefaults().Types
+	if fromList != "" {
+		froms = splitList(fromList)
+	}
+	for _, from := range froms {
+		if _, ok := conversions.Lookup(from); !ok {
+			return errors.Errorf("%q isn't a primitive", from)
+		}
+	}
+
+	apis, err := conversions.ResolveAPIs(conversions.APIs)
+	if err != nil {
+		return errors.Wrap(err, "resolving APIs")
+	}
+
+	// NOTE: Only the conversions from each of froms to the primitives the APIs take are needed.
+	opts.Types = append([]string(nil), froms...)
+	for _, api := range apis {
+		opts.Types = append(opts.Types, api.Primitive)
+	}
+	opts.Types = conversions.Dedupe(opts.Types)
+	m, err := conversions.Analyze(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+	acs, err := conversions.APIConversions(m, apis, froms)
+	if err != nil {
+		return errors.Wrap(err, "working out API conversions")
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(acs)
+		if err != nil {
+			return errors.Wrap(err, "encoding API conversions")
+		}
+		return nil
+	}
+
+	for _, from := range froms {
+		logrus.Infof("passing %s values:", from)
+		for _, ac := range acs {
+			if ac.From != from {
+				continue
+			}
+			switch {
+			case !ac.Needed:
+				logrus.Infof("   ✅ %s: %s is %s already", ac.Call, ac.Param, ac.Type)
+			case !ac.Convertible:
+				logrus.Errorf("   ❌ %s: %s doesn't convert to %s", ac.Call, from, ac.Type)
+			case ac.Exact:
+				logrus.Infof("   ✅ %s: %s(%s) always preserves the value", ac.Call, ac.Type, ac.Param)
+			case ac.Width != "":
+				logrus.Warnf("   ⚠️ %s: %s(%s) compiles but is %s, and may change the value", ac.Call, ac.Type, ac.Param, ac.Width)
+			default:
+				logrus.Warnf("   ⚠️ %s: %s(%s) compiles but may change the value", ac.Call, ac.Type, ac.Param)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"strconv"
+)
+
+// Arrays analyzes fixed-size arrays of every primitive, in each of the lengths given by
+// -lengths, against each other and reports the results, followed by a summary of how many
+// conversions the array lengths and element types each rule out.
+func Arrays(ctx context.Context, args []string) error {
+	var lengthList string
+	fs := flag.NewFlagSet("arrays", flag.ContinueOnError)
+	fs.StringVar(&lengthList, "lengths", "2,4", "comma separated array lengths to check")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	var lengths []int
+	for _, s := range splitList(lengthList) {
+		length, err := strconv.Atoi(s)
+		if err != nil {
+			return errors.Wrapf(err, "parsing array length %q", s)
+		}
+		lengths = append(lengths, length)
+	}
+
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	m, err := conversions.AnalyzeArrays(ctx, opts, lengths)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+
+	p, err := ProvenanceFor(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "determining provenance")
+	}
+
+	var ropts ReportOptions
+	ropts.Provenance = p
+	ropts.Format = *reportFormat
+	err = Report(ctx, m, ropts)
+	if err != nil {
+		return errors.Wrap(err, "reporting results")
+	}
+
+	var convertible, length, elem, aliases int
+	for _, result := range m.Results {
+		switch {
+		case errors.Is(result.Err, conversions.ErrArrayLength):
+			length++
+		case errors.Is(result.Err, conversions.ErrArrayElem):
+			elem++
+		case result.From != result.To:
+			convertible++
+			aliases++
+		default:
+			convertible++
+		}
+	}
+	logrus.Infof("%d of %d array conversions compile, %d only between an element type and its alias, e.g. [2]byte -> [2]uint8", convertible, len(m.Results), aliases)
+	logrus.Infof("%d are ruled out by their lengths differing, and %d more by their element types not being identical, even where the elements alone would convert", length, elem)
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/Insulince/go-conversions/assertions"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Assertions analyzes every primitive against every other primitive and generates a package of
+// compile-time assertions that break the build of whatever project embeds it if a conversion it
+// depends on becomes illegal, or a platform dependent width it relies on changes.
+func Assertions(ctx context.Context, args []string) error {
+	var aopts assertions.Options
+	var pairs string
+	fs := flag.NewFlagSet("assertions", flag.ContinueOnError)
+	fs.StringVar(&aopts.OutputDir, "out", assertions.DefaultOutputDir, "directory to write the generated package to")
+	fs.StringVar(&aopts.Package, "package", assertions.DefaultPackage, "name of the generated package")
+	fs.StringVar(&pairs, "pairs", "", "comma separated conversions to assert, e.g. int64->int32,uint8->int (default every convertible pair)")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+	aopts.Pairs = splitList(pairs)
+
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	m, err := conversions.Analyze(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+
+	err = assertions.Generate(ctx, m, aopts)
+	if err != nil {
+		return errors.Wrap(err, "generating assertions")
+	}
+
+	logrus.Infof("generated assertions in %s", aopts.OutputDir)
+
+	return nil
+}
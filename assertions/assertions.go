@@ -0,0 +1,187 @@
+// Package assertions generates a file of compile-time assertions, for embedding in another
+// project, which break that project's build if a conversion it depends on ever becomes illegal
+// or the width of a platform dependent type it relies on changes.
+package assertions
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/template"
+	"time"
+	"unsafe"
+)
+
+const (
+	// DefaultPackage is the name of the generated package when Options.Package is not set.
+	DefaultPackage = "convassert"
+	// DefaultOutputDir is the directory the generated package is written to when Options.OutputDir is not set.
+	DefaultOutputDir = "./convassert"
+)
+
+type (
+	// Options configures the generated assertions.
+	Options struct {
+		// Package is the name of the generated package. Defaults to DefaultPackage.
+		Package string
+		// OutputDir is the directory the generated package is written to. Defaults to DefaultOutputDir.
+		OutputDir string
+		// Pairs are the conversions to assert, in the form from->to. Defaults to every pair of
+		// distinct types the Matrix reports as convertible and go vet accepts.
+		Pairs []string
+	}
+
+	// Pair is a conversion asserted to be legal.
+	Pair struct {
+		From string
+		To   string
+	}
+
+	// Width is a platform dependent type asserted to keep its current width.
+	Width struct {
+		Name string
+		Bits int
+	}
+)
+
+var (
+	// AssertionsTemplate is the template the assertions are generated from.
+	//go:embed template/assertions.tmpl
+	AssertionsTemplate string
+)
+
+// Generate writes the assertions for opts.Pairs, as reported convertible by m, along with the
+// widths of every platform dependent type involved in them, to opts.OutputDir. It is an error
+// to assert a pair m doesn't report as convertible.
+func Generate(_ context.Context, m conversions.Matrix, opts Options) error {
+	opts = opts.WithDefaults()
+
+	pairs, err := Pairs(m, opts.Pairs)
+	if err != nil {
+		return errors.Wrap(err, "selecting pairs")
+	}
+
+	type Data struct {
+		Now     string
+		App     string
+		Package string
+		Pairs   []Pair
+		Widths  []Width
+	}
+	var data Data
+	data.Now = time.Now().Format(time.RFC3339)
+	data.App = os.Args[0]
+	data.Package = opts.Package
+	data.Pairs = pairs
+	data.Widths = Widths(pairs)
+
+	t, err := template.New("assertions.tmpl").Parse(AssertionsTemplate)
+	if err != nil {
+		return errors.Wrap(err, "parsing template")
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, data)
+	if err != nil {
+		return errors.Wrap(err, "executing template")
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "formatting generated code")
+	}
+
+	outputFile := filepath.Join(opts.OutputDir, "assertions.go")
+	err = conversions.WriteFileAtomic(outputFile, src, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "writing %q", outputFile)
+	}
+
+	return nil
+}
+
+// Pairs returns the Pair for every pair in selected, each of the form from->to, or for every
+// pair of distinct types m reports as convertible and go vet accepts when selected is empty.
+// Assertions have to pass go vet, or they'd break the build of a project that runs it.
+func Pairs(m conversions.Matrix, selected []string) ([]Pair, error) {
+	var pairs []Pair
+	if len(selected) == 0 {
+		for _, result := range m.Results {
+			if result.Convertible && result.From != result.To && !vetRejects(result.From, result.To) {
+				pairs = append(pairs, Pair{From: result.From, To: result.To})
+			}
+		}
+		return pairs, nil
+	}
+
+	for _, s := range selected {
+		from, to, err := conversions.ParsePair(s)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing pair")
+		}
+		result, ok := m.Result(from, to)
+		if !ok {
+			return nil, errors.Errorf("pair %s was not analyzed", s)
+		}
+		if !result.Convertible {
+			return nil, errors.Wrapf(result.Err, "pair %s can't be asserted", s)
+		}
+		if vetRejects(from, to) {
+			return nil, errors.Errorf("pair %s can't be asserted, go vet rejects converting integers other than rune and byte to strings", s)
+		}
+		pairs = append(pairs, Pair{From: from, To: to})
+	}
+	return pairs, nil
+}
+
+// vetRejects reports whether go vet's stringintconv check rejects converting from to to, which
+// it does for every integer type but rune and byte.
+func vetRejects(from, to string) bool {
+	fromInfo, ok := conversions.Lookup(from)
+	if !ok || !fromInfo.IsInteger() {
+		return false
+	}
+	toInfo, ok := conversions.Lookup(to)
+	if !ok || toInfo.Kind != conversions.KindString {
+		return false
+	}
+	canonical := fromInfo.Canonical()
+	return canonical != "int32" && canonical != "uint8"
+}
+
+// Widths returns the current Width of every platform dependent type involved in pairs.
+func Widths(pairs []Pair) []Width {
+	var widths []Width
+	seen := make(map[string]bool)
+	for _, p := range pairs {
+		for _, name := range []string{p.From, p.To} {
+			info, ok := conversions.Lookup(name)
+			if !ok || !info.IsNumeric() || info.Bits != 0 || seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			bits := strconv.IntSize
+			if name == "uintptr" {
+				bits = int(unsafe.Sizeof(uintptr(0))) * 8
+			}
+			widths = append(widths, Width{Name: name, Bits: bits})
+		}
+	}
+	return widths
+}
+
+// WithDefaults returns a copy of opts with every unset field given its default value.
+func (opts Options) WithDefaults() Options {
+	if opts.Package == "" {
+		opts.Package = DefaultPackage
+	}
+	if opts.OutputDir == "" {
+		opts.OutputDir = DefaultOutputDir
+	}
+	return opts
+}
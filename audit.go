@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// AuditLog logs the findings of an audit.
+	AuditLog = "log"
+	// AuditRDJSON writes the findings of an audit to stdout in reviewdog's RDJSON format, so
+	// they can be posted as review comments.
+	AuditRDJSON = "rdjson"
+)
+
+type (
+	// RDJSONResult is a set of diagnostics in reviewdog's RDJSON format.
+	RDJSONResult struct {
+		Source      RDJSONSource       `json:"source"`
+		Severity    string             `json:"severity,omitempty"`
+		Diagnostics []RDJSONDiagnostic `json:"diagnostics"`
+	}
+
+	// RDJSONSource names the tool the diagnostics come from.
+	RDJSONSource struct {
+		Name string `json:"name"`
+		URL  string `json:"url,omitempty"`
+	}
+
+	// RDJSONDiagnostic is a single finding, attached to a range of a file.
+	RDJSONDiagnostic struct {
+		Message  string         `json:"message"`
+		Location RDJSONLocation `json:"location"`
+		Severity string         `json:"severity,omitempty"`
+		Code     *RDJSONCode    `json:"code,omitempty"`
+	}
+
+	// RDJSONLocation is a range of a file, relative to where reviewdog is run.
+	RDJSONLocation struct {
+		Path  string      `json:"path"`
+		Range RDJSONRange `json:"range"`
+	}
+
+	// RDJSONRange is where a diagnostic starts and ends.
+	RDJSONRange struct {
+		Start RDJSONPosition `json:"start"`
+		End   RDJSONPosition `json:"end"`
+	}
+
+	// RDJSONPosition is a 1-based line and column, in bytes.
+	RDJSONPosition struct {
+		Line   int `json:"line"`
+		Column int `json:"column,omitempty"`
+	}
+
+	// RDJSONCode identifies the kind of a diagnostic.
+	RDJSONCode struct {
+		Value string `json:"value"`
+		URL   string `json:"url,omitempty"`
+	}
+)
+
+// Audit reports every numeric conversion in a user package which can lose information,
+// leaving out those the value domain analysis proves are guarded by a bounds check, at the
+// severity the config's Severities give it, and fails if any of them is an error. Given more
+// than one package, or a pattern like ./..., it also ranks the packages by how densely they
+// convert lossily, and with -rollup writes the ranking to an HTML or JSON dashboard.
+func Audit(_ context.Context, args []string) error {
+	var showProven bool
+	var format, benchFile, rollupFile string
+	var copts conversions.CostOptions
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	fs.BoolVar(&showProven, "show-proven", false, "also list conversions proven safe by a bounds check")
+	fs.StringVar(&format, "format", AuditLog, fmt.Sprintf("one of %s or %s", AuditLog, AuditRDJSON))
+	fs.StringVar(&benchFile, "bench", "", "go test -bench output to estimate what the conversions in each file cost from")
+	fs.Float64Var(&copts.LoopWeight, "loop-weight", conversions.DefaultLoopWeight, "how many times more a conversion in a loop is assumed to run than the code around it, with -bench")
+	fs.StringVar(&rollupFile, "rollup", "", "file to write every package ranked by lossy conversions per thousand lines to, as a dashboard if it ends in .html, or as .json")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	dirs, err := packageDirs(patterns)
+	if err != nil {
+		return errors.Wrap(err, "finding packages")
+	}
+	aggregate := len(patterns) > 1 || strings.HasSuffix(patterns[0], "/...")
+
+	c := config
+	err = c.Severities.Validate()
+	if err != nil {
+		return errors.Wrap(err, "validating config")
+	}
+
+	switch format {
+	case AuditLog, AuditRDJSON:
+	default:
+		return errors.Errorf("unknown audit format %q", format)
+	}
+
+	var aopts conversions.AuditOptions
+	aopts.Tags = c.Tags
+	pas, err := conversions.AuditPackages(dirs, aopts)
+	if err != nil {
+		return errors.Wrap(err, "auditing")
+	}
+	var findings []conversions.AuditFinding
+	r := RDJSONFor("", nil, c.Severities, showProven)
+	for _, pa := range pas {
+		r.Diagnostics = append(r.Diagnostics, RDJSONFor(pa.Dir, pa.Findings, c.Severities, showProven).Diagnostics...)
+		for _, af := range pa.Findings {
+			// NOTE: File names are only relative to their package, which is ambiguous across several.
+			if aggregate {
+				af.Pos.Filename = filepath.Join(pa.Dir, af.Pos.Filename)
+				af.End.Filename = af.Pos.Filename
+			}
+			findings = append(findings, af)
+		}
+	}
+
+	switch format {
+	case AuditLog:
+		logFindings(findings, c.Severities, showProven)
+	case AuditRDJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(r)
+		if err != nil {
+			return errors.Wrap(err, "encoding findings")
+		}
+	}
+
+	rollup := conversions.RollupAudits(pas, c.Severities)
+	if aggregate && format == AuditLog {
+		logRollup(rollup)
+	}
+	if rollupFile != "" {
+		err = writeRollup(rollupFile, rollup)
+		if err != nil {
+			return errors.Wrapf(err, "writing rollup %q", rollupFile)
+		}
+		logrus.Infof("wrote the rollup of %d packages to %s", len(rollup.Packages), rollupFile)
+	}
+
+	if benchFile != "" {
+		for _, dir := range dirs {
+			err = auditCosts(dir, benchFile, copts)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	var failed int
+	for _, af := range findings {
+		if !af.Proven && severityOf(af, c.Severities) == conversions.SeverityError {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("%d conversions may lose information at error severity", failed)
+	}
+
+	return nil
+}
+
+// auditCosts logs what the conversions in each file of the package in dir cost, estimated from
+// the go test -bench output in benchFile.
+func auditCosts(dir, benchFile string, copts conversions.CostOptions) error {
+	f, err := os.Open(benchFile)
+	if err != nil {
+		return errors.Wrapf(err, "opening benchmarks %q", benchFile)
+	}
+	defer func() { _ = f.Close() }()
+	copts.Model, err = conversions.ParseBenchmarks(f)
+	if err != nil {
+		return errors.Wrapf(err, "parsing benchmarks %q", benchFile)
+	}
+	costs, err := conversions.AuditCosts(dir, copts)
+	if err != nil {
+		return errors.Wrapf(err, "estimating the cost of conversions in %q", dir)
+	}
+	logCosts(costs)
+
+	return nil
+}
+
+// logCosts logs the estimated cost of the conversions in every file, costliest first, along with
+// the pair of types costing the most in each.
+func logCosts(costs []conversions.FileCost) {
+	var total time.Duration
+	for _, fc := range costs {
+		if fc.Unmeasured == fc.Conversions {
+			logrus.Warnf("%s: none of its %d conversions have a benchmark", fc.File, fc.Conversions)
+			continue
+		}
+		cost := time.Duration(fc.Cost)
+		total += cost
+		top := fc.Pairs[0]
+		logrus.Infof("%s: ~%s across %d conversions, most of it %s -> %s (%d×, ~%s)", fc.File, cost, fc.Conversions, top.From, top.To, top.Count, time.Duration(top.Cost))
+		if fc.Unmeasured > 0 {
+			logrus.Warnf("%s: %d conversions have no benchmark, so aren't included", fc.File, fc.Unmeasured)
+		}
+	}
+
+	logrus.Infof("~%s converting across %d files", total, len(costs))
+}
+
+// logFindings logs every finding which may lose information at its severity, leaving out those
+// severities turns off, and those proven safe when showProven is set.
+func logFindings(findings []conversions.AuditFinding, severities conversions.Severities, showProven bool) {
+	var flagged, proven, off int
+	for _, af := range findings {
+		if af.Proven {
+			proven++
+			if showProven {
+				logrus.Infof("%s: %s converts %s to %s, proven safe as the value is within %s%s", af.Pos, af.Expr, af.From, af.To, af.Known, findingTags(af))
+			}
+			continue
+		}
+		log := logrus.Warnf
+		switch severityOf(af, severities) {
+		case conversions.SeverityOff:
+			off++
+			continue
+		case conversions.SeverityInfo:
+			log = logrus.Infof
+		case conversions.SeverityError:
+			log = logrus.Errorf
+		}
+		flagged++
+		log("%s: %s converts %s to %s, %s, and may lose information%s", af.Pos, af.Expr, af.From, af.To, af.Width, findingTags(af))
+	}
+
+	logrus.Infof("%d conversions may lose information, %d more were proven safe, and %d were turned off", flagged, proven, off)
+}
+
+// RDJSONFor converts the findings of auditing the package in dir into reviewdog diagnostics,
+// with paths relative to the current directory, at the severity severities gives them. Findings
+// severities turns off are left out, and those proven safe are only included, as informational
+// diagnostics, when showProven is set.
+func RDJSONFor(dir string, findings []conversions.AuditFinding, severities conversions.Severities, showProven bool) RDJSONResult {
+	var r RDJSONResult
+	r.Source.Name = "go-conversions"
+	r.Source.URL = "https://github.com/Insulince/go-conversions"
+	r.Severity = "WARNING"
+	// NOTE: Always a list, even with no findings, so other tools reading the output needn't handle null.
+	r.Diagnostics = []RDJSONDiagnostic{}
+	for _, af := range findings {
+		severity := severityOf(af, severities)
+		if af.Proven && !showProven || !af.Proven && severity == conversions.SeverityOff {
+			continue
+		}
+
+		var d RDJSONDiagnostic
+		d.Location.Path = filepath.ToSlash(filepath.Join(dir, af.Pos.Filename))
+		d.Location.Range.Start = rdjsonPosition(af.Pos)
+		d.Location.Range.End = rdjsonPosition(af.End)
+		d.Code = &RDJSONCode{Value: "lossy-conversion"}
+		d.Severity = strings.ToUpper(severity)
+		d.Message = fmt.Sprintf("%s converts %s to %s, %s, and may lose information%s", af.Expr, af.From, af.To, af.Width, findingTags(af))
+		if af.Proven {
+			d.Code.Value = "proven-conversion"
+			d.Severity = "INFO"
+			d.Message = fmt.Sprintf("%s converts %s to %s, proven safe as the value is within %s%s", af.Expr, af.From, af.To, af.Known, findingTags(af))
+		}
+		r.Diagnostics = append(r.Diagnostics, d)
+	}
+	return r
+}
+
+// findingTags are the tags of af, to follow its message with, e.g. " [api-boundary, db-layer]".
+func findingTags(af conversions.AuditFinding) string {
+	if len(af.Tags) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(af.Tags, ", ") + "]"
+}
+
+// severityOf is the severity of af, as severities overrides it by its pair of primitives.
+func severityOf(af conversions.AuditFinding, severities conversions.Severities) string {
+	return severities.For(af.FromPrimitive, af.ToPrimitive, af.Width)
+}
+
+// rdjsonPosition converts pos into its RDJSON equivalent.
+func rdjsonPosition(pos token.Position) RDJSONPosition {
+	var p RDJSONPosition
+	p.Line = pos.Line
+	p.Column = pos.Column
+	return p
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Big analyzes every primitive against every other, extended with math/big's *big.Int,
+// *big.Float, and *big.Rat, and reports the results, followed by which of the conversions
+// involving math/big are always exact and which the generated helpers have to check.
+func Big(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("big", flag.ContinueOnError)
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	m, err := conversions.AnalyzeBig(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+
+	p, err := ProvenanceFor(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "determining provenance")
+	}
+
+	var ropts ReportOptions
+	ropts.Provenance = p
+	ropts.Format = *reportFormat
+	err = Report(ctx, m, ropts)
+	if err != nil {
+		return errors.Wrap(err, "reporting results")
+	}
+
+	var exact, checked int
+	for _, bc := range conversions.BigConversions(m.Types) {
+		if bc.Exact {
+			exact++
+		} else {
+			checked++
+		}
+	}
+	logrus.Infof("%d conversions to or from math/big are always exact, and %d can lose a value, which the helpers generated with -big report as a *RangeError", exact, checked)
+
+	return nil
+}
@@ -0,0 +1,148 @@
+// Package client calls the HTTP API of go-conversions serve, which OpenAPI describes, so other
+// tools can use the matrix of each go release without writing the requests themselves.
+package client
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type (
+	// Client calls the API served at BaseURL.
+	Client struct {
+		// BaseURL is where the API is served, e.g. http://localhost:8080.
+		BaseURL string
+		// HTTPClient sends the requests. Defaults to http.DefaultClient.
+		HTTPClient *http.Client
+	}
+
+	// Release is the analysis of a go release.
+	Release struct {
+		Version    string             `json:"version"`
+		AnalyzedAt time.Time          `json:"analyzedAt"`
+		Matrix     conversions.Matrix `json:"matrix"`
+	}
+
+	// Diff is how the conversions which compile changed from one go release to another, each
+	// pair written as from -> to.
+	Diff struct {
+		From   string   `json:"from"`
+		To     string   `json:"to"`
+		Gained []string `json:"gained"`
+		Lost   []string `json:"lost"`
+	}
+
+	// Error is returned when the API responds with anything but 200 OK.
+	Error struct {
+		// StatusCode is the HTTP status the API responded with.
+		StatusCode int
+		// Message is the error the API gave, or the status when it gave none.
+		Message string
+	}
+)
+
+var (
+	// OpenAPI is the OpenAPI 3 document describing the API, which serve also serves as /openapi.json.
+	//go:embed openapi.json
+	OpenAPI []byte
+)
+
+// New is a Client calling the API served at baseURL.
+func New(baseURL string) *Client {
+	var c Client
+	c.BaseURL = baseURL
+	return &c
+}
+
+// Releases lists every analyzed release, oldest first.
+func (c *Client) Releases(ctx context.Context) ([]string, error) {
+	var versions []string
+	err := c.get(ctx, "/releases", nil, &versions)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing releases")
+	}
+	return versions, nil
+}
+
+// Release is the analysis of the release version, e.g. go1.23.4.
+func (c *Client) Release(ctx context.Context, version string) (Release, error) {
+	var release Release
+	err := c.get(ctx, "/releases/"+url.PathEscape(version), nil, &release)
+	if err != nil {
+		return Release{}, errors.Wrapf(err, "getting release %s", version)
+	}
+	return release, nil
+}
+
+// Diff is what changed between the releases from and to, each of which defaults to one of the
+// latest two when empty.
+func (c *Client) Diff(ctx context.Context, from, to string) (Diff, error) {
+	query := url.Values{}
+	if from != "" {
+		query.Set("from", from)
+	}
+	if to != "" {
+		query.Set("to", to)
+	}
+	var d Diff
+	err := c.get(ctx, "/diff", query, &d)
+	if err != nil {
+		return Diff{}, errors.Wrap(err, "diffing releases")
+	}
+	return d, nil
+}
+
+// get requests path with query, decoding the JSON response into v.
+func (c *Client) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	u := strings.TrimSuffix(c.BaseURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return errors.Wrapf(err, "creating request to %q", u)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "requesting %q", u)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Error string `json:"error"`
+		}
+		var e Error
+		e.StatusCode = resp.StatusCode
+		e.Message = resp.Status
+		if json.NewDecoder(resp.Body).Decode(&body) == nil && body.Error != "" {
+			e.Message = body.Error
+		}
+		return &e
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(v)
+	if err != nil {
+		return errors.Wrapf(err, "decoding response from %q", u)
+	}
+	return nil
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d: %s", e.StatusCode, e.Message)
+}
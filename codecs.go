@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"strings"
+)
+
+// Codecs runs round trip probes of boundary values for every primitive through common
+// serialization codecs and reports which values don't come back unchanged, since
+// serialization is where conversions bite hardest.
+func Codecs(ctx context.Context) error {
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	results, err := conversions.AnalyzeCodecs(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "analyzing codecs")
+	}
+
+	p, err := ProvenanceFor(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "determining provenance")
+	}
+	for _, line := range p.Lines() {
+		logrus.Info(line)
+	}
+
+	for _, typ := range opts.Types {
+		logrus.Infof("---------- round tripping %s values ----------\n", typ)
+		for _, codec := range conversions.Codecs {
+			var total int
+			var lost []string
+			for _, result := range results {
+				if result.Type != typ || result.Codec != codec {
+					continue
+				}
+				total++
+				if !result.Survived {
+					lost = append(lost, result.Value)
+				}
+			}
+			if len(lost) == 0 {
+				logrus.Infof("%10s via %-8s ✅ all %d values survive", typ, codec, total)
+				continue
+			}
+			logrus.Infof("%10s via %-8s ❌ %d of %d values don't survive: %s", typ, codec, len(lost), total, strings.Join(lost, ", "))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"os"
+	"strings"
+)
+
+const (
+	// DefaultConfigFile is the config file loaded when -config is not set, if it exists.
+	DefaultConfigFile = "./go-conversions.json"
+)
+
+type (
+	// Config is the optional configuration file for this program. Anything set by a
+	// command line flag is combined with, or takes precedence over, what is set here.
+	Config struct {
+		// Types are the primitives to analyze, in place of every primitive. Aliases and types
+		// listed more than once are warned about, since their rows and columns are identical.
+		Types []string `json:"types"`
+		// ExcludeTypes are types left out of generation and reporting entirely.
+		ExcludeTypes []string `json:"excludeTypes"`
+		// Tags assigns user-defined tags, e.g. "api-boundary" or "db-layer", to types and pairs
+		// so reports can be grouped and filtered by them.
+		Tags conversions.Tags `json:"tags"`
+		// Hooks are shell commands run around every command, see Hooks.
+		Hooks Hooks `json:"hooks"`
+		// Helpers configures the package generated by the helpers command. Its flags take precedence.
+		Helpers HelpersConfig `json:"helpers"`
+		// Reports are the files the report is written to in each format, e.g. {"json":
+		// "out/matrix.json"}, when -format lists more than one.
+		Reports map[string]string `json:"reports"`
+		// Constraints are type parameter constraints, by name, to probe with the constraints
+		// command, e.g. {"Integer": "~int | ~int64"}, each the body of the constraint's interface.
+		Constraints map[string]string `json:"constraints"`
+		// Corpus are values, as Go expressions, for runtime probes to try by type name, e.g.
+		// {"int64": ["1<<53 + 1"], "float64": ["19.99"]}, when -values includes corpus.
+		Corpus conversions.Corpus `json:"corpus"`
+		// Severities overrides the severity the audit reports a conversion at by its pair, e.g.
+		// {"int64->int32": "error", "int->int64": "off"}, deciding whether it fails the audit.
+		Severities conversions.Severities `json:"severities"`
+		// Theme names the theme reports mark each pair with, when -theme doesn't, either one of
+		// Themes or a built-in one such as "colorblind".
+		Theme string `json:"theme"`
+		// Themes are custom themes by name, e.g. {"mono": {"yes": "+", "lossy": "~", "no": "-"}},
+		// each taking anything it leaves empty from the default theme. They take precedence
+		// over the built-in themes of the same name.
+		Themes map[string]conversions.Theme `json:"themes"`
+	}
+
+	// HelpersConfig is how the helper package is generated to fit a repo's conventions.
+	HelpersConfig struct {
+		// Package is the name of the generated package.
+		Package string `json:"package"`
+		// ImportPath is the generated package's import path, written as its import comment.
+		ImportPath string `json:"importPath"`
+		// Header is text commented out below the generated notice of every file.
+		Header string `json:"header"`
+		// LicenseFile is a file whose text is commented out at the top of every file.
+		LicenseFile string `json:"licenseFile"`
+	}
+)
+
+// LoadConfig reads the Config in configFile. When configFile is empty DefaultConfigFile
+// is read instead, and an empty Config is returned if it does not exist.
+func LoadConfig(configFile string) (Config, error) {
+	optional := configFile == ""
+	if optional {
+		configFile = DefaultConfigFile
+	}
+
+	b, err := os.ReadFile(configFile)
+	if optional && os.IsNotExist(err) {
+		return Config{}, nil
+	}
+	if err != nil {
+		return Config{}, errors.Wrapf(err, "reading config file %q", configFile)
+	}
+
+	var c Config
+	err = json.Unmarshal(b, &c)
+	if err != nil {
+		return Config{}, errors.Wrapf(err, "decoding config file %q", configFile)
+	}
+
+	return c, nil
+}
+
+// ThemeFor is the theme named name, one of c.Themes or else a built-in conversions.Theme, or the
+// default theme when name is empty.
+func (c Config) ThemeFor(name string) (conversions.Theme, error) {
+	if t, ok := c.Themes[name]; ok {
+		return t.WithDefaults(), nil
+	}
+	return conversions.LookupTheme(name)
+}
+
+// splitList splits a comma separated flag value into its trimmed, non-empty elements.
+func splitList(s string) []string {
+	var list []string
+	for _, e := range strings.Split(s, ",") {
+		e = strings.TrimSpace(e)
+		if e != "" {
+			list = append(list, e)
+		}
+	}
+	return list
+}
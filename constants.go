@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"os"
+	"strings"
+)
+
+// Constants analyzes every primitive against every other primitive, then compares what a
+// variable of each can do with every other with what its constant values, as generated by
+// -values, can, and reports the pairs where they differ, e.g. float64 -> int, which converts a
+// variable but only a constant with an integer value. Pairs whose constants are only assigned
+// without converting where a variable isn't, e.g. every widening pair, are counted, and with
+// -verbose listed too. With -format json every comparison is written to stdout instead.
+func Constants(ctx context.Context, args []string) error {
+	var verbose bool
+	fs := flag.NewFlagSet("constants", flag.ContinueOnError)
+	fs.BoolVar(&verbose, "verbose", false, "also list the pairs whose constants only differ from a variable by being assigned without converting")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	switch *reportFormat {
+	case "", "json":
+	default:
+		return errors.Errorf("constant reports can only be logged or rendered as json, not %q", *reportFormat)
+	}
+
+	m, err := conversions.Analyze(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+	ccs, err := conversions.CompareConstants(opts, m)
+	if err != nil {
+		return errors.Wrap(err, "comparing constants")
+	}
+
+	if *reportFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(ccs)
+		if err != nil {
+			return errors.Wrap(err, "encoding comparisons")
+		}
+		return nil
+	}
+
+	var differ, assign int
+	for _, cc := range ccs {
+		log := logrus.Warnf
+		switch {
+		case cc.Differs():
+			differ++
+		case cc.AssignmentDiffers():
+			assign++
+			if !verbose {
+				continue
+			}
+			log = logrus.Infof
+		default:
+			continue
+		}
+		variable := "a variable does not convert"
+		switch {
+		case cc.VariableAssignable:
+			variable = "a variable assigns"
+		case cc.Variable:
+			variable = "a variable converts"
+		}
+		var assigned, converted, rejected []string
+		for _, cr := range cc.Constants {
+			switch {
+			case cr.Assignable:
+				assigned = append(assigned, cr.Value)
+			case cr.Convertible:
+				converted = append(converted, cr.Value)
+			default:
+				rejected = append(rejected, cr.Value)
+				logrus.Debugf("%s(%s(%s)): %s", cc.To, cc.From, cr.Value, cr.Reason)
+			}
+		}
+		verdicts := []string{variable}
+		if len(assigned) > 0 {
+			verdicts = append(verdicts, "constants assign without converting: "+strings.Join(assigned, ", "))
+		}
+		if len(converted) > 0 {
+			verdicts = append(verdicts, "constants convert: "+strings.Join(converted, ", "))
+		}
+		if len(rejected) > 0 {
+			verdicts = append(verdicts, "constants do not convert: "+strings.Join(rejected, ", "))
+		}
+		log("%s -> %s: %s", cc.From, cc.To, strings.Join(verdicts, "; "))
+	}
+	logrus.Infof("%d of %d conversions treat constants differently than variables", differ, len(ccs))
+	if assign > 0 && !verbose {
+		logrus.Infof("%d more only assign constants without converting where a variable needs converting, add -verbose to list them", assign)
+	}
+
+	return nil
+}
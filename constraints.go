@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Constraints reports which of the analyzed primitives, types defined with them, and some
+// common composite types satisfy any, comparable, and each constraint in the config or given
+// as Name=elements arguments, e.g. Integer='~int | ~int64'. With -format json every result is
+// written to stdout instead.
+func Constraints(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("constraints", flag.ContinueOnError)
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	c := config
+	var names []string
+	for name := range c.Constraints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var constraints []conversions.Constraint
+	for _, name := range names {
+		constraints = append(constraints, conversions.Constraint{Name: name, Elements: c.Constraints[name]})
+	}
+	for _, arg := range fs.Args() {
+		name, elements, ok := strings.Cut(arg, "=")
+		if !ok {
+			return errors.Errorf("constraint %q is not of the form Name=elements", arg)
+		}
+		constraints = append(constraints, conversions.Constraint{Name: strings.TrimSpace(name), Elements: elements})
+	}
+
+	switch *reportFormat {
+	case "", "json":
+	default:
+		return errors.Errorf("constraint reports can only be logged or rendered as json, not %q", *reportFormat)
+	}
+
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	crs, err := conversions.ConstraintSatisfaction(opts, constraints)
+	if err != nil {
+		return errors.Wrap(err, "probing constraints")
+	}
+
+	if *reportFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(crs)
+		if err != nil {
+			return errors.Wrap(err, "encoding results")
+		}
+		return nil
+	}
+
+	satisfied := make(map[string][]string)
+	var order []string
+	for _, cr := range crs {
+		if _, ok := satisfied[cr.Constraint]; !ok {
+			order = append(order, cr.Constraint)
+			satisfied[cr.Constraint] = []string{}
+		}
+		if cr.Satisfies {
+			satisfied[cr.Constraint] = append(satisfied[cr.Constraint], cr.Type)
+			continue
+		}
+		logrus.Debugf("%s does not satisfy %s: %s", cr.Type, cr.Constraint, cr.Reason)
+	}
+	for _, name := range order {
+		if len(satisfied[name]) == 0 {
+			logrus.Warnf("%s is satisfied by none of the types", name)
+			continue
+		}
+		logrus.Infof("%s is satisfied by %d types: %s", name, len(satisfied[name]), strings.Join(satisfied[name], ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"os"
+	"strings"
+)
+
+// Contexts analyzes every primitive against every other primitive, then probes each pair as a
+// statement, a return value, a struct literal field, and a function argument, and reports any
+// pair which compiles in some of them but not others, which the spec says never happens. With
+// -format json every comparison is written to stdout instead.
+func Contexts(ctx context.Context) error {
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	switch *reportFormat {
+	case "", "json":
+	default:
+		return errors.Errorf("context reports can only be logged or rendered as json, not %q", *reportFormat)
+	}
+
+	m, err := conversions.Analyze(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+	ccs, err := conversions.CompareContexts(opts, m)
+	if err != nil {
+		return errors.Wrap(err, "comparing contexts")
+	}
+
+	if *reportFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(ccs)
+		if err != nil {
+			return errors.Wrap(err, "encoding comparisons")
+		}
+		return nil
+	}
+
+	var differ int
+	for _, cc := range ccs {
+		if !cc.Differs() {
+			continue
+		}
+		differ++
+		variable := "does not convert"
+		if cc.Variable {
+			variable = "converts"
+		}
+		verdicts := []string{"the matrix says it " + variable}
+		for _, cr := range cc.Contexts {
+			verdict := "✅ compiles"
+			if !cr.Convertible {
+				verdict = fmt.Sprintf("❌ %s", cr.Reason)
+			}
+			verdicts = append(verdicts, fmt.Sprintf("as a %s %s", cr.Context, verdict))
+		}
+		logrus.Warnf("%s -> %s depends on its context: %s", cc.From, cc.To, strings.Join(verdicts, ", "))
+	}
+	if differ == 0 {
+		logrus.Infof("all %d conversions compile the same as a %s, as the spec says they should", len(ccs), strings.Join(conversions.Contexts, ", "))
+		return nil
+	}
+	logrus.Infof("%d of %d conversions depend on their context", differ, len(ccs))
+
+	return nil
+}
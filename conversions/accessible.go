@@ -0,0 +1,51 @@
+package conversions
+
+const (
+	// WordYes marks a pair which compiles and always preserves the value in an accessible report.
+	WordYes = "YES"
+	// WordNo marks a pair which doesn't compile in an accessible report.
+	WordNo = "NO"
+	// WordLossy marks a pair which compiles but may change the value in an accessible report.
+	WordLossy = "LOSSY"
+)
+
+type (
+	// Verbalizer is implemented by RowWriters which can mark every pair with one of WordYes,
+	// WordNo, or WordLossy rather than by emoji alone, for screen readers and monochrome
+	// terminals, see Matrix.Accessible. Verbalize is called, if at all, before any rows are written.
+	Verbalizer interface {
+		Verbalize()
+	}
+)
+
+// Word is the word marking result in an accessible report.
+func Word(result Result) string {
+	switch {
+	case !result.Convertible:
+		return WordNo
+	case ExactNames(result.From, result.To):
+		return WordYes
+	default:
+		return WordLossy
+	}
+}
+
+// ExactNames reports whether every value of the type named from converts to the type named to
+// unchanged. Only numeric pairs, and a type with itself or its alias, ever do.
+func ExactNames(from, to string) bool {
+	fromInfo, ok := Lookup(from)
+	if !ok {
+		return false
+	}
+	toInfo, ok := Lookup(to)
+	if !ok {
+		return false
+	}
+	if fromInfo.Canonical() == toInfo.Canonical() {
+		return true
+	}
+	if !fromInfo.IsNumeric() || !toInfo.IsNumeric() {
+		return false
+	}
+	return Exact(fromInfo, toInfo)
+}
@@ -0,0 +1,537 @@
+package conversions
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultOutputDir is the directory generated go code is written to when
+	// Options.OutputDir is not set.
+	DefaultOutputDir = "./output"
+
+	// EngineBuild writes the generated go code to Options.OutputDir and compiles it with `go build`.
+	EngineBuild = "build"
+	// EngineTypes type checks the generated go code in memory with go/types, never touching disk.
+	EngineTypes = "types"
+	// EngineRemote submits the generated go code to the HTTP build service at Options.RemoteURL,
+	// see RemoteBackend.
+	EngineRemote = "remote"
+)
+
+type (
+	// Options configures an analysis.
+	Options struct {
+		// Types is the list of types to check against each other, any listed more than once are
+		// only checked once. Defaults to Primitives.
+		Types []string
+		// TemplateFile is an optional template to use in place of DefaultTemplate.
+		TemplateFile string
+		// OutputDir is the directory to put the generated go code in. Defaults to DefaultOutputDir.
+		OutputDir string
+		// Parallelism is the maximum number of shards compiled at once. Defaults to runtime.NumCPU().
+		Parallelism int
+		// Engine is how the generated go code is checked, one of EngineBuild, EngineTypes, or
+		// EngineRemote. Defaults to EngineBuild.
+		Engine string
+		// RemoteURL is the build service the EngineRemote engine submits shards to.
+		RemoteURL string
+		// MaxErrors is the most errors the compiler reports for a single file. Shards are sized so
+		// that even if every conversion in one fails it stays below this, and any shard which
+		// still reaches it fails the analysis rather than silently dropping failures. Defaults to
+		// measuring it with MeasureErrorLimit, a negative value means there is no limit.
+		MaxErrors int
+		// GOARCH is the architecture the generated go code is checked for. Defaults to the
+		// architecture of the go toolchain, or of the remote build service.
+		GOARCH string
+		// Toolchain, when set, is the go release the EngineBuild engine compiles with, e.g.
+		// go1.23.4, as GOTOOLCHAIN selects it, so the go command downloads it on first use.
+		// Defaults to the go command on PATH.
+		Toolchain string
+		// LangVersion, when set, is the Go language version, e.g. go1.19, the generated go code
+		// is checked under, as a go directive in the probe module's go.mod sets it, so features
+		// gated behind a newer version are rejected even by a newer toolchain. Defaults to the
+		// toolchain's own version.
+		LangVersion string
+		// Events, when set, is called with an Event as each stage of the analysis, each Shard,
+		// and each pair completes, see NDJSONEvents. It is never called concurrently.
+		Events func(Event)
+		// Backend, when set, checks the generated go code in place of the one named by Engine.
+		Backend Backend
+		// StateFile is where the State of the analysis is saved to after every completed Shard.
+		// Nothing is saved when it is not set.
+		StateFile string
+		// Resume picks up the analysis saved to StateFile by an earlier, interrupted run instead
+		// of starting over, skipping every Shard that run already completed.
+		Resume bool
+		// CacheFile, when set, is where the Cache of every Result is kept between analyses. Pairs
+		// it already has a Result for are not checked again, so adding a type to the list only
+		// checks the pairs involving it.
+		CacheFile string
+		// CacheStore, when set, is where the Cache is kept rather than in the file CacheFile, as
+		// the document keyed by its name, e.g. a DirStore, or a Store of your own shared by
+		// every instance of a server, see RegisterStore.
+		CacheStore Store
+		// Tags assigns user-defined tags to pairs. Every Result is given the tags that apply to it.
+		Tags Tags
+		// Strict makes any compiler output that can't be parsed, any unexpected exit status, and any
+		// shard reporting results it doesn't cover a hard failure, rather than silently accepting
+		// a possibly incomplete Matrix.
+		Strict bool
+		// DebugDir, when set, is where every file of generated go code that's checked is saved,
+		// along with everything the compiler or type checker printed about it, as .output.txt,
+		// and the DebugParse of that output, as .parsed.json, so a misparsed diagnostic can be
+		// reproduced from them alone.
+		DebugDir string
+		// Retries is how many more times a shard is checked when checking it fails for a reason
+		// which has nothing to do with the code, see IsTransient, e.g. the compiler being killed
+		// for running out of memory, before the analysis fails. Defaults to never retrying.
+		Retries int
+		// RetryBackoff is how long to wait before retrying a shard the first time, doubling
+		// every time after it. Defaults to DefaultRetryBackoff.
+		RetryBackoff time.Duration
+		// Values supplies the values runtime probes, such as AnalyzeCodecs and AnalyzeValueLoss,
+		// try for each type, and CompareConstants declares constants of. Defaults to the
+		// GeneratorBoundaries one.
+		Values ValueGenerator
+	}
+
+	// Shard is a slice of the full matrix which is generated and compiled on its own.
+	// Each Shard checks its Sources against its Targets, and is indexed by the position of its
+	// first source in Options.Types.
+	Shard struct {
+		Index   int
+		Sources []string
+		// Targets are the types Sources are converted to, every type in Options.Types when empty.
+		Targets []string `json:",omitempty"`
+	}
+
+	// shardResult carries the Results of one compiled Shard back to AnalyzeStream.
+	shardResult struct {
+		shard   Shard
+		results []Result
+		err     error
+	}
+)
+
+// Analyze runs a full analysis and collects every Result into a Matrix, in canonical order.
+func Analyze(ctx context.Context, opts Options) (Matrix, error) {
+	opts = opts.WithDefaults()
+
+	var m Matrix
+	m.Types = opts.Types
+	err := AnalyzeStream(ctx, opts, func(result Result) error {
+		m.Results = append(m.Results, result)
+		return nil
+	})
+	if err != nil {
+		return Matrix{}, err
+	}
+	SortResults(m.Results)
+
+	return m.Indexed(), nil
+}
+
+// AnalyzeRows runs a full analysis and invokes fn with every Result converting from each
+// type, in the order of opts.Types, as soon as that row and every row before it are complete.
+// Only rows which complete out of order are held in memory, so reports can be rendered a row
+// at a time for type lists far too big to hold the whole Matrix for. If fn returns an error
+// the analysis is stopped and that error is returned.
+func AnalyzeRows(ctx context.Context, opts Options, fn func(from string, row []Result) error) error {
+	opts = opts.WithDefaults()
+
+	index := make(map[string]int)
+	for i, t := range opts.Types {
+		index[t] = i
+	}
+	pending := make(map[string][]Result)
+	next := 0
+	return AnalyzeStream(ctx, opts, func(result Result) error {
+		pending[result.From] = append(pending[result.From], result)
+		for next < len(opts.Types) {
+			from := opts.Types[next]
+			row := pending[from]
+			if len(row) < len(opts.Types) {
+				return nil
+			}
+			delete(pending, from)
+			// NOTE: A row can be pieced together from cached Results and more than one Shard.
+			sort.Slice(row, func(i, j int) bool { return index[row[i].To] < index[row[j].To] })
+			err := fn(from, row)
+			if err != nil {
+				return err
+			}
+			next++
+		}
+		return nil
+	})
+}
+
+// AnalyzeStream runs a full analysis and invokes fn with each Result as soon as the
+// Shard it belongs to finishes compiling, so callers never need to hold the entire
+// Matrix in memory. Results arrive in no particular order, but fn is never called
+// concurrently. If fn returns an error the analysis is stopped and that error is returned.
+func AnalyzeStream(ctx context.Context, opts Options, fn func(Result) error) error {
+	opts = opts.WithDefaults()
+	emit := opts.emitter()
+
+	err := analyzeStream(ctx, opts, emit, fn)
+
+	var e Event
+	e.Type = EventAnalysisFinished
+	e.Err = errString(err)
+	emit(e)
+
+	return err
+}
+
+// analyzeStream implements AnalyzeStream, passing the progress of the analysis to emit.
+func analyzeStream(ctx context.Context, opts Options, emit func(Event), fn func(Result) error) error {
+	err := opts.Tags.Validate(opts.Types)
+	if err != nil {
+		return errors.Wrap(err, "validating tags")
+	}
+	backend, err := backendFor(opts)
+	if err != nil {
+		return err
+	}
+	emit(Event{Type: EventAnalysisStarted, Pairs: len(opts.Types) * len(opts.Types)})
+	if opts.TemplateFile != "" {
+		// NOTE: Parsed up front, so a template which can't be used fails the analysis before any
+		// shard is checked, and one which was adapted is only reported once.
+		_, schema, err := parseTemplate(opts)
+		if err != nil {
+			return errors.Wrap(err, "parsing template")
+		}
+		if schema < TemplateSchema {
+			emit(Event{Type: EventTemplateMigrated, Schema: schema})
+		}
+	}
+	handle := func(result Result) error {
+		result.Tags = opts.Tags.For(result.From, result.To)
+		result.Width = ClassifyNames(result.From, result.To)
+		result.Columns = classify(result)
+		emit(Event{Type: EventPair, Result: &result})
+		err := fn(result)
+		if err != nil {
+			return errors.Wrapf(err, "handling result %s -> %s", result.From, result.To)
+		}
+		return nil
+	}
+
+	state, err := resumeState(opts)
+	if err != nil {
+		return errors.Wrap(err, "resuming")
+	}
+	var completed []int
+	for index := range state.Shards {
+		completed = append(completed, index)
+	}
+	sort.Ints(completed)
+	// done are the pairs whose Result is already known, and so never checked again.
+	done := make(map[[2]string]bool)
+	for _, index := range completed {
+		for _, result := range state.Shards[index] {
+			done[[2]string{result.From, result.To}] = true
+			err := handle(result)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	var cache *Cache
+	var checked []Result
+	if opts.CacheFile != "" {
+		cache, _, err = cachedResults(ctx, opts)
+		if err != nil {
+			return err
+		}
+		analyzed := make(map[string]bool)
+		for _, t := range opts.Types {
+			analyzed[t] = true
+		}
+		for _, result := range cache.Results {
+			pair := [2]string{result.From, result.To}
+			if !analyzed[result.From] || !analyzed[result.To] || done[pair] {
+				continue
+			}
+			done[pair] = true
+			err := handle(result)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	remaining := remainingShards(opts, done)
+
+	if _, ok := backend.(BuildBackend); ok {
+		// NOTE: Written once up front, rather than per shard, so no compiler ever sees it half written.
+		err := writeProbeModule(opts.OutputDir, opts.LangVersion)
+		if err != nil {
+			return errors.Wrap(err, "writing probe module")
+		}
+	}
+
+	limit := opts.MaxErrors
+	if limit == 0 && len(remaining) > 0 {
+		limit, err = measureErrorLimit(ctx, opts, backend)
+		if err != nil {
+			return errors.Wrap(err, "measuring error limit")
+		}
+		emit(Event{Type: EventErrorLimitMeasured, Limit: limit})
+	}
+	var planned []Shard
+	for _, r := range remaining {
+		shards, err := planShards(opts, r.Sources, r.Targets, limit)
+		if err != nil {
+			return errors.Wrap(err, "planning shards")
+		}
+		planned = append(planned, shards...)
+	}
+	emit(Event{Type: EventShardsPlanned, Shards: len(planned)})
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	shards := make(chan Shard)
+	go func() {
+		defer close(shards)
+		for _, shard := range planned {
+			select {
+			case shards <- shard:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	shardResults := make(chan shardResult)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for shard := range shards {
+				shard := shard
+				emit(Event{Type: EventShardStarted, Shard: &shard})
+				var sr shardResult
+				sr.shard = shard
+				sr.results, sr.err = retryShard(ctx, opts, backend, limit, shard, emit)
+				emit(Event{Type: EventShardFinished, Shard: &shard, Err: errString(sr.err)})
+				select {
+				case shardResults <- sr:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(shardResults)
+	}()
+
+	// saveCache merges every Result checked into the Cache, along with those of the shards an
+	// interrupted run completed.
+	saveCache := func() error {
+		if cache == nil || len(checked) == 0 {
+			return nil
+		}
+		for _, index := range completed {
+			cache.Add(state.Shards[index])
+		}
+		cache.Add(checked)
+		// NOTE: Saved with a context of its own, since ctx is cancelled when interrupted, which is
+		// exactly when what was checked would otherwise be lost.
+		err := mergeCache(context.Background(), opts, cache)
+		if err != nil {
+			return errors.Wrap(err, "saving cache")
+		}
+		return nil
+	}
+	// stopped returns err, the reason the analysis stopped early, once the pairs checked before it
+	// did are saved to the Cache, so the next run doesn't check them again.
+	stopped := func(err error) error {
+		saveErr := saveCache()
+		if saveErr != nil {
+			return errors.WithMessagef(err, "%v after", saveErr)
+		}
+		return err
+	}
+
+	for sr := range shardResults {
+		if sr.err != nil {
+			return stopped(sr.err)
+		}
+		if cache != nil {
+			checked = append(checked, sr.results...)
+		}
+		for _, result := range sr.results {
+			err := handle(result)
+			if err != nil {
+				return stopped(err)
+			}
+		}
+		if opts.StateFile != "" {
+			state.Shards[sr.shard.Index] = sr.results
+			err := state.Save(opts.StateFile)
+			if err != nil {
+				return stopped(errors.Wrapf(err, "saving state after shard %d", sr.shard.Index))
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return stopped(ctx.Err())
+	}
+
+	if opts.StateFile != "" {
+		// NOTE: The analysis is complete, so there is nothing left to resume.
+		err := os.Remove(opts.StateFile)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "removing state file %q", opts.StateFile)
+		}
+	}
+
+	return saveCache()
+}
+
+// remainingShards groups the pairs of opts.Types which aren't done into the Shards left to
+// check, before they are split up by planShards. Sources with nothing done are checked against
+// every type, and the rest, e.g. every type already cached when one is added, only against
+// the types they're missing.
+func remainingShards(opts Options, done map[[2]string]bool) []Shard {
+	var remaining []Shard
+	groups := make(map[string]int)
+	for _, from := range opts.Types {
+		var targets []string
+		for _, to := range opts.Types {
+			if !done[[2]string{from, to}] {
+				targets = append(targets, to)
+			}
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		if len(targets) == len(opts.Types) {
+			targets = nil
+		}
+
+		key := strings.Join(targets, ",")
+		i, ok := groups[key]
+		if !ok {
+			i = len(remaining)
+			groups[key] = i
+			var shard Shard
+			shard.Targets = targets
+			remaining = append(remaining, shard)
+		}
+		remaining[i].Sources = append(remaining[i].Sources, from)
+	}
+	return remaining
+}
+
+// resumeState loads the State to resume from when opts.Resume is set, otherwise
+// it starts a fresh one.
+func resumeState(opts Options) (*State, error) {
+	if !opts.Resume {
+		return newState(opts), nil
+	}
+	if opts.StateFile == "" {
+		return nil, errors.New("no state file to resume from")
+	}
+
+	state, err := LoadState(opts.StateFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading state")
+	}
+	if state == nil {
+		return newState(opts), nil
+	}
+	if !state.Matches(opts) {
+		return nil, errors.Errorf("state file %q was saved by an analysis of different types or with a different engine", opts.StateFile)
+	}
+
+	return state, nil
+}
+
+// analyzeShard checks a single Shard with backend and converts its ConversionFailures into a
+// Result for every pair it covers. limit is the number of errors backend reports per file.
+func analyzeShard(ctx context.Context, opts Options, backend Backend, limit int, shard Shard) ([]Result, error) {
+	src, err := Render(ctx, opts, shard)
+	if err != nil {
+		return nil, errors.Wrapf(err, "rendering shard %d", shard.Index)
+	}
+
+	cfs, err := checkSource(ctx, opts, backend, shardFileName(shard), src)
+	if err != nil {
+		return nil, errors.Wrapf(err, "checking shard %d", shard.Index)
+	}
+
+	err = checkErrorLimit(shard, cfs, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := shard.targets(opts)
+	if opts.Strict {
+		for _, cf := range cfs {
+			if !contains(shard.Sources, cf.From) || !contains(targets, cf.To) {
+				return nil, errors.Errorf("shard %d reported a conversion failure for %s -> %s which it does not cover", shard.Index, cf.From, cf.To)
+			}
+		}
+	}
+
+	var results []Result
+	for _, from := range shard.Sources {
+		for _, to := range targets {
+			var result Result
+			result.From = from
+			result.To = to
+			result.Err = cfs.Find(from, to)
+			result.Convertible = result.Err == nil
+			results = append(results, result)
+		}
+	}
+
+	return results, nil
+}
+
+// targets are the types shard's Sources are converted to.
+func (shard Shard) targets(opts Options) []string {
+	if len(shard.Targets) == 0 {
+		return opts.Types
+	}
+	return shard.Targets
+}
+
+// WithDefaults returns a copy of opts with every unset field given its default value.
+func (opts Options) WithDefaults() Options {
+	if len(opts.Types) == 0 {
+		opts.Types = Primitives
+	}
+	// NOTE: A type listed twice would be declared twice in the generated code.
+	opts.Types = SortTypes(Dedupe(opts.Types))
+	if opts.OutputDir == "" {
+		opts.OutputDir = DefaultOutputDir
+	}
+	if opts.Parallelism <= 0 {
+		opts.Parallelism = runtime.NumCPU()
+	}
+	if opts.Engine == "" {
+		opts.Engine = EngineBuild
+	}
+	if opts.Values == nil {
+		opts.Values, _ = LookupGenerator(GeneratorBoundaries)
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = DefaultRetryBackoff
+	}
+	return opts
+}
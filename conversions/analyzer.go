@@ -0,0 +1,108 @@
+package conversions
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"os"
+	"sync"
+)
+
+type (
+	// Analyzer runs analyses configured by the same Options, and is safe for concurrent use, so
+	// a server can create one and answer every request with it. Its Options are copied when it
+	// is created and never change, each analysis using the build engine generates its code in a
+	// temporary directory of its own under OutputDir, and analyses sharing a CacheFile merge
+	// their Results into it rather than overwriting each other's.
+	Analyzer struct {
+		opts Options
+		// eventsMu makes sure opts.Events is never called concurrently, even by concurrent analyses.
+		eventsMu sync.Mutex
+	}
+)
+
+// NewAnalyzer creates an Analyzer for opts. Since a StateFile can only hold the State of one
+// analysis at a time, opts can't set one, nor Resume.
+func NewAnalyzer(opts Options) (*Analyzer, error) {
+	if opts.StateFile != "" || opts.Resume {
+		return nil, errors.New("an Analyzer can't save or resume the state of its analyses, they would share a StateFile")
+	}
+	_, err := backendFor(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = opts.WithDefaults()
+	opts.Types = append([]string(nil), opts.Types...)
+	tags := make(Tags, len(opts.Tags))
+	for name, rule := range opts.Tags {
+		rule.Types = append([]string(nil), rule.Types...)
+		rule.Pairs = append([]string(nil), rule.Pairs...)
+		tags[name] = rule
+	}
+	opts.Tags = tags
+
+	var a Analyzer
+	a.opts = opts
+	if events := opts.Events; events != nil {
+		a.opts.Events = func(e Event) {
+			a.eventsMu.Lock()
+			defer a.eventsMu.Unlock()
+			events(e)
+		}
+	}
+	return &a, nil
+}
+
+// Options returns a copy of the Options a analyzes with, with their defaults filled in.
+func (a *Analyzer) Options() Options {
+	opts := a.opts
+	opts.Types = append([]string(nil), opts.Types...)
+	return opts
+}
+
+// Analyze is Analyze for types, or every type in the Options of a when types is empty.
+func (a *Analyzer) Analyze(ctx context.Context, types []string) (Matrix, error) {
+	opts, cleanup, err := a.call(types)
+	if err != nil {
+		return Matrix{}, err
+	}
+	defer cleanup()
+	return Analyze(ctx, opts)
+}
+
+// AnalyzeStream is AnalyzeStream for types, or every type in the Options of a when types is empty.
+func (a *Analyzer) AnalyzeStream(ctx context.Context, types []string, fn func(Result) error) error {
+	opts, cleanup, err := a.call(types)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return AnalyzeStream(ctx, opts, fn)
+}
+
+// call returns the Options of a single analysis of types, along with a function removing
+// anything it generated once it's done.
+func (a *Analyzer) call(types []string) (Options, func(), error) {
+	opts := a.opts
+	if len(types) > 0 {
+		opts.Types = types
+	}
+	backend, err := backendFor(opts)
+	if err != nil {
+		return Options{}, nil, err
+	}
+	if _, ok := backend.(BuildBackend); !ok {
+		return opts, func() {}, nil
+	}
+
+	err = os.MkdirAll(a.opts.OutputDir, 0o755)
+	if err != nil {
+		return Options{}, nil, errors.Wrapf(err, "creating output directory %q", a.opts.OutputDir)
+	}
+	opts.OutputDir, err = os.MkdirTemp(a.opts.OutputDir, "analysis-")
+	if err != nil {
+		return Options{}, nil, errors.Wrap(err, "creating a directory for the analysis")
+	}
+	dir := opts.OutputDir
+	return opts, func() { _ = os.RemoveAll(dir) }, nil
+}
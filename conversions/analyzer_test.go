@@ -0,0 +1,123 @@
+package conversions
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// TestAnalyzerConcurrent analyzes overlapping type lists with a single Analyzer, with Analyze and
+// AnalyzeStream called at the same time and sharing a CacheFile, and checks that every analysis
+// gets the same Results as one on its own, and that none of them lost another's Results from the
+// Cache. Run it with -race, which is what it's really there for.
+func TestAnalyzerConcurrent(t *testing.T) {
+	typeLists := [][]string{
+		{"bool", "uint8", "int8", "string"},
+		{"uint8", "uint16", "int16", "float32"},
+		{"int8", "int32", "float64", "complex64"},
+		{"uint64", "int64", "float32", "string"},
+	}
+
+	var opts Options
+	opts.Types = Primitives
+	opts.Engine = EngineTypes
+	opts.OutputDir = t.TempDir()
+	opts.CacheFile = filepath.Join(t.TempDir(), "cache.json")
+	// NOTE: Counted without a lock, since the Analyzer promises never to call Events concurrently.
+	events := 0
+	opts.Events = func(Event) { events++ }
+	a, err := NewAnalyzer(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	matrices := make([]Matrix, 2*len(typeLists))
+	errs := make([]error, len(matrices))
+	for i := range matrices {
+		i := i
+		types := typeLists[i%len(typeLists)]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i < len(typeLists) {
+				matrices[i], errs[i] = a.Analyze(ctx, types)
+				return
+			}
+			matrices[i].Types = types
+			errs[i] = a.AnalyzeStream(ctx, types, func(result Result) error {
+				matrices[i].Results = append(matrices[i].Results, result)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	var alone Options
+	alone.Engine = EngineTypes
+	alone.OutputDir = t.TempDir()
+	for i, m := range matrices {
+		if errs[i] != nil {
+			t.Fatalf("analyzing %v: %v", m.Types, errs[i])
+		}
+		alone.Types = m.Types
+		want, err := Analyze(ctx, alone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(m.Results) != len(want.Results) {
+			t.Errorf("analyzing %v: %d results, expected %d", m.Types, len(m.Results), len(want.Results))
+		}
+		if changes := Diff(want, m); len(changes) > 0 {
+			t.Errorf("analyzing %v: %d pairs differ from analyzing them alone", m.Types, len(changes))
+		}
+	}
+	if events == 0 {
+		t.Error("no events were emitted")
+	}
+
+	c, err := LoadCache(opts.CacheFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c == nil {
+		t.Fatal("nothing was cached")
+	}
+	var cached Matrix
+	cached.Results = c.Results
+	for _, types := range typeLists {
+		for _, from := range types {
+			for _, to := range types {
+				if _, ok := cached.Result(from, to); !ok {
+					t.Errorf("%s -> %s is missing from the cache", from, to)
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkAnalyzerAnalyze analyzes Primitives with the same Analyzer again and again, as it
+// answers one request after another. It uses the types engine so that running the compiler
+// doesn't drown out everything else an analysis does, and no CacheFile, which would answer
+// every analysis after the first without checking anything.
+func BenchmarkAnalyzerAnalyze(b *testing.B) {
+	var opts Options
+	opts.Engine = EngineTypes
+	opts.OutputDir = b.TempDir()
+	a, err := NewAnalyzer(opts)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err = a.Analyze(ctx, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
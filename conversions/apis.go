@@ -0,0 +1,175 @@
+package conversions
+
+import (
+	"github.com/pkg/errors"
+	"go/importer"
+	"go/types"
+	"strings"
+)
+
+type (
+	// API is a frequently called standard library function, builtin, or conversion, and the
+	// parameter of it whose type values so often have to be converted to.
+	API struct {
+		// Call is how the API is called, e.g. strconv.FormatInt(i, base).
+		Call string
+		// Package and Func name the function, whose Param is looked up in the standard library
+		// itself. Both are empty for a builtin or a conversion, whose Type is given instead.
+		Package string `json:",omitempty"`
+		Func    string `json:",omitempty"`
+		// Param is the name of the parameter in Call.
+		Param string
+		// Type is the type Param has to be, e.g. int64 or time.Duration.
+		Type string
+		// Primitive is the primitive underlying Type, which decides what converts to it.
+		Primitive string
+	}
+
+	// APIConversion is what a value of type From takes to be passed as the Param of an API.
+	APIConversion struct {
+		API
+		From string
+		// Needed is whether From has to be converted at all, false when it is Type already.
+		Needed bool
+		// Convertible is whether From converts to Type, if it's Needed.
+		Convertible bool
+		// Exact is whether every value of From survives being converted to Type, if it's Convertible.
+		Exact bool
+		// Width is whether the conversion is widening, narrowing, or reinterpreting, see Classify.
+		Width string `json:",omitempty"`
+	}
+)
+
+var (
+	// APIs are the standard library APIs APIConversions reports on. The types of those which are
+	// functions are looked up in the standard library rather than written down.
+	APIs = []API{
+		{Call: "make([]byte, n)", Param: "n", Type: "int"},
+		{Call: "s[i]", Param: "i", Type: "int"},
+		{Call: "time.Duration(n)", Param: "n", Type: "time.Duration"},
+		{Call: "os.FileMode(perm)", Param: "perm", Type: "os.FileMode"},
+		{Call: "strconv.Itoa(i)", Package: "strconv", Func: "Itoa", Param: "i"},
+		{Call: "strconv.FormatInt(i, base)", Package: "strconv", Func: "FormatInt", Param: "i"},
+		{Call: "strconv.FormatUint(i, base)", Package: "strconv", Func: "FormatUint", Param: "i"},
+		{Call: "strconv.FormatFloat(f, fmt, prec, bitSize)", Package: "strconv", Func: "FormatFloat", Param: "f"},
+		{Call: "strings.Repeat(s, count)", Package: "strings", Func: "Repeat", Param: "count"},
+		{Call: "strings.IndexByte(s, c)", Package: "strings", Func: "IndexByte", Param: "c"},
+		{Call: "strings.ContainsRune(s, r)", Package: "strings", Func: "ContainsRune", Param: "r"},
+		{Call: "time.Unix(sec, nsec)", Package: "time", Func: "Unix", Param: "sec"},
+		{Call: "time.Sleep(d)", Package: "time", Func: "Sleep", Param: "d"},
+		{Call: "math.Sqrt(x)", Package: "math", Func: "Sqrt", Param: "x"},
+		{Call: "math.Float32bits(f)", Package: "math", Func: "Float32bits", Param: "f"},
+		{Call: "math/bits.OnesCount64(x)", Package: "math/bits", Func: "OnesCount64", Param: "x"},
+		{Call: "math/rand.Intn(n)", Package: "math/rand", Func: "Intn", Param: "n"},
+		{Call: "io.CopyN(dst, src, n)", Package: "io", Func: "CopyN", Param: "n"},
+		{Call: "os.Exit(code)", Package: "os", Func: "Exit", Param: "code"},
+		{Call: "unicode.IsLetter(r)", Package: "unicode", Func: "IsLetter", Param: "r"},
+		{Call: "encoding/binary.BigEndian.PutUint32(b, v)", Package: "encoding/binary", Func: "BigEndian.PutUint32", Param: "v"},
+	}
+)
+
+// ResolveAPIs fills in the Type of every one of apis which is a function from the standard
+// library, and the Primitive underlying every Type.
+func ResolveAPIs(apis []API) ([]API, error) {
+	imp := importer.Default()
+	var resolved []API
+	for _, api := range apis {
+		t, err := apiType(imp, api)
+		if err != nil {
+			return nil, errors.Wrapf(err, "resolving %s", api.Call)
+		}
+		info, ok := basicInfo(t)
+		if !ok {
+			return nil, errors.Errorf("%s of %s is a %s, which isn't a primitive underneath", api.Param, api.Call, t)
+		}
+		api.Type = types.TypeString(t, func(p *types.Package) string { return p.Name() })
+		api.Primitive = info.Name
+		resolved = append(resolved, api)
+	}
+	return resolved, nil
+}
+
+// APIConversions reports what a value of each of froms takes to be passed to each of apis,
+// according to m, which must have a Result for converting each of froms to the Primitive of
+// each API. apis must have been resolved with ResolveAPIs.
+func APIConversions(m Matrix, apis []API, froms []string) ([]APIConversion, error) {
+	var acs []APIConversion
+	for _, api := range apis {
+		for _, from := range froms {
+			var ac APIConversion
+			ac.API = api
+			ac.From = from
+			// NOTE: A defined type like time.Duration has to be converted to even from its primitive.
+			ac.Needed = api.Type != api.Primitive || CanonicalName(from) != CanonicalName(api.Primitive)
+			if !ac.Needed {
+				acs = append(acs, ac)
+				continue
+			}
+			result, ok := m.Result(from, api.Primitive)
+			if !ok {
+				return nil, errors.Errorf("no result for %s -> %s, which %s takes", from, api.Primitive, api.Call)
+			}
+			ac.Convertible = result.Convertible
+			if ac.Convertible {
+				ac.Exact = ExactNames(from, api.Primitive)
+				ac.Width = ClassifyNames(from, api.Primitive)
+			}
+			acs = append(acs, ac)
+		}
+	}
+	return acs, nil
+}
+
+// apiType is the type api's Param has to be, looked up with imp when api is a function.
+func apiType(imp types.Importer, api API) (types.Type, error) {
+	if api.Package == "" {
+		if strings.Contains(api.Type, ".") {
+			return namedType(imp, api.Type)
+		}
+		obj := types.Universe.Lookup(api.Type)
+		if obj == nil {
+			return nil, errors.Errorf("unknown type %q", api.Type)
+		}
+		return obj.Type(), nil
+	}
+
+	pkg, err := imp.Import(api.Package)
+	if err != nil {
+		return nil, errors.Wrapf(err, "importing %q", api.Package)
+	}
+	var fn *types.Func
+	if recv, method, ok := strings.Cut(api.Func, "."); ok {
+		v, ok := pkg.Scope().Lookup(recv).(*types.Var)
+		if !ok {
+			return nil, errors.Errorf("%s.%s isn't a variable", api.Package, recv)
+		}
+		obj, _, _ := types.LookupFieldOrMethod(v.Type(), true, pkg, method)
+		fn, _ = obj.(*types.Func)
+	} else {
+		fn, _ = pkg.Scope().Lookup(api.Func).(*types.Func)
+	}
+	if fn == nil {
+		return nil, errors.Errorf("%s.%s isn't a function", api.Package, api.Func)
+	}
+	params := fn.Type().(*types.Signature).Params()
+	for i := 0; i < params.Len(); i++ {
+		if params.At(i).Name() == api.Param {
+			return params.At(i).Type(), nil
+		}
+	}
+	return nil, errors.Errorf("%s.%s has no parameter %q", api.Package, api.Func, api.Param)
+}
+
+// namedType looks up the type qualified by its package's name, e.g. time.Duration, with imp.
+func namedType(imp types.Importer, name string) (types.Type, error) {
+	pkgName, typeName, _ := strings.Cut(name, ".")
+	pkg, err := imp.Import(pkgName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "importing %q", pkgName)
+	}
+	tn, ok := pkg.Scope().Lookup(typeName).(*types.TypeName)
+	if !ok {
+		return nil, errors.Errorf("%s isn't a type", name)
+	}
+	return tn.Type(), nil
+}
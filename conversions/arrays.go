@@ -0,0 +1,182 @@
+package conversions
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"github.com/pkg/errors"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	// ArraysTemplate is the template the array conversion probes are generated from.
+	//go:embed template/arrays.tmpl
+	ArraysTemplate string
+
+	// DefaultArrayLengths are the array lengths AnalyzeArrays checks when none are given.
+	DefaultArrayLengths = []int{2, 4}
+
+	// arrayRegexp splits an array type, e.g. [4]int32, into its length and element type.
+	arrayRegexp = regexp.MustCompile(`^\[(\d+)\](.+)$`)
+)
+
+// ArrayTypes returns an array type of every one of lengths for every type in elems, e.g.
+// [2]int8, [2]int16, ..., [4]int8, [4]int16, and so on.
+func ArrayTypes(lengths []int, elems []string) []string {
+	var arrays []string
+	for _, length := range lengths {
+		for _, elem := range elems {
+			arrays = append(arrays, fmt.Sprintf("[%d]%s", length, elem))
+		}
+	}
+	return arrays
+}
+
+// ParseArray splits the array type name into its length and element type, reporting false
+// when it is not an array type.
+func ParseArray(name string) (int, string, bool) {
+	matches := arrayRegexp.FindStringSubmatch(name)
+	if matches == nil {
+		return 0, "", false
+	}
+	length, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return 0, "", false
+	}
+	return length, matches[2], true
+}
+
+// AnalyzeArrays checks every array of lengths, with every one of opts.Types as its element
+// type, against every other, collecting the Results into a Matrix in that order. Since an
+// array only converts to arrays of the same length with identical element types, each Result
+// which isn't convertible is given ErrArrayLength or ErrArrayElem as its reason. No lengths
+// means DefaultArrayLengths.
+func AnalyzeArrays(ctx context.Context, opts Options, lengths []int) (Matrix, error) {
+	opts = opts.WithDefaults()
+	if len(lengths) == 0 {
+		lengths = DefaultArrayLengths
+	}
+	for _, length := range lengths {
+		if length < 0 {
+			return Matrix{}, errors.Errorf("invalid array length %d", length)
+		}
+	}
+
+	return analyzeComposites(ctx, opts, Dedupe(ArrayTypes(lengths, opts.Types)))
+}
+
+// analyzeComposites checks every one of composites, type names such as [4]int32 or []int32
+// which Lookup doesn't know, against every other, collecting the Results into a Matrix in that
+// order.
+func analyzeComposites(ctx context.Context, opts Options, composites []string) (Matrix, error) {
+	backend, err := backendFor(opts)
+	if err != nil {
+		return Matrix{}, err
+	}
+
+	arrayOpts := opts
+	arrayOpts.Types = composites
+
+	type Array struct {
+		Field string
+		Type  string
+	}
+	fields := make(map[string]string)
+	index := make(map[string]Array)
+	var arrays []Array
+	for i, t := range arrayOpts.Types {
+		var a Array
+		a.Field = fmt.Sprintf("array%03d", i)
+		a.Type = t
+		fields[a.Field] = t
+		index[t] = a
+		arrays = append(arrays, a)
+	}
+
+	t, err := parseCached("arrays.tmpl", ArraysTemplate, nil)
+	if err != nil {
+		return Matrix{}, errors.Wrap(err, "parsing template")
+	}
+
+	if _, ok := backend.(BuildBackend); ok {
+		err := writeProbeModule(opts.OutputDir, opts.LangVersion)
+		if err != nil {
+			return Matrix{}, errors.Wrap(err, "writing probe module")
+		}
+	}
+
+	limit := opts.MaxErrors
+	if limit == 0 {
+		limit, err = measureErrorLimit(ctx, arrayOpts, backend)
+		if err != nil {
+			return Matrix{}, errors.Wrap(err, "measuring error limit")
+		}
+	}
+	shards, err := planShards(arrayOpts, arrayOpts.Types, nil, limit)
+	if err != nil {
+		return Matrix{}, errors.Wrap(err, "planning shards")
+	}
+
+	var m Matrix
+	m.Types = arrayOpts.Types
+	for _, shard := range shards {
+		type Data struct {
+			Now     string
+			App     string
+			Arrays  []Array
+			Sources []Array
+		}
+		var data Data
+		data.Now = time.Now().Format(time.RFC3339)
+		data.App = os.Args[0]
+		data.Arrays = arrays
+		for _, source := range shard.Sources {
+			data.Sources = append(data.Sources, index[source])
+		}
+
+		var src bytes.Buffer
+		err := t.Execute(&src, data)
+		if err != nil {
+			return Matrix{}, errors.Wrapf(err, "executing template for shard %d", shard.Index)
+		}
+
+		cfs, err := checkSource(ctx, opts, backend, fmt.Sprintf("arrays_%03d.go", shard.Index), src.Bytes())
+		if err != nil {
+			return Matrix{}, errors.Wrapf(err, "checking shard %d", shard.Index)
+		}
+		err = checkErrorLimit(shard, cfs, limit)
+		if err != nil {
+			return Matrix{}, err
+		}
+
+		// NOTE: The compiler reports the field being converted rather than its type.
+		byType := make(ConversionFailures, 0, len(cfs))
+		for _, cf := range cfs {
+			from, ok := fields[cf.From]
+			if !ok {
+				return Matrix{}, errors.Errorf("shard %d reported a conversion failure for unknown field %q", shard.Index, cf.From)
+			}
+			e := NewConversionError(from, cf.To)
+			e.Position = cf.Position
+			e.CompilerMessage = cf.CompilerMessage
+			byType = append(byType, e)
+		}
+
+		for _, from := range shard.Sources {
+			for _, to := range m.Types {
+				var result Result
+				result.From = from
+				result.To = to
+				result.Err = byType.Find(from, to)
+				result.Convertible = result.Err == nil
+				m.Results = append(m.Results, result)
+			}
+		}
+	}
+
+	return m.Indexed(), nil
+}
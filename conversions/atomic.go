@@ -0,0 +1,96 @@
+package conversions
+
+import (
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+)
+
+type (
+	// AtomicFile is written under a temporary name beside the file it's for, and only replaces
+	// that file, all at once, when it's committed. A write which fails partway, e.g. when a
+	// template fails to execute, so never leaves a half-written file behind, nor truncates the
+	// one written last time, and concurrent writers never see each other's partial files.
+	AtomicFile struct {
+		*os.File
+		// name is the name of the file f is for.
+		name string
+		perm os.FileMode
+		// done is whether f has been committed or discarded.
+		done bool
+	}
+)
+
+// CreateAtomic starts writing name, with the permissions perm, creating its directory first if
+// need be. The file isn't written until it's committed.
+func CreateAtomic(name string, perm os.FileMode) (*AtomicFile, error) {
+	dir := filepath.Dir(name)
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating directory for %q", name)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating temporary file for %q", name)
+	}
+
+	var f AtomicFile
+	f.File = tmp
+	f.name = name
+	f.perm = perm
+	return &f, nil
+}
+
+// Commit closes f and renames it over the file it's for.
+func (f *AtomicFile) Commit() error {
+	if f.done {
+		return errors.Errorf("%q is already closed", f.name)
+	}
+	f.done = true
+
+	err := f.File.Chmod(f.perm)
+	closeErr := f.File.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(f.File.Name())
+		return errors.Wrapf(err, "closing temporary file for %q", f.name)
+	}
+	err = os.Rename(f.File.Name(), f.name)
+	if err != nil {
+		_ = os.Remove(f.File.Name())
+		return errors.Wrapf(err, "renaming %q to %q", f.File.Name(), f.name)
+	}
+	return nil
+}
+
+// Close discards f, leaving the file it's for as it was, unless f has been committed, in which
+// case it does nothing, so it can always be deferred.
+func (f *AtomicFile) Close() error {
+	if f.done {
+		return nil
+	}
+	f.done = true
+	_ = f.File.Close()
+	err := os.Remove(f.File.Name())
+	if err != nil {
+		return errors.Wrapf(err, "removing temporary file for %q", f.name)
+	}
+	return nil
+}
+
+// WriteFileAtomic is os.WriteFile by way of an AtomicFile, creating name's directory if need be.
+func WriteFileAtomic(name string, b []byte, perm os.FileMode) error {
+	f, err := CreateAtomic(name, perm)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	_, err = f.Write(b)
+	if err != nil {
+		return errors.Wrapf(err, "writing %q", name)
+	}
+	return f.Commit()
+}
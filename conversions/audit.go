@@ -0,0 +1,398 @@
+package conversions
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"math/big"
+	"path/filepath"
+)
+
+type (
+	// Interval is the range of values an integer is known to lie within. A nil bound is unbounded.
+	Interval struct {
+		Min *big.Int
+		Max *big.Int
+	}
+
+	// ValueDomain narrows down the values the argument of a conversion may have, given where the
+	// conversion is. Domains are what let an audit tell conversions guarded by a bounds check
+	// apart from ones which can really lose information.
+	ValueDomain interface {
+		// Bounds narrows in, the interval arg is already known to lie within, using path, the
+		// nodes from the file down to the conversion itself.
+		Bounds(info *types.Info, path []ast.Node, arg ast.Expr, in Interval) Interval
+	}
+
+	// IntervalDomain is a simple interval analysis. It narrows a variable by the comparisons
+	// against constants in the if statements the conversion is nested in, e.g.
+	// `if x < math.MaxInt32 {`, and in preceding if statements which leave the block, e.g.
+	// `if x > math.MaxInt32 { return }`. Variables which are assigned to after they are
+	// declared are never narrowed.
+	IntervalDomain struct{}
+
+	// AuditOptions configures Audit.
+	AuditOptions struct {
+		// Domains narrow the values a conversion's argument may have, in order. Defaults to IntervalDomain.
+		Domains []ValueDomain
+		// Tags are tagged onto each finding by the primitives underlying its types, see Options.Tags.
+		Tags Tags
+	}
+
+	// AuditFinding is a conversion in a user package which can lose information.
+	AuditFinding struct {
+		// Pos and End are where the conversion starts and ends, with a file name relative to the package directory.
+		Pos  token.Position
+		End  token.Position
+		Expr string
+		From string
+		To   string
+		// FromPrimitive and ToPrimitive are the primitives underlying From and To, e.g. float64
+		// for a `type Celsius float64`.
+		FromPrimitive string
+		ToPrimitive   string
+		// Width is whether the conversion is narrowing or reinterpreting, going by the primitives
+		// underlying From and To, see Classify. It is never widening, as those can't lose information.
+		Width string
+		// Tags are the names of the AuditOptions' Tags which apply to FromPrimitive and ToPrimitive.
+		Tags []string `json:",omitempty"`
+		// Known is the interval the argument is known to lie within, for integer arguments.
+		Known Interval
+		// Proven is whether the Domains proved the argument always converts exactly, making
+		// the conversion safe despite its types.
+		Proven bool
+	}
+
+	// bound is a comparison of a variable against a constant, i.e. variable Op Value.
+	bound struct {
+		op    token.Token
+		value *big.Int
+	}
+)
+
+// Audit type checks the package in dir and reports every numeric conversion in it which can
+// lose information, according to the conversion's types, along with whether the value domain
+// analysis in opts proved it safe anyway.
+func Audit(dir string, opts AuditOptions) ([]AuditFinding, error) {
+	if opts.Domains == nil {
+		opts.Domains = []ValueDomain{IntervalDomain{}}
+	}
+
+	lp, err := loadPackage(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []AuditFinding
+	lp.audit(opts, func(af AuditFinding, _ []ast.Node) {
+		findings = append(findings, af)
+	})
+	return findings, nil
+}
+
+// audit calls found with every numeric conversion in lp which can lose information, along with
+// path, the nodes from its file down to the conversion itself.
+func (lp *loadedPackage) audit(opts AuditOptions, found func(af AuditFinding, path []ast.Node)) {
+	for _, f := range lp.files {
+		var path []ast.Node
+		ast.Inspect(f, func(n ast.Node) bool {
+			if n == nil {
+				path = path[:len(path)-1]
+				return true
+			}
+			path = append(path, n)
+
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) != 1 {
+				return true
+			}
+			fun, ok := lp.info.Types[call.Fun]
+			if !ok || !fun.IsType() {
+				return true
+			}
+			arg, ok := lp.info.Types[call.Args[0]]
+			if !ok || arg.Type == nil || arg.Value != nil {
+				// NOTE: The compiler already rejects constants which don't fit.
+				return true
+			}
+			from, ok := basicInfo(arg.Type)
+			if !ok || !from.IsNumeric() {
+				return true
+			}
+			to, ok := basicInfo(fun.Type)
+			if !ok || !to.IsNumeric() || Exact(from, to) {
+				return true
+			}
+
+			var af AuditFinding
+			af.Pos = lp.fset.Position(call.Pos())
+			af.Pos.Filename = filepath.Base(af.Pos.Filename)
+			af.End = lp.fset.Position(call.End())
+			af.End.Filename = af.Pos.Filename
+			af.Expr = types.ExprString(call)
+			af.From = describeType(arg.Type, lp.qualifier)
+			af.To = describeType(fun.Type, lp.qualifier)
+			af.FromPrimitive = from.Name
+			af.ToPrimitive = to.Name
+			af.Width = Classify(from, to)
+			af.Tags = opts.Tags.For(from.Name, to.Name)
+			if from.IsInteger() {
+				af.Known = integerRange(from, from.MaxBits())
+				for _, d := range opts.Domains {
+					af.Known = d.Bounds(lp.info, path, call.Args[0], af.Known)
+				}
+				af.Proven = fitsIn(af.Known, from, to)
+			}
+			found(af, path)
+			return true
+		})
+	}
+}
+
+// Bounds implements ValueDomain.
+func (IntervalDomain) Bounds(info *types.Info, path []ast.Node, arg ast.Expr, in Interval) Interval {
+	id, ok := unparen(arg).(*ast.Ident)
+	if !ok {
+		return in
+	}
+	v, ok := info.Uses[id].(*types.Var)
+	if !ok {
+		return in
+	}
+
+	known := in
+	for i := len(path) - 1; i > 0; i-- {
+		child, parent := path[i], path[i-1]
+		switch p := parent.(type) {
+		case *ast.FuncDecl:
+			if assigned(info, p.Body, v) {
+				return in
+			}
+			return known
+		case *ast.FuncLit:
+			if assigned(info, p.Body, v) {
+				return in
+			}
+			return known
+		case *ast.IfStmt:
+			switch child {
+			case p.Body:
+				known = known.narrow(bounds(info, p.Cond, v, false))
+			case p.Else:
+				known = known.narrow(bounds(info, p.Cond, v, true))
+			}
+		case *ast.BlockStmt:
+			for _, stmt := range p.List {
+				if stmt == child {
+					break
+				}
+				ifs, ok := stmt.(*ast.IfStmt)
+				if ok && ifs.Else == nil && exits(ifs.Body) {
+					known = known.narrow(bounds(info, ifs.Cond, v, true))
+				}
+			}
+		}
+	}
+	// NOTE: Package level variables can be assigned anywhere, so are never narrowed.
+	return in
+}
+
+// narrow returns i narrowed by every bound in bs.
+func (i Interval) narrow(bs []bound) Interval {
+	one := big.NewInt(1)
+	for _, b := range bs {
+		switch b.op {
+		case token.LSS:
+			i.Max = minInt(i.Max, new(big.Int).Sub(b.value, one))
+		case token.LEQ:
+			i.Max = minInt(i.Max, b.value)
+		case token.GTR:
+			i.Min = maxInt(i.Min, new(big.Int).Add(b.value, one))
+		case token.GEQ:
+			i.Min = maxInt(i.Min, b.value)
+		case token.EQL:
+			i.Min = maxInt(i.Min, b.value)
+			i.Max = minInt(i.Max, b.value)
+		}
+	}
+	return i
+}
+
+// String implements fmt.Stringer.
+func (i Interval) String() string {
+	lo, hi := "-∞", "+∞"
+	if i.Min != nil {
+		lo = i.Min.String()
+	}
+	if i.Max != nil {
+		hi = i.Max.String()
+	}
+	return "[" + lo + ", " + hi + "]"
+}
+
+// bounds returns the bounds on v that hold when cond is true, or when it is false if negate is set.
+func bounds(info *types.Info, cond ast.Expr, v *types.Var, negate bool) []bound {
+	switch c := unparen(cond).(type) {
+	case *ast.UnaryExpr:
+		if c.Op == token.NOT {
+			return bounds(info, c.X, v, !negate)
+		}
+	case *ast.BinaryExpr:
+		switch {
+		case c.Op == token.LAND && !negate, c.Op == token.LOR && negate:
+			// NOTE: Both sides hold, either because both are true or, by De Morgan, both are false.
+			return append(bounds(info, c.X, v, negate), bounds(info, c.Y, v, negate)...)
+		case c.Op == token.LAND, c.Op == token.LOR:
+			return nil
+		}
+
+		op := c.Op
+		x, y := unparen(c.X), unparen(c.Y)
+		if id, ok := y.(*ast.Ident); ok && info.Uses[id] == v {
+			// NOTE: Put the variable on the left, e.g. C > x is x < C.
+			x, y = y, x
+			op = map[token.Token]token.Token{token.LSS: token.GTR, token.LEQ: token.GEQ, token.GTR: token.LSS, token.GEQ: token.LEQ}[op]
+		}
+		id, ok := x.(*ast.Ident)
+		if !ok || info.Uses[id] != v {
+			return nil
+		}
+		value, ok := constantInt(info, y)
+		if !ok {
+			return nil
+		}
+		if negate {
+			op = map[token.Token]token.Token{token.LSS: token.GEQ, token.LEQ: token.GTR, token.GTR: token.LEQ, token.GEQ: token.LSS, token.EQL: token.NEQ, token.NEQ: token.EQL}[op]
+		}
+		return []bound{{op: op, value: value}}
+	}
+	return nil
+}
+
+// constantInt returns the value of e if it is an integer constant.
+func constantInt(info *types.Info, e ast.Expr) (*big.Int, bool) {
+	tv, ok := info.Types[e]
+	if !ok || tv.Value == nil {
+		return nil, false
+	}
+	value := constant.ToInt(tv.Value)
+	if value.Kind() != constant.Int {
+		return nil, false
+	}
+	return new(big.Int).SetString(value.ExactString(), 10)
+}
+
+// assigned reports whether v is assigned to, incremented, decremented, or has its address taken anywhere in body.
+func assigned(info *types.Info, body *ast.BlockStmt, v *types.Var) bool {
+	found := false
+	is := func(e ast.Expr) bool {
+		id, ok := unparen(e).(*ast.Ident)
+		return ok && info.Uses[id] == v
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch s := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range s.Lhs {
+				found = found || is(lhs)
+			}
+		case *ast.IncDecStmt:
+			found = found || is(s.X)
+		case *ast.UnaryExpr:
+			found = found || (s.Op == token.AND && is(s.X))
+		}
+		return !found
+	})
+	return found
+}
+
+// exits reports whether block always leaves the block it is in, by returning, branching, or panicking.
+func exits(block *ast.BlockStmt) bool {
+	if len(block.List) == 0 {
+		return false
+	}
+	switch s := block.List[len(block.List)-1].(type) {
+	case *ast.ReturnStmt, *ast.BranchStmt:
+		return true
+	case *ast.ExprStmt:
+		call, ok := s.X.(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		id, ok := call.Fun.(*ast.Ident)
+		return ok && id.Name == "panic"
+	}
+	return false
+}
+
+// basicInfo describes the primitive underlying t.
+func basicInfo(t types.Type) (Info, bool) {
+	basic, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return Info{}, false
+	}
+	return Lookup(types.Typ[basic.Kind()].Name())
+}
+
+// integerRange returns every value of the integer type i when it is bits wide.
+func integerRange(i Info, bits int) Interval {
+	var r Interval
+	if i.Kind == KindUint {
+		r.Min = big.NewInt(0)
+		r.Max = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(bits)), big.NewInt(1))
+		return r
+	}
+	r.Min = new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), uint(bits-1)))
+	r.Max = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(bits-1)), big.NewInt(1))
+	return r
+}
+
+// fitsIn reports whether every value in known, of the integer type from, converts exactly to
+// the numeric type to on every platform.
+func fitsIn(known Interval, from, to Info) bool {
+	if known.Min == nil || known.Max == nil {
+		return false
+	}
+	var target Interval
+	switch {
+	case to.IsInteger() && from.Bits == 0 && to.Bits == 0:
+		// NOTE: Platform dependent types are the same width as each other on any one platform.
+		target = integerRange(to, to.MaxBits())
+	case to.IsInteger():
+		target = integerRange(to, to.MinBits())
+	case to.Kind == KindFloat:
+		limit := new(big.Int).Lsh(big.NewInt(1), uint(to.Mantissa()))
+		target.Min = new(big.Int).Neg(limit)
+		target.Max = limit
+	default:
+		return false
+	}
+	return known.Min.Cmp(target.Min) >= 0 && known.Max.Cmp(target.Max) <= 0
+}
+
+// unparen returns e with any enclosing parentheses removed.
+func unparen(e ast.Expr) ast.Expr {
+	for {
+		p, ok := e.(*ast.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.X
+	}
+}
+
+// minInt returns the smaller of a, which may be nil for unbounded, and b.
+func minInt(a, b *big.Int) *big.Int {
+	if a == nil || b.Cmp(a) < 0 {
+		return b
+	}
+	return a
+}
+
+// maxInt returns the larger of a, which may be nil for unbounded, and b.
+func maxInt(a, b *big.Int) *big.Int {
+	if a == nil || b.Cmp(a) > 0 {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,168 @@
+package conversions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+)
+
+const (
+	// maxRemoteResponse is the largest response read back from a remote build service. Even a
+	// full matrix of failures is a small fraction of this.
+	maxRemoteResponse = 64 << 20
+)
+
+type (
+	// Backend checks generated go code, reporting the conversions in it which fail to compile.
+	Backend interface {
+		// Check checks src, the go code generated into the file named file.
+		Check(ctx context.Context, opts Options, file string, src []byte) (ConversionFailures, error)
+	}
+
+	// BuildBackend writes the generated go code to Options.OutputDir and compiles it with the
+	// local go toolchain.
+	BuildBackend struct{}
+
+	// TypesBackend type checks the generated go code in memory with go/types.
+	TypesBackend struct{}
+
+	// RemoteBackend submits the generated go code to an HTTP build service and collects the diagnostics it
+	// reports, for environments where no go toolchain may be installed locally. The service is
+	// sent a RemoteRequest as a JSON POST to URL and must reply with a RemoteResponse.
+	RemoteBackend struct {
+		// URL is where RemoteRequests are POSTed to.
+		URL string
+		// Client sends the requests. Defaults to http.DefaultClient.
+		Client *http.Client
+	}
+
+	// RemoteRequest is a single probe chunk submitted to a remote build service. The service is
+	// expected to write GoMod and Source, as File, to an empty directory and run
+	// `go build -gcflags=-e` from within it.
+	RemoteRequest struct {
+		File   string `json:"file"`
+		Source string `json:"source"`
+		GoMod  string `json:"goMod"`
+		// GOARCH is the architecture to build for, the service's own when empty.
+		GOARCH string `json:"goarch,omitempty"`
+		// Lang is the language version to build under, e.g. go1.19, as the go directive in GoMod
+		// also says. A file named on the command line isn't held to its module's go directive,
+		// so the service is expected to pass it on as -gcflags=-lang=. It's the toolchain's own
+		// version when empty.
+		Lang string `json:"lang,omitempty"`
+	}
+
+	// RemoteResponse is what a remote build service replies with once it has built a RemoteRequest.
+	RemoteResponse struct {
+		// ExitCode is the exit status of go build, expected to be non-zero.
+		ExitCode int `json:"exitCode"`
+		// Output is everything go build wrote to stderr.
+		Output string `json:"output"`
+	}
+)
+
+// backendFor returns the Backend analyses configured by opts check their shards with.
+func backendFor(opts Options) (Backend, error) {
+	err := checkLangVersion(opts.LangVersion)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Backend != nil {
+		return opts.Backend, nil
+	}
+
+	switch opts.Engine {
+	case EngineBuild:
+		return BuildBackend{}, nil
+	case EngineTypes:
+		return TypesBackend{}, nil
+	case EngineRemote:
+		if opts.RemoteURL == "" {
+			return nil, errors.New("the remote engine needs a remote URL")
+		}
+		var rb RemoteBackend
+		rb.URL = opts.RemoteURL
+		return rb, nil
+	default:
+		return nil, errors.Errorf("unknown engine %q", opts.Engine)
+	}
+}
+
+// Check implements Backend.
+func (BuildBackend) Check(ctx context.Context, opts Options, file string, src []byte) (ConversionFailures, error) {
+	outputFile, err := writeSource(opts, file, src)
+	if err != nil {
+		return nil, errors.Wrap(err, "writing")
+	}
+
+	cfs, err := Compile(ctx, opts, outputFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "compiling")
+	}
+
+	return cfs, nil
+}
+
+// Check implements Backend.
+func (TypesBackend) Check(ctx context.Context, opts Options, file string, src []byte) (ConversionFailures, error) {
+	cfs, err := TypeCheck(ctx, opts, file, src)
+	if err != nil {
+		return nil, errors.Wrap(err, "type checking")
+	}
+
+	return cfs, nil
+}
+
+// Check implements Backend.
+func (rb RemoteBackend) Check(ctx context.Context, opts Options, file string, src []byte) (ConversionFailures, error) {
+	var req RemoteRequest
+	req.File = file
+	req.Source = string(src)
+	req.GoMod = probeGoMod(opts.LangVersion)
+	req.GOARCH = opts.GOARCH
+	req.Lang = opts.LangVersion
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding request")
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, rb.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating request to %q", rb.URL)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := rb.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, errors.Wrapf(err, "submitting to %q", rb.URL)
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+
+	if httpResp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(httpResp.Body, 1024))
+		if httpResp.StatusCode == http.StatusTooManyRequests || httpResp.StatusCode >= 500 {
+			var te TransientError
+			te.File = req.File
+			te.Reason = fmt.Sprintf("remote build service %q replied %s", rb.URL, httpResp.Status)
+			te.ExitCode = -1
+			te.Output = string(bytes.TrimSpace(msg))
+			return nil, &te
+		}
+		return nil, errors.Errorf("remote build service %q replied %s: %s", rb.URL, httpResp.Status, bytes.TrimSpace(msg))
+	}
+
+	var resp RemoteResponse
+	err = json.NewDecoder(io.LimitReader(httpResp.Body, maxRemoteResponse)).Decode(&resp)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decoding response from %q", rb.URL)
+	}
+
+	return diagnose(opts, req.File, resp.ExitCode, resp.Output)
+}
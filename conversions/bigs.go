@@ -0,0 +1,131 @@
+package conversions
+
+import (
+	"context"
+	"strings"
+)
+
+const (
+	// BigInt, BigFloat, and BigRat are math/big's arbitrary precision types, as they're passed around.
+	BigInt   = "*big.Int"
+	BigFloat = "*big.Float"
+	BigRat   = "*big.Rat"
+)
+
+type (
+	// BigConversion is a conversion to or from one of BigTypes, which Go has no native conversion
+	// for, since they're structs, and is instead done through math/big's methods by a helper.
+	BigConversion struct {
+		From string
+		To   string
+		// Helper is the function the helpers command generates, with -big, to do the conversion
+		// and report whether it was exact.
+		Helper string
+		// Exact is whether every value of From converts to To exactly, so the Helper never
+		// returns an error.
+		Exact bool
+	}
+)
+
+var (
+	// BigTypes are the math/big types AnalyzeBig extends the matrix with.
+	BigTypes = []string{BigInt, BigFloat, BigRat}
+)
+
+// IsBig reports whether t is one of BigTypes.
+func IsBig(t string) bool {
+	for _, big := range BigTypes {
+		if t == big {
+			return true
+		}
+	}
+	return false
+}
+
+// BigConversions lists the conversions between every integer and float in types and each of
+// BigTypes, in both directions, and between BigTypes themselves. A primitive which is an alias,
+// e.g. byte, shares the Helper of the type it aliases.
+func BigConversions(types []string) []BigConversion {
+	var bcs []BigConversion
+	add := func(from, to string, exact bool) {
+		var bc BigConversion
+		bc.From = from
+		bc.To = to
+		bc.Helper = bigName(from) + "To" + bigName(to)
+		bc.Exact = exact
+		bcs = append(bcs, bc)
+	}
+
+	for _, t := range types {
+		info, ok := Lookup(t)
+		if !ok || !info.IsNumeric() || info.Kind == KindComplex {
+			continue
+		}
+		integer := info.IsInteger()
+		// NOTE: NaN and the infinities are floats no math/big type holds, and a fraction isn't
+		// an integer, while every integer fits all three exactly.
+		add(t, BigInt, integer)
+		add(t, BigFloat, integer)
+		add(t, BigRat, integer)
+		add(BigInt, t, false)
+		add(BigFloat, t, false)
+		add(BigRat, t, false)
+	}
+
+	add(BigInt, BigFloat, true)
+	add(BigInt, BigRat, true)
+	add(BigFloat, BigInt, false)
+	add(BigFloat, BigRat, false)
+	add(BigRat, BigInt, false)
+	add(BigRat, BigFloat, false)
+
+	return bcs
+}
+
+// AnalyzeBig analyzes opts.Types as Analyze does, then extends the Matrix with BigTypes, for
+// users who need arbitrary precision at their boundaries. The compiler has nothing to say
+// about converting to or from a struct, so each Result involving one of BigTypes instead
+// records whether BigConversions has a helper for it, and is otherwise given ErrBig as its
+// reason.
+func AnalyzeBig(ctx context.Context, opts Options) (Matrix, error) {
+	opts = opts.WithDefaults()
+
+	m, err := Analyze(ctx, opts)
+	if err != nil {
+		return Matrix{}, err
+	}
+
+	helpers := make(map[[2]string]bool)
+	for _, bc := range BigConversions(opts.Types) {
+		helpers[[2]string{bc.From, bc.To}] = true
+	}
+
+	m.Types = append(append([]string(nil), m.Types...), BigTypes...)
+	for _, from := range m.Types {
+		for _, to := range m.Types {
+			if !IsBig(from) && !IsBig(to) {
+				continue
+			}
+			var result Result
+			result.From = from
+			result.To = to
+			result.Convertible = from == to || helpers[[2]string{from, to}]
+			if !result.Convertible {
+				result.Err = NewConversionError(from, to)
+			}
+			m.Results = append(m.Results, result)
+		}
+	}
+	SortResults(m.Results)
+
+	return m.Indexed(), nil
+}
+
+// bigName names t as the helpers do, e.g. BigInt for *big.Int and Uint8 for byte.
+func bigName(t string) string {
+	if IsBig(t) {
+		return "Big" + strings.TrimPrefix(t, "*big.")
+	}
+	info, _ := Lookup(t)
+	return exportedName(info.Canonical())
+}
@@ -0,0 +1,112 @@
+package conversions
+
+import (
+	"math/big"
+)
+
+const (
+	// OutcomeUnchanged is a BoundaryRange of values which convert exactly.
+	OutcomeUnchanged = "unchanged"
+	// OutcomeChangesSign is a BoundaryRange of values which come out with the opposite sign,
+	// e.g. negative values converted to an unsigned type.
+	OutcomeChangesSign = "changes sign"
+	// OutcomeWraps is a BoundaryRange of values too large for the target type, which keep only
+	// their low bits and so wrap around, possibly changing sign as well.
+	OutcomeWraps = "wraps"
+)
+
+type (
+	// Boundary describes exactly which values of From survive being converted to To, for a pair
+	// of integer types where one is signed and the other unsigned.
+	Boundary struct {
+		From string
+		To   string
+		// WordBits is the size of int, uint, and uintptr the Ranges assume, or 0 when neither
+		// type depends on the platform.
+		WordBits int `json:",omitempty"`
+		// Ranges partition every value of From, in ascending order.
+		Ranges []BoundaryRange
+	}
+
+	// BoundaryRange is a range of values which all have the same Outcome when converted.
+	BoundaryRange struct {
+		Interval
+		Outcome string
+	}
+)
+
+// Boundaries derives a Boundary for every pair of a signed and an unsigned integer type in
+// types, in both directions, from their widths alone. Pairs involving int, uint, or uintptr get
+// a Boundary for both 32 and 64-bit platforms. Aliases are left out, they share the boundaries
+// of the types they alias.
+func Boundaries(types []string) []Boundary {
+	var infos []Info
+	for _, name := range types {
+		info, ok := Lookup(name)
+		if ok && info.IsInteger() && info.AliasOf == "" {
+			infos = append(infos, info)
+		}
+	}
+
+	var boundaries []Boundary
+	for _, from := range infos {
+		for _, to := range infos {
+			if from.Kind == to.Kind {
+				continue
+			}
+			if from.Bits != 0 && to.Bits != 0 {
+				boundaries = append(boundaries, boundary(from, to, 0))
+				continue
+			}
+			for _, wordBits := range []int{32, 64} {
+				boundaries = append(boundaries, boundary(from, to, wordBits))
+			}
+		}
+	}
+	return boundaries
+}
+
+// boundary derives the Boundary of converting from to to, where int, uint, and uintptr are wordBits wide.
+func boundary(from, to Info, wordBits int) Boundary {
+	fromBits, toBits := from.Bits, to.Bits
+	if fromBits == 0 {
+		fromBits = wordBits
+	}
+	if toBits == 0 {
+		toBits = wordBits
+	}
+	fromRange := integerRange(from, fromBits)
+	toRange := integerRange(to, toBits)
+	one := big.NewInt(1)
+
+	var b Boundary
+	b.From = from.Name
+	b.To = to.Name
+	b.WordBits = wordBits
+	add := func(min, max *big.Int, outcome string) {
+		if min.Cmp(max) > 0 {
+			return
+		}
+		var r BoundaryRange
+		r.Min = min
+		r.Max = max
+		r.Outcome = outcome
+		b.Ranges = append(b.Ranges, r)
+	}
+
+	if from.Kind == KindInt {
+		// NOTE: Negative values always come out non-negative, however wide the unsigned type is.
+		add(fromRange.Min, big.NewInt(-1), OutcomeChangesSign)
+		add(big.NewInt(0), minInt(fromRange.Max, toRange.Max), OutcomeUnchanged)
+		add(new(big.Int).Add(toRange.Max, one), fromRange.Max, OutcomeWraps)
+		return b
+	}
+
+	// NOTE: Values with the sign bit of the signed type set, but nothing above it, come out
+	// negative. Anything larger loses its high bits altogether.
+	add(big.NewInt(0), minInt(fromRange.Max, toRange.Max), OutcomeUnchanged)
+	signBitMax := new(big.Int).Sub(new(big.Int).Lsh(one, uint(toBits)), one)
+	add(new(big.Int).Add(toRange.Max, one), minInt(fromRange.Max, signBitMax), OutcomeChangesSign)
+	add(new(big.Int).Add(signBitMax, one), fromRange.Max, OutcomeWraps)
+	return b
+}
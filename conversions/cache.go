@@ -0,0 +1,246 @@
+package conversions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+type (
+	// Cache records the Results of earlier analyses, whatever types they were of, so that an
+	// analysis of a type list which only adds to a cached one just checks the pairs involving
+	// the types that were added. It only applies to analyses with the same engine, toolchain,
+	// architecture, template, and language version it was recorded with.
+	Cache struct {
+		Engine       string `json:"engine"`
+		Toolchain    string `json:"toolchain"`
+		GOARCH       string `json:"goarch"`
+		TemplateFile string `json:"templateFile"`
+		// TemplateHash is the SHA-256 of what TemplateFile held when the Cache was recorded, so
+		// a template edited in place doesn't match it, empty when there's no TemplateFile.
+		TemplateHash string   `json:"templateHash,omitempty"`
+		LangVersion  string   `json:"langVersion,omitempty"`
+		Results      []Result `json:"results"`
+	}
+)
+
+var (
+	// cacheLocks holds a *sync.Mutex for every cache file saved to, by its absolute path, see cacheLock.
+	cacheLocks sync.Map
+)
+
+// newCache creates an empty Cache for an analysis configured by opts, checked with toolchain,
+// whose TemplateFile hashes to templateHash.
+func newCache(opts Options, toolchain, templateHash string) *Cache {
+	var c Cache
+	c.Engine = opts.Engine
+	c.Toolchain = toolchain
+	c.GOARCH = opts.GOARCH
+	c.TemplateFile = opts.TemplateFile
+	c.TemplateHash = templateHash
+	c.LangVersion = opts.LangVersion
+	return &c
+}
+
+// LoadCache reads the Cache previously saved to cacheFile. A missing cacheFile is not an
+// error, it just means nothing has been cached yet, so a nil Cache is returned.
+func LoadCache(cacheFile string) (*Cache, error) {
+	s, key := fileStore(cacheFile)
+	return LoadCacheFrom(context.Background(), s, key)
+}
+
+// LoadCacheFrom reads the Cache previously saved to s as key. A missing key is not an error,
+// it just means nothing has been cached yet, so a nil Cache is returned.
+func LoadCacheFrom(ctx context.Context, s Store, key string) (*Cache, error) {
+	b, ok, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading cache %q", key)
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	var c Cache
+	err = json.Unmarshal(b, &c)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decoding cache %q", key)
+	}
+	// NOTE: Reasons are worded by this package rather than the compiler, so a Cache recorded by
+	// an earlier version gets the current wording.
+	for _, result := range c.Results {
+		if result.Err != nil {
+			result.Err.Reason = describeReason(result.From, result.To)
+		}
+	}
+
+	return &c, nil
+}
+
+// Save writes c to cacheFile, by way of a temporary file so an interruption never leaves a
+// half-written cache behind.
+func (c *Cache) Save(cacheFile string) error {
+	s, key := fileStore(cacheFile)
+	return c.SaveTo(context.Background(), s, key)
+}
+
+// SaveTo writes c to s as key, replacing whatever was saved as it.
+func (c *Cache) SaveTo(ctx context.Context, s Store, key string) error {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return errors.Wrap(err, "encoding cache")
+	}
+
+	err = s.Put(ctx, key, b)
+	if err != nil {
+		return errors.Wrapf(err, "writing cache %q", key)
+	}
+
+	return nil
+}
+
+// fileStore is the DirStore file is kept in, and its key in it.
+func fileStore(file string) (Store, string) {
+	var ds DirStore
+	ds.Dir = filepath.Dir(file)
+	return ds, filepath.Base(file)
+}
+
+// cacheStore is the Store the Cache of an analysis configured by opts is kept in, and its key
+// in it: opts.CacheStore when set, or the directory of opts.CacheFile otherwise, keyed by the
+// name of opts.CacheFile either way.
+func (opts Options) cacheStore() (Store, string) {
+	if opts.CacheStore != nil {
+		return opts.CacheStore, filepath.Base(opts.CacheFile)
+	}
+	return fileStore(opts.CacheFile)
+}
+
+// Matches reports whether c was recorded by an analysis configured like opts, checked with
+// toolchain, whose TemplateFile hashes to templateHash, see TemplateHash.
+func (c *Cache) Matches(opts Options, toolchain, templateHash string) bool {
+	return c.Engine == opts.Engine && c.Toolchain == toolchain && c.GOARCH == opts.GOARCH && c.TemplateFile == opts.TemplateFile && c.TemplateHash == templateHash && c.LangVersion == opts.LangVersion
+}
+
+// TemplateHash is the hex SHA-256 of what opts.TemplateFile holds, empty when it isn't set.
+func TemplateHash(opts Options) (string, error) {
+	if opts.TemplateFile == "" {
+		return "", nil
+	}
+	b, err := os.ReadFile(opts.TemplateFile)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading template file %q", opts.TemplateFile)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Add records results in c, replacing any it already holds for the same pairs.
+func (c *Cache) Add(results []Result) {
+	index := make(map[[2]string]int)
+	for i, result := range c.Results {
+		index[[2]string{result.From, result.To}] = i
+	}
+	for _, result := range results {
+		// NOTE: Tags and Columns come from the config and code of each run, not the compiler, so
+		// they aren't cached.
+		result.Tags = nil
+		result.Columns = nil
+		if i, ok := index[[2]string{result.From, result.To}]; ok {
+			c.Results[i] = result
+			continue
+		}
+		index[[2]string{result.From, result.To}] = len(c.Results)
+		c.Results = append(c.Results, result)
+	}
+}
+
+// mergeCache saves c to opts.CacheFile, keeping any Results another analysis saved there since
+// c was loaded, so analyses sharing a CacheFile concurrently don't lose each other's Results.
+// Analyses in other processes sharing a CacheStore merge the same way, but only an analysis in
+// this process is stopped from saving between the reload and the save.
+func mergeCache(ctx context.Context, opts Options, c *Cache) error {
+	mu := cacheLock(opts)
+	mu.Lock()
+	defer mu.Unlock()
+
+	s, key := opts.cacheStore()
+	saved, err := LoadCacheFrom(ctx, s, key)
+	if err != nil {
+		return errors.Wrap(err, "reloading cache")
+	}
+	if saved != nil && saved.Matches(opts, c.Toolchain, c.TemplateHash) {
+		saved.Add(c.Results)
+		c = saved
+	}
+	return c.SaveTo(ctx, s, key)
+}
+
+// cacheLock is the lock held while saving the Cache of an analysis configured by opts, shared
+// by every analysis in this process saving to the same file, or the same key of a CacheStore.
+func cacheLock(opts Options) *sync.Mutex {
+	lock := opts.CacheFile
+	if opts.CacheStore != nil {
+		lock = fmt.Sprintf("%T %v %s", opts.CacheStore, opts.CacheStore, opts.CacheFile)
+	} else if abs, err := filepath.Abs(opts.CacheFile); err == nil {
+		lock = abs
+	}
+	mu, _ := cacheLocks.LoadOrStore(lock, new(sync.Mutex))
+	return mu.(*sync.Mutex)
+}
+
+// cachedResults loads the Cache at opts.CacheFile, returning it, or a fresh one when it doesn't
+// exist or was recorded by an analysis configured differently, along with the toolchain the
+// analysis is checked with.
+func cachedResults(ctx context.Context, opts Options) (*Cache, string, error) {
+	toolchain, err := toolchainFor(ctx, opts)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "determining toolchain")
+	}
+	templateHash, err := TemplateHash(opts)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "hashing template")
+	}
+
+	s, key := opts.cacheStore()
+	c, err := LoadCacheFrom(ctx, s, key)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "loading cache")
+	}
+	if c == nil || !c.Matches(opts, toolchain, templateHash) {
+		return newCache(opts, toolchain, templateHash), toolchain, nil
+	}
+
+	return c, toolchain, nil
+}
+
+// toolchainFor identifies the toolchain the analysis configured by opts is checked with, so
+// results are never reused across go versions.
+func toolchainFor(ctx context.Context, opts Options) (string, error) {
+	switch {
+	case opts.Backend != nil:
+		// NOTE: There's no telling what a custom Backend checks with, so it is only ever
+		// trusted to match itself.
+		return "", nil
+	case opts.Engine == EngineTypes:
+		return runtime.Version(), nil
+	case opts.Engine == EngineRemote:
+		return opts.RemoteURL, nil
+	case opts.Toolchain != "":
+		return opts.Toolchain, nil
+	default:
+		out, err := exec.CommandContext(ctx, "go", "env", "GOVERSION").Output()
+		if err != nil {
+			return "", errors.Wrap(err, "running go env GOVERSION")
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+}
@@ -0,0 +1,95 @@
+package conversions
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCacheInterrupted cancels an analysis once it has handled its first Result, and checks that
+// every pair it handled before stopping was saved to the Cache, so the next run needn't check
+// them again.
+func TestCacheInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var opts Options
+	opts.Types = Primitives
+	opts.Engine = EngineTypes
+	opts.OutputDir = t.TempDir()
+	opts.CacheFile = filepath.Join(t.TempDir(), "cache.json")
+	opts.Parallelism = 1
+	opts.MaxErrors = len(Primitives) + 1
+	var handled [][2]string
+	opts.Events = func(e Event) {
+		if e.Type != EventPair {
+			return
+		}
+		handled = append(handled, [2]string{e.Result.From, e.Result.To})
+		cancel()
+	}
+
+	_, err := Analyze(ctx, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the analysis to be cancelled, got %v", err)
+	}
+	if len(handled) == 0 || len(handled) == len(Primitives)*len(Primitives) {
+		t.Fatalf("handled %d pairs, expected the analysis to stop partway through", len(handled))
+	}
+
+	c, err := LoadCache(opts.CacheFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c == nil {
+		t.Fatal("nothing was cached")
+	}
+	var cached Matrix
+	cached.Results = c.Results
+	cached = cached.Indexed()
+	for _, pair := range handled {
+		if _, ok := cached.Result(pair[0], pair[1]); !ok {
+			t.Errorf("%s -> %s was checked but not cached", pair[0], pair[1])
+		}
+	}
+}
+
+// TestCacheTemplateEdited analyzes with a TemplateFile twice, and then again once it's edited in
+// place, and checks that only the analysis after the edit doesn't take its Results from the Cache.
+func TestCacheTemplateEdited(t *testing.T) {
+	var opts Options
+	opts.Types = []string{"bool", "int8", "string"}
+	opts.Engine = EngineTypes
+	opts.OutputDir = t.TempDir()
+	opts.CacheFile = filepath.Join(t.TempDir(), "cache.json")
+	opts.TemplateFile = filepath.Join(t.TempDir(), "conversions.tmpl")
+	var planned int
+	opts.Events = func(e Event) {
+		if e.Type == EventShardsPlanned {
+			planned = e.Shards
+		}
+	}
+	err := os.WriteFile(opts.TemplateFile, []byte(DefaultTemplate), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for i, edit := range []string{"", "", "\n// Edited in place.\n"} {
+		if edit != "" {
+			err := os.WriteFile(opts.TemplateFile, []byte(DefaultTemplate+edit), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, err := Analyze(ctx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cached := i == 1; cached != (planned == 0) {
+			t.Errorf("analysis %d checked %d shards, expected the cache to be used: %t", i+1, planned, cached)
+		}
+	}
+}
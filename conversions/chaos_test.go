@@ -0,0 +1,180 @@
+package conversions
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	// faultCrash makes the compiler crash partway through its output, as an internal compiler
+	// error does, exiting with status 2.
+	faultCrash = "crash"
+	// faultTruncated cuts the compiler's output off partway through a line, as a full pipe or a
+	// killed process can.
+	faultTruncated = "truncated"
+	// faultExit has the compiler report everything it should, but exit with status 137, as one
+	// killed for running out of memory does.
+	faultExit = "exit"
+	// faultSlow holds every check up by chaosDelay before letting it through unharmed.
+	faultSlow = "slow"
+	// faultFlaky fails the first check of every file as a compiler killed for running out of
+	// memory does, and lets every check after it through unharmed.
+	faultFlaky = "flaky"
+
+	// chaosDelay is how long faultSlow holds each check up.
+	chaosDelay = 10 * time.Millisecond
+)
+
+type (
+	// faultyBackend injects fault into what backend reports, by rendering the conversion failures
+	// it finds back into compiler output, tampering with it, and parsing that as Compile does.
+	faultyBackend struct {
+		backend Backend
+		fault   string
+		// failed are the files faultFlaky has already failed the check of.
+		failed *sync.Map
+	}
+)
+
+// TestChaos injects a fault into every check of an analysis, with and without Options.Strict,
+// and checks that it either fails with the error expected of the fault or gets the Matrix right,
+// never silently getting it wrong.
+func TestChaos(t *testing.T) {
+	var opts Options
+	opts.Types = []string{"bool", "uint8", "int8", "int64", "float32", "string"}
+	opts.Engine = EngineTypes
+	opts.OutputDir = t.TempDir()
+	opts.MaxErrors = 10
+	opts.RetryBackoff = time.Millisecond
+	opts = opts.WithDefaults()
+
+	ctx := context.Background()
+	reference, err := Analyze(ctx, opts)
+	if err != nil {
+		t.Fatalf("analyzing without faults: %v", err)
+	}
+
+	isDiagnostic := func(err error) bool {
+		var de *DiagnosticError
+		return errors.As(err, &de)
+	}
+	isUnrecognized := func(err error) bool {
+		return errors.Is(err, ErrUnrecognizedPhrasing)
+	}
+	tests := []struct {
+		fault   string
+		strict  bool
+		retries int
+		// wantErr is whether the analysis returned the error expected of the fault, nil when it's
+		// expected to get the Matrix right.
+		wantErr func(error) bool
+	}{
+		{fault: faultCrash, wantErr: isDiagnostic},
+		{fault: faultCrash, strict: true, wantErr: isDiagnostic},
+		{fault: faultTruncated, wantErr: isUnrecognized},
+		{fault: faultTruncated, strict: true, wantErr: isUnrecognized},
+		{fault: faultExit, wantErr: IsTransient},
+		{fault: faultExit, strict: true, wantErr: IsTransient},
+		{fault: faultSlow},
+		{fault: faultSlow, strict: true},
+		{fault: faultFlaky, wantErr: IsTransient},
+		{fault: faultFlaky, strict: true, wantErr: IsTransient},
+		{fault: faultFlaky, retries: 1},
+		{fault: faultFlaky, strict: true, retries: 1},
+	}
+	for _, tt := range tests {
+		name := tt.fault
+		if tt.strict {
+			name += "/strict"
+		}
+		if tt.retries > 0 {
+			name += "/retried"
+		}
+		t.Run(name, func(t *testing.T) {
+			faulty := opts
+			faulty.Strict = tt.strict
+			faulty.Retries = tt.retries
+			var fb faultyBackend
+			fb.backend = TypesBackend{}
+			fb.fault = tt.fault
+			fb.failed = new(sync.Map)
+			faulty.Backend = fb
+
+			m, err := Analyze(ctx, faulty)
+			switch {
+			case tt.wantErr != nil && err == nil:
+				t.Fatalf("expected an error, got a Matrix with %d pairs changed", len(Diff(reference, m)))
+			case tt.wantErr != nil && !tt.wantErr(err):
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr == nil:
+				if changes := Diff(reference, m); len(changes) > 0 {
+					t.Fatalf("silently got %d pairs wrong", len(changes))
+				}
+			}
+		})
+	}
+}
+
+// Check implements Backend.
+func (fb faultyBackend) Check(ctx context.Context, opts Options, file string, src []byte) (ConversionFailures, error) {
+	if fb.fault == faultSlow {
+		select {
+		case <-time.After(chaosDelay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return fb.backend.Check(ctx, opts, file, src)
+	}
+	if fb.fault == faultFlaky {
+		if _, failed := fb.failed.LoadOrStore(file, true); !failed {
+			return nil, transientOutput(file, 137, "signal: killed\n")
+		}
+		return fb.backend.Check(ctx, opts, file, src)
+	}
+
+	// NOTE: Strict is left to diagnose, which is what's given the tampered output.
+	clean := opts
+	clean.Strict = false
+	cfs, err := fb.backend.Check(ctx, clean, file, src)
+	if err != nil {
+		return nil, err
+	}
+	lines := []string{"# conversions"}
+	for _, cf := range cfs {
+		line := cf.CompilerMessage
+		if cf.Position != "" {
+			line = cf.Position + ": " + line
+		}
+		lines = append(lines, line)
+	}
+	exitCode := 0
+	if len(cfs) > 0 {
+		exitCode = 1
+	}
+
+	switch fb.fault {
+	case faultCrash:
+		lines = append(lines[:len(lines)/2+1],
+			file+":1:1: internal compiler error: panic: runtime error: invalid memory address or nil pointer dereference",
+			"",
+			"Please file a bug report including a short program that triggers the error.",
+			"https://go.dev/issue/new",
+		)
+		exitCode = 2
+	case faultTruncated:
+		last := len(lines) / 2
+		lines = append(lines[:last], lines[last][:len(lines[last])/2])
+	case faultExit:
+		exitCode = 137
+	default:
+		return nil, errors.Errorf("unknown fault %q", fb.fault)
+	}
+
+	return diagnose(opts, file, exitCode, strings.Join(lines, "\n")+"\n")
+}
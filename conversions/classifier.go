@@ -0,0 +1,95 @@
+package conversions
+
+import (
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type (
+	// ClassifierFunc classifies a Pair by a rule of its own, e.g. whether a style guide allows
+	// the conversion, returning the value of its column for the pair, or "" to leave it empty.
+	ClassifierFunc func(p Pair) string
+)
+
+var (
+	// classifiersMu guards classifiers.
+	classifiersMu sync.RWMutex
+	// classifiers are the registered ClassifierFuncs by the name of their column.
+	classifiers = map[string]ClassifierFunc{}
+)
+
+// RegisterClassifier adds a column named column to the Result of every pair analyzed from then
+// on, holding what fn returns for it, see Result.Columns. Every report includes the column:
+// the json report as part of each Result, the csv report as a column of its own, and the rest
+// alongside each pair's verdict. It is an error to register the same column twice.
+func RegisterClassifier(column string, fn ClassifierFunc) error {
+	classifiersMu.Lock()
+	defer classifiersMu.Unlock()
+
+	if column == "" {
+		return errors.New("classifier column must not be empty")
+	}
+	if fn == nil {
+		return errors.Errorf("classifier %q must not be nil", column)
+	}
+	if _, ok := classifiers[column]; ok {
+		return errors.Errorf("classifier %q is already registered", column)
+	}
+	classifiers[column] = fn
+	return nil
+}
+
+// Classifiers returns the column of every registered ClassifierFunc, sorted, which is the order
+// reports show them in.
+func Classifiers() []string {
+	classifiersMu.RLock()
+	defer classifiersMu.RUnlock()
+
+	columns := make([]string, 0, len(classifiers))
+	for column := range classifiers {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// classify is the value of every registered ClassifierFunc's column for result, leaving out
+// those which are empty, or nil when all of them are.
+func classify(result Result) map[string]string {
+	classifiersMu.RLock()
+	defer classifiersMu.RUnlock()
+
+	if len(classifiers) == 0 {
+		return nil
+	}
+	p := PairOf(result)
+	var columns map[string]string
+	for column, fn := range classifiers {
+		value := fn(p)
+		if value == "" {
+			continue
+		}
+		if columns == nil {
+			columns = make(map[string]string)
+		}
+		columns[column] = value
+	}
+	return columns
+}
+
+// DescribeColumns describes the Columns of result in the order of Classifiers, e.g.
+// "style-guide: allowed, owner: payments", or "" when it has none.
+func DescribeColumns(result Result) string {
+	if len(result.Columns) == 0 {
+		return ""
+	}
+	var described []string
+	for _, column := range Classifiers() {
+		if value, ok := result.Columns[column]; ok {
+			described = append(described, column+": "+value)
+		}
+	}
+	return strings.Join(described, ", ")
+}
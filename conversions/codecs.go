@@ -0,0 +1,189 @@
+package conversions
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	// CodecJSON is encoding/json, decoding back into the original type.
+	CodecJSON = "json"
+	// CodecJSONAny is encoding/json, decoding into an interface{} and converting back to the original type.
+	CodecJSONAny = "json-any"
+	// CodecMsgPack is msgpack, encoding numbers in its widest format for their type and decoding
+	// them into an interface{}, as an int64, a uint64 above math.MaxInt64, or a float64.
+	CodecMsgPack = "msgpack"
+	// CodecCBOR is CBOR, encoding numbers in its widest form for their type, negative integers as
+	// -1 minus their magnitude, and decoding them into an interface{} as CodecMsgPack does.
+	CodecCBOR = "cbor"
+)
+
+type (
+	// CodecResult is whether a single probe value of Type survived a round trip through Codec.
+	CodecResult struct {
+		Type     string
+		Value    string
+		Codec    string
+		Survived bool
+		Error    string `json:",omitempty"`
+	}
+)
+
+var (
+	// Codecs lists every codec probed by AnalyzeCodecs, in the order they are reported.
+	Codecs = []string{CodecJSON, CodecJSONAny, CodecMsgPack, CodecCBOR}
+
+	// CodecsTemplate is the template the round trip probe program is generated from.
+	//go:embed template/codecs.tmpl
+	CodecsTemplate string
+)
+
+// AnalyzeCodecs generates and runs a program which round trips the values opts.Values generates
+// of every type through each of the Codecs, reporting which values come back unchanged. Since the probes must
+// actually run, this requires EngineBuild.
+func AnalyzeCodecs(ctx context.Context, opts Options) ([]CodecResult, error) {
+	opts = opts.WithDefaults()
+	if opts.Engine != EngineBuild {
+		return nil, errors.Errorf("codec probes have to be run, which the %s engine can't do", opts.Engine)
+	}
+
+	type Type struct {
+		Name    string
+		Kind    Kind
+		Numeric bool
+		Values  []string
+	}
+	type Data struct {
+		Now     string
+		App     string
+		Types   []Type
+		JSON    string
+		JSONAny string
+		MsgPack string
+		CBOR    string
+	}
+	var data Data
+	data.Now = time.Now().Format(time.RFC3339)
+	data.App = os.Args[0]
+	data.JSON = CodecJSON
+	data.JSONAny = CodecJSONAny
+	data.MsgPack = CodecMsgPack
+	data.CBOR = CodecCBOR
+	for _, name := range opts.Types {
+		info, ok := Lookup(name)
+		if !ok {
+			continue
+		}
+		var t Type
+		t.Name = info.Name
+		t.Kind = info.Kind
+		t.Numeric = info.IsNumeric()
+		values, err := opts.Values.Values(info)
+		if err != nil {
+			return nil, errors.Wrapf(err, "generating %s values", name)
+		}
+		if len(values) == 0 {
+			continue
+		}
+		t.Values = values
+		data.Types = append(data.Types, t)
+	}
+
+	t, err := parseCached("codecs.tmpl", CodecsTemplate, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing template")
+	}
+	var src bytes.Buffer
+	err = t.Execute(&src, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "executing template")
+	}
+
+	probeFile := filepath.Join(opts.OutputDir, "codecs", "main.go")
+	err = WriteFileAtomic(probeFile, src.Bytes(), 0o644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "writing probe file %q", probeFile)
+	}
+
+	err = WriteProbeModule(opts.OutputDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "writing probe module")
+	}
+
+	cmd := probeCommand(opts.OutputDir, "run", "./codecs")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = runCommand(ctx, cmd)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "running probes: %s", stderr.String())
+	}
+
+	var results []CodecResult
+	err = json.Unmarshal(stdout.Bytes(), &results)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding probe results")
+	}
+
+	return results, nil
+}
+
+// ProbeValues returns expressions for the interesting boundary values of the type i, each of
+// which is converted to i before use.
+func ProbeValues(i Info) []string {
+	switch i.Kind {
+	case KindBool:
+		return []string{"false", "true"}
+	case KindString:
+		return []string{`""`, `"hello"`, `"\xff"`}
+	case KindComplex:
+		return []string{"0", "complex(1, 2)"}
+	case KindFloat:
+		name := "Float" + strconv.Itoa(i.Bits)
+		return []string{
+			"0",
+			"math.Copysign(0, -1)",
+			"0.1",
+			"1 << 53",
+			"1<<53 + 1",
+			"math.Max" + name,
+			"math.SmallestNonzero" + name,
+			"math.NaN()",
+			"math.Inf(1)",
+		}
+	}
+
+	var values []string
+	switch i.Canonical() {
+	case "uintptr":
+		values = []string{"0", "1", "1 << 53", "^uintptr(0)"}
+	case "int", "int8", "int16", "int32", "int64":
+		suffix := "Int" + bitsSuffix(i)
+		values = []string{"0", "1", "-1", "math.Min" + suffix, "math.Max" + suffix}
+	default:
+		values = []string{"0", "1", "math.MaxUint" + bitsSuffix(i)}
+	}
+	if i.MinBits() > 53 {
+		// NOTE: Values just past float64's exact integer range are where JSON starts to bite.
+		values = append(values, "1<<53 + 1")
+	}
+	return values
+}
+
+// bitsSuffix is the suffix math uses for the bounds of the integer type i, e.g. 64 in math.MaxInt64.
+func bitsSuffix(i Info) string {
+	if i.Bits == 0 {
+		return ""
+	}
+	return strconv.Itoa(i.Bits)
+}
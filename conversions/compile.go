@@ -0,0 +1,178 @@
+package conversions
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// positionRegexp extracts the file:line:column prefix out of a compiler error.
+	positionRegexp = regexp.MustCompile(`^(\S+:\d+:\d+): `)
+)
+
+// Compile compiles the generated go code located at outputFile, expecting it
+// to fail compilation and throw errors. It records these compilation errors
+// into a ConversionFailures and returns them. outputFile is compiled from within
+// its own directory, which is expected to hold the module written by WriteProbeModule.
+// With opts.Strict set, any output that can't be accounted for is returned as a
+// *DiagnosticError instead.
+func Compile(ctx context.Context, opts Options, outputFile string) (ConversionFailures, error) {
+	gcflags := "-gcflags=-e"
+	if opts.LangVersion != "" {
+		// NOTE: A file named on the command line isn't held to the go directive of the module
+		// it's in, so the probe module's go.mod alone wouldn't gate anything.
+		gcflags += " -lang=" + opts.LangVersion
+	}
+	cmd := probeCommand(filepath.Dir(outputFile), "build", gcflags, "-o", os.DevNull, filepath.Base(outputFile))
+	if opts.GOARCH != "" {
+		cmd.Env = append(cmd.Env, "GOARCH="+opts.GOARCH)
+	}
+	if opts.Toolchain != "" {
+		cmd.Env = append(cmd.Env, "GOTOOLCHAIN="+opts.Toolchain)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := runCommand(ctx, cmd)
+	if ctx.Err() != nil {
+		// NOTE: A killed compiler exits non-zero just like a complaining one does, so its
+		// output can't be trusted once we've been asked to stop.
+		return nil, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		// NOTE(justin): We expect to get a non-zero exit status since we expect the compiler to complain.
+		// If we got some other error, this will be triggered.
+		return nil, errors.Wrap(err, "unexpected error while running compilation command")
+	}
+
+	exitCode := 0
+	if exitErr != nil {
+		exitCode = exitErr.ExitCode()
+	}
+
+	return diagnose(opts, outputFile, exitCode, stderr.String())
+}
+
+// diagnose parses the conversion failures out of the output of building file, which exited with
+// exitCode. A compiler which was killed, or ran out of a resource, is returned as a
+// *TransientError, and one which crashed as a *DiagnosticError. With opts.Strict set, any other
+// output that can't be accounted for is returned as a *DiagnosticError too.
+func diagnose(opts Options, file string, exitCode int, output string) (ConversionFailures, error) {
+	cfs, unparsed := ParseFailures(output)
+	err := saveDebugOutput(opts, file, exitCode, output, cfs, unparsed)
+	if err != nil {
+		return nil, err
+	}
+	if exitCode != 0 {
+		// NOTE: A killed compiler's output can't be trusted to cover every failure, even outside
+		// of strict mode, so the shard is failed, or retried, rather than diagnosed.
+		err = transientOutput(file, exitCode, output)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// NOTE: Skipping a failure we can't parse would report its pair as convertible, so this is
+	// never left to -strict.
+	err = unrecognizedPhrasing(unparsed)
+	if err != nil {
+		return nil, errors.Wrapf(err, "compiling %q", file)
+	}
+
+	var de DiagnosticError
+	de.File = file
+	de.ExitCode = exitCode
+	de.Output = output
+	de.Unparsed = unparsed
+	if exitCode != 0 && strings.Contains(output, "internal compiler error") {
+		// NOTE: A crashed compiler only reported the failures it found before crashing, so
+		// this isn't left to -strict either.
+		de.Reason = "the compiler crashed"
+		return nil, &de
+	}
+	if !opts.Strict {
+		return cfs, nil
+	}
+
+	switch {
+	case len(unparsed) > 0:
+		de.Reason = fmt.Sprintf("%d lines of compiler output could not be parsed", len(unparsed))
+	case exitCode != 0 && exitCode != 1 && exitCode != 2:
+		de.Reason = fmt.Sprintf("unexpected exit status %d", exitCode)
+	case exitCode != 0 && len(cfs) == 0:
+		de.Reason = fmt.Sprintf("exit status %d without reporting any conversion failures", exitCode)
+	case exitCode == 0 && output != "":
+		de.Reason = "compilation succeeded but still produced output"
+	default:
+		return cfs, nil
+	}
+
+	return nil, &de
+}
+
+// runCommand runs cmd to completion, killing it along with every process it started
+// (go build forks off the actual compiler) if ctx is done before it finishes.
+func runCommand(ctx context.Context, cmd *exec.Cmd) error {
+	setProcessGroup(cmd)
+
+	err := cmd.Start()
+	if err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		_ = killProcessGroup(cmd)
+		return <-done
+	}
+}
+
+// ParseFailures extracts a *ConversionError for every conversion failure out of the compiler
+// output in stderrContent. Any lines which are neither a conversion failure nor the package
+// header go build prints are returned as unparsed.
+func ParseFailures(stderrContent string) (ConversionFailures, []string) {
+	stderrLines := strings.Split(stderrContent, "\n")
+
+	var conversionErrs []string
+	var unparsed []string
+	for _, stderrLine := range stderrLines {
+		switch {
+		case strings.Contains(stderrLine, "cannot convert"):
+			conversionErrs = append(conversionErrs, stderrLine)
+		case strings.TrimSpace(stderrLine) == "", strings.HasPrefix(stderrLine, "# "):
+		default:
+			unparsed = append(unparsed, stderrLine)
+		}
+	}
+
+	var cfs ConversionFailures
+	for _, conversionErr := range conversionErrs {
+		from, to, ok := ParsePhrasing(conversionErr)
+		if !ok {
+			unparsed = append(unparsed, conversionErr)
+			continue
+		}
+		conversionFailure := NewConversionError(from, to)
+		conversionFailure.CompilerMessage = conversionErr
+		if position := positionRegexp.FindStringSubmatch(conversionErr); position != nil {
+			conversionFailure.Position = position[1]
+			conversionFailure.CompilerMessage = strings.TrimPrefix(conversionErr, position[0])
+		}
+		cfs = append(cfs, conversionFailure)
+	}
+
+	return cfs, unparsed
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package conversions
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup is a no-op on platforms without process groups.
+func setProcessGroup(_ *exec.Cmd) {}
+
+// killProcessGroup kills the process started by cmd.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package conversions
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup puts cmd in its own process group so it can be killed along with its children.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kills the process group started by cmd.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
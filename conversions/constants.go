@@ -0,0 +1,218 @@
+package conversions
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/pkg/errors"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+type (
+	// ConstantComparison contrasts what a variable of From can do with To, as the Matrix the
+	// comparison was made from has it, with what each constant value of From can, since the
+	// compiler treats the two differently: an untyped constant is assigned to any type it is
+	// representable by without a conversion, while a constant which isn't representable by To
+	// can't even be converted to it, e.g. int(1.5), though a float64 variable holding 1.5 can.
+	ConstantComparison struct {
+		From string
+		To   string
+		// Variable is whether a variable of From converts to To.
+		Variable bool
+		// VariableAssignable is whether a variable of From is assigned to To without converting
+		// it, only when they are the same type.
+		VariableAssignable bool
+		// Constants are each constant value of From the comparison was made with.
+		Constants []ConstantResult
+	}
+
+	// ConstantResult is what a single constant Value of From can do with To.
+	ConstantResult struct {
+		// Value is the constant expression, untyped unless it says otherwise, e.g. 1<<53 + 1.
+		Value string
+		// Assignable is whether Value, as is, is assigned to To without a conversion.
+		Assignable bool
+		// Convertible is whether Value, as a constant of From, converts to To.
+		Convertible bool
+		// Reason is what the type checker reported, if Value isn't Convertible.
+		Reason string `json:",omitempty"`
+	}
+)
+
+// Differs reports whether any constant value of From converts to To when a variable doesn't,
+// or doesn't when a variable does.
+func (cc ConstantComparison) Differs() bool {
+	for _, cr := range cc.Constants {
+		if cr.Convertible != cc.Variable {
+			return true
+		}
+	}
+	return false
+}
+
+// AssignmentDiffers reports whether any constant value of From is assigned to To without
+// converting when a variable isn't, or the reverse, as every constant which fits is assigned to
+// each type it converts to. Unlike Differs, it's rarely a surprise.
+func (cc ConstantComparison) AssignmentDiffers() bool {
+	for _, cr := range cc.Constants {
+		if cr.Assignable != cc.VariableAssignable {
+			return true
+		}
+	}
+	return false
+}
+
+// CompareConstants compares every pair of m with the constant values opts.Values generates of its
+// From type, see ConstantComparison. Values which aren't constant expressions, e.g. math.NaN(),
+// are left out. The constants are type checked with go/types whatever opts.Engine is, under
+// opts.LangVersion when set.
+func CompareConstants(opts Options, m Matrix) ([]ConstantComparison, error) {
+	opts = opts.WithDefaults()
+	err := checkLangVersion(opts.LangVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	type Value struct {
+		From  string
+		Value string
+		// Name is the untyped constant declared with the Value, and Typed the constant of From.
+		Name  string
+		Typed string
+		// valid is whether Value is a constant of From at all.
+		valid bool
+	}
+	type Line struct {
+		value *Value
+		// result is the ConstantResult the line probes, nil for a line declaring value.
+		result *ConstantResult
+		// assign is whether the line probes result.Assignable, rather than result.Convertible.
+		assign bool
+	}
+	var values []*Value
+	for _, name := range m.Types {
+		info, ok := Lookup(name)
+		if !ok {
+			continue
+		}
+		vs, err := opts.Values.Values(info)
+		if err != nil {
+			return nil, errors.Wrapf(err, "generating %s values", name)
+		}
+		for _, v := range vs {
+			var value Value
+			value.From = name
+			value.Value = v
+			value.Name = fmt.Sprintf("probeValue%03d", len(values))
+			value.Typed = fmt.Sprintf("probeTyped%03d", len(values))
+			value.valid = true
+			values = append(values, &value)
+		}
+	}
+
+	var src bytes.Buffer
+	src.WriteString("package conversions\n\n")
+	for _, v := range values {
+		// NOTE: ProbeValues are written with the bounds math declares.
+		if strings.Contains(v.Value, "math.") {
+			src.WriteString("import \"math\"\n\n")
+			break
+		}
+	}
+	// NOTE: Each declaration and probe is on a line of its own, so the errors can be told apart by line.
+	line := strings.Count(src.String(), "\n") + 1
+	lines := make(map[int]Line)
+	for _, v := range values {
+		_, _ = fmt.Fprintf(&src, "const %s = %s\n", v.Name, v.Value)
+		_, _ = fmt.Fprintf(&src, "const %s %s = %s\n", v.Typed, v.From, v.Name)
+		lines[line] = Line{value: v}
+		lines[line+1] = Line{value: v}
+		line += 2
+	}
+	src.WriteString("\nfunc probe() {\n")
+	line += 2
+	probes := make(map[[2]string][]Line)
+	for _, v := range values {
+		for _, to := range m.Types {
+			var cr ConstantResult
+			cr.Value = v.Value
+			cr.Assignable = true
+			cr.Convertible = true
+			pair := [2]string{v.From, to}
+			probes[pair] = append(probes[pair], Line{value: v, result: &cr})
+			_, _ = fmt.Fprintf(&src, "\tvar _ %s = %s\n", to, v.Name)
+			_, _ = fmt.Fprintf(&src, "\t_ = %s(%s)\n", to, v.Typed)
+			lines[line] = Line{value: v, result: &cr, assign: true}
+			lines[line+1] = Line{value: v, result: &cr}
+			line += 2
+		}
+	}
+	src.WriteString("}\n")
+
+	const filename = "constants.go"
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src.Bytes(), parser.AllErrors)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing constants")
+	}
+
+	var unexpected []string
+	var conf types.Config
+	conf.Importer = importer.Default()
+	conf.GoVersion = opts.LangVersion
+	if opts.GOARCH != "" {
+		conf.Sizes = types.SizesFor("gc", opts.GOARCH)
+	}
+	conf.Error = func(err error) {
+		te, ok := err.(types.Error)
+		if !ok {
+			unexpected = append(unexpected, err.Error())
+			return
+		}
+		l, ok := lines[fset.Position(te.Pos).Line]
+		if !ok {
+			unexpected = append(unexpected, te.Msg)
+			return
+		}
+		switch {
+		case l.result == nil:
+			l.value.valid = false
+		case l.assign:
+			l.result.Assignable = false
+		default:
+			l.result.Convertible = false
+			l.result.Reason = te.Msg
+		}
+	}
+	// NOTE: The returned error is only the first of the errors already collected by conf.Error.
+	_, _ = conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+	if len(unexpected) > 0 {
+		return nil, errors.Errorf("type checking constants: %s", strings.Join(unexpected, "; "))
+	}
+
+	var ccs []ConstantComparison
+	for _, from := range m.Types {
+		for _, to := range m.Types {
+			result, ok := m.Result(from, to)
+			if !ok {
+				continue
+			}
+			var cc ConstantComparison
+			cc.From = from
+			cc.To = to
+			cc.Variable = result.Convertible
+			cc.VariableAssignable = CanonicalName(from) == CanonicalName(to)
+			for _, l := range probes[[2]string{from, to}] {
+				if l.value.valid {
+					cc.Constants = append(cc.Constants, *l.result)
+				}
+			}
+			ccs = append(ccs, cc)
+		}
+	}
+	return ccs, nil
+}
@@ -0,0 +1,174 @@
+package conversions
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/pkg/errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+type (
+	// Constraint is a type parameter constraint to probe, e.g. {Name: "Integer", Elements: "~int | ~int64"}.
+	Constraint struct {
+		Name string
+		// Elements is the body of the constraint's interface, usually a union of type terms,
+		// e.g. ~int | ~string. It may also list methods, one element per line or separated by
+		// semicolons.
+		Elements string
+	}
+
+	// ConstraintResult is whether Type satisfies Constraint, i.e. whether a type parameter
+	// constrained by it can be instantiated with Type.
+	ConstraintResult struct {
+		Constraint string
+		Type       string
+		Satisfies  bool
+		// Reason is what the type checker reported, if Type doesn't satisfy Constraint.
+		Reason string `json:",omitempty"`
+	}
+)
+
+var (
+	// BuiltinConstraints are the predeclared constraints every probe checks, along with any others.
+	BuiltinConstraints = []Constraint{
+		{Name: "any", Elements: ""},
+		{Name: "comparable", Elements: ""},
+	}
+
+	// ConstraintProbeTypes are the types, other than the primitives, every constraint is
+	// instantiated with, covering the ones which aren't comparable and interfaces, which only
+	// satisfy comparable as of go1.20.
+	ConstraintProbeTypes = []string{"[]byte", "[4]byte", "*int", "map[string]int", "chan int", "func()", "struct{}", "any", "error"}
+)
+
+// NamedType is the name ConstraintSatisfaction reports a type defined with the primitive named
+// name as its underlying type by, e.g. named int8, which only satisfies a union of type terms
+// if it's listed with a tilde, as ~int8.
+func NamedType(name string) string {
+	return "named " + name
+}
+
+// ConstraintSatisfaction reports, for each of BuiltinConstraints and constraints, whether each of
+// opts.Types, a type defined with each of them as its underlying type, see NamedType, and each of
+// ConstraintProbeTypes satisfies it. The probe is type checked with go/types whatever opts.Engine
+// is, under opts.LangVersion when set.
+func ConstraintSatisfaction(opts Options, constraints []Constraint) ([]ConstraintResult, error) {
+	opts = opts.WithDefaults()
+	err := checkLangVersion(opts.LangVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	declared := make(map[string]bool)
+	for _, c := range BuiltinConstraints {
+		declared[c.Name] = true
+	}
+	for _, c := range constraints {
+		if !token.IsIdentifier(c.Name) {
+			return nil, errors.Errorf("constraint name %q is not an identifier", c.Name)
+		}
+		if declared[c.Name] {
+			return nil, errors.Errorf("constraint %q is declared more than once", c.Name)
+		}
+		declared[c.Name] = true
+	}
+	all := append(append([]Constraint{}, BuiltinConstraints...), constraints...)
+
+	type Probe struct {
+		// Name is what the type is reported as, and Type what it's written as in the probe.
+		Name string
+		Type string
+		// Underlying is the primitive a generated type is defined with.
+		Underlying string
+	}
+	var probes, named []Probe
+	var renames []string
+	for _, t := range opts.Types {
+		if _, ok := Lookup(t); !ok {
+			continue
+		}
+		probes = append(probes, Probe{Name: t, Type: t})
+		n := Probe{Name: NamedType(t), Type: fmt.Sprintf("probeNamed%03d", len(named)), Underlying: t}
+		named = append(named, n)
+		renames = append(renames, n.Type, n.Name)
+	}
+	probes = append(probes, named...)
+	for _, t := range ConstraintProbeTypes {
+		probes = append(probes, Probe{Name: t, Type: t})
+	}
+	// NOTE: The type checker names the generated types in what it reports.
+	renamer := strings.NewReplacer(renames...)
+
+	var src bytes.Buffer
+	src.WriteString("package conversions\n\n")
+	for _, n := range named {
+		_, _ = fmt.Fprintf(&src, "type %s %s\n", n.Type, n.Underlying)
+	}
+	for _, c := range constraints {
+		_, _ = fmt.Fprintf(&src, "\ntype %s interface {\n%s\n}\n", c.Name, c.Elements)
+	}
+	for i, c := range all {
+		_, _ = fmt.Fprintf(&src, "\nfunc satisfies%03d[T %s]() {}\n", i, c.Name)
+	}
+	src.WriteString("\nfunc probe() {\n")
+	// NOTE: Each instantiation is on a line of its own, so the errors can be told apart by line.
+	line := strings.Count(src.String(), "\n") + 1
+	results := make(map[int]*ConstraintResult)
+	var ordered []*ConstraintResult
+	for i, c := range all {
+		for _, p := range probes {
+			_, _ = fmt.Fprintf(&src, "\tsatisfies%03d[%s]()\n", i, p.Type)
+			var cr ConstraintResult
+			cr.Constraint = c.Name
+			cr.Type = p.Name
+			cr.Satisfies = true
+			results[line] = &cr
+			ordered = append(ordered, &cr)
+			line++
+		}
+	}
+	src.WriteString("}\n")
+
+	const filename = "constraints.go"
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src.Bytes(), parser.AllErrors)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing constraints")
+	}
+
+	var unexpected []string
+	var conf types.Config
+	conf.GoVersion = opts.LangVersion
+	if opts.GOARCH != "" {
+		conf.Sizes = types.SizesFor("gc", opts.GOARCH)
+	}
+	conf.Error = func(err error) {
+		te, ok := err.(types.Error)
+		if !ok {
+			unexpected = append(unexpected, err.Error())
+			return
+		}
+		cr, ok := results[fset.Position(te.Pos).Line]
+		if !ok {
+			unexpected = append(unexpected, te.Msg)
+			return
+		}
+		cr.Satisfies = false
+		cr.Reason = renamer.Replace(te.Msg)
+	}
+	// NOTE: The returned error is only the first of the errors already collected by conf.Error.
+	_, _ = conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+	if len(unexpected) > 0 {
+		return nil, errors.Errorf("type checking constraints: %s", strings.Join(unexpected, "; "))
+	}
+
+	crs := make([]ConstraintResult, 0, len(ordered))
+	for _, cr := range ordered {
+		crs = append(crs, *cr)
+	}
+	return crs, nil
+}
@@ -0,0 +1,191 @@
+package conversions
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/pkg/errors"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+const (
+	// ContextStatement is a conversion on its own, assigned to the blank identifier, which is
+	// how the generated code probes every pair.
+	ContextStatement = "statement"
+	// ContextReturn is a conversion returned from a function.
+	ContextReturn = "return"
+	// ContextField is a conversion giving a field of a struct literal its value.
+	ContextField = "field"
+	// ContextArgument is a conversion passed to a function as an argument.
+	ContextArgument = "argument"
+)
+
+type (
+	// ContextComparison contrasts what a variable of From can do with To, as the Matrix the
+	// comparison was made from has it, with what it can do in each of Contexts. The spec makes a
+	// conversion's legality depend on its operand and type alone, so they should never differ.
+	ContextComparison struct {
+		From string
+		To   string
+		// Variable is whether a variable of From converts to To.
+		Variable bool
+		// Contexts are whether it converts in each of Contexts, in that order.
+		Contexts []ContextResult
+	}
+
+	// ContextResult is whether a variable of From converts to To in a single Context.
+	ContextResult struct {
+		// Context is one of Contexts.
+		Context string
+		// Convertible is whether the conversion compiles in Context.
+		Convertible bool
+		// Reason is what the type checker reported, if it isn't Convertible.
+		Reason string `json:",omitempty"`
+	}
+)
+
+var (
+	// Contexts are every context CompareContexts probes a conversion in.
+	Contexts = []string{ContextStatement, ContextReturn, ContextField, ContextArgument}
+)
+
+// Differs reports whether the conversion compiles in some of Contexts but not others, or in
+// none of them when a variable converts, or the other way around.
+func (cc ContextComparison) Differs() bool {
+	for _, cr := range cc.Contexts {
+		if cr.Convertible != cc.Variable {
+			return true
+		}
+	}
+	return false
+}
+
+// CompareContexts probes every pair of primitives in m in each of Contexts, see
+// ContextComparison. Since the probes need the ContextStatement probe alongside the others to
+// be compared like for like, they're type checked with go/types whatever opts.Engine is, under
+// opts.LangVersion when set.
+func CompareContexts(opts Options, m Matrix) ([]ContextComparison, error) {
+	opts = opts.WithDefaults()
+	err := checkLangVersion(opts.LangVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	var primitives []string
+	for _, name := range m.Types {
+		if _, ok := Lookup(name); ok {
+			primitives = append(primitives, name)
+		}
+	}
+	index := make(map[string]int, len(primitives))
+	for i, name := range primitives {
+		index[name] = i
+	}
+
+	var src bytes.Buffer
+	src.WriteString("package conversions\n\ntype probes struct {\n")
+	for i, name := range primitives {
+		_, _ = fmt.Fprintf(&src, "\tf%03d %s\n", i, name)
+	}
+	src.WriteString("}\n\nvar p probes\n\n")
+	for i, name := range primitives {
+		_, _ = fmt.Fprintf(&src, "type field%03d struct{ v %s }\n", i, name)
+		_, _ = fmt.Fprintf(&src, "func argument%03d(%s) {}\n", i, name)
+	}
+
+	// NOTE: Each probe is on a line of its own, so the errors can be told apart by line.
+	line := strings.Count(src.String(), "\n") + 1
+	lines := make(map[int]*ContextResult)
+	comparisons := make(map[[2]string]*ContextComparison)
+	var returns bytes.Buffer
+	src.WriteString("\nfunc probe() {\n")
+	line += 2
+	for _, from := range primitives {
+		for _, to := range primitives {
+			var cc ContextComparison
+			cc.From = from
+			cc.To = to
+			for _, context := range Contexts {
+				var cr ContextResult
+				cr.Context = context
+				cr.Convertible = true
+				cc.Contexts = append(cc.Contexts, cr)
+			}
+			comparisons[[2]string{from, to}] = &cc
+
+			conversion := fmt.Sprintf("%s(p.f%03d)", to, index[from])
+			_, _ = fmt.Fprintf(&src, "\t_ = %s\n", conversion)
+			_, _ = fmt.Fprintf(&src, "\t_ = field%03d{v: %s}\n", index[to], conversion)
+			_, _ = fmt.Fprintf(&src, "\targument%03d(%s)\n", index[to], conversion)
+			lines[line] = &cc.Contexts[0]
+			lines[line+1] = &cc.Contexts[2]
+			lines[line+2] = &cc.Contexts[3]
+			line += 3
+			_, _ = fmt.Fprintf(&returns, "func return%03d%03d() %s { return %s }\n", index[from], index[to], to, conversion)
+		}
+	}
+	src.WriteString("}\n\n")
+	line += 2
+	for _, from := range primitives {
+		for _, to := range primitives {
+			lines[line] = &comparisons[[2]string{from, to}].Contexts[1]
+			line++
+		}
+	}
+	src.Write(returns.Bytes())
+
+	const filename = "contexts.go"
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src.Bytes(), parser.AllErrors)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing contexts")
+	}
+
+	var unexpected []string
+	var conf types.Config
+	conf.Importer = importer.Default()
+	conf.GoVersion = opts.LangVersion
+	if opts.GOARCH != "" {
+		conf.Sizes = types.SizesFor("gc", opts.GOARCH)
+	}
+	conf.Error = func(err error) {
+		te, ok := err.(types.Error)
+		if !ok {
+			unexpected = append(unexpected, err.Error())
+			return
+		}
+		cr, ok := lines[fset.Position(te.Pos).Line]
+		if !ok {
+			unexpected = append(unexpected, te.Msg)
+			return
+		}
+		// NOTE: A context may report a second error for the same conversion, the first is kept.
+		if cr.Convertible {
+			cr.Convertible = false
+			cr.Reason = te.Msg
+		}
+	}
+	// NOTE: The returned error is only the first of the errors already collected by conf.Error.
+	_, _ = conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+	if len(unexpected) > 0 {
+		return nil, errors.Errorf("type checking contexts: %s", strings.Join(unexpected, "; "))
+	}
+
+	var ccs []ContextComparison
+	for _, from := range primitives {
+		for _, to := range primitives {
+			result, ok := m.Result(from, to)
+			if !ok {
+				continue
+			}
+			cc := comparisons[[2]string{from, to}]
+			cc.Variable = result.Convertible
+			ccs = append(ccs, *cc)
+		}
+	}
+	return ccs, nil
+}
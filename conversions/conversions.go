@@ -0,0 +1,247 @@
+// Package conversions determines which of Go's primitive types can be natively converted
+// between each other, as reported by the Go compiler itself.
+package conversions
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+)
+
+type (
+	// ConversionFailures is a helper type around a []*ConversionError to allow easier searching
+	// through a []*ConversionError.
+	ConversionFailures []*ConversionError
+
+	// Result is the outcome of converting a value of type From to type To.
+	Result struct {
+		From        string
+		To          string
+		Convertible bool
+		// Err is why From can't be converted to To, nil when it can.
+		Err *ConversionError `json:",omitempty"`
+		// Tags are the names of the user-defined tags that apply to this pair, see Options.Tags.
+		Tags []string `json:",omitempty"`
+		// Width is whether converting a numeric From to To is widening, narrowing, or
+		// reinterpreting, see Classify.
+		Width string `json:",omitempty"`
+		// Columns are the value of each registered ClassifierFunc's column for this pair, by
+		// the column's name, leaving out those it left empty, see RegisterClassifier.
+		Columns map[string]string `json:",omitempty"`
+	}
+
+	// Matrix holds a Result for every pair of Types.
+	Matrix struct {
+		Types   []string
+		Results []Result
+		// Pivoted is whether the rows of the Matrix are the types converted to, rather than from,
+		// when it is reported, see Pivot.
+		Pivoted bool `json:",omitempty"`
+		// Locale is the locale the headings and legends of its report are in, English when
+		// empty, see LookupCatalog.
+		Locale string `json:",omitempty"`
+		// Accessible is whether its report marks every pair with a word rather than by emoji
+		// alone, see Verbalizer.
+		Accessible bool `json:",omitempty"`
+		// Compare is whether its report is followed by a section comparing each pair to
+		// ForeignLangs, see Comparer.
+		Compare bool `json:",omitempty"`
+		// Theme is how its report marks each pair, ThemeDefault when zero, see Styler. It's
+		// how the Matrix looks rather than what it holds, so it's never encoded.
+		Theme Theme `json:"-"`
+		// Verbose is whether its report is followed by the TypeData of every one of its types,
+		// see Detailer. Like Theme, it's how the Matrix is reported, so it's never encoded.
+		Verbose bool `json:"-"`
+		// Provenance is what produced the Matrix, stamped on its report when set, see Stamper.
+		// It's left to whoever analyzed the Matrix to describe, so it's never decoded, and only
+		// encoded by FormatJSON.
+		Provenance Provenance `json:"-"`
+
+		// index locates each of Results by its pair, see Indexed.
+		index *resultIndex
+	}
+
+	// resultIndex is where each of the Results a Matrix was indexed with is, by its From and To.
+	resultIndex struct {
+		// results are the Results indexed, so a Matrix whose Results were replaced since, which
+		// still shares the index with the copy it was made from, isn't looked up with it.
+		results []Result
+		byPair  map[[2]string]int
+	}
+)
+
+var (
+	// Primitives contains the list of all primitives in golang, as reported by the builtin package,
+	// in canonical order (see SortTypes).
+	// I suppose even this could be extracted from the builtin package itself via some code introspection,
+	// but for now I hardcoded the list since the list of built-in primitives is unlikely to change
+	// frequently, if at all.
+	Primitives = []string{
+		"bool",
+		"uint8",
+		"byte", // NOTE(justin): is also a type alias for uint8
+		"uint16",
+		"uint32",
+		"uint64",
+		"uint",
+		"uintptr",
+		"int8",
+		"int16",
+		"int32",
+		"rune", // NOTE(justin): is also a type alias for int32
+		"int64",
+		"int",
+		"float32",
+		"float64",
+		"complex64",
+		"complex128",
+		"string",
+	}
+)
+
+// Contains is a helper function for determining if cfs contains a *ConversionError
+// that has it's From set to from and To set to to.
+func (cfs ConversionFailures) Contains(from, to string) bool {
+	return cfs.Find(from, to) != nil
+}
+
+// Find returns the *ConversionError in cfs converting from to to, or nil if there is none.
+func (cfs ConversionFailures) Find(from, to string) *ConversionError {
+	for _, conversionFailure := range cfs {
+		if conversionFailure.From == from && conversionFailure.To == to {
+			return conversionFailure
+		}
+	}
+	return nil
+}
+
+// Convertible reports whether m records that from can be converted to to.
+func (m Matrix) Convertible(from, to string) bool {
+	result, _ := m.Result(from, to)
+	return result.Convertible
+}
+
+// Result returns the Result m records for converting from to to, reporting false if it has none.
+// It's looked up by pair when m is Indexed, and found by scanning every Result when it isn't.
+func (m Matrix) Result(from, to string) (Result, bool) {
+	if m.index.covers(m.Results) {
+		i, ok := m.index.byPair[[2]string{from, to}]
+		if !ok {
+			return Result{}, false
+		}
+		// NOTE: A Result changed in place since it was indexed is looked for the slow way instead.
+		if result := m.Results[i]; result.From == from && result.To == to {
+			return result, true
+		}
+	}
+	for _, result := range m.Results {
+		if result.From == from && result.To == to {
+			return result, true
+		}
+	}
+	return Result{}, false
+}
+
+// Indexed returns m with its Results indexed by pair, so Result and Convertible find each pair
+// without scanning every Result. Analyze, Sort, Pivot, Filter, and decoding a Matrix from JSON
+// all index it already, so it's only needed by those building a Matrix by hand. The index is
+// left out of use as soon as Results is replaced, but not when it's appended to or reordered in
+// place, after which m needs indexing again.
+func (m Matrix) Indexed() Matrix {
+	var index resultIndex
+	index.results = m.Results
+	index.byPair = make(map[[2]string]int, len(m.Results))
+	for i, result := range m.Results {
+		pair := [2]string{result.From, result.To}
+		// NOTE: The first Result for a pair is the one a scan would find.
+		if _, ok := index.byPair[pair]; !ok {
+			index.byPair[pair] = i
+		}
+	}
+	m.index = &index
+	return m
+}
+
+// covers reports whether index was built from results, rather than Results since replaced.
+func (index *resultIndex) covers(results []Result) bool {
+	if index == nil || len(index.results) != len(results) {
+		return false
+	}
+	return len(results) == 0 || &index.results[0] == &results[0]
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding m as encoding/json would and indexing it.
+func (m *Matrix) UnmarshalJSON(b []byte) error {
+	// NOTE: matrix has none of the methods of Matrix, so decoding it doesn't recurse.
+	type matrix Matrix
+	var decoded matrix
+	err := json.Unmarshal(b, &decoded)
+	if err != nil {
+		return err
+	}
+	*m = Matrix(decoded).Indexed()
+	return nil
+}
+
+// Compare returns the Results in after which became convertible, and which stopped being
+// convertible, since before. Pairs missing from before are left out of both.
+func Compare(before, after Matrix) ([]Result, []Result) {
+	var gained, lost []Result
+	for _, result := range after.Results {
+		previous, ok := before.Result(result.From, result.To)
+		if !ok || previous.Convertible == result.Convertible {
+			continue
+		}
+		if result.Convertible {
+			gained = append(gained, result)
+		} else {
+			lost = append(lost, result)
+		}
+	}
+	return gained, lost
+}
+
+// Lossy returns the Results in m which are convertible but may not preserve the value being
+// converted, see Exact.
+func (m Matrix) Lossy() []Result {
+	var lossy []Result
+	for _, result := range m.Results {
+		from, fromOK := Lookup(result.From)
+		to, toOK := Lookup(result.To)
+		if result.Convertible && fromOK && toOK && from.IsNumeric() && to.IsNumeric() && !Exact(from, to) {
+			lossy = append(lossy, result)
+		}
+	}
+	return lossy
+}
+
+// Exclude returns types without any of the types in excluded. It is an error to
+// exclude a type that is not in types, since that is almost certainly a typo.
+func Exclude(types, excluded []string) ([]string, error) {
+	for _, e := range excluded {
+		if !contains(types, e) {
+			return nil, errors.Errorf("cannot exclude unknown type %q", e)
+		}
+	}
+
+	var remaining []string
+	for _, t := range types {
+		if !contains(excluded, t) {
+			remaining = append(remaining, t)
+		}
+	}
+	if len(remaining) == 0 {
+		return nil, errors.New("every type was excluded")
+	}
+
+	return remaining, nil
+}
+
+// contains reports whether s is in ss.
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
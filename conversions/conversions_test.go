@@ -0,0 +1,90 @@
+package conversions
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestMatrixResult checks that Result finds the same Result by its index as it does by scanning,
+// including once the Results it was indexed with are replaced or changed in place.
+func TestMatrixResult(t *testing.T) {
+	var m Matrix
+	m.Types = []string{"int8", "uint8", "string"}
+	for _, from := range m.Types {
+		for _, to := range m.Types {
+			var result Result
+			result.From = from
+			result.To = to
+			result.Convertible = from != "string" || to == "string"
+			m.Results = append(m.Results, result)
+		}
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Matrix
+	err = json.Unmarshal(b, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pivoted := m.Indexed().Pivot()
+	tests := []struct {
+		name string
+		m    Matrix
+	}{
+		{name: "scanned", m: m},
+		{name: "indexed", m: m.Indexed()},
+		{name: "decoded", m: decoded},
+		{name: "pivoted", m: pivoted},
+		{name: "sorted", m: mustSort(t, pivoted, SortName)},
+		{name: "filtered", m: m.Indexed().Filter(func(Pair) bool { return true })},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, want := range m.Results {
+				got, ok := tt.m.Result(want.From, want.To)
+				if !ok || got.From != want.From || got.To != want.To || got.Convertible != want.Convertible {
+					t.Errorf("%s -> %s: got %+v, %t", want.From, want.To, got, ok)
+				}
+			}
+			if _, ok := tt.m.Result("int8", "bool"); ok {
+				t.Error("int8 -> bool: found a Result which was never recorded")
+			}
+		})
+	}
+
+	t.Run("replaced", func(t *testing.T) {
+		replaced := m.Indexed()
+		replaced.Results = replaced.Results[len(replaced.Results)-1:]
+		if _, ok := replaced.Result("int8", "int8"); ok {
+			t.Error("int8 -> int8: found a Result which was replaced")
+		}
+		if _, ok := replaced.Result("string", "string"); !ok {
+			t.Error("string -> string: missing")
+		}
+	})
+
+	t.Run("changed in place", func(t *testing.T) {
+		changed := m.Indexed()
+		changed.Results = append([]Result(nil), changed.Results...)
+		changed = changed.Indexed()
+		changed.Results[0], changed.Results[1] = changed.Results[1], changed.Results[0]
+		for _, want := range m.Results {
+			if got, ok := changed.Result(want.From, want.To); !ok || got.From != want.From || got.To != want.To {
+				t.Errorf("%s -> %s: got %+v, %t", want.From, want.To, got, ok)
+			}
+		}
+	})
+}
+
+// mustSort is m sorted by, failing t if it can't be.
+func mustSort(t *testing.T, m Matrix, by string) Matrix {
+	t.Helper()
+	sorted, err := m.Sort(by)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sorted
+}
@@ -0,0 +1,249 @@
+package conversions
+
+import (
+	"bufio"
+	"github.com/pkg/errors"
+	"go/ast"
+	"go/types"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultLoopWeight is how many times a conversion in a loop is assumed to run for every time
+	// the code around the loop does, when CostOptions.LoopWeight is not set.
+	DefaultLoopWeight = 10
+)
+
+type (
+	// CostModel is how many nanoseconds converting from one type to another takes, keyed by the
+	// underlying types converted from and to, e.g. {"string", "[]uint8"}, with byte and rune
+	// spelled uint8 and int32.
+	CostModel map[[2]string]float64
+
+	// CostOptions configures AuditCosts.
+	CostOptions struct {
+		// Model is what each conversion costs, see ParseBenchmarks.
+		Model CostModel
+		// LoopWeight multiplies the cost of a conversion for every loop it is nested in, since
+		// that is where conversions are hot. Defaults to DefaultLoopWeight.
+		LoopWeight float64
+	}
+
+	// FileCost is the estimated overhead of every conversion in a file.
+	FileCost struct {
+		// File is the name of the file, relative to the package directory.
+		File string
+		// Conversions is how many conversions are in the file, and Unmeasured how many of them
+		// convert between types the CostModel has no cost for, and so are left out of Cost.
+		Conversions int
+		Unmeasured  int
+		// Cost is the estimated nanoseconds spent converting if every function in the file ran
+		// once, weighted by the loops each conversion is in.
+		Cost float64
+		// Pairs break Cost down by the types converted between, costliest first.
+		Pairs []PairCost
+	}
+
+	// PairCost is the estimated overhead of every conversion between two types in a file.
+	PairCost struct {
+		From  string
+		To    string
+		Count int
+		Cost  float64
+	}
+)
+
+var (
+	// benchmarkRegexp matches a line of go test -bench output, capturing the benchmark's name,
+	// without its GOMAXPROCS suffix, and its nanoseconds per operation.
+	benchmarkRegexp = regexp.MustCompile(`^Benchmark(\S+?)(?:-\d+)?\s+\d+\s+([0-9.e+-]+) ns/op`)
+	// aliasRegexp matches the names of the aliased primitives within a type.
+	aliasRegexp = regexp.MustCompile(`\b(byte|rune)\b`)
+)
+
+// ParseBenchmarks reads the output of go test -bench into a CostModel. A benchmark measures the
+// conversion it is named after, either a sub-benchmark named from->to, e.g.
+// BenchmarkConversions/string->[]byte, or the benchmarks generated alongside the helpers, e.g.
+// BenchmarkInt64ToInt32. Benchmarks run more than once, e.g. with -count, are averaged, and any
+// others are ignored.
+func ParseBenchmarks(r io.Reader) (CostModel, error) {
+	helpers := make(map[string][2]string)
+	for _, from := range Primitives {
+		for _, to := range Primitives {
+			helpers[exportedName(from)+"To"+exportedName(to)] = [2]string{from, to}
+		}
+	}
+
+	totals := make(map[[2]string]float64)
+	runs := make(map[[2]string]int)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		matches := benchmarkRegexp.FindStringSubmatch(scanner.Text())
+		if matches == nil {
+			continue
+		}
+		ns, err := strconv.ParseFloat(matches[2], 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing ns/op of %q", matches[1])
+		}
+
+		var pair [2]string
+		name := matches[1]
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			from, to, ok := strings.Cut(name[i+1:], "->")
+			if !ok {
+				continue
+			}
+			pair = [2]string{from, to}
+		} else if p, ok := helpers[name]; ok {
+			pair = p
+		} else {
+			continue
+		}
+		pair = [2]string{canonicalType(pair[0]), canonicalType(pair[1])}
+		totals[pair] += ns
+		runs[pair]++
+	}
+	err := scanner.Err()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading benchmarks")
+	}
+
+	model := make(CostModel, len(totals))
+	for pair, total := range totals {
+		model[pair] = total / float64(runs[pair])
+	}
+	return model, nil
+}
+
+// AuditCosts type checks the package in dir and estimates how much time every file in it spends
+// converting, by counting its conversions between types with different underlying types and
+// costing each as opts.Model says, ranking the costliest file first.
+func AuditCosts(dir string, opts CostOptions) ([]FileCost, error) {
+	if opts.LoopWeight == 0 {
+		opts.LoopWeight = DefaultLoopWeight
+	}
+
+	lp, err := loadPackage(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var costs []FileCost
+	for _, f := range lp.files {
+		var fc FileCost
+		fc.File = filepath.Base(lp.fset.Position(f.Pos()).Filename)
+		pairs := make(map[[2]string]*PairCost)
+
+		var path []ast.Node
+		ast.Inspect(f, func(n ast.Node) bool {
+			if n == nil {
+				path = path[:len(path)-1]
+				return true
+			}
+			path = append(path, n)
+
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) != 1 {
+				return true
+			}
+			fun, ok := lp.info.Types[call.Fun]
+			if !ok || !fun.IsType() {
+				return true
+			}
+			arg, ok := lp.info.Types[call.Args[0]]
+			if !ok || arg.Type == nil {
+				return true
+			}
+			if result, ok := lp.info.Types[call]; ok && result.Value != nil {
+				// NOTE: Constant conversions are done by the compiler.
+				return true
+			}
+			from := canonicalType(types.TypeString(arg.Type.Underlying(), nil))
+			to := canonicalType(types.TypeString(fun.Type.Underlying(), nil))
+			if from == to {
+				// NOTE: Converting between types with the same underlying type costs nothing.
+				return true
+			}
+
+			key := [2]string{from, to}
+			pc, ok := pairs[key]
+			if !ok {
+				pc = &PairCost{From: from, To: to}
+				pairs[key] = pc
+			}
+			pc.Count++
+			fc.Conversions++
+			ns, ok := opts.Model[key]
+			if !ok {
+				fc.Unmeasured++
+				return true
+			}
+			weight := 1.0
+			for depth := loopDepth(path); depth > 0; depth-- {
+				weight *= opts.LoopWeight
+			}
+			pc.Cost += ns * weight
+			fc.Cost += ns * weight
+			return true
+		})
+
+		if fc.Conversions == 0 {
+			continue
+		}
+		for _, pc := range pairs {
+			fc.Pairs = append(fc.Pairs, *pc)
+		}
+		sort.Slice(fc.Pairs, func(i, j int) bool {
+			if fc.Pairs[i].Cost != fc.Pairs[j].Cost {
+				return fc.Pairs[i].Cost > fc.Pairs[j].Cost
+			}
+			return fc.Pairs[i].Count > fc.Pairs[j].Count
+		})
+		costs = append(costs, fc)
+	}
+
+	sort.SliceStable(costs, func(i, j int) bool { return costs[i].Cost > costs[j].Cost })
+	return costs, nil
+}
+
+// loopDepth counts the loops in path, the nodes from the file down to a conversion, within the
+// innermost function, since a function literal in a loop isn't necessarily called there.
+func loopDepth(path []ast.Node) int {
+	depth := 0
+	for i := len(path) - 1; i > 0; i-- {
+		child, parent := path[i], path[i-1]
+		switch p := parent.(type) {
+		case *ast.ForStmt:
+			// NOTE: Everything but the init statement runs on every iteration.
+			if child != p.Init {
+				depth++
+			}
+		case *ast.RangeStmt:
+			if child == p.Body {
+				depth++
+			}
+		case *ast.FuncDecl, *ast.FuncLit:
+			return depth
+		}
+	}
+	return depth
+}
+
+// canonicalType spells the aliased primitives in the type t by the types they alias.
+func canonicalType(t string) string {
+	return aliasRegexp.ReplaceAllStringFunc(t, func(alias string) string {
+		info, _ := Lookup(alias)
+		return info.Canonical()
+	})
+}
+
+// exportedName capitalizes the first letter of name, as the helpers name their functions.
+func exportedName(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -0,0 +1,57 @@
+package conversions
+
+type (
+	// Coverage counts how many of the pairs of a report's types it actually has a Result for, so
+	// a partial analysis can be told apart from a complete one without counting the Results.
+	Coverage struct {
+		// Pairs is every pair of the types, each of which a complete report has a Result for.
+		Pairs int
+		// Probed is how many pairs have a Result in the report.
+		Probed int
+		// Filtered is how many pairs were analyzed but left out of the report on purpose, e.g.
+		// every pair without the tag the report is limited to.
+		Filtered int
+		// Unknown is how many pairs are missing from the report for no reason it knows of, e.g.
+		// the rows of an analysis which was cut short, or of a Matrix missing Results.
+		Unknown int
+		// Complete is whether every pair was probed, so Probed is Pairs.
+		Complete bool
+	}
+
+	// Filterer is implemented by RowWriters which report their Coverage, to be told how many
+	// pairs were left out of a row on purpose before it was written to them.
+	Filterer interface {
+		Filtered(n int)
+	}
+)
+
+// CoverageOf returns the Coverage of pairs pairs, probed of which have a Result and filtered of
+// which were left out on purpose.
+func CoverageOf(pairs, probed, filtered int) Coverage {
+	var c Coverage
+	c.Pairs = pairs
+	c.Probed = probed
+	c.Filtered = filtered
+	c.Unknown = pairs - probed - filtered
+	// NOTE: Results for pairs outside the types, or for one pair twice, aren't unknown ones.
+	if c.Unknown < 0 {
+		c.Unknown = 0
+	}
+	c.Complete = probed >= pairs
+	return c
+}
+
+// Coverage returns the Coverage of m, counting every pair of its Types it has a Result for once.
+func (m Matrix) Coverage() Coverage {
+	types := make(map[string]bool)
+	for _, t := range m.Types {
+		types[t] = true
+	}
+	probed := make(map[[2]string]bool)
+	for _, result := range m.Results {
+		if types[result.From] && types[result.To] {
+			probed[[2]string{result.From, result.To}] = true
+		}
+	}
+	return CoverageOf(len(types)*len(types), len(probed), 0)
+}
@@ -0,0 +1,109 @@
+package conversions
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go/types"
+	"path/filepath"
+)
+
+type (
+	// CrossMatrix holds a CrossResult for every pair of Types, across every one of Archs.
+	CrossMatrix struct {
+		Archs   []string
+		Types   []string
+		Results []CrossResult
+	}
+
+	// CrossResult is what converting From to To does on each architecture it was checked for.
+	CrossResult struct {
+		From string
+		To   string
+		// Convertible and Exact are keyed by architecture. Exact is whether every value of From
+		// converts to To unchanged on that architecture, and is false for any pair which isn't numeric.
+		Convertible map[string]bool
+		Exact       map[string]bool
+	}
+)
+
+// AnalyzeCross runs a full analysis for each of archs, as GOARCH values, and combines the
+// results. The generated go code is only ever compiled, never run, so any architecture the
+// go toolchain can target can be checked from this machine. Each architecture gets its own
+// directory under opts.OutputDir, and nothing is saved to opts.StateFile or opts.CacheFile.
+func AnalyzeCross(ctx context.Context, opts Options, archs []string) (CrossMatrix, error) {
+	opts = opts.WithDefaults()
+
+	for _, arch := range archs {
+		if types.SizesFor("gc", arch) == nil {
+			return CrossMatrix{}, errors.Errorf("unknown architecture %q", arch)
+		}
+	}
+
+	var cm CrossMatrix
+	cm.Archs = archs
+	cm.Types = opts.Types
+	index := make(map[[2]string]int)
+	for _, from := range opts.Types {
+		for _, to := range opts.Types {
+			var cr CrossResult
+			cr.From = from
+			cr.To = to
+			cr.Convertible = make(map[string]bool)
+			cr.Exact = make(map[string]bool)
+			index[[2]string{from, to}] = len(cm.Results)
+			cm.Results = append(cm.Results, cr)
+		}
+	}
+
+	for _, arch := range archs {
+		archOpts := opts
+		archOpts.GOARCH = arch
+		archOpts.OutputDir = filepath.Join(opts.OutputDir, arch)
+		archOpts.StateFile = ""
+		archOpts.CacheFile = ""
+		archOpts.Resume = false
+		m, err := Analyze(ctx, archOpts)
+		if err != nil {
+			return CrossMatrix{}, errors.Wrapf(err, "analyzing for %s", arch)
+		}
+
+		for _, result := range m.Results {
+			cr := cm.Results[index[[2]string{result.From, result.To}]]
+			cr.Convertible[arch] = result.Convertible
+			cr.Exact[arch] = result.Convertible && exactOn(result.From, result.To, arch)
+		}
+	}
+
+	return cm, nil
+}
+
+// Differs reports whether converting cr.From to cr.To does something different on some architectures.
+func (cr CrossResult) Differs() bool {
+	var seen, convertible, exact bool
+	for arch := range cr.Convertible {
+		if !seen {
+			seen, convertible, exact = true, cr.Convertible[arch], cr.Exact[arch]
+			continue
+		}
+		if cr.Convertible[arch] != convertible || cr.Exact[arch] != exact {
+			return true
+		}
+	}
+	return false
+}
+
+// exactOn reports whether every value of the type from converts to the type to unchanged when
+// compiling for arch, which must be known to the gc compiler.
+func exactOn(from, to, arch string) bool {
+	fromInfo, ok := Lookup(from)
+	if !ok {
+		return false
+	}
+	toInfo, ok := Lookup(to)
+	if !ok {
+		return false
+	}
+	fromInfo, _ = fromInfo.On(arch)
+	toInfo, _ = toInfo.On(arch)
+	return Exact(fromInfo, toInfo)
+}
@@ -0,0 +1,74 @@
+package conversions
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"path/filepath"
+)
+
+type (
+	// DebugParse is what was parsed out of the output of checking a file of generated go code,
+	// saved alongside it when Options.DebugDir is set.
+	DebugParse struct {
+		// File is the generated file that was checked.
+		File string
+		// ExitCode is the exit status of the compiler, or -1 if no compiler was run.
+		ExitCode int
+		// Failures are the conversion failures the output was parsed into.
+		Failures ConversionFailures
+		// Unparsed are the lines of the output which weren't recognized.
+		Unparsed []string `json:",omitempty"`
+	}
+)
+
+// checkSource checks src, the go code generated into the file named file, with backend, first
+// saving it to opts.DebugDir when that is set, along with the go.mod it's built with.
+func checkSource(ctx context.Context, opts Options, backend Backend, file string, src []byte) (ConversionFailures, error) {
+	err := saveDebugArtifact(opts, file, src)
+	if err != nil {
+		return nil, err
+	}
+	err = saveDebugArtifact(opts, "go.mod", []byte(probeGoMod(opts.LangVersion)))
+	if err != nil {
+		return nil, err
+	}
+	return backend.Check(ctx, opts, file, src)
+}
+
+// saveDebugOutput saves output, everything checking file printed, as it was and as it was
+// parsed, to opts.DebugDir when that is set.
+func saveDebugOutput(opts Options, file string, exitCode int, output string, cfs ConversionFailures, unparsed []string) error {
+	if opts.DebugDir == "" {
+		return nil
+	}
+	name := filepath.Base(file)
+	err := saveDebugArtifact(opts, name+".output.txt", []byte(output))
+	if err != nil {
+		return err
+	}
+
+	var dp DebugParse
+	dp.File = name
+	dp.ExitCode = exitCode
+	dp.Failures = cfs
+	dp.Unparsed = unparsed
+	b, err := json.MarshalIndent(dp, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "encoding what was parsed out of checking %q", name)
+	}
+	return saveDebugArtifact(opts, name+".parsed.json", b)
+}
+
+// saveDebugArtifact writes b to the file named name in opts.DebugDir, when that is set.
+func saveDebugArtifact(opts Options, name string, b []byte) error {
+	if opts.DebugDir == "" {
+		return nil
+	}
+	file := filepath.Join(opts.DebugDir, name)
+	err := WriteFileAtomic(file, b, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "writing debug artifact %q", file)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package conversions
+
+type (
+	// Change is a pair whose Result differs between two Matrices, see Diff.
+	Change struct {
+		From string
+		To   string
+		// Before and After are the pair's Results in each Matrix, nil if it has none.
+		Before *Result `json:",omitempty"`
+		After  *Result `json:",omitempty"`
+	}
+)
+
+// Diff returns a Change for every pair which became, or stopped being, convertible between
+// before and after, whose reason for not being convertible changed, or which only one of them
+// has, in the order of after, followed by the pairs only before has.
+func Diff(before, after Matrix) []Change {
+	var changes []Change
+	seen := make(map[[2]string]bool)
+	for i := range after.Results {
+		result := after.Results[i]
+		seen[[2]string{result.From, result.To}] = true
+		previous, ok := before.Result(result.From, result.To)
+		if ok && previous.Convertible == result.Convertible && reason(previous) == reason(result) {
+			continue
+		}
+
+		var c Change
+		c.From = result.From
+		c.To = result.To
+		if ok {
+			c.Before = &previous
+		}
+		c.After = &result
+		changes = append(changes, c)
+	}
+
+	for i := range before.Results {
+		result := before.Results[i]
+		if seen[[2]string{result.From, result.To}] {
+			continue
+		}
+		var c Change
+		c.From = result.From
+		c.To = result.To
+		c.Before = &result
+		changes = append(changes, c)
+	}
+
+	return changes
+}
+
+// Reason is why the pair isn't convertible after the Change, or, if it now is, why it wasn't
+// before, or "" if it is convertible on both sides.
+func (c Change) Reason() string {
+	if c.After != nil && c.After.Err != nil {
+		return c.After.Err.Reason
+	}
+	if c.Before != nil && c.Before.Err != nil {
+		return c.Before.Err.Reason
+	}
+	return ""
+}
+
+// reason is the Reason of result's Err, "" if it has none.
+func reason(result Result) string {
+	if result.Err == nil {
+		return ""
+	}
+	return result.Err.Reason
+}
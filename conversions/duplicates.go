@@ -0,0 +1,86 @@
+package conversions
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	// Duplicate is a group of types in a type list which convert identically, because they are
+	// one type under several names, e.g. byte and uint8, or one name listed several times.
+	Duplicate struct {
+		// Canonical is the name of the type every one of Types is.
+		Canonical string
+		// Types are the names in the group, in the order they were listed, including repeats.
+		Types []string
+		// Repeated is whether some name in Types is listed more than once.
+		Repeated bool
+	}
+)
+
+// Duplicates finds every group of types in types which convert identically, so their rows and
+// columns of a Matrix are the same. Types are identical when they are aliases of one another,
+// or are arrays of the same length with identical element types.
+func Duplicates(types []string) []Duplicate {
+	groups := make(map[string]*Duplicate)
+	var order []string
+	for _, t := range types {
+		canonical := CanonicalName(t)
+		d, ok := groups[canonical]
+		if !ok {
+			d = &Duplicate{Canonical: canonical}
+			groups[canonical] = d
+			order = append(order, canonical)
+		}
+		if contains(d.Types, t) {
+			d.Repeated = true
+		}
+		d.Types = append(d.Types, t)
+	}
+
+	var duplicates []Duplicate
+	for _, canonical := range order {
+		if d := groups[canonical]; len(d.Types) > 1 {
+			duplicates = append(duplicates, *d)
+		}
+	}
+	return duplicates
+}
+
+// Dedupe returns types with every name listed more than once kept only the first time, in order.
+// Aliases are kept, as they are reported under their own names.
+func Dedupe(types []string) []string {
+	var deduped []string
+	for _, t := range types {
+		if !contains(deduped, t) {
+			deduped = append(deduped, t)
+		}
+	}
+	return deduped
+}
+
+// CanonicalName is the name of the type name is an alias of, with arrays named by the
+// canonical name of their element type, e.g. [2]uint8 for [2]byte. Any other name is
+// returned as is.
+func CanonicalName(name string) string {
+	if length, elem, ok := ParseArray(name); ok {
+		return fmt.Sprintf("[%d]%s", length, CanonicalName(elem))
+	}
+	if info, ok := Lookup(name); ok {
+		return info.Canonical()
+	}
+	return name
+}
+
+// String describes d for a warning.
+func (d Duplicate) String() string {
+	names := Dedupe(d.Types)
+	if len(names) == 1 {
+		return fmt.Sprintf("%s is listed %d times, it is only checked once", d.Canonical, len(d.Types))
+	}
+	s := fmt.Sprintf("%s are all %s, so their rows and columns are identical", strings.Join(names, ", "), d.Canonical)
+	if d.Repeated {
+		s += ", and names listed more than once are only checked once"
+	}
+	return s
+}
@@ -0,0 +1,121 @@
+package conversions
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+)
+
+type (
+	// Enum is an enum-style defined type: an integer type with a block of constants declared
+	// using iota.
+	Enum struct {
+		// Package is the name of the package the Enum is declared in.
+		Package string
+		Name    string
+		// Underlying is the primitive the Enum is defined as, e.g. int.
+		Underlying string
+		// Constants are every constant of the Enum, in the order they are declared.
+		Constants []EnumConstant
+		// HasString, HasFromInt, and HasParse are whether the Enum already has a String method,
+		// a NameFromInt function, or a ParseName function.
+		HasString  bool
+		HasFromInt bool
+		HasParse   bool
+	}
+
+	// EnumConstant is a single constant of an Enum.
+	EnumConstant struct {
+		Name string
+		// Value is the exact value of the constant.
+		Value string
+	}
+)
+
+// Enums type checks the package in dir and reports every Enum declared in it. Declarations in the
+// file named generated, which is expected to have been written by an earlier run, are ignored
+// when working out what the Enums already have.
+func Enums(dir string, generated string) ([]Enum, error) {
+	lp, err := loadPackage(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	scope := lp.pkg.Scope()
+	declared := func(obj types.Object) bool {
+		return obj != nil && filepath.Base(lp.fset.Position(obj.Pos()).Filename) != generated
+	}
+
+	var enums []Enum
+	index := make(map[*types.Named]int)
+	for _, f := range lp.files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.CONST || !usesIota(gd) {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				for _, name := range spec.(*ast.ValueSpec).Names {
+					c, ok := scope.Lookup(name.Name).(*types.Const)
+					if !ok || name.Name == "_" {
+						continue
+					}
+					named, ok := c.Type().(*types.Named)
+					if !ok || named.Obj().Pkg() != lp.pkg {
+						continue
+					}
+					basic, ok := named.Underlying().(*types.Basic)
+					if !ok || basic.Info()&types.IsInteger == 0 {
+						continue
+					}
+
+					i, ok := index[named]
+					if !ok {
+						var e Enum
+						e.Package = lp.pkg.Name()
+						e.Name = named.Obj().Name()
+						e.Underlying = basic.Name()
+						method, _, _ := types.LookupFieldOrMethod(named, true, lp.pkg, "String")
+						e.HasString = declared(method)
+						e.HasFromInt = declared(scope.Lookup(e.Name + "FromInt"))
+						e.HasParse = declared(scope.Lookup("Parse" + e.Name))
+						i = len(enums)
+						index[named] = i
+						enums = append(enums, e)
+					}
+					var ec EnumConstant
+					ec.Name = c.Name()
+					ec.Value = c.Val().ExactString()
+					enums[i].Constants = append(enums[i].Constants, ec)
+				}
+			}
+		}
+	}
+
+	return enums, nil
+}
+
+// Distinct returns the first of the Enum's Constants with each value, leaving out any which
+// repeat an earlier value, e.g. a Default constant equal to another.
+func (e Enum) Distinct() []EnumConstant {
+	seen := make(map[string]bool)
+	var distinct []EnumConstant
+	for _, c := range e.Constants {
+		if !seen[c.Value] {
+			seen[c.Value] = true
+			distinct = append(distinct, c)
+		}
+	}
+	return distinct
+}
+
+// usesIota reports whether any of the constants declared by gd are given a value using iota.
+func usesIota(gd *ast.GenDecl) bool {
+	found := false
+	ast.Inspect(gd, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		found = found || (ok && id.Name == "iota")
+		return !found
+	})
+	return found
+}
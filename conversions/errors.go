@@ -0,0 +1,228 @@
+package conversions
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+type (
+	// ConversionError is why a value of type From can't be converted to type To. It matches
+	// ErrNotConvertible, and the reason it describes, with errors.Is, so callers can program
+	// against specific classes of failure.
+	ConversionError struct {
+		From string
+		To   string
+		// Position is where the compiler reported the conversion in the generated code, as
+		// file:line:column, if it did.
+		Position string `json:",omitempty"`
+		// CompilerMessage is what the compiler reported, without its Position.
+		CompilerMessage string `json:",omitempty"`
+		// Reason explains why the conversion is illegal in terms of From and To, going by which
+		// of the Err reason errors it matches.
+		Reason string
+	}
+
+	// DiagnosticError is returned when the compiler crashed, or in strict mode when the output of
+	// compiling or type checking generated code can't be fully accounted for. It carries
+	// everything needed to diagnose why.
+	DiagnosticError struct {
+		// File is the generated file being checked.
+		File string
+		// Reason describes why the output was rejected.
+		Reason string
+		// ExitCode is the exit status of the compiler, or -1 if no compiler was run.
+		ExitCode int
+		// Unparsed are the lines of Output which were not recognized.
+		Unparsed []string
+		// Output is the full, raw output.
+		Output string
+	}
+
+	// TransientError is returned when checking generated code failed for a reason which has
+	// nothing to do with the code, e.g. the compiler being killed for running out of memory,
+	// or the build service being unavailable, so checking it again may well succeed, see
+	// Options.Retries.
+	TransientError struct {
+		// File is the generated file being checked.
+		File string
+		// Reason describes what failed.
+		Reason string
+		// ExitCode is the exit status of the compiler, or -1 if it was killed or never run.
+		ExitCode int
+		// Output is the full, raw output, if there was any.
+		Output string `json:",omitempty"`
+	}
+)
+
+var (
+	// ErrNotConvertible matches every *ConversionError.
+	ErrNotConvertible = errors.New("not convertible")
+	// ErrBool matches a *ConversionError converting to or from bool.
+	ErrBool = errors.New("bool only converts to bool")
+	// ErrComplex matches a *ConversionError converting between a complex type and a type that isn't one.
+	ErrComplex = errors.New("complex numbers only convert to other complex numbers")
+	// ErrString matches a *ConversionError converting between a string and a type that isn't
+	// an integer, or from a string to an integer.
+	ErrString = errors.New("strings only convert from integers, which are treated as runes")
+	// ErrArrayLength matches a *ConversionError converting between arrays of different lengths.
+	ErrArrayLength = errors.New("arrays only convert to arrays of the same length")
+	// ErrArrayElem matches a *ConversionError converting between arrays of the same length whose
+	// element types aren't identical, even if the elements themselves could be converted.
+	ErrArrayElem = errors.New("arrays only convert to arrays with identical element types")
+	// ErrBig matches a *ConversionError converting to or from one of BigTypes, for which there is
+	// no helper, see BigConversions.
+	ErrBig = errors.New("math/big types only convert to and from integers, floats, and each other, through their methods")
+	// ErrLangVersion matches a *ConversionError converting a slice to an array, or a pointer to
+	// one, of its element type, which the language version checked under predates, see
+	// Options.LangVersion.
+	ErrLangVersion = errors.New("slices only convert to arrays, and pointers to arrays, in newer language versions")
+	// ErrOther matches a *ConversionError the other reasons don't explain.
+	ErrOther = errors.New("the types are not convertible")
+
+	// ErrUnrecognizedPhrasing matches every *PhrasingError.
+	ErrUnrecognizedPhrasing = errors.New("conversion failure phrased in an unrecognized way")
+	// ErrUnaccountedOutput matches every *DiagnosticError.
+	ErrUnaccountedOutput = errors.New("compiler output could not be accounted for")
+	// ErrTransient matches every *TransientError.
+	ErrTransient = errors.New("checking failed for a reason unrelated to the code")
+)
+
+// NewConversionError returns a *ConversionError for converting from to to, along with its Reason.
+func NewConversionError(from, to string) *ConversionError {
+	var e ConversionError
+	e.From = from
+	e.To = to
+	e.Reason = describeReason(from, to)
+	return &e
+}
+
+// Error implements error.
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("cannot convert %s to %s: %s", e.From, e.To, e.Reason)
+}
+
+// Is matches ErrNotConvertible, and the reason error e was given for. A nil e, as held by
+// a Result which is convertible, matches nothing.
+func (e *ConversionError) Is(target error) bool {
+	if e == nil {
+		return false
+	}
+	return target == ErrNotConvertible || target == reasonFor(e.From, e.To)
+}
+
+// reasonFor returns the reason error explaining why from can't be converted to to.
+func reasonFor(from, to string) error {
+	fromLength, _, fromArray := ParseArray(from)
+	toLength, _, toArray := ParseArray(to)
+	switch {
+	case fromArray && toArray && fromLength != toLength:
+		return ErrArrayLength
+	case fromArray && toArray:
+		return ErrArrayElem
+	}
+
+	if elem, ok := ParseSlice(from); ok {
+		if _, toElem, ok := ParseArray(strings.TrimPrefix(to, "*")); ok && toElem == elem {
+			return ErrLangVersion
+		}
+	}
+
+	if IsBig(from) || IsBig(to) {
+		return ErrBig
+	}
+
+	fromInfo, fromOK := Lookup(from)
+	toInfo, toOK := Lookup(to)
+	if !fromOK || !toOK {
+		return ErrOther
+	}
+
+	switch {
+	case fromInfo.Kind == KindBool || toInfo.Kind == KindBool:
+		return ErrBool
+	case fromInfo.Kind == KindString || toInfo.Kind == KindString:
+		return ErrString
+	case fromInfo.Kind == KindComplex || toInfo.Kind == KindComplex:
+		return ErrComplex
+	default:
+		return ErrOther
+	}
+}
+
+// describeReason explains why from can't be converted to to in terms of both, going by the reason
+// error reasonFor gives them, e.g. "float64 is a float, and only bool converts to bool".
+func describeReason(from, to string) string {
+	reason := reasonFor(from, to)
+	fromInfo, _ := Lookup(from)
+	toInfo, _ := Lookup(to)
+	switch {
+	case reason == ErrBool && fromInfo.Kind == KindBool:
+		return fmt.Sprintf("bool only converts to bool, and %s is %s", to, kindNoun(toInfo))
+	case reason == ErrBool:
+		return fmt.Sprintf("%s is %s, and only bool converts to bool", from, kindNoun(fromInfo))
+	case reason == ErrString && fromInfo.Kind == KindString:
+		return fmt.Sprintf("string only converts to string and byte and rune slices, and %s is %s", to, kindNoun(toInfo))
+	case reason == ErrString:
+		return fmt.Sprintf("%s is %s, and only integers, which are treated as runes, convert to string", from, kindNoun(fromInfo))
+	case reason == ErrComplex && fromInfo.Kind == KindComplex:
+		return fmt.Sprintf("%s is a complex number, which only converts to other complex numbers, and %s is %s", from, to, kindNoun(toInfo))
+	case reason == ErrComplex:
+		return fmt.Sprintf("%s is %s, and only complex numbers convert to %s", from, kindNoun(fromInfo), to)
+	case reason == ErrArrayLength:
+		return fmt.Sprintf("%s and %s are arrays of different lengths, and %s", from, to, reason)
+	case reason == ErrArrayElem:
+		return fmt.Sprintf("%s and %s have different element types, and %s", from, to, reason)
+	case reason == ErrBig:
+		return fmt.Sprintf("there's no helper converting %s to %s, and %s", from, to, reason)
+	case reason == ErrLangVersion:
+		return fmt.Sprintf("%s only converts to %s in newer language versions", from, to)
+	default:
+		return fmt.Sprintf("%s doesn't convert to %s", from, to)
+	}
+}
+
+// kindNoun is what a value of the type i describes is, e.g. "an integer".
+func kindNoun(i Info) string {
+	switch i.Kind {
+	case KindBool:
+		return "a bool"
+	case KindInt, KindUint:
+		return "an integer"
+	case KindFloat:
+		return "a float"
+	case KindComplex:
+		return "a complex number"
+	case KindString:
+		return "a string"
+	}
+	return "not a primitive"
+}
+
+// Error implements error.
+func (e *DiagnosticError) Error() string {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "%s: %s (exit status %d)", e.File, e.Reason, e.ExitCode)
+	for _, line := range e.Unparsed {
+		_, _ = fmt.Fprintf(&b, "\n\tunparsed: %s", line)
+	}
+	if len(e.Unparsed) == 0 && e.Output != "" {
+		_, _ = fmt.Fprintf(&b, "\n\toutput: %s", e.Output)
+	}
+	return b.String()
+}
+
+// Is matches ErrUnaccountedOutput.
+func (e *DiagnosticError) Is(target error) bool {
+	return target == ErrUnaccountedOutput
+}
+
+// Error implements error.
+func (e *TransientError) Error() string {
+	return fmt.Sprintf("%s: %s (exit status %d)", e.File, e.Reason, e.ExitCode)
+}
+
+// Is matches ErrTransient.
+func (e *TransientError) Is(target error) bool {
+	return target == ErrTransient
+}
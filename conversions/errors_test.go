@@ -0,0 +1,34 @@
+package conversions
+
+import (
+	"github.com/pkg/errors"
+	"testing"
+)
+
+// TestConversionErrorReason pins how the Reason of a ConversionError is worded for a mismatched
+// pair of each kind, which has to say why From doesn't convert as well as what To takes, and
+// checks that it still matches its reason error.
+func TestConversionErrorReason(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     string
+		is       error
+	}{
+		{from: "float64", to: "bool", want: "float64 is a float, and only bool converts to bool", is: ErrBool},
+		{from: "bool", to: "int8", want: "bool only converts to bool, and int8 is an integer", is: ErrBool},
+		{from: "float32", to: "string", want: "float32 is a float, and only integers, which are treated as runes, convert to string", is: ErrString},
+		{from: "string", to: "uint16", want: "string only converts to string and byte and rune slices, and uint16 is an integer", is: ErrString},
+		{from: "complex64", to: "float64", want: "complex64 is a complex number, which only converts to other complex numbers, and float64 is a float", is: ErrComplex},
+		{from: "int", to: "complex128", want: "int is an integer, and only complex numbers convert to complex128", is: ErrComplex},
+		{from: "[2]int8", to: "[3]int8", want: "[2]int8 and [3]int8 are arrays of different lengths, and arrays only convert to arrays of the same length", is: ErrArrayLength},
+	}
+	for _, tt := range tests {
+		e := NewConversionError(tt.from, tt.to)
+		if e.Reason != tt.want {
+			t.Errorf("%s -> %s: got %q, expected %q", tt.from, tt.to, e.Reason, tt.want)
+		}
+		if !errors.Is(e, tt.is) || !errors.Is(e, ErrNotConvertible) {
+			t.Errorf("%s -> %s: doesn't match %v", tt.from, tt.to, tt.is)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package conversions
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// EventAnalysisStarted is emitted once an analysis has validated its Options, with the number of Pairs it covers.
+	EventAnalysisStarted = "analysis_started"
+	// EventErrorLimitMeasured is emitted with the Limit measured by MeasureErrorLimit, when it is measured.
+	EventErrorLimitMeasured = "error_limit_measured"
+	// EventTemplateMigrated is emitted once an analysis has adapted Options.TemplateFile, written
+	// against the older TemplateSchema Schema, to the current one, see MigrateTemplate.
+	EventTemplateMigrated = "template_migrated"
+	// EventShardsPlanned is emitted with the number of Shards left to check, after any resumed ones.
+	EventShardsPlanned = "shards_planned"
+	// EventShardStarted is emitted as each Shard starts being rendered and checked.
+	EventShardStarted = "shard_started"
+	// EventShardRetried is emitted as each Shard fails for a reason which has nothing to do with
+	// the code and is about to be checked again, with the Attempt which failed and its Err, see
+	// Options.Retries.
+	EventShardRetried = "shard_retried"
+	// EventShardFinished is emitted as each Shard finishes being checked, with Err set if it failed.
+	EventShardFinished = "shard_finished"
+	// EventPair is emitted with the Result of every pair, including those resumed from a State.
+	EventPair = "pair"
+	// EventAnalysisFinished is emitted once an analysis ends, with Err set if it failed.
+	EventAnalysisFinished = "analysis_finished"
+)
+
+type (
+	// Event marks the progress of an analysis, see Options.Events. Only the fields relevant to its
+	// Type are set.
+	Event struct {
+		Time    time.Time
+		Type    string
+		Pairs   int     `json:",omitempty"`
+		Limit   int     `json:",omitempty"`
+		Shards  int     `json:",omitempty"`
+		Shard   *Shard  `json:",omitempty"`
+		Attempt int     `json:",omitempty"`
+		Schema  int     `json:",omitempty"`
+		Result  *Result `json:",omitempty"`
+		Err     string  `json:",omitempty"`
+	}
+)
+
+// NDJSONEvents returns an Options.Events which writes every Event to w as a line of JSON, as
+// soon as it happens, so other programs can follow a long analysis as it runs. Errors writing
+// to w are ignored rather than failing the analysis.
+func NDJSONEvents(w io.Writer) func(Event) {
+	enc := json.NewEncoder(w)
+	return func(e Event) {
+		_ = enc.Encode(e)
+	}
+}
+
+// emitter returns a function which stamps the Time on every Event and passes it to opts.Events,
+// if set. It is safe to call concurrently, while opts.Events never is.
+func (opts Options) emitter() func(Event) {
+	if opts.Events == nil {
+		return func(Event) {}
+	}
+	var mu sync.Mutex
+	return func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		e.Time = time.Now()
+		opts.Events(e)
+	}
+}
+
+// errString is the message of err, or empty when err is nil.
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
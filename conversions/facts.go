@@ -0,0 +1,68 @@
+package conversions
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+type (
+	// KindFact is what is known about a package-level defined type whose underlying type is a
+	// primitive, e.g. `type Celsius float64`. It implements the Fact interface of
+	// golang.org/x/tools/go/analysis, so an analyzer can export it for every type KindFacts
+	// returns with pass.ExportObjectFact, and the analyzers depending on it import it with
+	// pass.ImportObjectFact rather than working the type out again.
+	KindFact struct {
+		// Info describes the primitive underlying the type, whose Name is that primitive's.
+		Info Info
+		// ConvertsTo are the primitives the Matrix KindFacts was given reports the underlying
+		// primitive as convertible to, in the Matrix's order.
+		ConvertsTo []string `json:",omitempty"`
+	}
+)
+
+// AFact implements the Fact interface of golang.org/x/tools/go/analysis.
+func (*KindFact) AFact() {}
+
+// String describes kf, as analysis drivers print facts.
+func (kf *KindFact) String() string {
+	s := fmt.Sprintf("kind(%s)", kf.Info.Canonical())
+	if len(kf.ConvertsTo) > 0 {
+		s += " converts to " + strings.Join(kf.ConvertsTo, ", ")
+	}
+	return s
+}
+
+// Classify is Classify for converting a value of the type kf describes to one of the type to
+// describes.
+func (kf *KindFact) Classify(to *KindFact) string {
+	return Classify(kf.Info, to.Info)
+}
+
+// KindFacts returns a KindFact for every package-level defined type in pkg whose underlying type
+// is a primitive, filling in what each converts to from m, which may be empty if only the kinds
+// are needed. Type parameters and aliases are left out, as they aren't types of their own.
+func KindFacts(pkg *types.Package, m Matrix) map[*types.TypeName]*KindFact {
+	facts := make(map[*types.TypeName]*KindFact)
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || tn.IsAlias() {
+			continue
+		}
+		info, ok := basicInfo(tn.Type())
+		if !ok {
+			continue
+		}
+
+		var kf KindFact
+		kf.Info = info
+		for _, result := range m.Results {
+			if result.From == info.Name && result.Convertible {
+				kf.ConvertsTo = append(kf.ConvertsTo, result.To)
+			}
+		}
+		facts[tn] = &kf
+	}
+	return facts
+}
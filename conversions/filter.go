@@ -0,0 +1,126 @@
+package conversions
+
+import (
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+)
+
+const (
+	// PredicateLossy names LossyOnly in Predicates.
+	PredicateLossy = "lossy"
+	// PredicateCrossSign names CrossSignOnly in Predicates.
+	PredicateCrossSign = "cross-sign"
+	// PredicateWidening names WideningOnly in Predicates.
+	PredicateWidening = "widening"
+	// PredicateNarrowing names NarrowingOnly in Predicates.
+	PredicateNarrowing = "narrowing"
+	// PredicateReinterpreting names ReinterpretingOnly in Predicates.
+	PredicateReinterpreting = "reinterpreting"
+)
+
+type (
+	// Pair is a Result along with what is known about the types it converts between, for
+	// predicates to decide on, see Matrix.Filter.
+	Pair struct {
+		Result
+		// FromInfo and ToInfo describe the types converted from and to, and are the zero Info
+		// for any type which isn't a primitive.
+		FromInfo Info
+		ToInfo   Info
+	}
+)
+
+var (
+	// Predicates are the predicates reports can be limited to by name, e.g. with -only.
+	Predicates = map[string]func(Pair) bool{
+		PredicateLossy:          LossyOnly,
+		PredicateCrossSign:      CrossSignOnly,
+		PredicateWidening:       WideningOnly,
+		PredicateNarrowing:      NarrowingOnly,
+		PredicateReinterpreting: ReinterpretingOnly,
+	}
+)
+
+// PairOf returns the Pair of result.
+func PairOf(result Result) Pair {
+	var p Pair
+	p.Result = result
+	p.FromInfo, _ = Lookup(result.From)
+	p.ToInfo, _ = Lookup(result.To)
+	return p
+}
+
+// Filter returns a copy of m with only the Results whose Pair keep returns true for. Its Types
+// are left as they are, so the pairs filtered out are reported as missing.
+func (m Matrix) Filter(keep func(Pair) bool) Matrix {
+	var results []Result
+	for _, result := range m.Results {
+		if keep(PairOf(result)) {
+			results = append(results, result)
+		}
+	}
+	m.Results = results
+	return m.Indexed()
+}
+
+// LookupPredicate returns the predicate in Predicates named name.
+func LookupPredicate(name string) (func(Pair) bool, error) {
+	keep, ok := Predicates[name]
+	if !ok {
+		var names []string
+		for n := range Predicates {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, errors.Errorf("unknown predicate %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	return keep, nil
+}
+
+// AllOf returns a predicate which is only true for a Pair every one of keeps is true for.
+func AllOf(keeps ...func(Pair) bool) func(Pair) bool {
+	return func(p Pair) bool {
+		for _, keep := range keeps {
+			if !keep(p) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// LossyOnly is true for numeric conversions which compile but may not preserve the value being
+// converted, see Exact.
+func LossyOnly(p Pair) bool {
+	return numeric(p) && !Exact(p.FromInfo, p.ToInfo)
+}
+
+// CrossSignOnly is true for conversions which compile between a signed and an unsigned integer.
+func CrossSignOnly(p Pair) bool {
+	return numeric(p) && p.FromInfo.IsInteger() && p.ToInfo.IsInteger() && p.FromInfo.Kind != p.ToInfo.Kind
+}
+
+// WideningOnly is true for numeric conversions which compile between distinct types and never
+// lose information, because To holds every value of From exactly on every platform.
+func WideningOnly(p Pair) bool {
+	return numeric(p) && Classify(p.FromInfo, p.ToInfo) == WidthWidening
+}
+
+// NarrowingOnly is true for numeric conversions which compile into a type which is smaller than
+// From on some platform, e.g. int64 to int32, float64 to float32, or int to int32, or between
+// integer and float without always being exact, e.g. int64 to float64.
+func NarrowingOnly(p Pair) bool {
+	return numeric(p) && Classify(p.FromInfo, p.ToInfo) == WidthNarrowing
+}
+
+// ReinterpretingOnly is true for numeric conversions which compile and neither widen nor narrow,
+// but change how the bits are read, e.g. int32 to uint32, or int to uint.
+func ReinterpretingOnly(p Pair) bool {
+	return numeric(p) && Classify(p.FromInfo, p.ToInfo) == WidthReinterpreting
+}
+
+// numeric reports whether p compiles and converts between two numeric primitives.
+func numeric(p Pair) bool {
+	return p.Convertible && p.FromInfo.IsNumeric() && p.ToInfo.IsNumeric()
+}
@@ -0,0 +1,195 @@
+package conversions
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"github.com/pkg/errors"
+	"strings"
+	"sync"
+)
+
+const (
+	// LangGo is Go, whose conversions are always explicit.
+	LangGo = "Go"
+	// LangC is C, as of C11.
+	LangC = "C"
+	// LangJava is Java.
+	LangJava = "Java"
+	// LangRust is Rust.
+	LangRust = "Rust"
+
+	// RuleImplicit is a conversion which happens without any syntax, e.g. on assignment.
+	RuleImplicit = "implicit"
+	// RuleLossless is a conversion by a function which never changes the value, e.g. Rust's From.
+	RuleLossless = "lossless"
+	// RuleChecked is a conversion by a function which fails rather than change the value, e.g.
+	// Rust's TryFrom.
+	RuleChecked = "checked"
+	// RuleCast is a conversion by a cast, which may change the value.
+	RuleCast = "cast"
+	// RuleNone is no conversion at all, as the language has no counterpart to one of the types.
+	RuleNone = "none"
+)
+
+type (
+	// Foreign is how another language converts between its counterparts of a pair of Go types.
+	Foreign struct {
+		// Lang is the language, one of ForeignLangs.
+		Lang string
+		// From and To are the counterparts of the Go types in Lang, empty when it has none.
+		From string
+		To   string
+		// Rule is how Lang converts From to To, one of the Rule constants.
+		Rule string
+		// Note is what the conversion does to the value, e.g. "keeps the low bits".
+		Note string
+	}
+
+	// ForeignComparison contrasts how Go converts a pair with how each of ForeignLangs does.
+	ForeignComparison struct {
+		// Result is Go's Result for the pair.
+		Result Result
+		// Go is how Go converts the pair, as a Foreign whose Lang is LangGo.
+		Go Foreign
+		// Foreign is how each of ForeignLangs converts the pair, in that order.
+		Foreign []Foreign
+	}
+
+	// Comparer is implemented by RowWriters which can follow the Matrix with a section
+	// comparing each of its pairs to ForeignLangs, see Matrix.Compare. Compare is called, if at
+	// all, before any rows are written.
+	Comparer interface {
+		Compare()
+	}
+)
+
+var (
+	// ForeignLangs are the languages ForeignConversions compares Go to, in the order they're reported.
+	ForeignLangs = []string{LangC, LangJava, LangRust}
+
+	// foreignData is the dataset ForeignConversions is read from, a CSV row per pair and language.
+	//go:embed data/foreign.csv
+	foreignData string
+
+	// foreignOnce guards foreigns and foreignErr, which are read from foreignData once.
+	foreignOnce sync.Once
+	foreigns    map[[2]string][]Foreign
+	foreignErr  error
+)
+
+// ForeignConversions is how each of ForeignLangs converts between its counterparts of the Go
+// types from and to, in that order, from a dataset bundled with this package. Only Go's
+// fixed-size integers and floats, and their aliases, have counterparts in every language, so
+// it reports false for any other pair, and for a type with itself.
+func ForeignConversions(from, to string) ([]Foreign, bool, error) {
+	foreignOnce.Do(func() {
+		foreigns, foreignErr = parseForeign(foreignData)
+	})
+	if foreignErr != nil {
+		return nil, false, foreignErr
+	}
+	fs, ok := foreigns[[2]string{CanonicalName(from), CanonicalName(to)}]
+	return fs, ok, nil
+}
+
+// CompareForeign is a ForeignComparison for every one of results ForeignConversions has a
+// dataset entry for, in the same order.
+func CompareForeign(results []Result) ([]ForeignComparison, error) {
+	var fcs []ForeignComparison
+	for _, result := range results {
+		fs, ok, err := ForeignConversions(result.From, result.To)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		var fc ForeignComparison
+		fc.Result = result
+		fc.Go.Lang = LangGo
+		fc.Go.From = result.From
+		fc.Go.To = result.To
+		fc.Go.Rule = RuleCast
+		fc.Go.Note = goNote(result)
+		if !result.Convertible {
+			fc.Go.Rule = RuleNone
+		}
+		fc.Foreign = fs
+		fcs = append(fcs, fc)
+	}
+	return fcs, nil
+}
+
+// goNote is what Go's conversion for result does to the value, in the words of the dataset.
+func goNote(result Result) string {
+	from, _ := Lookup(result.From)
+	to, _ := Lookup(result.To)
+	switch {
+	case !result.Convertible:
+		return "does not compile"
+	case ExactNames(result.From, result.To):
+		return "exact"
+	case from.IsInteger() && to.IsInteger():
+		return "keeps the low bits"
+	case from.IsInteger():
+		return "rounds to nearest"
+	case to.IsInteger():
+		return "truncates toward zero, implementation-specific out of range"
+	default:
+		return "rounds, overflowing to infinity"
+	}
+}
+
+// Describe describes f, e.g. "cast long to int, keeps the low bits".
+func (f Foreign) Describe() string {
+	if f.Rule == RuleNone {
+		return f.Note
+	}
+	return fmt.Sprintf("%s %s to %s, %s", f.Rule, f.From, f.To, f.Note)
+}
+
+// parseForeign parses the dataset in data, whose header names its columns.
+func parseForeign(data string) (map[[2]string][]Foreign, error) {
+	r := csv.NewReader(strings.NewReader(data))
+	r.Comment = '#'
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading foreign conversions")
+	}
+	if len(records) == 0 || strings.Join(records[0], ",") != "from,to,lang,from_type,to_type,rule,note" {
+		return nil, errors.New("foreign conversions are missing their header")
+	}
+
+	order := make(map[string]int, len(ForeignLangs))
+	for i, lang := range ForeignLangs {
+		order[lang] = i
+	}
+	fs := make(map[[2]string][]Foreign)
+	for _, record := range records[1:] {
+		key := [2]string{record[0], record[1]}
+		var f Foreign
+		f.Lang = record[2]
+		f.From = record[3]
+		f.To = record[4]
+		f.Rule = record[5]
+		f.Note = record[6]
+		i, ok := order[f.Lang]
+		if !ok {
+			return nil, errors.Errorf("unknown language %q converting %s to %s", f.Lang, key[0], key[1])
+		}
+		if fs[key] == nil {
+			fs[key] = make([]Foreign, len(ForeignLangs))
+		}
+		fs[key][i] = f
+	}
+	for key, langs := range fs {
+		for i, f := range langs {
+			if f.Lang == "" {
+				return nil, errors.Errorf("%s is missing converting %s to %s", ForeignLangs[i], key[0], key[1])
+			}
+		}
+	}
+	return fs, nil
+}
@@ -0,0 +1,123 @@
+package conversions
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+)
+
+type (
+	// TemplateData is the data a template, DefaultTemplate or Options.TemplateFile, is executed
+	// with. A template which references anything else is rejected before it's executed, see
+	// LintTemplate.
+	TemplateData struct {
+		// Now is when the code is generated, in RFC 3339 format.
+		Now string
+		// App is the program generating the code.
+		App string
+		// Primitives are every type being analyzed.
+		Primitives []string
+		// Sources are the types the generated code converts from.
+		Sources []string
+		// Targets are the types Sources are converted to.
+		Targets []string
+	}
+)
+
+var (
+	// DefaultTemplate is the template used to generate the go code when
+	// Options.TemplateFile is not set.
+	//go:embed template/conversions.tmpl
+	DefaultTemplate string
+)
+
+// Generate executes the template for the given shard and generates the go code for it.
+// The generated go code is written to a file in opts.OutputDir whose path is returned.
+func Generate(ctx context.Context, opts Options, shard Shard) (string, error) {
+	src, err := Render(ctx, opts, shard)
+	if err != nil {
+		return "", errors.Wrap(err, "rendering")
+	}
+
+	return writeSource(opts, shardFileName(shard), src)
+}
+
+// writeSource writes the generated go code src to the file named file in opts.OutputDir,
+// returning its path.
+func writeSource(opts Options, file string, src []byte) (string, error) {
+	outputFile := filepath.Join(opts.OutputDir, file)
+	err := WriteFileAtomic(outputFile, src, 0o644)
+	if err != nil {
+		return "", errors.Wrapf(err, "writing output file %q", outputFile)
+	}
+
+	return outputFile, nil
+}
+
+// Render executes the template for the given shard and returns the generated go code
+// without writing anything to disk.
+func Render(_ context.Context, opts Options, shard Shard) ([]byte, error) {
+	t, _, err := parseTemplate(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing template")
+	}
+
+	var data TemplateData
+	data.Now = time.Now().Format(time.RFC3339)
+	data.App = os.Args[0]
+	data.Primitives = opts.Types
+	data.Sources = shard.Sources
+	data.Targets = shard.targets(opts)
+
+	var buf bytes.Buffer
+	err = t.Execute(&buf, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "executing template")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// shardFileName is the name of the file the go code for shard is generated into.
+func shardFileName(shard Shard) string {
+	return fmt.Sprintf("conversions_%03d.go", shard.Index)
+}
+
+// parseTemplate parses opts.TemplateFile, falling back to DefaultTemplate when it is not set,
+// returning the TemplateSchema it was written against. Either is only parsed, adapted to the
+// current TemplateSchema, and linted once, see parseCached. A template file is still read every
+// time, so any change to it is picked up.
+func parseTemplate(opts Options) (*template.Template, int, error) {
+	if opts.TemplateFile == "" {
+		t, err := parseCached("conversions.tmpl", DefaultTemplate, nil)
+		return t, TemplateSchema, err
+	}
+
+	text, err := os.ReadFile(opts.TemplateFile)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "reading template file %q", opts.TemplateFile)
+	}
+	schema, err := TemplateSchemaOf(string(text))
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "parsing template file %q", opts.TemplateFile)
+	}
+	check := func(t *template.Template) error {
+		_, err := MigrateTemplate(t, schema)
+		if err != nil {
+			return errors.Wrap(err, "migrating")
+		}
+		return errors.Wrap(LintTemplate(t), "linting")
+	}
+	t, err := parseCached(opts.TemplateFile, string(text), check)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "parsing template file %q", opts.TemplateFile)
+	}
+
+	return t, schema, nil
+}
@@ -0,0 +1,382 @@
+package conversions
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"go/types"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type (
+	// SampleField is a field of a sample document, e.g. a JSON or YAML config, along with the go
+	// type inferred from the values it has.
+	SampleField struct {
+		// Path locates the field, by its keys joined with dots and [] for the elements of an
+		// array, e.g. servers[].port.
+		Path string
+		// Type is the go type the values were inferred as: bool, string, float64, int64, or uint64
+		// for integers too big for an int64. It is empty when the values are of different kinds.
+		Type string
+		// Values are every value the field has in the sample, as written, leaving out nulls.
+		Values []string
+
+		// valueTypes are the types each of Values is inferred as on its own.
+		valueTypes []string
+	}
+
+	// FieldTarget is a struct field a SampleField is decoded into.
+	FieldTarget struct {
+		// Field is the field's selector from the struct, e.g. Config.Servers[].Port.
+		Field string
+		// Type is the field's type, followed by its underlying type when it differs.
+		Type string
+
+		typ types.Type
+	}
+
+	// FieldFit is whether the values of a SampleField convert safely to the types they're
+	// decoded into.
+	FieldFit struct {
+		Sample SampleField
+		// Fits lists the primitives every value converts to exactly, when there is no struct to
+		// decode into.
+		Fits []string `json:",omitempty"`
+		// Target is the struct field the sample field is decoded into, nil when the struct has
+		// none or there's no struct.
+		Target *FieldTarget `json:",omitempty"`
+		// Legal is whether converting every value, as the type it is inferred as, to the Target
+		// compiles, and Safe whether every value converts exactly as well.
+		Legal bool
+		Safe  bool
+		// Unsafe is the first value which doesn't convert, or doesn't convert exactly.
+		Unsafe string `json:",omitempty"`
+	}
+
+	// sampleValue is a value of a SampleField, with the go type it is inferred as.
+	sampleValue struct {
+		text string
+		typ  string
+	}
+)
+
+// InferFields infers a SampleField for every field of doc, a document decoded as encoding/json
+// does with UseNumber, or as DecodeYAML does, ordered by Path.
+func InferFields(doc interface{}) []SampleField {
+	values := make(map[string][]sampleValue)
+	collectValues(doc, "", values)
+
+	var fields []SampleField
+	for path, vs := range values {
+		var f SampleField
+		f.Path = path
+		numbers := true
+		for _, v := range vs {
+			f.Values = append(f.Values, v.text)
+			f.valueTypes = append(f.valueTypes, v.typ)
+			numbers = numbers && isNumber(v.typ)
+		}
+		f.Type = vs[0].typ
+		for _, v := range vs {
+			if v.typ != f.Type {
+				f.Type = ""
+			}
+		}
+		if numbers && f.Type == "" {
+			f.Type = numbersType(f.Values)
+		}
+		fields = append(fields, f)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Path < fields[j].Path })
+	return fields
+}
+
+// StructTargets type checks the package in dir and lists the fields of the struct named name
+// which a sample document is decoded into, by the lower case Path of the SampleField decoded
+// into each, as encoding/json matches keys without regard to case. Fields are named by their
+// json tag, or failing that their yaml tag, or their name.
+func StructTargets(dir, name string) (map[string]FieldTarget, error) {
+	lp, err := loadPackage(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	if lp.pkg == nil {
+		return nil, errors.Errorf("type checking %q", dir)
+	}
+	obj, ok := lp.pkg.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return nil, errors.Errorf("no type named %s in %q", name, dir)
+	}
+	if _, ok := derefType(obj.Type()).Underlying().(*types.Struct); !ok {
+		return nil, errors.Errorf("%s is not a struct", name)
+	}
+
+	targets := make(map[string]FieldTarget)
+	collectTargets(obj.Type(), "", name, targets, make(map[types.Type]bool), lp.qualifier)
+	return targets, nil
+}
+
+// FitFields determines how safely each of fields converts to the struct field it decodes into in
+// targets, or when targets is nil, which primitives every value of each converts to exactly.
+func FitFields(fields []SampleField, targets map[string]FieldTarget) []FieldFit {
+	var fits []FieldFit
+	for _, f := range fields {
+		var fit FieldFit
+		fit.Sample = f
+		if targets == nil {
+			for _, name := range Primitives {
+				to, _ := Lookup(name)
+				if to.AliasOf == "" && firstUnfit(f, types.Typ[basicKind(name)]) == "" {
+					fit.Fits = append(fit.Fits, name)
+				}
+			}
+			fits = append(fits, fit)
+			continue
+		}
+
+		target, ok := targets[strings.ToLower(f.Path)]
+		if !ok {
+			fits = append(fits, fit)
+			continue
+		}
+		fit.Target = &target
+		fit.Legal = true
+		for _, v := range sampleValues(f) {
+			if !types.ConvertibleTo(types.Typ[basicKind(v.typ)], target.typ) {
+				fit.Legal = false
+				fit.Unsafe = v.text
+				break
+			}
+		}
+		if fit.Legal {
+			fit.Unsafe = firstUnfit(f, target.typ)
+			fit.Safe = fit.Unsafe == ""
+		}
+		fits = append(fits, fit)
+	}
+	return fits
+}
+
+// collectValues records every scalar in v, which is at path, by the path of the field it's in.
+func collectValues(v interface{}, path string, values map[string][]sampleValue) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			collectValues(child, childPath, values)
+		}
+	case []interface{}:
+		for _, child := range v {
+			collectValues(child, path+"[]", values)
+		}
+	case bool:
+		values[path] = append(values[path], sampleValue{text: strconv.FormatBool(v), typ: "bool"})
+	case string:
+		values[path] = append(values[path], sampleValue{text: v, typ: "string"})
+	case json.Number:
+		values[path] = append(values[path], sampleValue{text: v.String(), typ: numberType(v.String())})
+	case float64:
+		// NOTE: Documents decoded without UseNumber.
+		text := strconv.FormatFloat(v, 'g', -1, 64)
+		values[path] = append(values[path], sampleValue{text: text, typ: numberType(text)})
+	}
+}
+
+// collectTargets records the fields of t, which is at path and selected by field, that sample
+// fields are decoded into.
+func collectTargets(t types.Type, path, field string, targets map[string]FieldTarget, seen map[types.Type]bool, qualifier types.Qualifier) {
+	t = derefType(t)
+	switch u := t.Underlying().(type) {
+	case *types.Struct:
+		if seen[t] {
+			return
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		for i := 0; i < u.NumFields(); i++ {
+			f := u.Field(i)
+			tag := reflect.StructTag(u.Tag(i))
+			name, _, _ := strings.Cut(tag.Get("json"), ",")
+			if name == "" {
+				name, _, _ = strings.Cut(tag.Get("yaml"), ",")
+			}
+			if name == "-" {
+				continue
+			}
+			if name == "" && f.Anonymous() {
+				// NOTE: The fields of an untagged embedded struct, even an unexported one, are
+				// decoded as if they were the outer struct's.
+				if _, ok := derefType(f.Type()).Underlying().(*types.Struct); ok {
+					collectTargets(f.Type(), path, field, targets, seen, qualifier)
+					continue
+				}
+			}
+			if !f.Exported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name()
+			}
+			childPath := name
+			if path != "" {
+				childPath = path + "." + name
+			}
+			collectTargets(f.Type(), childPath, field+"."+f.Name(), targets, seen, qualifier)
+		}
+	case *types.Slice:
+		if !isByteSlice(u) {
+			collectTargets(u.Elem(), path+"[]", field+"[]", targets, seen, qualifier)
+			return
+		}
+		recordTarget(t, path, field, targets, qualifier)
+	case *types.Array:
+		collectTargets(u.Elem(), path+"[]", field+"[]", targets, seen, qualifier)
+	default:
+		recordTarget(t, path, field, targets, qualifier)
+	}
+}
+
+// recordTarget records the struct field selected by field, of type t, as the target of path.
+func recordTarget(t types.Type, path, field string, targets map[string]FieldTarget, qualifier types.Qualifier) {
+	var ft FieldTarget
+	ft.Field = field
+	ft.Type = describeType(t, qualifier)
+	ft.typ = t
+	targets[strings.ToLower(path)] = ft
+}
+
+// firstUnfit returns the first value of f which doesn't convert to t exactly, or "" if they all
+// do. Values are only checked against primitives, they decode into any other type t they can
+// be converted to, e.g. an interface{}, as they are.
+func firstUnfit(f SampleField, t types.Type) string {
+	to, ok := basicInfo(t)
+	if !ok {
+		return ""
+	}
+	for _, v := range sampleValues(f) {
+		if !valueFits(v, to) {
+			return v.text
+		}
+	}
+	return ""
+}
+
+// valueFits reports whether v converts to the primitive described by to exactly, on every platform.
+func valueFits(v sampleValue, to Info) bool {
+	switch {
+	case v.typ == "bool" || v.typ == "string":
+		return string(to.Kind) == v.typ
+	case to.IsInteger():
+		r, ok := new(big.Rat).SetString(v.text)
+		if !ok || !r.IsInt() {
+			return false
+		}
+		bounds := integerRange(to, to.MinBits())
+		return r.Num().Cmp(bounds.Min) >= 0 && r.Num().Cmp(bounds.Max) <= 0
+	case to.Kind == KindFloat || to.Kind == KindComplex:
+		bits := to.Bits
+		if to.Kind == KindComplex {
+			bits /= 2
+		}
+		r, ok := new(big.Rat).SetString(v.text)
+		if ok && r.IsInt() {
+			f := new(big.Float).SetInt(r.Num())
+			if bits == 32 {
+				_, accuracy := f.Float32()
+				return accuracy == big.Exact
+			}
+			_, accuracy := f.Float64()
+			return accuracy == big.Exact
+		}
+		// NOTE: Few fractions can be represented exactly, so one fits a float when it reads back
+		// as the same float64 it would decode to.
+		f64, err := strconv.ParseFloat(v.text, 64)
+		if err != nil || math.IsInf(f64, 0) {
+			return false
+		}
+		f, err := strconv.ParseFloat(v.text, bits)
+		return err == nil && f == f64
+	default:
+		return false
+	}
+}
+
+// sampleValues are the values of f, each with the go type it is inferred as on its own.
+func sampleValues(f SampleField) []sampleValue {
+	var vs []sampleValue
+	for i, text := range f.Values {
+		var v sampleValue
+		v.text = text
+		v.typ = f.Type
+		if i < len(f.valueTypes) {
+			v.typ = f.valueTypes[i]
+		}
+		vs = append(vs, v)
+	}
+	return vs
+}
+
+// numberType infers the go type of the number text.
+func numberType(text string) string {
+	if _, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return "int64"
+	}
+	if _, err := strconv.ParseUint(text, 10, 64); err == nil {
+		return "uint64"
+	}
+	return "float64"
+}
+
+// isNumber reports whether typ is one of the types numbers are inferred as.
+func isNumber(typ string) bool {
+	return typ == "int64" || typ == "uint64" || typ == "float64"
+}
+
+// numbersType infers the go type of a field whose values are all numbers.
+func numbersType(texts []string) string {
+	typ := "int64"
+	for _, text := range texts {
+		switch numberType(text) {
+		case "float64":
+			return "float64"
+		case "uint64":
+			typ = "uint64"
+		}
+	}
+	if typ == "uint64" {
+		for _, text := range texts {
+			// NOTE: A negative int64 and a uint64 too big for an int64 only have a float in common.
+			if strings.HasPrefix(text, "-") {
+				return "float64"
+			}
+		}
+	}
+	return typ
+}
+
+// basicKind is the types.BasicKind of the primitive named name.
+func basicKind(name string) types.BasicKind {
+	return types.Universe.Lookup(name).Type().(*types.Basic).Kind()
+}
+
+// derefType is the type t points to, or t when it isn't a pointer.
+func derefType(t types.Type) types.Type {
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		return p.Elem()
+	}
+	return t
+}
+
+// isByteSlice reports whether s is a []byte, which is decoded from a string rather than an array.
+func isByteSlice(s *types.Slice) bool {
+	b, ok := s.Elem().Underlying().(*types.Basic)
+	return ok && b.Kind() == types.Byte
+}
@@ -0,0 +1,239 @@
+package conversions
+
+import (
+	"go/types"
+)
+
+const (
+	// KindBool is the Kind of bool.
+	KindBool Kind = "bool"
+	// KindInt is the Kind of the signed integer types.
+	KindInt Kind = "int"
+	// KindUint is the Kind of the unsigned integer types.
+	KindUint Kind = "uint"
+	// KindFloat is the Kind of the floating point types.
+	KindFloat Kind = "float"
+	// KindComplex is the Kind of the complex types.
+	KindComplex Kind = "complex"
+	// KindString is the Kind of string.
+	KindString Kind = "string"
+
+	// WidthWidening classifies a numeric conversion into a type which holds every value of the
+	// type converted from exactly, e.g. int32 to int64, see Classify.
+	WidthWidening = "widening"
+	// WidthNarrowing classifies a numeric conversion which can lose information, into a type with
+	// fewer bits than the type converted from on some platform, e.g. int64 to int32 or int to
+	// int32, or between integer and float, which loses precision or the fraction, e.g. int64 to
+	// float64 or float64 to int64, see Classify.
+	WidthNarrowing = "narrowing"
+	// WidthReinterpreting classifies a numeric conversion which doesn't lose any bits, but changes
+	// how they are read, between signed and unsigned integers no narrower than the type converted
+	// from on any platform, e.g. int32 to uint32, int to uint, or int8 to uint64, see Classify.
+	WidthReinterpreting = "reinterpreting"
+)
+
+type (
+	// Kind is the broad family a primitive type belongs to.
+	Kind string
+
+	// Info describes the shape of a primitive type as reported by go/types.
+	Info struct {
+		Name string
+		Kind Kind
+		// Bits is the size of the type in bits, or 0 when it depends on the platform
+		// being compiled for, as it does for int, uint, and uintptr.
+		Bits int
+		// AliasOf is the type Name is an alias for, if it is one.
+		AliasOf string
+	}
+)
+
+// Lookup describes the primitive type named name, reporting false when it is not a primitive.
+func Lookup(name string) (Info, bool) {
+	obj := types.Universe.Lookup(name)
+	if obj == nil {
+		return Info{}, false
+	}
+	tn, ok := obj.(*types.TypeName)
+	if !ok {
+		return Info{}, false
+	}
+	basic, ok := tn.Type().(*types.Basic)
+	if !ok {
+		return Info{}, false
+	}
+
+	var info Info
+	info.Name = name
+	if canonical := types.Typ[basic.Kind()].Name(); canonical != name {
+		info.AliasOf = canonical
+	}
+
+	switch basic.Kind() {
+	case types.Bool:
+		info.Kind = KindBool
+	case types.Int8, types.Int16, types.Int32, types.Int64:
+		info.Kind = KindInt
+		info.Bits = bitsOf(basic)
+	case types.Int:
+		info.Kind = KindInt
+	case types.Uint8, types.Uint16, types.Uint32, types.Uint64:
+		info.Kind = KindUint
+		info.Bits = bitsOf(basic)
+	case types.Uint, types.Uintptr:
+		info.Kind = KindUint
+	case types.Float32, types.Float64:
+		info.Kind = KindFloat
+		info.Bits = bitsOf(basic)
+	case types.Complex64, types.Complex128:
+		info.Kind = KindComplex
+		info.Bits = bitsOf(basic)
+	case types.String:
+		info.Kind = KindString
+	default:
+		return Info{}, false
+	}
+
+	return info, true
+}
+
+// IsNumeric reports whether i is an integer or floating point type.
+func (i Info) IsNumeric() bool {
+	return i.Kind == KindInt || i.Kind == KindUint || i.Kind == KindFloat
+}
+
+// IsInteger reports whether i is a signed or unsigned integer type.
+func (i Info) IsInteger() bool {
+	return i.Kind == KindInt || i.Kind == KindUint
+}
+
+// MinBits is the fewest bits i can occupy on any platform.
+func (i Info) MinBits() int {
+	if i.Bits == 0 {
+		return 32
+	}
+	return i.Bits
+}
+
+// MaxBits is the most bits i can occupy on any platform.
+func (i Info) MaxBits() int {
+	if i.Bits == 0 {
+		return 64
+	}
+	return i.Bits
+}
+
+// On returns i as it is when compiling for goarch, with the size of platform dependent types
+// filled in, reporting false when goarch is not known to the gc compiler.
+func (i Info) On(goarch string) (Info, bool) {
+	sizes := types.SizesFor("gc", goarch)
+	if sizes == nil {
+		return Info{}, false
+	}
+	if i.Bits == 0 && i.IsInteger() {
+		i.Bits = int(sizes.Sizeof(types.Typ[types.Int])) * 8
+	}
+	return i, true
+}
+
+// bitsOf is the size of basic in bits. It must not be used for platform dependent types.
+func bitsOf(basic *types.Basic) int {
+	sizes := types.SizesFor("gc", "amd64")
+	return int(sizes.Sizeof(basic)) * 8
+}
+
+// Canonical is the name of the type i is an alias of, or its own name if it is not an alias.
+func (i Info) Canonical() string {
+	if i.AliasOf != "" {
+		return i.AliasOf
+	}
+	return i.Name
+}
+
+// Exact reports whether every value of the numeric type from can be represented exactly as
+// the numeric type to on every platform, meaning the conversion can never lose information.
+// It is false for any pair which isn't numeric, unless both are the same type.
+func Exact(from, to Info) bool {
+	if from.Canonical() == to.Canonical() {
+		return true
+	}
+
+	switch {
+	case from.IsInteger() && to.IsInteger():
+		return integerFits(from, to)
+	case from.IsInteger() && to.Kind == KindFloat:
+		return integerFitsFloat(from, to)
+	case from.Kind == KindFloat && to.Kind == KindFloat:
+		return to.MinBits() >= from.MaxBits()
+	default:
+		return false
+	}
+}
+
+// Classify reports whether converting from to to is widening, narrowing, or reinterpreting,
+// going by their bit widths, signedness, and whether they are integers or floats. It is empty
+// for the same type and for any pair which isn't numeric.
+func Classify(from, to Info) string {
+	switch {
+	case !from.IsNumeric() || !to.IsNumeric() || from.Canonical() == to.Canonical():
+		return ""
+	case Exact(from, to):
+		return WidthWidening
+	case from.IsInteger() != to.IsInteger():
+		// NOTE: An integer which doesn't fit a float's mantissa is rounded, and a float's fraction
+		// is dropped, whatever their widths.
+		return WidthNarrowing
+	case from.Bits == 0 && to.Bits == 0:
+		// NOTE: int, uint, and uintptr are the same width as each other on every platform.
+		return WidthReinterpreting
+	case to.MinBits() < from.MaxBits():
+		return WidthNarrowing
+	default:
+		return WidthReinterpreting
+	}
+}
+
+// ClassifyNames is Classify for the types named from and to, empty unless both are primitives.
+func ClassifyNames(from, to string) string {
+	fromInfo, ok := Lookup(from)
+	if !ok {
+		return ""
+	}
+	toInfo, ok := Lookup(to)
+	if !ok {
+		return ""
+	}
+	return Classify(fromInfo, toInfo)
+}
+
+// Mantissa is the number of bits of precision in the float type i, including the implicit bit.
+func (i Info) Mantissa() int {
+	if i.Bits == 64 {
+		return 53
+	}
+	return 24
+}
+
+// integerFits reports whether every value of the integer type from fits in the integer type to
+// on every platform.
+func integerFits(from, to Info) bool {
+	switch {
+	case from.Kind == to.Kind:
+		return to.MinBits() >= from.MaxBits()
+	case from.Kind == KindUint:
+		return to.MinBits() > from.MaxBits()
+	default:
+		// NOTE: Negative values never fit in an unsigned type.
+		return false
+	}
+}
+
+// integerFitsFloat reports whether every value of the integer type from is exactly representable
+// by the float type to, which is the case when it fits in to's mantissa.
+func integerFitsFloat(from, to Info) bool {
+	magnitude := from.MaxBits()
+	if from.Kind == KindInt {
+		magnitude--
+	}
+	return magnitude <= to.Mantissa()
+}
@@ -0,0 +1,61 @@
+package conversions
+
+import (
+	"testing"
+)
+
+// TestClassify checks Classify against every pair of Primitives. Each want has a letter for every
+// type converted to, in the order of Primitives: w for WidthWidening, n for WidthNarrowing, r for
+// WidthReinterpreting, and - for none.
+func TestClassify(t *testing.T) {
+	//                     to: bool, uint8, byte, uint16, uint32, uint64, uint, uintptr, int8, int16,
+	//                         int32, rune, int64, int, float32, float64, complex64, complex128, string
+	tests := []struct {
+		from string
+		want string
+	}{
+		{from: "bool", want: "-------------------"},
+		{from: "uint8", want: "---wwwwwrwwwwwww---"},
+		{from: "byte", want: "---wwwwwrwwwwwww---"},
+		{from: "uint16", want: "-nn-wwwwnrwwwwww---"},
+		{from: "uint32", want: "-nnn-wwwnnrrwrnw---"},
+		{from: "uint64", want: "-nnnn-nnnnnnrnnn---"},
+		{from: "uint", want: "-nnnnw-rnnnnrrnn---"},
+		{from: "uintptr", want: "-nnnnwr-nnnnrrnn---"},
+		{from: "int8", want: "-rrrrrrr-wwwwwww---"},
+		{from: "int16", want: "-nnrrrrrn-wwwwww---"},
+		{from: "int32", want: "-nnnrrrrnn--wwnw---"},
+		{from: "rune", want: "-nnnrrrrnn--wwnw---"},
+		{from: "int64", want: "-nnnnrnnnnnn-nnn---"},
+		{from: "int", want: "-nnnnrrrnnnnw-nn---"},
+		{from: "float32", want: "-nnnnnnnnnnnnn-w---"},
+		{from: "float64", want: "-nnnnnnnnnnnnnn----"},
+		{from: "complex64", want: "-------------------"},
+		{from: "complex128", want: "-------------------"},
+		{from: "string", want: "-------------------"},
+	}
+	letters := map[string]byte{
+		"":                  '-',
+		WidthWidening:       'w',
+		WidthNarrowing:      'n',
+		WidthReinterpreting: 'r',
+	}
+
+	if len(tests) != len(Primitives) {
+		t.Fatalf("%d rows for %d primitives", len(tests), len(Primitives))
+	}
+	for i, tt := range tests {
+		if tt.from != Primitives[i] {
+			t.Fatalf("row %d is %s, expected %s", i, tt.from, Primitives[i])
+		}
+		if len(tt.want) != len(Primitives) {
+			t.Fatalf("%s: %d classes for %d primitives", tt.from, len(tt.want), len(Primitives))
+		}
+		for j, to := range Primitives {
+			got := ClassifyNames(tt.from, to)
+			if letters[got] != tt.want[j] {
+				t.Errorf("Classify(%s, %s) = %q, expected %c", tt.from, to, got, tt.want[j])
+			}
+		}
+	}
+}
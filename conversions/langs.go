@@ -0,0 +1,130 @@
+package conversions
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"path/filepath"
+	"strings"
+)
+
+type (
+	// LangMatrix holds a LangResult for every pair of Types, across every one of Langs.
+	LangMatrix struct {
+		Langs   []string
+		Types   []string
+		Results []LangResult
+	}
+
+	// LangResult is whether From converts to To under each language version it was checked under.
+	LangResult struct {
+		From string
+		To   string
+		// Convertible is keyed by language version.
+		Convertible map[string]bool
+	}
+)
+
+const (
+	// SliceLength is the length of the arrays AnalyzeLangs converts slices to.
+	SliceLength = 4
+)
+
+// SliceTypes returns, for every type in elems, a slice of it, an array of length of it, and a
+// pointer to that array, e.g. []int8, [4]int8, *[4]int8, []int16, and so on.
+func SliceTypes(length int, elems []string) []string {
+	var slices []string
+	for _, elem := range elems {
+		slices = append(slices, "[]"+elem, fmt.Sprintf("[%d]%s", length, elem), fmt.Sprintf("*[%d]%s", length, elem))
+	}
+	return slices
+}
+
+// ParseSlice returns the element type of the slice type name, reporting false when it is not
+// a slice type.
+func ParseSlice(name string) (string, bool) {
+	if !strings.HasPrefix(name, "[]") {
+		return "", false
+	}
+	return strings.TrimPrefix(name, "[]"), true
+}
+
+// AnalyzeLangs runs a full analysis under each of langs, as language versions such as go1.19,
+// see Options.LangVersion, and combines the results. Alongside opts.Types, it checks the
+// SliceTypes of those which are primitives, since converting a slice to an array pointer
+// requires go1.17 and to an array go1.20, while the primitive conversions are the same under
+// every version. Each language version gets its own directory under opts.OutputDir, and nothing
+// is saved to opts.StateFile or opts.CacheFile.
+func AnalyzeLangs(ctx context.Context, opts Options, langs []string) (LangMatrix, error) {
+	opts = opts.WithDefaults()
+
+	for _, lang := range langs {
+		err := checkLangVersion(lang)
+		if err != nil {
+			return LangMatrix{}, err
+		}
+	}
+
+	var elems []string
+	for _, t := range opts.Types {
+		if _, ok := Lookup(t); ok {
+			elems = append(elems, t)
+		}
+	}
+	slices := Dedupe(SliceTypes(SliceLength, elems))
+
+	var lm LangMatrix
+	lm.Langs = langs
+	lm.Types = append(append([]string{}, opts.Types...), slices...)
+	index := make(map[[2]string]int)
+
+	for _, lang := range langs {
+		langOpts := opts
+		langOpts.LangVersion = lang
+		langOpts.OutputDir = filepath.Join(opts.OutputDir, lang)
+		langOpts.StateFile = ""
+		langOpts.CacheFile = ""
+		langOpts.Resume = false
+		m, err := Analyze(ctx, langOpts)
+		if err != nil {
+			return LangMatrix{}, errors.Wrapf(err, "analyzing under %s", lang)
+		}
+		sm, err := analyzeComposites(ctx, langOpts, slices)
+		if err != nil {
+			return LangMatrix{}, errors.Wrapf(err, "analyzing slices under %s", lang)
+		}
+
+		// NOTE: Slices are only checked against each other, not against opts.Types.
+		for _, result := range append(m.Results, sm.Results...) {
+			key := [2]string{result.From, result.To}
+			i, ok := index[key]
+			if !ok {
+				var lr LangResult
+				lr.From = result.From
+				lr.To = result.To
+				lr.Convertible = make(map[string]bool)
+				i = len(lm.Results)
+				index[key] = i
+				lm.Results = append(lm.Results, lr)
+			}
+			lm.Results[i].Convertible[lang] = result.Convertible
+		}
+	}
+
+	return lm, nil
+}
+
+// Differs reports whether lr.From converts to lr.To under some language versions but not others.
+func (lr LangResult) Differs() bool {
+	var seen, convertible bool
+	for lang := range lr.Convertible {
+		if !seen {
+			seen, convertible = true, lr.Convertible[lang]
+			continue
+		}
+		if lr.Convertible[lang] != convertible {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package conversions
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+const (
+	// maxShardSources is the most sources a Shard is ever given, however many errors the compiler
+	// reports, so no single file grows unreasonably large.
+	maxShardSources = 16
+	// errorLimitFile is the name of the file MeasureErrorLimit checks.
+	errorLimitFile = "error_limit.go"
+)
+
+// MeasureErrorLimit measures the effective error limit of the backend opts are configured
+// with, i.e. how many conversion failures it reports for a single file before giving up.
+// It checks a file with exactly as many failing conversions as the largest Shard opts could be
+// split into, returning -1 when every one of them is reported.
+func MeasureErrorLimit(ctx context.Context, opts Options) (int, error) {
+	opts = opts.WithDefaults()
+
+	backend, err := backendFor(opts)
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := backend.(BuildBackend); ok {
+		err := writeProbeModule(opts.OutputDir, opts.LangVersion)
+		if err != nil {
+			return 0, errors.Wrap(err, "writing probe module")
+		}
+	}
+
+	return measureErrorLimit(ctx, opts, backend)
+}
+
+// measureErrorLimit implements MeasureErrorLimit for backend.
+func measureErrorLimit(ctx context.Context, opts Options, backend Backend) (int, error) {
+	probes := len(opts.Types) * largestShard(opts)
+
+	var b strings.Builder
+	b.WriteString("package conversions\n\nvar p struct{ bool bool }\n\nfunc errorLimit() {\n")
+	for i := 0; i < probes; i++ {
+		b.WriteString("\t_ = int(p.bool)\n")
+	}
+	b.WriteString("}\n")
+
+	cfs, err := checkSource(ctx, opts, backend, errorLimitFile, []byte(b.String()))
+	if err != nil {
+		return 0, errors.Wrap(err, "checking error limit probe")
+	}
+	if len(cfs) >= probes {
+		return -1, nil
+	}
+	if len(cfs) == 0 {
+		return 0, errors.Errorf("none of the %d failing conversions in the error limit probe were reported", probes)
+	}
+
+	return len(cfs), nil
+}
+
+// largestShard is the most sources a Shard of an analysis configured by opts could be given,
+// spreading the sources over every worker.
+func largestShard(opts Options) int {
+	size := (len(opts.Types) + opts.Parallelism - 1) / opts.Parallelism
+	if size > maxShardSources {
+		size = maxShardSources
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// planShards splits sources into Shards, converting them to targets, or every type when targets
+// is empty, small enough that, even if every conversion in one fails, it stays below limit, the
+// number of errors the compiler reports per file. A negative limit means there is no limit. Each
+// Shard is indexed by the position of its first source in opts.Types, so shards remain distinct
+// when an analysis is resumed with different sizes.
+func planShards(opts Options, sources, targets []string, limit int) ([]Shard, error) {
+	conversions := len(targets)
+	if conversions == 0 {
+		conversions = len(opts.Types)
+	}
+	size := largestShard(opts)
+	if limit >= 0 {
+		fits := (limit - 1) / conversions
+		if fits < 1 {
+			return nil, errors.Errorf("the compiler reports at most %d errors per file, too few for even the %d conversions from a single type", limit, conversions)
+		}
+		if fits < size {
+			size = fits
+		}
+	}
+
+	index := make(map[string]int)
+	for i, t := range opts.Types {
+		index[t] = i
+	}
+
+	var shards []Shard
+	for len(sources) > 0 {
+		n := size
+		if n > len(sources) {
+			n = len(sources)
+		}
+		var shard Shard
+		shard.Index = index[sources[0]]
+		shard.Sources = sources[:n]
+		shard.Targets = targets
+		shards = append(shards, shard)
+		sources = sources[n:]
+	}
+
+	return shards, nil
+}
+
+// checkErrorLimit returns an error when cfs, the failures reported for shard, reaches limit,
+// since the compiler may then have stopped before reporting them all.
+func checkErrorLimit(shard Shard, cfs ConversionFailures, limit int) error {
+	if limit < 0 || len(cfs) < limit {
+		return nil
+	}
+	return errors.Errorf("shard %d reported %d conversion failures, as many as the %d errors the compiler reports per file, so some may be missing", shard.Index, len(cfs), limit)
+}
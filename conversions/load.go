@@ -0,0 +1,94 @@
+package conversions
+
+import (
+	"github.com/pkg/errors"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+)
+
+type (
+	// loadedPackage is a user package parsed and type checked by loadPackage.
+	loadedPackage struct {
+		fset  *token.FileSet
+		files []*ast.File
+		pkg   *types.Package
+		info  *types.Info
+		// illegal holds the position of every conversion the type checker rejected.
+		illegal map[token.Pos]bool
+	}
+)
+
+// loadPackage parses and type checks the package in dir, for the platform and build tags in ts.
+func loadPackage(dir string, ts TagSet) (*loadedPackage, error) {
+	// NOTE: The source importer resolves dependencies with build.Default, so it has to be
+	// pointed at the same platform and tags for the duration of the analysis.
+	defaultContext := build.Default
+	defer func() { build.Default = defaultContext }()
+	build.Default.BuildTags = nil
+	for _, t := range ts {
+		switch {
+		case contains(knownGOOS, t):
+			build.Default.GOOS = t
+		case contains(knownGOARCH, t):
+			build.Default.GOARCH = t
+		default:
+			build.Default.BuildTags = append(build.Default.BuildTags, t)
+		}
+	}
+
+	pkg, err := build.Default.ImportDir(dir, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading package in %q", dir)
+	}
+
+	var lp loadedPackage
+	lp.fset = token.NewFileSet()
+	for _, name := range pkg.GoFiles {
+		f, err := parser.ParseFile(lp.fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing %q", name)
+		}
+		lp.files = append(lp.files, f)
+	}
+
+	lp.check(pkg.ImportPath, build.Default.GOARCH)
+
+	return &lp, nil
+}
+
+// check type checks the parsed files of lp as the package path, for goarch, recording the
+// results into lp.
+func (lp *loadedPackage) check(path string, goarch string) {
+	lp.illegal = make(map[token.Pos]bool)
+	var conf types.Config
+	conf.Importer = importer.ForCompiler(lp.fset, "source", nil)
+	conf.Sizes = types.SizesFor("gc", goarch)
+	conf.Error = func(err error) {
+		var terr types.Error
+		// NOTE: Constants which don't fit the type they're converted to are reported as overflows.
+		if errors.As(err, &terr) && (strings.Contains(terr.Msg, "cannot convert") || strings.Contains(terr.Msg, "overflows")) {
+			lp.illegal[terr.Pos] = true
+		}
+	}
+	lp.info = &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	// NOTE: Errors other than illegal conversions are the package's own problem, and collected by conf.Error.
+	lp.pkg, _ = conf.Check(path, lp.fset, lp.files, lp.info)
+}
+
+// qualifier leaves types declared in the loaded package unqualified, and qualifies every
+// other type by its package name.
+func (lp *loadedPackage) qualifier(p *types.Package) string {
+	if p == lp.pkg {
+		return ""
+	}
+	return p.Name()
+}
@@ -0,0 +1,176 @@
+package conversions
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// LocaleEnglish is the locale reports are in when none is given.
+	LocaleEnglish = "en"
+	// LocaleGerman renders the headings and legends of reports in German.
+	LocaleGerman = "de"
+	// LocaleJapanese renders the headings and legends of reports in Japanese.
+	LocaleJapanese = "ja"
+	// LocaleChinese renders the headings and legends of reports in Simplified Chinese.
+	LocaleChinese = "zh"
+
+	// MessageCorner labels the rows and columns of a grid whose rows are the types converted from.
+	MessageCorner = "corner"
+	// MessageCornerPivoted labels the rows and columns of a grid whose rows are the types converted to.
+	MessageCornerPivoted = "corner-pivoted"
+	// MessageConverting heads the section of a type's conversions to every other, given its name.
+	MessageConverting = "converting"
+	// MessageConvertingTo heads the section of every type's conversions to a type, given its name.
+	MessageConvertingTo = "converting-to"
+	// MessageTitle is the title of a report.
+	MessageTitle = "title"
+	// MessageRows explains what the rows and columns of a grid are, whose rows are the types
+	// converted from.
+	MessageRows = "rows"
+	// MessageRowsPivoted explains what the rows and columns of a grid are, whose rows are the
+	// types converted to.
+	MessageRowsPivoted = "rows-pivoted"
+	// MessageLegend explains the symbols in the cells of a grid.
+	MessageLegend = "legend"
+	// MessageLegendAccessible explains the words in the cells of an accessible grid, see Word.
+	MessageLegendAccessible = "legend-accessible"
+	// MessageCompared heads the section comparing each pair to other languages, given their names.
+	MessageCompared = "compared"
+	// MessageClassified heads the section listing each pair's Columns, given their names, for
+	// reports with nowhere else to put them.
+	MessageClassified = "classified"
+	// MessageLang is the language tag of the locale, as HTML's lang attribute takes it.
+	MessageLang = "lang"
+)
+
+type (
+	// Catalog holds the text of every Message in a report, by its Message constant, in one locale.
+	// Text for a Message can have fmt verbs for what the Message says it's given.
+	Catalog map[string]string
+
+	// Localizer is implemented by RowWriters whose headings and legends can be rendered in
+	// another locale. Localize is called, if at all, before any rows are written.
+	Localizer interface {
+		Localize(c Catalog)
+	}
+)
+
+var (
+	// catalogsMu guards catalogs.
+	catalogsMu sync.RWMutex
+	// catalogs are the registered Catalogs by locale.
+	catalogs = map[string]Catalog{
+		LocaleEnglish: {
+			MessageLang:             "en",
+			MessageCorner:           "from \\ to",
+			MessageCornerPivoted:    "to \\ from",
+			MessageConverting:       "converting %s values",
+			MessageConvertingTo:     "converting to %s",
+			MessageTitle:            "Go primitive conversions",
+			MessageRows:             "Rows are the type being converted from, columns the type being converted to.",
+			MessageRowsPivoted:      "Rows are the type being converted to, columns the type being converted from.",
+			MessageLegend:           "✅ always preserves the value, ⚠️ compiles but may change the value, ❌ does not compile. Click a type or a cell for details.",
+			MessageCompared:         "compared with %s",
+			MessageClassified:       "classified by %s",
+			MessageLegendAccessible: "YES always preserves the value, LOSSY compiles but may change the value, NO does not compile. Click a type or a cell for details.",
+		},
+		LocaleGerman: {
+			MessageLang:             "de",
+			MessageCorner:           "von \\ nach",
+			MessageCornerPivoted:    "nach \\ von",
+			MessageConverting:       "Konvertierung von %s-Werten",
+			MessageConvertingTo:     "Konvertierung nach %s",
+			MessageTitle:            "Konvertierungen zwischen Go-Primitivtypen",
+			MessageRows:             "Zeilen sind der Typ, von dem konvertiert wird, Spalten der Typ, in den konvertiert wird.",
+			MessageRowsPivoted:      "Zeilen sind der Typ, in den konvertiert wird, Spalten der Typ, von dem konvertiert wird.",
+			MessageLegend:           "✅ erhält den Wert immer, ⚠️ kompiliert, kann den Wert aber verändern, ❌ kompiliert nicht. Für Details auf einen Typ oder eine Zelle klicken.",
+			MessageCompared:         "im Vergleich mit %s",
+			MessageClassified:       "klassifiziert nach %s",
+			MessageLegendAccessible: "YES erhält den Wert immer, LOSSY kompiliert, kann den Wert aber verändern, NO kompiliert nicht. Für Details auf einen Typ oder eine Zelle klicken.",
+		},
+		LocaleJapanese: {
+			MessageLang:             "ja",
+			MessageCorner:           "変換元 \\ 変換先",
+			MessageCornerPivoted:    "変換先 \\ 変換元",
+			MessageConverting:       "%s の値の変換",
+			MessageConvertingTo:     "%s への変換",
+			MessageTitle:            "Go のプリミティブ型の変換",
+			MessageRows:             "行は変換元の型、列は変換先の型です。",
+			MessageRowsPivoted:      "行は変換先の型、列は変換元の型です。",
+			MessageLegend:           "✅ 値は常に保たれます、⚠️ コンパイルできますが値が変わる可能性があります、❌ コンパイルできません。詳細は型またはセルをクリックしてください。",
+			MessageCompared:         "%s との比較",
+			MessageClassified:       "%s による分類",
+			MessageLegendAccessible: "YES 値は常に保たれます、LOSSY コンパイルできますが値が変わる可能性があります、NO コンパイルできません。詳細は型またはセルをクリックしてください。",
+		},
+		LocaleChinese: {
+			MessageLang:             "zh",
+			MessageCorner:           "源 \\ 目标",
+			MessageCornerPivoted:    "目标 \\ 源",
+			MessageConverting:       "转换 %s 类型的值",
+			MessageConvertingTo:     "转换为 %s",
+			MessageTitle:            "Go 基本类型转换",
+			MessageRows:             "行为转换的源类型，列为转换的目标类型。",
+			MessageRowsPivoted:      "行为转换的目标类型，列为转换的源类型。",
+			MessageLegend:           "✅ 始终保留原值，⚠️ 可以编译但可能改变值，❌ 无法编译。点击类型或单元格查看详情。",
+			MessageCompared:         "与 %s 的比较",
+			MessageClassified:       "按 %s 分类",
+			MessageLegendAccessible: "YES 始终保留原值，LOSSY 可以编译但可能改变值，NO 无法编译。点击类型或单元格查看详情。",
+		},
+	}
+)
+
+// RegisterCatalog makes c available as locale, so reports can be rendered in locales which
+// aren't built in. Any Message c is missing is rendered in English. It is an error to register
+// a locale twice.
+func RegisterCatalog(locale string, c Catalog) error {
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+
+	if locale == "" {
+		return errors.New("catalog locale must not be empty")
+	}
+	if _, ok := catalogs[locale]; ok {
+		return errors.Errorf("catalog %q is already registered", locale)
+	}
+	catalogs[locale] = c
+	return nil
+}
+
+// LookupCatalog returns the Catalog registered as locale, or the English one when locale is empty.
+func LookupCatalog(locale string) (Catalog, error) {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+
+	if locale == "" {
+		locale = LocaleEnglish
+	}
+	c, ok := catalogs[locale]
+	if !ok {
+		var locales []string
+		for l := range catalogs {
+			locales = append(locales, l)
+		}
+		sort.Strings(locales)
+		return nil, errors.Errorf("unknown locale %q, expected one of %s", locale, strings.Join(locales, ", "))
+	}
+	return c, nil
+}
+
+// Message is the text of the Message key in c, formatted with args, falling back to English
+// when c doesn't have it, e.g. when c is nil.
+func (c Catalog) Message(key string, args ...interface{}) string {
+	text, ok := c[key]
+	if !ok {
+		catalogsMu.RLock()
+		text = catalogs[LocaleEnglish][key]
+		catalogsMu.RUnlock()
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
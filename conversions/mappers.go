@@ -0,0 +1,208 @@
+package conversions
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"go/types"
+	"strings"
+)
+
+const (
+	// MappingAssign is a field assigned as is, its type being the same on both sides.
+	MappingAssign = "assign"
+	// MappingConvert is a field converted to the other side's type, which always preserves its value.
+	MappingConvert = "convert"
+	// MappingChecked is a field converted by the checked helper for its primitives, since the
+	// conversion may change its value, returning an error when it would.
+	MappingChecked = "checked"
+	// MappingNone is a field which isn't mapped, see FieldConversion.Reason.
+	MappingNone = "none"
+)
+
+type (
+	// StructMapping is how the fields of a domain struct and a transport struct, e.g. User and
+	// UserDTO, map to one another, see MapStructs.
+	StructMapping struct {
+		// Package is the name of the package both structs are declared in.
+		Package   string
+		Domain    string
+		Transport string
+		// Fields are the fields of Domain with a field of the same name in Transport, in the
+		// order Domain declares them.
+		Fields []FieldMapping
+		// DomainOnly and TransportOnly are the fields of either struct without a field of the
+		// same name in the other, which are left as their zero value when mapping to it.
+		DomainOnly    []string
+		TransportOnly []string
+	}
+
+	// FieldMapping is a field of a StructMapping's Domain and the Transport field of the same name.
+	FieldMapping struct {
+		Domain    string
+		Transport string
+		// DomainType and TransportType are the types of the fields, as written in their package.
+		DomainType    string
+		TransportType string
+		// DomainPrimitive and TransportPrimitive are the primitives the types are defined as,
+		// empty for types which aren't.
+		DomainPrimitive    string
+		TransportPrimitive string
+		// ToTransport and ToDomain are how the field maps in either direction.
+		ToTransport FieldConversion
+		ToDomain    FieldConversion
+	}
+
+	// FieldConversion is how a field maps in one direction.
+	FieldConversion struct {
+		// Mapping is one of MappingAssign, MappingConvert, MappingChecked, or MappingNone.
+		Mapping string
+		// Helper is the name of the checked helper a MappingChecked field is converted by, e.g.
+		// Int64ToInt32.
+		Helper string `json:",omitempty"`
+		// Reason is why a MappingNone field isn't mapped.
+		Reason string `json:",omitempty"`
+	}
+)
+
+// MapStructs type checks the package in dir and works out how the fields of the structs named
+// domain and transport declared in it map to one another, using m, which is expected to
+// include every primitive their fields are defined as, for whether a field's conversion may
+// change its value. Fields are matched by name, ignoring case when neither struct has a field
+// of exactly the same name, e.g. ID and Id.
+func MapStructs(dir string, domain string, transport string, m Matrix) (StructMapping, error) {
+	lp, err := loadPackage(dir, nil)
+	if err != nil {
+		return StructMapping{}, err
+	}
+	domainStruct, err := lp.lookupStruct(domain)
+	if err != nil {
+		return StructMapping{}, err
+	}
+	transportStruct, err := lp.lookupStruct(transport)
+	if err != nil {
+		return StructMapping{}, err
+	}
+
+	var sm StructMapping
+	sm.Package = lp.pkg.Name()
+	sm.Domain = domain
+	sm.Transport = transport
+
+	qualifier := types.RelativeTo(lp.pkg)
+	matched := make(map[int]bool)
+	for i := 0; i < domainStruct.NumFields(); i++ {
+		df := domainStruct.Field(i)
+		j := matchField(transportStruct, df.Name())
+		if j < 0 {
+			sm.DomainOnly = append(sm.DomainOnly, df.Name())
+			continue
+		}
+		matched[j] = true
+		tf := transportStruct.Field(j)
+
+		var fm FieldMapping
+		fm.Domain = df.Name()
+		fm.Transport = tf.Name()
+		fm.DomainType = types.TypeString(df.Type(), qualifier)
+		fm.TransportType = types.TypeString(tf.Type(), qualifier)
+		fm.DomainPrimitive = primitiveOf(df.Type())
+		fm.TransportPrimitive = primitiveOf(tf.Type())
+		fm.ToTransport = mapField(df.Type(), tf.Type(), m)
+		fm.ToDomain = mapField(tf.Type(), df.Type(), m)
+		sm.Fields = append(sm.Fields, fm)
+	}
+	for j := 0; j < transportStruct.NumFields(); j++ {
+		if !matched[j] {
+			sm.TransportOnly = append(sm.TransportOnly, transportStruct.Field(j).Name())
+		}
+	}
+
+	return sm, nil
+}
+
+// Checked reports whether any field of the StructMapping is converted by a checked helper, in
+// either direction.
+func (sm StructMapping) Checked() bool {
+	for _, fm := range sm.Fields {
+		if fm.ToTransport.Mapping == MappingChecked || fm.ToDomain.Mapping == MappingChecked {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupStruct returns the struct type declared as name in the package.
+func (lp *loadedPackage) lookupStruct(name string) (*types.Struct, error) {
+	tn, ok := lp.pkg.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return nil, errors.Errorf("no type named %q in package %s", name, lp.pkg.Name())
+	}
+	s, ok := tn.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil, errors.Errorf("%s is a %s, not a struct", name, tn.Type().Underlying())
+	}
+	return s, nil
+}
+
+// matchField is the index of the field of s named name, or of the one named the same ignoring
+// case when none is, or -1 when there's neither.
+func matchField(s *types.Struct, name string) int {
+	fold := -1
+	for i := 0; i < s.NumFields(); i++ {
+		switch {
+		case s.Field(i).Name() == name:
+			return i
+		case fold < 0 && strings.EqualFold(s.Field(i).Name(), name):
+			fold = i
+		}
+	}
+	return fold
+}
+
+// primitiveOf is the name of the primitive t is, or is defined as, or "" when it's neither.
+func primitiveOf(t types.Type) string {
+	basic, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return ""
+	}
+	return basic.Name()
+}
+
+// mapField is how a field of type from maps to one of type to.
+func mapField(from, to types.Type, m Matrix) FieldConversion {
+	var fc FieldConversion
+	if types.Identical(from, to) {
+		fc.Mapping = MappingAssign
+		return fc
+	}
+
+	fc.Mapping = MappingNone
+	fromPrimitive, toPrimitive := primitiveOf(from), primitiveOf(to)
+	if fromPrimitive == "" || toPrimitive == "" {
+		fc.Reason = fmt.Sprintf("only fields of the same type, or of primitives, are mapped, not %s to %s", from, to)
+		return fc
+	}
+	result, ok := m.Result(fromPrimitive, toPrimitive)
+	switch {
+	case !ok:
+		fc.Reason = fmt.Sprintf("%s to %s wasn't analyzed", fromPrimitive, toPrimitive)
+	case !result.Convertible:
+		fc.Reason = fmt.Sprintf("%s doesn't convert to %s", fromPrimitive, toPrimitive)
+	case ExactNames(fromPrimitive, toPrimitive):
+		fc.Mapping = MappingConvert
+	case !isNumeric(fromPrimitive) || !isNumeric(toPrimitive):
+		// NOTE: Checked helpers are only generated for numeric pairs, e.g. int to string
+		// compiles, but makes a rune of the value rather than its digits.
+		fc.Reason = fmt.Sprintf("%s to %s may change the value, and has no checked helper", fromPrimitive, toPrimitive)
+	default:
+		fc.Mapping = MappingChecked
+		fc.Helper = exportedName(fromPrimitive) + "To" + exportedName(toPrimitive)
+	}
+	return fc
+}
+
+// isNumeric reports whether the type named name is a numeric primitive.
+func isNumeric(name string) bool {
+	info, ok := Lookup(name)
+	return ok && info.IsNumeric()
+}
@@ -0,0 +1,94 @@
+package conversions
+
+import (
+	"go/types"
+	"sort"
+)
+
+type (
+	// MethodLoss is a conversion between a named type and its underlying type, or another named
+	// type, which strips methods from the value being converted.
+	MethodLoss struct {
+		From string
+		To   string
+		// Lost are the methods callable on an addressable From value which can't be called on the To value.
+		Lost []string
+	}
+)
+
+// MethodSetImpact type checks the package in dir and reports, for every named type declared in
+// it which has methods, which of them are lost by converting it to its underlying type or to
+// any other named type in the package it is convertible to. Conversions silently strip method
+// sets, which is easy to forget when e.g. a String or MarshalJSON method stops being called.
+func MethodSetImpact(dir string) ([]MethodLoss, error) {
+	lp, err := loadPackage(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var named []*types.Named
+	scope := lp.pkg.Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || tn.IsAlias() {
+			continue
+		}
+		t, ok := tn.Type().(*types.Named)
+		if !ok || t.TypeParams().Len() > 0 || types.IsInterface(t) {
+			continue
+		}
+		named = append(named, t)
+	}
+
+	var losses []MethodLoss
+	for _, from := range named {
+		methods := methodNames(from)
+		if len(methods) == 0 {
+			continue
+		}
+
+		targets := []types.Type{from.Underlying()}
+		for _, to := range named {
+			if to != from && types.ConvertibleTo(from, to) {
+				targets = append(targets, to)
+			}
+		}
+
+		for _, to := range targets {
+			kept := make(map[string]bool)
+			for _, m := range methodNames(to) {
+				kept[m] = true
+			}
+
+			var ml MethodLoss
+			ml.From = types.TypeString(from, lp.qualifier)
+			ml.To = types.TypeString(to, lp.qualifier)
+			for _, m := range methods {
+				if !kept[m] {
+					ml.Lost = append(ml.Lost, m)
+				}
+			}
+			if len(ml.Lost) > 0 {
+				losses = append(losses, ml)
+			}
+		}
+	}
+
+	return losses, nil
+}
+
+// methodNames returns the sorted names of the methods callable on an addressable value of t,
+// i.e. the method set of *t.
+func methodNames(t types.Type) []string {
+	if _, ok := t.Underlying().(*types.Pointer); !ok {
+		t = types.NewPointer(t)
+	}
+	ms := types.NewMethodSet(t)
+
+	var names []string
+	for i := 0; i < ms.Len(); i++ {
+		names = append(names, ms.At(i).Obj().Name())
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,70 @@
+package conversions
+
+import (
+	"github.com/pkg/errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// ProbeModulePath is the module path of the standalone module the generated go code is compiled in.
+	ProbeModulePath = "go-conversions.probe"
+	// probeModGoVersion is the go directive of the probe module. The generated code only uses
+	// the standard library, so it needs nothing newer than the oldest toolchain we support.
+	probeModGoVersion = "1.19"
+)
+
+var (
+	// langVersionRegexp matches a Go language version, as Options.LangVersion is given.
+	langVersionRegexp = regexp.MustCompile(`^go1\.\d+$`)
+)
+
+// WriteProbeModule writes a minimal go.mod with no requirements to dir, making it the root of a
+// module of its own. Generated code compiled from within dir then never depends on whatever
+// module, workspace, or vendor directory happens to surround it, so this program works the same
+// when installed globally and run from anywhere.
+func WriteProbeModule(dir string) error {
+	return writeProbeModule(dir, "")
+}
+
+// writeProbeModule writes the probe module to dir as WriteProbeModule does, with the go
+// directive of the language version lang, e.g. go1.19, or the default one when lang is empty.
+func writeProbeModule(dir, lang string) error {
+	goModFile := filepath.Join(dir, "go.mod")
+	err := WriteFileAtomic(goModFile, []byte(probeGoMod(lang)), 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "writing %q", goModFile)
+	}
+
+	return nil
+}
+
+// probeGoMod returns the contents of the probe module's go.mod, with the go directive of the
+// language version lang, or the default one when lang is empty.
+func probeGoMod(lang string) string {
+	version := probeModGoVersion
+	if lang != "" {
+		version = strings.TrimPrefix(lang, "go")
+	}
+	return "module " + ProbeModulePath + "\n\ngo " + version + "\n"
+}
+
+// checkLangVersion checks that lang, when set, is a Go language version, e.g. go1.19.
+func checkLangVersion(lang string) error {
+	if lang != "" && !langVersionRegexp.MatchString(lang) {
+		return errors.Errorf("invalid language version %q, expected e.g. go1.19", lang)
+	}
+	return nil
+}
+
+// probeCommand returns a go command run from within the probe module rooted at dir, isolated
+// from any workspace or flags set in the environment.
+func probeCommand(dir string, args ...string) *exec.Cmd {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	return cmd
+}
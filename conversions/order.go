@@ -0,0 +1,156 @@
+package conversions
+
+import (
+	"github.com/pkg/errors"
+	"sort"
+)
+
+const (
+	// SortFamily orders types canonically, see SortTypes.
+	SortFamily = "family"
+	// SortName orders types by name.
+	SortName = "name"
+	// SortDegree orders types by how many types their row converts to, or from when the Matrix
+	// is Pivoted, most first, and then canonically.
+	SortDegree = "degree"
+)
+
+var (
+	// kindOrder is the order Kinds are grouped in by SortTypes.
+	kindOrder = []Kind{KindBool, KindUint, KindInt, KindFloat, KindComplex, KindString}
+)
+
+// SortTypes returns a copy of types in canonical order: grouped by Kind, in the order of
+// kindOrder, then by size, with platform dependent sizes after fixed ones, then by name, with
+// aliases immediately after the type they alias. Types which aren't primitives come last, by name.
+// Every output orders types this way, so diffs between runs only ever show real changes.
+func SortTypes(types []string) []string {
+	sorted := append([]string(nil), types...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return typeLess(sorted[i], sorted[j])
+	})
+	return sorted
+}
+
+// SortResults sorts results in place in canonical order of their From, then their To, types.
+func SortResults(results []Result) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.From != b.From {
+			return typeLess(a.From, b.From)
+		}
+		return typeLess(a.To, b.To)
+	})
+}
+
+// typeLess reports whether the type a comes before the type b in canonical order.
+func typeLess(a, b string) bool {
+	ai, aok := Lookup(a)
+	bi, bok := Lookup(b)
+	switch {
+	case !aok && !bok:
+		return a < b
+	case aok != bok:
+		return aok
+	}
+
+	if ak, bk := kindRank(ai.Kind), kindRank(bi.Kind); ak != bk {
+		return ak < bk
+	}
+	if as, bs := sizeRank(ai), sizeRank(bi); as != bs {
+		return as < bs
+	}
+	if ac, bc := ai.Canonical(), bi.Canonical(); ac != bc {
+		return ac < bc
+	}
+	if (ai.AliasOf == "") != (bi.AliasOf == "") {
+		return ai.AliasOf == ""
+	}
+	return a < b
+}
+
+// kindRank is the position of k in kindOrder.
+func kindRank(k Kind) int {
+	for i, o := range kindOrder {
+		if o == k {
+			return i
+		}
+	}
+	return len(kindOrder)
+}
+
+// sizeRank orders i by its size, placing platform dependent sizes after every fixed one.
+func sizeRank(i Info) int {
+	if i.Bits == 0 {
+		return 1 << 16
+	}
+	return i.Bits
+}
+
+// Sort returns a copy of m with its Types, and so its rows and columns, in the order named by,
+// one of SortFamily, SortName, or SortDegree, and its Results in that order row by row.
+func (m Matrix) Sort(by string) (Matrix, error) {
+	types := SortTypes(m.Types)
+	switch by {
+	case SortFamily, "":
+	case SortName:
+		sort.Strings(types)
+	case SortDegree:
+		degrees := make(map[string]int)
+		for _, result := range m.Results {
+			if result.Convertible {
+				degrees[m.RowOf(result)]++
+			}
+		}
+		sort.SliceStable(types, func(i, j int) bool {
+			return degrees[types[i]] > degrees[types[j]]
+		})
+	default:
+		return Matrix{}, errors.Errorf("unknown sort %q, expected %s, %s, or %s", by, SortFamily, SortName, SortDegree)
+	}
+
+	return m.ordered(types), nil
+}
+
+// Pivot returns a copy of m whose rows are the types converted to, and columns the types
+// converted from, with its Results reordered to match. Every Result is left as it is.
+func (m Matrix) Pivot() Matrix {
+	m.Pivoted = !m.Pivoted
+	return m.ordered(m.Types)
+}
+
+// ordered returns m with its Types, and its Results row by row, in the order of types.
+func (m Matrix) ordered(types []string) Matrix {
+	index := make(map[string]int)
+	for i, t := range types {
+		index[t] = i
+	}
+	results := append([]Result(nil), m.Results...)
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if ar, br := index[m.RowOf(a)], index[m.RowOf(b)]; ar != br {
+			return ar < br
+		}
+		return index[m.ColumnOf(a)] < index[m.ColumnOf(b)]
+	})
+
+	m.Types = types
+	m.Results = results
+	return m.Indexed()
+}
+
+// RowOf is the type whose row result is reported in, its From, or its To when m is Pivoted.
+func (m Matrix) RowOf(result Result) string {
+	if m.Pivoted {
+		return result.To
+	}
+	return result.From
+}
+
+// ColumnOf is the type whose column result is reported in, its To, or its From when m is Pivoted.
+func (m Matrix) ColumnOf(result Result) string {
+	if m.Pivoted {
+		return result.From
+	}
+	return result.To
+}
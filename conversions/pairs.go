@@ -0,0 +1,88 @@
+package conversions
+
+import (
+	"encoding/csv"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+)
+
+type (
+	// PairCheck is a pair of types to check, read from a list of them by ParsePairs.
+	PairCheck struct {
+		From string
+		To   string
+		// Expect is the Word the pair is expected to have, when it's given one.
+		Expect string `json:",omitempty"`
+		// Line is the line of the list the pair is on.
+		Line int
+	}
+)
+
+// ParsePairs reads a CSV list of pairs of types to check, each record being the type converted
+// from, the type converted to, and optionally the Word it's expected to have, e.g.
+// "int64,int32,lossy". A first record of "from,to" or "from,to,expect" is a header and skipped,
+// and lines starting with # are comments.
+func ParsePairs(r io.Reader) ([]PairCheck, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var pcs []PairCheck
+	for i := 0; ; i++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "reading pairs")
+		}
+		line, _ := cr.FieldPos(0)
+		if i == 0 && strings.EqualFold(record[0], "from") {
+			continue
+		}
+		if len(record) < 2 || len(record) > 3 {
+			return nil, errors.Errorf("line %d: expected from,to or from,to,expect, got %d fields", line, len(record))
+		}
+
+		var pc PairCheck
+		pc.From = strings.TrimSpace(record[0])
+		pc.To = strings.TrimSpace(record[1])
+		pc.Line = line
+		if pc.From == "" || pc.To == "" {
+			return nil, errors.Errorf("line %d: expected a type to convert from and to", line)
+		}
+		if len(record) == 3 && strings.TrimSpace(record[2]) != "" {
+			pc.Expect = strings.ToUpper(strings.TrimSpace(record[2]))
+			switch pc.Expect {
+			case WordYes, WordLossy, WordNo:
+			default:
+				return nil, errors.Errorf("line %d: unknown expectation %q, expected one of %s, %s, or %s", line, record[2], WordYes, WordLossy, WordNo)
+			}
+		}
+		pcs = append(pcs, pc)
+	}
+	return pcs, nil
+}
+
+// PairTypes are every type pcs converts from or to, in the order they first appear.
+func PairTypes(pcs []PairCheck) []string {
+	var types []string
+	for _, pc := range pcs {
+		types = append(types, pc.From, pc.To)
+	}
+	return Dedupe(types)
+}
+
+// Listed returns a predicate which is only true for a Pair in pcs, to limit a report to them,
+// see Matrix.Filter.
+func Listed(pcs []PairCheck) func(Pair) bool {
+	listed := make(map[[2]string]bool, len(pcs))
+	for _, pc := range pcs {
+		listed[[2]string{pc.From, pc.To}] = true
+	}
+	return func(p Pair) bool {
+		return listed[[2]string{p.From, p.To}]
+	}
+}
@@ -0,0 +1,96 @@
+package conversions
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type (
+	// Phrasing is one way a Go release has worded the "cannot convert" error for the conversions
+	// in the generated code, so that failures are recognized whichever toolchain compiled it.
+	Phrasing struct {
+		// Releases are the Go releases which use the phrasing.
+		Releases string
+		// Fixture names the file under testdata/phrasings with real output in this phrasing.
+		Fixture string
+		// Regexp extracts the field converted from, which is named after its type, and the
+		// type converted to out of an error in this phrasing.
+		Regexp *regexp.Regexp
+	}
+
+	// PhrasingError is returned when the compiler reports a conversion failure in a phrasing
+	// none of the Phrasings recognize, most likely because a new Go release changed its wording.
+	PhrasingError struct {
+		// Line is the unrecognized compiler output.
+		Line string
+	}
+)
+
+var (
+	// Phrasings are every known phrasing of the "cannot convert" error, oldest first.
+	Phrasings = []Phrasing{
+		{
+			Releases: "go1.17 and older",
+			Fixture:  "go1.17.txt",
+			Regexp:   regexp.MustCompile(`cannot convert p\.(\S+) \(type \S+\) to type (\S+)$`),
+		},
+		{
+			Releases: "go1.18 through go1.23",
+			Fixture:  "go1.18.txt",
+			Regexp:   regexp.MustCompile(`cannot convert p\.(\S+) \(variable of type \S+\) to type (\S+)$`),
+		},
+		{
+			Releases: "go1.24 and newer",
+			Fixture:  "go1.24.txt",
+			Regexp:   regexp.MustCompile(`cannot convert p\.(\S+) \(variable of (?:\S+ )?type \S+\) to type (\S+)$`),
+		},
+		{
+			Releases: "go1.27 and newer, when -lang predates the conversion",
+			Fixture:  "lang.txt",
+			Regexp:   regexp.MustCompile(`cannot convert p\.(\S+) \(variable of (?:\S+ )?type \S+\) to type (\S+): .* requires go1\.\d+ or later`),
+		},
+	}
+)
+
+// ParsePhrasing extracts the field converted from and the type converted to out of the
+// "cannot convert" compiler error line, in any of the Phrasings. It reports false if no
+// phrasing recognizes line.
+func ParsePhrasing(line string) (string, string, bool) {
+	for _, phrasing := range Phrasings {
+		matches := phrasing.Regexp.FindStringSubmatch(line)
+		if matches != nil {
+			return matches[1], matches[2], true
+		}
+	}
+	return "", "", false
+}
+
+// unrecognizedPhrasing returns a *PhrasingError for the first of the unparsed compiler output
+// lines which is a conversion failure, or nil if there are none.
+func unrecognizedPhrasing(unparsed []string) error {
+	for _, line := range unparsed {
+		if strings.Contains(line, "cannot convert") {
+			var pe PhrasingError
+			pe.Line = line
+			return &pe
+		}
+	}
+	return nil
+}
+
+// Error implements error.
+func (e *PhrasingError) Error() string {
+	var known []string
+	for _, phrasing := range Phrasings {
+		known = append(known, phrasing.Releases)
+	}
+	return fmt.Sprintf("unrecognized %q phrasing %q, the compiler has likely changed its wording, "+
+		"add it to Phrasings with a fixture in testdata/phrasings (known phrasings are from %s)",
+		"cannot convert", e.Line, strings.Join(known, ", "))
+}
+
+// Is matches ErrUnrecognizedPhrasing.
+func (e *PhrasingError) Is(target error) bool {
+	return target == ErrUnrecognizedPhrasing
+}
@@ -0,0 +1,35 @@
+package conversions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestPhrasings checks that every conversion failure in the fixture of each of the Phrasings is
+// recognized by the phrasing it documents, and parsed by ParsePhrasing.
+func TestPhrasings(t *testing.T) {
+	for _, phrasing := range Phrasings {
+		b, err := os.ReadFile(filepath.Join("testdata", "phrasings", phrasing.Fixture))
+		if err != nil {
+			t.Fatalf("reading fixture for %s: %v", phrasing.Releases, err)
+		}
+		failures := 0
+		for _, line := range strings.Split(string(b), "\n") {
+			if !strings.Contains(line, "cannot convert") {
+				continue
+			}
+			failures++
+			if !phrasing.Regexp.MatchString(line) {
+				t.Errorf("the %s phrasing doesn't recognize its own fixture %q", phrasing.Releases, line)
+			}
+			if _, _, ok := ParsePhrasing(line); !ok {
+				t.Errorf("ParsePhrasing doesn't recognize %q", line)
+			}
+		}
+		if failures == 0 {
+			t.Errorf("the fixture for %s has no conversion failures", phrasing.Releases)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package conversions
+
+import (
+	"math"
+)
+
+type (
+	// Precision quantifies how much converting From to To, a float type, can round the value
+	// being converted.
+	Precision struct {
+		From string
+		To   string
+		// MaxRelativeError is the largest relative error the conversion introduces, 2^-p where p
+		// is the precision of To in bits, e.g. about 5.96e-08 for float32, or 0 when every From
+		// converts exactly. It holds for values within To's normal range; smaller values keep
+		// fewer bits still, and larger ones become infinities.
+		MaxRelativeError float64
+		// ExactIntegerBits is the precision of To in bits, and ExactIntegers is 2^ExactIntegerBits,
+		// the largest magnitude below which every integer is represented exactly by To, e.g.
+		// 16777216 for float32.
+		ExactIntegerBits int
+		ExactIntegers    uint64
+	}
+)
+
+// Precisions derives a Precision for every pair of an integer or float type and a different
+// float type in types, from their widths alone. Aliases are left out, they share the precision of
+// the types they alias.
+func Precisions(types []string) []Precision {
+	var infos []Info
+	for _, name := range types {
+		info, ok := Lookup(name)
+		if ok && info.IsNumeric() && info.AliasOf == "" {
+			infos = append(infos, info)
+		}
+	}
+
+	var precisions []Precision
+	for _, from := range infos {
+		for _, to := range infos {
+			if to.Kind != KindFloat || from.Name == to.Name {
+				continue
+			}
+			precisions = append(precisions, PrecisionOf(from, to))
+		}
+	}
+	return precisions
+}
+
+// PrecisionOf derives the Precision of converting the integer or float type from to the float
+// type to.
+func PrecisionOf(from, to Info) Precision {
+	var p Precision
+	p.From = from.Name
+	p.To = to.Name
+	p.ExactIntegerBits = to.Mantissa()
+	p.ExactIntegers = 1 << uint(p.ExactIntegerBits)
+	if !Exact(from, to) {
+		p.MaxRelativeError = math.Ldexp(1, -p.ExactIntegerBits)
+	}
+	return p
+}
@@ -0,0 +1,33 @@
+package conversions
+
+import (
+	"strings"
+)
+
+type (
+	// Provenance describes what produced a Matrix, e.g. the build of the program which analyzed
+	// it and the toolchain it was analyzed with, so its report can be traced back to them. A
+	// FormatJSON report encodes it as it is, so it's best a struct with json tags.
+	Provenance interface {
+		// Lines describes the Provenance as a block of human readable lines.
+		Lines() []string
+	}
+
+	// Stamper is implemented by RowWriters which can stamp their report with the Provenance of
+	// the Matrix, see Matrix.Provenance. Stamp is called, if at all, before any rows are written.
+	Stamper interface {
+		Stamp(p Provenance)
+	}
+)
+
+// stamp renders p as a block of lines, each starting with prefix, or nothing when p is nil.
+func stamp(p Provenance, prefix string) string {
+	if p == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, line := range p.Lines() {
+		b.WriteString(prefix + line + "\n")
+	}
+	return b.String()
+}
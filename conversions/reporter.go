@@ -0,0 +1,773 @@
+package conversions
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// FormatText renders a section per type with a line per pair, like the default log output.
+	FormatText = "text"
+	// FormatJSON renders the Matrix as JSON, along with the signed/unsigned Boundaries and float
+	// Precisions of its types, and the Coverage of its pairs.
+	FormatJSON = "json"
+	// FormatCSV renders a line per pair as CSV.
+	FormatCSV = "csv"
+	// FormatMarkdown renders the Matrix as a markdown table.
+	FormatMarkdown = "markdown"
+
+	// widthColumn heads the Width of each pair where reports list it alongside the Columns.
+	widthColumn = "width"
+
+	// markdownBlockRows is how many rows of a FormatMarkdown table are written before the Columns
+	// of their pairs, if any, are listed and the table is started over, so that no more than that
+	// many rows of Results are held at once.
+	markdownBlockRows = 64
+)
+
+type (
+	// Reporter renders a Matrix in some output format.
+	Reporter interface {
+		Render(ctx context.Context, m Matrix, w io.Writer) error
+	}
+
+	// RowReporter is a Reporter which can also render a Matrix a row at a time, as the rows
+	// come out of AnalyzeRows, so the whole Matrix never has to be held in memory.
+	RowReporter interface {
+		Reporter
+		// Rows starts rendering a Matrix of types to w. Every row is written to the returned
+		// RowWriter, which is closed once the last one has been.
+		Rows(ctx context.Context, types []string, w io.Writer) (RowWriter, error)
+	}
+
+	// RowWriter renders the rows of a single Matrix for a RowReporter.
+	RowWriter interface {
+		// Row renders every Result in row, all of which convert from the type from, or to it
+		// when the Matrix is Pivoted. A row may be missing Results which were filtered out, or
+		// be empty altogether.
+		Row(ctx context.Context, from string, row []Result) error
+		// Close finishes rendering the Matrix.
+		Close() error
+	}
+
+	// Pivoter is implemented by RowWriters which label their rows and columns, to be told before
+	// the first row that the Matrix is Pivoted, so its rows are the types converted to.
+	Pivoter interface {
+		Pivot()
+	}
+
+	// ReporterFunc adapts a function to a Reporter.
+	ReporterFunc func(ctx context.Context, m Matrix, w io.Writer) error
+
+	// textReporter implements FormatText.
+	textReporter struct{}
+	// textRows renders the rows of a FormatText report.
+	textRows struct {
+		w       io.Writer
+		pivoted bool
+		catalog Catalog
+		verbal  bool
+		theme   Theme
+		// compared are the Results written so far, kept when the rows are followed by a
+		// comparison to ForeignLangs, see Comparer.
+		compare  bool
+		compared []Result
+		// provenance is stamped after the rows, see Stamper.
+		provenance Provenance
+	}
+
+	// jsonReporter implements FormatJSON.
+	jsonReporter struct{}
+	// jsonRows renders the rows of a FormatJSON report.
+	jsonRows struct {
+		w     io.Writer
+		types []string
+		// written is how many Results have been written so far.
+		written int
+		// filtered is how many pairs were left out of the rows written so far, see Filterer.
+		filtered int
+		// detailed is whether the TypeData of every type follows the Coverage, see Detailer.
+		detailed bool
+		// provenance follows the Coverage, see Stamper.
+		provenance Provenance
+	}
+
+	// csvReporter implements FormatCSV.
+	csvReporter struct{}
+	// csvRows renders the rows of a FormatCSV report.
+	csvRows struct {
+		w  io.Writer
+		cw *csv.Writer
+		// columns are the Classifiers registered when the report was started.
+		columns []string
+		// started is whether the header has been written, which waits for the first row so that
+		// it can be preceded by the provenance, as a comment, see Stamper.
+		started    bool
+		provenance Provenance
+	}
+
+	// markdownReporter implements FormatMarkdown.
+	markdownReporter struct{}
+	// markdownRows renders the rows of a FormatMarkdown report.
+	markdownRows struct {
+		w       io.Writer
+		types   []string
+		pivoted bool
+		catalog Catalog
+		verbal  bool
+		theme   Theme
+		// started is whether the header has been written, which waits for the first row so
+		// that it is labeled for a Pivoted Matrix.
+		started bool
+		// compared are the Results written so far, kept when the table is followed by a
+		// comparison to ForeignLangs, see Comparer.
+		compare  bool
+		compared []Result
+		// rows is how many rows have been written to the current block of the table, and restart
+		// whether the header is to be written again ahead of the next, see markdownBlockRows.
+		rows    int
+		restart bool
+		// classified are the Results of the current block with a Width or any Columns, listed after it.
+		classified []Result
+		// provenance is stamped after the table, see Stamper.
+		provenance Provenance
+	}
+)
+
+var (
+	// reportersMu guards reporters.
+	reportersMu sync.RWMutex
+	// reporters are the registered Reporters by format name.
+	reporters = map[string]Reporter{
+		FormatText:     textReporter{},
+		FormatJSON:     jsonReporter{},
+		FormatCSV:      csvReporter{},
+		FormatMarkdown: markdownReporter{},
+		FormatTable:    TableReporter{},
+	}
+)
+
+// Render implements Reporter.
+func (f ReporterFunc) Render(ctx context.Context, m Matrix, w io.Writer) error {
+	return f(ctx, m, w)
+}
+
+// RegisterReporter makes r available as the format name, so bespoke output formats can be
+// plugged in without forking. It is an error to register a name twice.
+func RegisterReporter(name string, r Reporter) error {
+	reportersMu.Lock()
+	defer reportersMu.Unlock()
+
+	if name == "" {
+		return errors.New("reporter name must not be empty")
+	}
+	if _, ok := reporters[name]; ok {
+		return errors.Errorf("reporter %q is already registered", name)
+	}
+	reporters[name] = r
+	return nil
+}
+
+// LookupReporter returns the Reporter registered as the format name.
+func LookupReporter(name string) (Reporter, error) {
+	reportersMu.RLock()
+	defer reportersMu.RUnlock()
+
+	r, ok := reporters[name]
+	if !ok {
+		return nil, errors.Errorf("unknown format %q, expected one of %s", name, strings.Join(reporterNames(), ", "))
+	}
+	return r, nil
+}
+
+// Reporters returns the names of every registered Reporter, sorted.
+func Reporters() []string {
+	reportersMu.RLock()
+	defer reportersMu.RUnlock()
+
+	return reporterNames()
+}
+
+// reporterNames returns the sorted names of every registered Reporter. reportersMu must be held.
+func reporterNames() []string {
+	var names []string
+	for name := range reporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RenderRows renders m with r a row at a time, in the order of m.Types. It lets a RowReporter
+// implement Render in terms of its RowWriter.
+func RenderRows(ctx context.Context, r RowReporter, m Matrix, w io.Writer) error {
+	rows := make(map[string][]Result)
+	for _, result := range m.Results {
+		rows[m.RowOf(result)] = append(rows[m.RowOf(result)], result)
+	}
+
+	rw, err := r.Rows(ctx, m.Types, w)
+	if err != nil {
+		return err
+	}
+	if p, ok := rw.(Pivoter); ok && m.Pivoted {
+		p.Pivot()
+	}
+	if l, ok := rw.(Localizer); ok && m.Locale != "" {
+		c, err := LookupCatalog(m.Locale)
+		if err != nil {
+			return err
+		}
+		l.Localize(c)
+	}
+	if v, ok := rw.(Verbalizer); ok && m.Accessible {
+		v.Verbalize()
+	}
+	if s, ok := rw.(Styler); ok && m.Theme != (Theme{}) {
+		s.Style(m.Theme)
+	}
+	if c, ok := rw.(Comparer); ok && m.Compare {
+		c.Compare()
+	}
+	if d, ok := rw.(Detailer); ok && m.Verbose {
+		d.Detail()
+	}
+	if s, ok := rw.(Stamper); ok && m.Provenance != nil {
+		s.Stamp(m.Provenance)
+	}
+	for _, from := range m.Types {
+		err := rw.Row(ctx, from, rows[from])
+		if err != nil {
+			return err
+		}
+	}
+	return rw.Close()
+}
+
+// Render implements Reporter.
+func (r textReporter) Render(ctx context.Context, m Matrix, w io.Writer) error {
+	return RenderRows(ctx, r, m, w)
+}
+
+// Rows implements RowReporter.
+func (textReporter) Rows(_ context.Context, _ []string, w io.Writer) (RowWriter, error) {
+	return &textRows{w: w}, nil
+}
+
+// Pivot implements Pivoter.
+func (tr *textRows) Pivot() {
+	tr.pivoted = true
+}
+
+// Localize implements Localizer.
+func (tr *textRows) Localize(c Catalog) {
+	tr.catalog = c
+}
+
+// Verbalize implements Verbalizer.
+func (tr *textRows) Verbalize() {
+	tr.verbal = true
+}
+
+// Style implements Styler.
+func (tr *textRows) Style(t Theme) {
+	tr.theme = t
+}
+
+// Compare implements Comparer.
+func (tr *textRows) Compare() {
+	tr.compare = true
+}
+
+// Stamp implements Stamper.
+func (tr *textRows) Stamp(p Provenance) {
+	tr.provenance = p
+}
+
+// Row implements RowWriter.
+func (tr *textRows) Row(_ context.Context, from string, row []Result) error {
+	heading := tr.catalog.Message(MessageConverting, from)
+	if tr.pivoted {
+		heading = tr.catalog.Message(MessageConvertingTo, from)
+	}
+	_, err := fmt.Fprintf(tr.w, "---------- %s ----------\n", heading)
+	if err != nil {
+		return err
+	}
+	if tr.compare {
+		tr.compared = append(tr.compared, row...)
+	}
+	for _, result := range row {
+		compatible := cell(result, tr.verbal, tr.theme)
+		var tags string
+		if len(result.Tags) > 0 {
+			tags = " [" + strings.Join(result.Tags, ", ") + "]"
+		}
+		var width string
+		if result.Convertible && result.Width != "" {
+			width = " (" + result.Width + ")"
+		}
+		var columns string
+		if described := DescribeColumns(result); described != "" {
+			columns = " {" + described + "}"
+		}
+		_, err := fmt.Fprintf(tr.w, "%10s -> %-10s %s%s%s%s\n", result.From, result.To, compatible, width, tags, columns)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close implements RowWriter, following the rows with the comparison to ForeignLangs, if any,
+// and the provenance.
+func (tr *textRows) Close() error {
+	err := tr.compareForeign()
+	if err != nil {
+		return err
+	}
+	if tr.provenance == nil {
+		return nil
+	}
+	_, err = io.WriteString(tr.w, "\n"+stamp(tr.provenance, ""))
+	return err
+}
+
+// compareForeign writes the comparison of the rows to ForeignLangs, if asked to.
+func (tr *textRows) compareForeign() error {
+	if !tr.compare {
+		return nil
+	}
+	fcs, err := CompareForeign(tr.compared)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(tr.w, "---------- %s ----------\n", tr.catalog.Message(MessageCompared, strings.Join(ForeignLangs, ", ")))
+	if err != nil {
+		return err
+	}
+	for _, fc := range fcs {
+		_, err := fmt.Fprintf(tr.w, "%10s -> %s\n", fc.Result.From, fc.Result.To)
+		if err != nil {
+			return err
+		}
+		for _, f := range append([]Foreign{fc.Go}, fc.Foreign...) {
+			_, err := fmt.Fprintf(tr.w, "%14s: %s\n", f.Lang, f.Describe())
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Render implements Reporter.
+func (r jsonReporter) Render(ctx context.Context, m Matrix, w io.Writer) error {
+	return RenderRows(ctx, r, m, w)
+}
+
+// Rows implements RowReporter. The Matrix is written the same as encoding/json would, but a
+// Result at a time, followed by the Boundaries and Precisions of its types and the Coverage of
+// its pairs, and the TypeData of its types when it's Verbose.
+func (jsonReporter) Rows(_ context.Context, types []string, w io.Writer) (RowWriter, error) {
+	b, err := json.Marshal(types)
+	if err != nil {
+		return nil, err
+	}
+	_, err = fmt.Fprintf(w, "{\n  \"Types\": %s,\n  \"Results\": [", b)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonRows{w: w, types: types}, nil
+}
+
+// Row implements RowWriter.
+func (jr *jsonRows) Row(_ context.Context, _ string, row []Result) error {
+	for _, result := range row {
+		b, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		separator := ",\n    "
+		if jr.written == 0 {
+			separator = "\n    "
+		}
+		_, err = io.WriteString(jr.w, separator+string(b))
+		if err != nil {
+			return err
+		}
+		jr.written++
+	}
+	return nil
+}
+
+// Filtered implements Filterer.
+func (jr *jsonRows) Filtered(n int) {
+	jr.filtered += n
+}
+
+// Detail implements Detailer.
+func (jr *jsonRows) Detail() {
+	jr.detailed = true
+}
+
+// Stamp implements Stamper.
+func (jr *jsonRows) Stamp(p Provenance) {
+	jr.provenance = p
+}
+
+// Close implements RowWriter.
+func (jr *jsonRows) Close() error {
+	b, err := json.Marshal(Boundaries(jr.types))
+	if err != nil {
+		return err
+	}
+	p, err := json.Marshal(Precisions(jr.types))
+	if err != nil {
+		return err
+	}
+	c, err := json.Marshal(CoverageOf(len(jr.types)*len(jr.types), jr.written, jr.filtered))
+	if err != nil {
+		return err
+	}
+	end := "\n  ],\n  \"Boundaries\": %s,\n  \"Precisions\": %s,\n  \"Coverage\": %s"
+	if jr.written == 0 {
+		end = "],\n  \"Boundaries\": %s,\n  \"Precisions\": %s,\n  \"Coverage\": %s"
+	}
+	_, err = fmt.Fprintf(jr.w, end, b, p, c)
+	if err != nil {
+		return err
+	}
+	if jr.provenance != nil {
+		pr, err := json.Marshal(jr.provenance)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(jr.w, ",\n  \"Provenance\": %s", pr)
+		if err != nil {
+			return err
+		}
+	}
+	if jr.detailed {
+		td, err := json.Marshal(DescribeTypes(jr.types))
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(jr.w, ",\n  \"TypeData\": %s", td)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(jr.w, "\n}\n")
+	return err
+}
+
+// Render implements Reporter.
+func (r csvReporter) Render(ctx context.Context, m Matrix, w io.Writer) error {
+	return RenderRows(ctx, r, m, w)
+}
+
+// Rows implements RowReporter. The provenance, when stamped, leads the report as lines of #
+// comments, which a csv.Reader skips with its Comment set to '#'.
+func (csvReporter) Rows(_ context.Context, _ []string, w io.Writer) (RowWriter, error) {
+	return &csvRows{w: w, cw: csv.NewWriter(w), columns: Classifiers()}, nil
+}
+
+// Stamp implements Stamper.
+func (cr *csvRows) Stamp(p Provenance) {
+	cr.provenance = p
+}
+
+// start writes the provenance and the header, unless they already have been.
+func (cr *csvRows) start() error {
+	if cr.started {
+		return nil
+	}
+	cr.started = true
+
+	// NOTE: Written around cr.cw, which would quote it, before it has buffered anything.
+	_, err := io.WriteString(cr.w, stamp(cr.provenance, "# "))
+	if err != nil {
+		return err
+	}
+	return cr.cw.Write(append([]string{"from", "to", "convertible", "tags", widthColumn}, cr.columns...))
+}
+
+// Row implements RowWriter.
+func (cr *csvRows) Row(_ context.Context, _ string, row []Result) error {
+	err := cr.start()
+	if err != nil {
+		return err
+	}
+	for _, result := range row {
+		record := []string{result.From, result.To, strconv.FormatBool(result.Convertible), strings.Join(result.Tags, ";"), result.Width}
+		for _, column := range cr.columns {
+			record = append(record, result.Columns[column])
+		}
+		err := cr.cw.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close implements RowWriter.
+func (cr *csvRows) Close() error {
+	err := cr.start()
+	if err != nil {
+		return err
+	}
+	cr.cw.Flush()
+	return cr.cw.Error()
+}
+
+// Render implements Reporter.
+func (r markdownReporter) Render(ctx context.Context, m Matrix, w io.Writer) error {
+	return RenderRows(ctx, r, m, w)
+}
+
+// Rows implements RowReporter. The report is a table with a row per from type and a column per
+// to type, or the other way around for a Pivoted Matrix. When any pair has Columns, the table is
+// split into blocks of markdownBlockRows rows, each followed by a table of its pairs' Columns.
+func (markdownReporter) Rows(_ context.Context, types []string, w io.Writer) (RowWriter, error) {
+	return &markdownRows{w: w, types: types}, nil
+}
+
+// Pivot implements Pivoter.
+func (mr *markdownRows) Pivot() {
+	mr.pivoted = true
+}
+
+// Localize implements Localizer.
+func (mr *markdownRows) Localize(c Catalog) {
+	mr.catalog = c
+}
+
+// Verbalize implements Verbalizer.
+func (mr *markdownRows) Verbalize() {
+	mr.verbal = true
+}
+
+// Style implements Styler.
+func (mr *markdownRows) Style(t Theme) {
+	mr.theme = t
+}
+
+// Compare implements Comparer.
+func (mr *markdownRows) Compare() {
+	mr.compare = true
+}
+
+// Stamp implements Stamper.
+func (mr *markdownRows) Stamp(p Provenance) {
+	mr.provenance = p
+}
+
+// start writes the header of the table, unless it already has been for the current block.
+func (mr *markdownRows) start() error {
+	if mr.started && !mr.restart {
+		return nil
+	}
+
+	var b strings.Builder
+	if mr.restart {
+		b.WriteString("\n")
+	}
+	mr.started = true
+	mr.restart = false
+	b.WriteString("| " + corner(mr.catalog, mr.pivoted) + " |")
+	for _, to := range mr.types {
+		b.WriteString(" " + to + " |")
+	}
+	b.WriteString("\n|---|")
+	for range mr.types {
+		b.WriteString("---|")
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(mr.w, b.String())
+	return err
+}
+
+// Row implements RowWriter.
+func (mr *markdownRows) Row(_ context.Context, from string, row []Result) error {
+	err := mr.start()
+	if err != nil {
+		return err
+	}
+
+	if mr.compare {
+		mr.compared = append(mr.compared, row...)
+	}
+	cells := make(map[string]string, len(row))
+	for _, result := range row {
+		if annotated(result) {
+			mr.classified = append(mr.classified, result)
+		}
+		column := result.To
+		if mr.pivoted {
+			column = result.From
+		}
+		cells[column] = cell(result, mr.verbal, mr.theme)
+	}
+
+	var b strings.Builder
+	b.WriteString("| **" + from + "** |")
+	for _, to := range mr.types {
+		cell, ok := cells[to]
+		if !ok {
+			cell = " "
+		}
+		b.WriteString(" " + cell + " |")
+	}
+	b.WriteString("\n")
+
+	_, err = io.WriteString(mr.w, b.String())
+	if err != nil {
+		return err
+	}
+	mr.rows++
+	if mr.rows < markdownBlockRows || len(mr.classified) == 0 {
+		return nil
+	}
+	// NOTE: The table is started over after listing the Columns of its block, so a table with
+	// classifiers streams like one without, a block at a time rather than a row at a time.
+	err = mr.flushClassified()
+	mr.restart = true
+	return err
+}
+
+// flushClassified writes a table of the Widths and Columns of the pairs of the current block of
+// rows, if any, and starts the next block.
+func (mr *markdownRows) flushClassified() error {
+	mr.rows = 0
+	if len(mr.classified) == 0 {
+		return nil
+	}
+	columns := append([]string{widthColumn}, Classifiers()...)
+	var b strings.Builder
+	b.WriteString("\n### " + mr.catalog.Message(MessageClassified, strings.Join(columns, ", ")) + "\n\n|")
+	for _, column := range columns {
+		b.WriteString(" | " + column)
+	}
+	b.WriteString(" |\n|---|" + strings.Repeat("---|", len(columns)) + "\n")
+	for _, result := range mr.classified {
+		b.WriteString("| **" + result.From + "** → **" + result.To + "** |")
+		b.WriteString(" " + annotatedWidth(result) + " |")
+		for _, column := range columns[1:] {
+			b.WriteString(" " + result.Columns[column] + " |")
+		}
+		b.WriteString("\n")
+	}
+	mr.classified = nil
+	_, err := io.WriteString(mr.w, b.String())
+	return err
+}
+
+// Close implements RowWriter, following the last block of the table with a table of its pairs'
+// Columns, if any, the whole table with one comparing its pairs to ForeignLangs, if asked to,
+// and everything with the provenance, in a code block to keep its lines lined up.
+func (mr *markdownRows) Close() error {
+	if !mr.started {
+		err := mr.start()
+		if err != nil {
+			return err
+		}
+	}
+	err := mr.flushClassified()
+	if err != nil {
+		return err
+	}
+	err = mr.compareForeign()
+	if err != nil {
+		return err
+	}
+	if mr.provenance == nil {
+		return nil
+	}
+	_, err = io.WriteString(mr.w, "\n```\n"+stamp(mr.provenance, "")+"```\n")
+	return err
+}
+
+// compareForeign writes a table comparing the pairs of the table to ForeignLangs, if asked to.
+func (mr *markdownRows) compareForeign() error {
+	if !mr.compare {
+		return nil
+	}
+	fcs, err := CompareForeign(mr.compared)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("\n### " + mr.catalog.Message(MessageCompared, strings.Join(ForeignLangs, ", ")) + "\n\n")
+	b.WriteString("| | " + LangGo + " |")
+	for _, lang := range ForeignLangs {
+		b.WriteString(" " + lang + " |")
+	}
+	b.WriteString("\n|---|---|")
+	for range ForeignLangs {
+		b.WriteString("---|")
+	}
+	b.WriteString("\n")
+	for _, fc := range fcs {
+		b.WriteString("| **" + fc.Result.From + "** → **" + fc.Result.To + "** | " + fc.Go.Describe() + " |")
+		for _, f := range fc.Foreign {
+			b.WriteString(" " + f.Describe() + " |")
+		}
+		b.WriteString("\n")
+	}
+	_, err = io.WriteString(mr.w, b.String())
+	return err
+}
+
+// annotated reports whether result has a Width or any Columns to list after a grid of pairs.
+func annotated(result Result) bool {
+	return annotatedWidth(result) != "" || len(result.Columns) > 0
+}
+
+// annotatedWidth is the Width of result, empty when it doesn't compile.
+func annotatedWidth(result Result) string {
+	if !result.Convertible {
+		return ""
+	}
+	return result.Width
+}
+
+// describeAnnotations describes the Width and Columns of result, e.g. "width: narrowing, vet: ...".
+func describeAnnotations(result Result) string {
+	described := DescribeColumns(result)
+	if width := annotatedWidth(result); width != "" {
+		if described != "" {
+			return widthColumn + ": " + width + ", " + described
+		}
+		return widthColumn + ": " + width
+	}
+	return described
+}
+
+// cell marks result in a grid or list, as Word does when verbal, or otherwise by whether it
+// compiles, with the symbols of theme.
+func cell(result Result, verbal bool, theme Theme) string {
+	if verbal {
+		return Word(result)
+	}
+	return theme.WithDefaults().Compiles(result)
+}
+
+// corner labels the rows and columns of a grid in c, whose rows are the types converted to when pivoted.
+func corner(c Catalog, pivoted bool) string {
+	if pivoted {
+		return c.Message(MessageCornerPivoted)
+	}
+	return c.Message(MessageCorner)
+}
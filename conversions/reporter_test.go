@@ -0,0 +1,150 @@
+package conversions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type (
+	// testProvenance is a Provenance for stamping reports with in tests.
+	testProvenance struct {
+		Version string `json:"version"`
+	}
+)
+
+const (
+	// benchmarkTypes is how many types the synthetic Matrix the RowReporters are benchmarked over
+	// has, for benchmarkTypes * benchmarkTypes pairs.
+	benchmarkTypes = 300
+)
+
+// BenchmarkRowReporters renders a synthetic Matrix with every built-in RowReporter a row at a
+// time, as AnalyzeRows hands them over. Alongside the allocations, retained-B is how much of the
+// heap the RowWriter is holding on to once every row has been written: next to nothing for the
+// streaming formats, however big the Matrix, but the whole Matrix for FormatTable, which needs
+// every row before it can write its first block of columns.
+func BenchmarkRowReporters(b *testing.B) {
+	types := make([]string, benchmarkTypes)
+	for i := range types {
+		types[i] = fmt.Sprintf("t%d", i)
+	}
+	row := make([]Result, len(types))
+
+	for _, format := range []string{FormatText, FormatJSON, FormatCSV, FormatMarkdown, FormatTable} {
+		b.Run(format, func(b *testing.B) {
+			r, err := LookupReporter(format)
+			if err != nil {
+				b.Fatal(err)
+			}
+			rr, ok := r.(RowReporter)
+			if !ok {
+				b.Fatalf("%s isn't a RowReporter", format)
+			}
+
+			ctx := context.Background()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				rw, err := rr.Rows(ctx, types, io.Discard)
+				if err != nil {
+					b.Fatal(err)
+				}
+				var before runtime.MemStats
+				if i == 0 {
+					runtime.GC()
+					runtime.ReadMemStats(&before)
+				}
+				for j, from := range types {
+					for k, to := range types {
+						row[k].From = from
+						row[k].To = to
+						row[k].Convertible = (j+k)%3 != 0
+					}
+					err = rw.Row(ctx, from, row)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+				if i == 0 {
+					var after runtime.MemStats
+					runtime.GC()
+					runtime.ReadMemStats(&after)
+					retained := int64(after.HeapAlloc) - int64(before.HeapAlloc)
+					if retained < 0 {
+						retained = 0
+					}
+					b.ReportMetric(float64(retained), "retained-B")
+				}
+				err = rw.Close()
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// TestStamp checks that every built-in format stamps its report with the Provenance of the
+// Matrix, and that a FormatJSON report still decodes as a Matrix once it's been stamped.
+func TestStamp(t *testing.T) {
+	var m Matrix
+	m.Types = []string{"int8", "uint8"}
+	for _, from := range m.Types {
+		for _, to := range m.Types {
+			var result Result
+			result.From = from
+			result.To = to
+			result.Convertible = true
+			m.Results = append(m.Results, result)
+		}
+	}
+	var p testProvenance
+	p.Version = "v1.2.3"
+	m.Provenance = p
+
+	var jsonReport []byte
+	for _, format := range []string{FormatText, FormatJSON, FormatCSV, FormatMarkdown, FormatTable} {
+		r, err := LookupReporter(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		err = r.Render(context.Background(), m, &buf)
+		if err != nil {
+			t.Fatalf("rendering %s: %v", format, err)
+		}
+		want := "go-conversions v1.2.3"
+		switch format {
+		case FormatJSON:
+			want = `"Provenance": {"version":"v1.2.3"}`
+		case FormatCSV:
+			want = "# go-conversions v1.2.3\nfrom,to,"
+		}
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("%s report isn't stamped with %q:\n%s", format, want, buf.String())
+		}
+		if format == FormatJSON {
+			jsonReport = buf.Bytes()
+		}
+	}
+
+	var decoded Matrix
+	err := json.Unmarshal(jsonReport, &decoded)
+	if err != nil {
+		t.Fatalf("decoding a stamped %s report: %v", FormatJSON, err)
+	}
+	if len(decoded.Results) != len(m.Results) {
+		t.Errorf("decoded %d Results from a stamped %s report, expected %d", len(decoded.Results), FormatJSON, len(m.Results))
+	}
+}
+
+// Lines implements Provenance.
+func (p testProvenance) Lines() []string {
+	return []string{"go-conversions " + p.Version}
+}
@@ -0,0 +1,107 @@
+package conversions
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultRetryBackoff is how long Options.RetryBackoff waits before the first retry when not set.
+	DefaultRetryBackoff = time.Second
+)
+
+var (
+	// transientOutputs are what the go command and the compiler print when they fail for a
+	// reason which has nothing to do with the code they're given.
+	transientOutputs = []string{
+		"signal: killed",
+		"out of memory",
+		"cannot allocate memory",
+		"no space left on device",
+		"resource temporarily unavailable",
+		"too many open files",
+	}
+	// transientErrnos are the system errors which may well not happen again.
+	transientErrnos = []syscall.Errno{
+		syscall.EAGAIN,
+		syscall.EINTR,
+		syscall.EIO,
+		syscall.EMFILE,
+		syscall.ENFILE,
+		syscall.ENOMEM,
+		syscall.ENOSPC,
+	}
+)
+
+// IsTransient reports whether err failed for a reason which has nothing to do with the code
+// being checked, so checking it again may well succeed: a *TransientError, a system error such
+// as running out of file descriptors or disk space, or a network error.
+func IsTransient(err error) bool {
+	if errors.Is(err, ErrTransient) {
+		return true
+	}
+	for _, errno := range transientErrnos {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// transientOutput returns a *TransientError when checking file exited with exitCode and printed
+// output, as a compiler which was killed, or ran out of a resource, does, or nil when it didn't.
+func transientOutput(file string, exitCode int, output string) error {
+	var te TransientError
+	te.File = file
+	te.ExitCode = exitCode
+	te.Output = output
+	switch {
+	case exitCode == -1:
+		te.Reason = "the compiler was killed"
+		return &te
+	case exitCode == 137:
+		te.Reason = "the compiler was killed, as it is for running out of memory"
+		return &te
+	}
+	lower := strings.ToLower(output)
+	for _, transient := range transientOutputs {
+		if strings.Contains(lower, transient) {
+			te.Reason = "the compiler failed with " + transient
+			return &te
+		}
+	}
+	return nil
+}
+
+// retryShard analyzes shard as analyzeShard does, checking it again up to opts.Retries times
+// for as long as it fails for a reason IsTransient, waiting opts.RetryBackoff before the first
+// retry and twice as long as the last before each one after it. Every retry is emitted
+// as an EventShardRetried.
+func retryShard(ctx context.Context, opts Options, backend Backend, limit int, shard Shard, emit func(Event)) ([]Result, error) {
+	backoff := opts.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		results, err := analyzeShard(ctx, opts, backend, limit, shard)
+		if err == nil || attempt > opts.Retries || ctx.Err() != nil || !IsTransient(err) {
+			return results, err
+		}
+
+		var e Event
+		e.Type = EventShardRetried
+		e.Shard = &shard
+		e.Attempt = attempt
+		e.Err = errString(err)
+		emit(e)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,465 @@
+package conversions
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/pkg/errors"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/ast/astutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type (
+	// RewriteOptions configures Rewrite.
+	RewriteOptions struct {
+		// HelpersImport is the import path of the package generated by the helpers command, e.g.
+		// "example.com/org/repo/conv".
+		HelpersImport string
+		// HelpersPackage is the name of that package. Defaults to the last element of HelpersImport.
+		HelpersPackage string
+		// Audit configures how conversions are found and proven safe, as for Audit.
+		Audit AuditOptions
+		// Skip, when set, leaves alone the findings it returns true for, e.g. those a config turns
+		// off. Findings proven safe are always left alone.
+		Skip func(AuditFinding) bool
+	}
+
+	// FileRewrite is a file Rewrite found conversions which can lose information in.
+	FileRewrite struct {
+		// Path is the path of the file, joined to the package directory.
+		Path string
+		// Before and After are the contents of the file before and after it's rewritten, the
+		// same when none of its conversions could be.
+		Before []byte
+		After  []byte
+		// Rewritten are the conversions rewritten to call a helper.
+		Rewritten []AuditFinding
+		// Skipped are the conversions which couldn't be, and why not.
+		Skipped []SkippedFinding
+	}
+
+	// SkippedFinding is a conversion Rewrite couldn't rewrite to call a helper.
+	SkippedFinding struct {
+		AuditFinding
+		Reason string
+	}
+
+	// edit replaces the bytes of a file from start to end with text.
+	edit struct {
+		start int
+		end   int
+		text  string
+	}
+)
+
+// Rewrite finds every conversion in the package in dir which can lose information, as Audit
+// does, and rewrites those it can to call the checked helper generated for their types,
+// returning any error the helper does. A conversion is rewritten when it's the whole right hand
+// side of an assignment, e.g. `n := int32(v)`, in a function whose last result is an error:
+//
+//	n, err := conv.Int64ToInt32(v)
+//	if err != nil {
+//		return 0, err
+//	}
+//
+// Files marked DO NOT EDIT before their package clause are generated, and left alone. Rewrite
+// only edits the source text around the conversions, and the import of the helpers, so the
+// rest of every file keeps its formatting. Nothing is written, each file's new contents are
+// returned for the caller to write or diff.
+func Rewrite(dir string, opts RewriteOptions) ([]FileRewrite, error) {
+	if opts.HelpersImport == "" {
+		return nil, errors.New("the import path of the helpers must be given")
+	}
+	if opts.HelpersPackage == "" {
+		opts.HelpersPackage = path.Base(opts.HelpersImport)
+	}
+	if opts.Audit.Domains == nil {
+		opts.Audit.Domains = []ValueDomain{IntervalDomain{}}
+	}
+
+	lp, err := loadPackage(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var rewrites []FileRewrite
+	edits := make(map[*ast.File][]edit)
+	// NOTE: The package was type checked before any edits, so the err each rewrite declares is
+	// tracked by its scope for the rewrites after it.
+	declared := make(map[*types.Scope]types.Object)
+	byFile := make(map[*ast.File]*FileRewrite)
+	sources := make(map[*ast.File][]byte)
+	for _, f := range lp.files {
+		var fr FileRewrite
+		fr.Path = lp.fset.Position(f.Pos()).Filename
+		fr.Before, err = os.ReadFile(fr.Path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %q", fr.Path)
+		}
+		sources[f] = fr.Before
+		rewrites = append(rewrites, fr)
+	}
+	for i, f := range lp.files {
+		byFile[f] = &rewrites[i]
+	}
+
+	lp.audit(opts.Audit, func(af AuditFinding, path []ast.Node) {
+		if af.Proven || (opts.Skip != nil && opts.Skip(af)) {
+			return
+		}
+		f := path[0].(*ast.File)
+		// NOTE: Generated code, such as the helpers themselves, is left to its generator.
+		if bytes.Contains(sources[f][:lp.offset(f.Package)], []byte("DO NOT EDIT")) {
+			return
+		}
+		fr := byFile[f]
+		es, reason := lp.rewriteEdits(sources[f], f, path, af, opts, declared)
+		if reason != "" {
+			var sf SkippedFinding
+			sf.AuditFinding = af
+			sf.Reason = reason
+			fr.Skipped = append(fr.Skipped, sf)
+			return
+		}
+		edits[f] = append(edits[f], es...)
+		fr.Rewritten = append(fr.Rewritten, af)
+	})
+
+	var found []FileRewrite
+	for _, f := range lp.files {
+		fr := byFile[f]
+		fr.After = fr.Before
+		if len(fr.Rewritten) > 0 {
+			es := edits[f]
+			e, ok, err := importEdit(fr.Before, opts.HelpersImport)
+			if err != nil {
+				return nil, errors.Wrapf(err, "importing %s into %q", opts.HelpersImport, fr.Path)
+			}
+			if ok {
+				es = append(es, e)
+			}
+			fr.After, err = applyEdits(fr.Before, es)
+			if err != nil {
+				return nil, errors.Wrapf(err, "rewriting %q", fr.Path)
+			}
+		}
+		if len(fr.Rewritten) > 0 || len(fr.Skipped) > 0 {
+			found = append(found, *fr)
+		}
+	}
+	return found, nil
+}
+
+// rewriteEdits are the edits rewriting the conversion at the end of path, in f, whose source is
+// src, to call a helper, or why it can't be. declared are the errs earlier rewrites declared, by
+// scope, which the err of this one is added to if it declares one.
+func (lp *loadedPackage) rewriteEdits(src []byte, f *ast.File, path []ast.Node, af AuditFinding, opts RewriteOptions, declared map[*types.Scope]types.Object) ([]edit, string) {
+	call := path[len(path)-1].(*ast.CallExpr)
+	stmt, ok := path[len(path)-2].(*ast.AssignStmt)
+	if !ok || len(stmt.Lhs) != 1 || len(stmt.Rhs) != 1 || (stmt.Tok != token.DEFINE && stmt.Tok != token.ASSIGN) {
+		return nil, "it isn't assigned on its own, so there's nowhere to check the error the helper returns"
+	}
+	switch path[len(path)-3].(type) {
+	case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+	default:
+		return nil, "it's assigned in the header of a statement, where the error the helper returns can't be checked"
+	}
+	if _, ok := lp.info.Types[call.Fun].Type.(*types.Basic); !ok {
+		return nil, fmt.Sprintf("it converts to %s, rather than the %s the helper returns", af.To, af.ToPrimitive)
+	}
+
+	var fn *ast.FuncType
+	for i := len(path) - 1; i >= 0 && fn == nil; i-- {
+		switch n := path[i].(type) {
+		case *ast.FuncLit:
+			fn = n.Type
+		case *ast.FuncDecl:
+			fn = n.Type
+		}
+	}
+	var results []ast.Expr
+	if fn != nil && fn.Results != nil {
+		for _, field := range fn.Results.List {
+			results = append(results, field.Type)
+			for i := 1; i < len(field.Names); i++ {
+				results = append(results, field.Type)
+			}
+		}
+	}
+	errorType := types.Universe.Lookup("error").Type()
+	if len(results) == 0 || !types.Identical(lp.info.Types[results[len(results)-1]].Type, errorType) {
+		return nil, "the function it's in doesn't return an error, to return the helper's with"
+	}
+	var returns []string
+	for _, result := range results[:len(results)-1] {
+		zero, ok := lp.zeroValue(src, result)
+		if !ok {
+			return nil, fmt.Sprintf("the zero value of %s, to return along with the error, can't be written", types.ExprString(result))
+		}
+		returns = append(returns, zero)
+	}
+	returns = append(returns, "err")
+
+	scope := lp.pkg.Scope().Innermost(stmt.Pos())
+	if scope == nil {
+		return nil, "the scope it's in can't be found"
+	}
+	s, obj := scope.LookupParent("err", stmt.Pos())
+	for ds := scope; ds != nil && ds != s; ds = ds.Parent() {
+		if d, ok := declared[ds]; ok && d.Pos() < stmt.Pos() {
+			s, obj = ds, d
+			break
+		}
+	}
+	switch {
+	case obj != nil && !types.Identical(obj.Type(), errorType):
+		return nil, "err is already declared, as something other than an error"
+	case stmt.Tok == token.DEFINE && obj != nil && s != scope:
+		return nil, "err is declared outside the block it's in, which would be shadowed"
+	case stmt.Tok == token.ASSIGN && obj == nil:
+		return nil, "it's assigned with =, but there's no err declared to assign the helper's error to"
+	}
+	if _, obj := scope.LookupParent(opts.HelpersPackage, stmt.Pos()); obj != nil {
+		if pn, ok := obj.(*types.PkgName); !ok || pn.Imported().Path() != opts.HelpersImport {
+			return nil, fmt.Sprintf("%s already names something else where it is", opts.HelpersPackage)
+		}
+	}
+	for _, spec := range f.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		if importPath == opts.HelpersImport && spec.Name != nil && spec.Name.Name != opts.HelpersPackage {
+			return nil, fmt.Sprintf("%s is imported as %s", opts.HelpersImport, spec.Name.Name)
+		}
+	}
+
+	arg := string(src[lp.offset(call.Args[0].Pos()):lp.offset(call.Args[0].End())])
+	if _, ok := lp.info.Types[call.Args[0]].Type.(*types.Basic); !ok {
+		// NOTE: The helper takes the primitive underlying a defined type, which it always converts to exactly.
+		arg = af.FromPrimitive + "(" + arg + ")"
+	}
+	if stmt.Tok == token.DEFINE && obj == nil {
+		declared[scope] = types.NewVar(stmt.Pos(), lp.pkg, "err", errorType)
+	}
+
+	start := lp.offset(stmt.Pos())
+	lineStart := bytes.LastIndexByte(src[:start], '\n') + 1
+	indent := src[lineStart:start]
+	indent = indent[:len(indent)-len(bytes.TrimLeft(indent, " \t"))]
+
+	var e edit
+	e.start = start
+	e.end = lp.offset(stmt.End())
+	e.text = fmt.Sprintf("%s, err %s %s.%sTo%s(%s)",
+		src[lp.offset(stmt.Lhs[0].Pos()):lp.offset(stmt.Lhs[0].End())], stmt.Tok,
+		opts.HelpersPackage, exportedName(af.FromPrimitive), exportedName(af.ToPrimitive), arg)
+	check := fmt.Sprintf("\n%sif err != nil {\n%s\treturn %s\n%s}", indent, indent, strings.Join(returns, ", "), indent)
+	// NOTE: A comment after the conversion stays on its line, with the check after it.
+	lineEnd := bytes.IndexByte(src[e.end:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(src) - e.end
+	}
+	rest := bytes.TrimSpace(src[e.end : e.end+lineEnd])
+	if len(rest) == 0 || bytes.HasPrefix(rest, []byte("//")) {
+		return []edit{e, {start: e.end + lineEnd, end: e.end + lineEnd, text: check}}, ""
+	}
+	e.text += check
+	return []edit{e}, ""
+}
+
+// zeroValue is the zero value of the type written as expr, in a file whose source is src.
+func (lp *loadedPackage) zeroValue(src []byte, expr ast.Expr) (string, bool) {
+	t := lp.info.Types[expr].Type
+	if t == nil {
+		return "", false
+	}
+	text := string(src[lp.offset(expr.Pos()):lp.offset(expr.End())])
+	if _, ok := t.(*types.TypeParam); ok {
+		return "*new(" + text + ")", true
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false", true
+		case u.Info()&types.IsString != 0:
+			return `""`, true
+		case u.Kind() == types.UnsafePointer:
+			return "nil", true
+		default:
+			return "0", true
+		}
+	case *types.Struct, *types.Array:
+		return text + "{}", true
+	default:
+		return "nil", true
+	}
+}
+
+// importEdit is the edit importing importPath into the file whose source is src, reporting false
+// if it imports it already. The import joins the group of imports astutil.AddImport picks for it,
+// in order, or is added after the package clause when the file has no imports.
+func importEdit(src []byte, importPath string) (edit, bool, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return edit{}, false, errors.Wrap(err, "parsing")
+	}
+	for _, spec := range f.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p == importPath {
+			return edit{}, false, nil
+		}
+	}
+	quoted := strconv.Quote(importPath)
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	lineStart := func(pos token.Pos) int { return bytes.LastIndexByte(src[:offset(pos)], '\n') + 1 }
+	lineEnd := func(pos token.Pos) int {
+		if i := bytes.IndexByte(src[offset(pos):], '\n'); i >= 0 {
+			return offset(pos) + i
+		}
+		return len(src)
+	}
+	// specStart and specEnd are where spec starts and ends, along with its comments.
+	specStart := func(spec *ast.ImportSpec) token.Pos {
+		if spec.Doc != nil {
+			return spec.Doc.Pos()
+		}
+		return spec.Pos()
+	}
+	specEnd := func(spec *ast.ImportSpec) token.Pos {
+		if spec.Comment != nil {
+			return spec.Comment.End()
+		}
+		return spec.End()
+	}
+
+	parens := make(map[*ast.GenDecl]bool)
+	for _, d := range f.Decls {
+		if gd, ok := d.(*ast.GenDecl); ok {
+			parens[gd] = gd.Lparen.IsValid()
+		}
+	}
+	// NOTE: Only which import the new one goes after is kept from astutil.AddImport, as printing
+	// the file it's added to would reformat it, and misplace the comments on the other imports.
+	astutil.AddImport(fset, f, importPath)
+	added := f.Imports[len(f.Imports)-1]
+	var decl *ast.GenDecl
+	var after *ast.ImportSpec
+	for _, d := range f.Decls {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok || gd.Tok != token.IMPORT {
+			continue
+		}
+		for i, spec := range gd.Specs {
+			if spec == added && i > 0 {
+				decl, after = gd, gd.Specs[i-1].(*ast.ImportSpec)
+			}
+		}
+	}
+
+	var e edit
+	switch {
+	case decl == nil:
+		e.start = lineEnd(f.Name.End())
+		e.end = e.start
+		e.text = "\n\nimport " + quoted
+	case !parens[decl]:
+		specs := []string{string(src[offset(specStart(after)):offset(specEnd(after))]), quoted}
+		if p, _ := strconv.Unquote(after.Path.Value); importPath < p {
+			specs[0], specs[1] = specs[1], specs[0]
+		}
+		e.start = offset(decl.Pos())
+		e.end = offset(specEnd(after))
+		e.text = "import (\n\t" + strings.Join(specs, "\n\t") + "\n)"
+	default:
+		// NOTE: Groups are separated by blank lines, and the import goes before the first in its
+		// group it sorts before, or after the last, indented the same.
+		var groups [][]*ast.ImportSpec
+		for _, spec := range decl.Specs {
+			spec := spec.(*ast.ImportSpec)
+			if spec == added {
+				continue
+			}
+			if len(groups) > 0 {
+				last := groups[len(groups)-1]
+				lines := bytes.Split(src[lineEnd(specEnd(last[len(last)-1])):lineStart(specStart(spec))], []byte("\n"))
+				blank := false
+				for _, line := range lines[1 : len(lines)-1] {
+					blank = blank || len(bytes.TrimSpace(line)) == 0
+				}
+				if !blank {
+					groups[len(groups)-1] = append(last, spec)
+					continue
+				}
+			}
+			groups = append(groups, []*ast.ImportSpec{spec})
+		}
+		var group []*ast.ImportSpec
+		for _, g := range groups {
+			for _, spec := range g {
+				if spec == after {
+					group = g
+				}
+			}
+		}
+		next, before := group[len(group)-1], false
+		for _, spec := range group {
+			if p, _ := strconv.Unquote(spec.Path.Value); p > importPath {
+				next, before = spec, true
+				break
+			}
+		}
+		start := lineStart(next.Pos())
+		indent := string(src[start:offset(next.Pos())])
+		if before {
+			e.start = lineStart(specStart(next))
+			e.text = indent + quoted + "\n"
+		} else {
+			e.start = lineEnd(specEnd(next))
+			e.text = "\n" + indent + quoted
+		}
+		e.end = e.start
+	}
+	return e, true, nil
+}
+
+// offset is the offset of pos within its file.
+func (lp *loadedPackage) offset(pos token.Pos) int {
+	return lp.fset.Position(pos).Offset
+}
+
+// applyEdits makes every edit in es to src, which mustn't overlap, and checks that the result
+// still parses. Source which was formatted with gofmt is formatted again afterwards.
+func applyEdits(src []byte, es []edit) ([]byte, error) {
+	sort.Slice(es, func(i, j int) bool { return es[i].start < es[j].start })
+	var b bytes.Buffer
+	last := 0
+	for _, e := range es {
+		if e.start < last {
+			return nil, errors.New("overlapping edits")
+		}
+		b.Write(src[last:e.start])
+		b.WriteString(e.text)
+		last = e.end
+	}
+	b.Write(src[last:])
+	out := b.Bytes()
+
+	_, err := parser.ParseFile(token.NewFileSet(), "", out, parser.ParseComments)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing the rewritten source")
+	}
+	if formatted, err := format.Source(src); err == nil && bytes.Equal(formatted, src) {
+		out, err = format.Source(out)
+		if err != nil {
+			return nil, errors.Wrap(err, "formatting the rewritten source")
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,63 @@
+package conversions
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRewrite checks that a conversion assigned to an existing variable is rewritten once an
+// earlier rewrite in the same function has declared err, and that the helpers are imported
+// into the group of imports they belong with.
+func TestRewrite(t *testing.T) {
+	dir := t.TempDir()
+	src := `package calc
+
+import (
+	"fmt"
+	"os"
+
+	"example.com/calc/internal/log"
+)
+
+func Narrow(f float64, n int64) (uint8, error) {
+	m := int32(n)
+	var u uint8
+	u = uint8(f)
+	log.Print(m)
+	fmt.Fprintln(os.Stdout, m)
+	return u, nil
+}
+`
+	err := os.WriteFile(filepath.Join(dir, "calc.go"), []byte(src), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var opts RewriteOptions
+	opts.HelpersImport = "example.com/calc/conv"
+	frs, err := Rewrite(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frs) != 1 {
+		t.Fatalf("rewrote %d files, expected 1", len(frs))
+	}
+	fr := frs[0]
+	for _, sf := range fr.Skipped {
+		t.Errorf("skipped %s: %s", sf.Expr, sf.Reason)
+	}
+	if len(fr.Rewritten) != 2 {
+		t.Errorf("rewrote %d conversions, expected 2", len(fr.Rewritten))
+	}
+	for _, want := range []string{
+		"m, err := conv.Int64ToInt32(n)",
+		"u, err = conv.Float64ToUint8(f)",
+		"import (\n\t\"fmt\"\n\t\"os\"\n\n\t\"example.com/calc/conv\"\n\t\"example.com/calc/internal/log\"\n)",
+	} {
+		if !bytes.Contains(fr.After, []byte(want)) {
+			t.Errorf("rewritten source is missing %q:\n%s", want, fr.After)
+		}
+	}
+}
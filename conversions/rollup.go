@@ -0,0 +1,148 @@
+package conversions
+
+import (
+	"go/ast"
+	"sort"
+)
+
+type (
+	// PackageAudit is the Audit of one of many packages, see AuditPackages.
+	PackageAudit struct {
+		// Dir is the directory of the package, as it was given.
+		Dir string
+		// Lines is how many lines of Go source the package's files have, leaving out tests.
+		Lines    int
+		Findings []AuditFinding
+	}
+
+	// Rollup ranks the packages of a PackageAudit each by how densely they convert lossily, so
+	// the packages most in need of attention across a large codebase come first.
+	Rollup struct {
+		// Packages are the summary of each package, densest first.
+		Packages []PackageRollup
+		// Lines, Flagged, Proven, Off, and Density are the totals of every one of Packages.
+		Lines   int
+		Flagged int
+		Proven  int
+		Off     int
+		Density float64
+	}
+
+	// PackageRollup summarizes the PackageAudit of a single package for a Rollup.
+	PackageRollup struct {
+		Dir   string
+		Lines int
+		// Flagged is how many conversions may lose information, Proven how many more the
+		// AuditOptions' Domains proved safe, and Off how many more the Severities turn off.
+		Flagged int
+		Proven  int
+		Off     int
+		// Density is how many conversions are Flagged for every thousand Lines.
+		Density float64
+		// Pairs break Flagged down by the primitives converted between, most first, making up
+		// the package's own matrix of lossy conversions.
+		Pairs []PairCount `json:",omitempty"`
+	}
+
+	// PairCount is how many conversions between two primitives a package has.
+	PairCount struct {
+		From  string
+		To    string
+		Count int
+	}
+)
+
+// AuditPackages is Audit for every package in dirs, in order, along with how many lines each has.
+func AuditPackages(dirs []string, opts AuditOptions) ([]PackageAudit, error) {
+	if opts.Domains == nil {
+		opts.Domains = []ValueDomain{IntervalDomain{}}
+	}
+
+	var pas []PackageAudit
+	for _, dir := range dirs {
+		lp, err := loadPackage(dir, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var pa PackageAudit
+		pa.Dir = dir
+		for _, f := range lp.files {
+			pa.Lines += lp.fset.File(f.Pos()).LineCount()
+		}
+		lp.audit(opts, func(af AuditFinding, _ []ast.Node) {
+			pa.Findings = append(pa.Findings, af)
+		})
+		pas = append(pas, pa)
+	}
+	return pas, nil
+}
+
+// RollupAudits ranks every one of pas by how many conversions it has which may lose
+// information for every thousand lines, at the severity severities gives them. Packages as
+// dense as each other are ranked by how many they have, and then by their directory.
+func RollupAudits(pas []PackageAudit, severities Severities) Rollup {
+	var r Rollup
+	for _, pa := range pas {
+		var pr PackageRollup
+		pr.Dir = pa.Dir
+		pr.Lines = pa.Lines
+		counts := make(map[[2]string]int)
+		for _, af := range pa.Findings {
+			switch {
+			case af.Proven:
+				pr.Proven++
+			case severities.For(af.FromPrimitive, af.ToPrimitive, af.Width) == SeverityOff:
+				pr.Off++
+			default:
+				pr.Flagged++
+				counts[[2]string{af.FromPrimitive, af.ToPrimitive}]++
+			}
+		}
+		for pair, count := range counts {
+			var pc PairCount
+			pc.From = pair[0]
+			pc.To = pair[1]
+			pc.Count = count
+			pr.Pairs = append(pr.Pairs, pc)
+		}
+		sort.Slice(pr.Pairs, func(i, j int) bool {
+			a, b := pr.Pairs[i], pr.Pairs[j]
+			if a.Count != b.Count {
+				return a.Count > b.Count
+			}
+			if a.From != b.From {
+				return a.From < b.From
+			}
+			return a.To < b.To
+		})
+		pr.Density = density(pr.Flagged, pr.Lines)
+
+		r.Packages = append(r.Packages, pr)
+		r.Lines += pr.Lines
+		r.Flagged += pr.Flagged
+		r.Proven += pr.Proven
+		r.Off += pr.Off
+	}
+	r.Density = density(r.Flagged, r.Lines)
+
+	sort.SliceStable(r.Packages, func(i, j int) bool {
+		a, b := r.Packages[i], r.Packages[j]
+		if a.Density != b.Density {
+			return a.Density > b.Density
+		}
+		if a.Flagged != b.Flagged {
+			return a.Flagged > b.Flagged
+		}
+		return a.Dir < b.Dir
+	})
+	return r
+}
+
+// density is how many of flagged there are for every thousand lines, 0 when there are no lines.
+func density(flagged, lines int) float64 {
+	if lines == 0 {
+		return 0
+	}
+	return float64(flagged) * 1000 / float64(lines)
+}
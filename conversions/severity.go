@@ -0,0 +1,83 @@
+package conversions
+
+import (
+	"github.com/pkg/errors"
+	"sort"
+)
+
+const (
+	// SeverityOff leaves a finding out entirely.
+	SeverityOff = "off"
+	// SeverityInfo reports a finding without warning about it.
+	SeverityInfo = "info"
+	// SeverityWarning warns about a finding.
+	SeverityWarning = "warning"
+	// SeverityError reports a finding as an error, which fails the audit.
+	SeverityError = "error"
+)
+
+type (
+	// Severities overrides the severity of lint findings by the pair of primitives they convert
+	// between, e.g. {"int->int64": "off", "int64->int32": "error"}, as an .editorconfig does
+	// for style rules. Pairs converting between defined types match the primitives underlying
+	// them, and aliases match the types they alias.
+	Severities map[string]string
+)
+
+var (
+	// severityLevels are every valid severity.
+	severityLevels = []string{SeverityOff, SeverityInfo, SeverityWarning, SeverityError}
+)
+
+// DefaultSeverity is the severity of a finding which may lose information, by its width, see
+// Classify: an error for narrowing conversions, which cut values down, and a warning for the
+// rest, which only reinterpret them.
+func DefaultSeverity(width string) string {
+	if width == WidthNarrowing {
+		return SeverityError
+	}
+	return SeverityWarning
+}
+
+// For returns the severity of a finding converting the primitive from to the primitive to,
+// whose width is width: the override for the pair in s if there is one, otherwise DefaultSeverity.
+func (s Severities) For(from, to, width string) string {
+	for _, pair := range s.pairs() {
+		f, t, _ := ParsePair(pair)
+		if canonicalType(f) == canonicalType(from) && canonicalType(t) == canonicalType(to) {
+			return s[pair]
+		}
+	}
+	return DefaultSeverity(width)
+}
+
+// Validate checks that every pair in s is between two primitives, and every severity is one
+// of SeverityOff, SeverityInfo, SeverityWarning, or SeverityError.
+func (s Severities) Validate() error {
+	for _, pair := range s.pairs() {
+		from, to, err := ParsePair(pair)
+		if err != nil {
+			return errors.Wrap(err, "severities")
+		}
+		for _, typ := range []string{from, to} {
+			if _, ok := Lookup(typ); !ok {
+				return errors.Errorf("severity of %q references %q, which isn't a primitive", pair, typ)
+			}
+		}
+		if !contains(severityLevels, s[pair]) {
+			return errors.Errorf("unknown severity %q for %q, expected one of %v", s[pair], pair, severityLevels)
+		}
+	}
+	return nil
+}
+
+// pairs returns the pairs in s, sorted so the same override always wins when two name the same
+// pair, e.g. through an alias.
+func (s Severities) pairs() []string {
+	var pairs []string
+	for pair := range s {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
@@ -0,0 +1,232 @@
+package conversions
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/pkg/errors"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+type (
+	// ShiftChart charts which primitives shift, and which they can be shifted by, under each of
+	// Langs. Go 1.13 allowed signed shift counts, which were an error before, so the chart is
+	// only the same under every language version from then on.
+	ShiftChart struct {
+		Langs []string
+		Types []string
+		// Operands are whether a variable of Operand shifts by a variable of Count, for every
+		// pair of Types.
+		Operands []ShiftResult
+		// Untyped are whether an untyped constant shifted by a variable assigns to each of Types.
+		Untyped []UntypedShift
+	}
+
+	// ShiftResult is whether x << y compiles, for x a variable of Operand and y a variable of Count.
+	ShiftResult struct {
+		Operand string
+		Count   string
+		// Valid is keyed by language version.
+		Valid map[string]bool
+		// Reasons are what the type checker reported under each language version it isn't Valid under.
+		Reasons map[string]string `json:",omitempty"`
+	}
+
+	// UntypedShift is whether var _ Type = Constant << s compiles, for s a uint variable. A
+	// non-constant shift converts its untyped constant operand to the type it would have alone,
+	// so 1.0 << s assigns to an int, but 1 << s doesn't assign to a float64.
+	UntypedShift struct {
+		Constant string
+		Type     string
+		// Valid is keyed by language version.
+		Valid map[string]bool
+		// Reasons are what the type checker reported under each language version it isn't Valid under.
+		Reasons map[string]string `json:",omitempty"`
+	}
+)
+
+var (
+	// ShiftLangs are the language versions ChartShifts charts under when given none, either side
+	// of signed shift counts being allowed.
+	ShiftLangs = []string{"go1.12", "go1.13"}
+	// ShiftConstants are the untyped constants ChartShifts shifts, one integer and one floating-point.
+	ShiftConstants = []string{"1", "1.0"}
+)
+
+// Differs reports whether x << y compiles under some language versions but not others.
+func (sr ShiftResult) Differs() bool {
+	return differs(sr.Valid)
+}
+
+// Differs reports whether the shift compiles under some language versions but not others.
+func (us UntypedShift) Differs() bool {
+	return differs(us.Valid)
+}
+
+// Shiftable are the Types which shift by a variable of some type under lang.
+func (sc ShiftChart) Shiftable(lang string) []string {
+	return sc.valid(lang, func(sr ShiftResult) string { return sr.Operand })
+}
+
+// Counts are the Types a variable of some type shifts by under lang.
+func (sc ShiftChart) Counts(lang string) []string {
+	return sc.valid(lang, func(sr ShiftResult) string { return sr.Count })
+}
+
+// valid are the Types which key gives for any of Operands Valid under lang, in the order of Types.
+func (sc ShiftChart) valid(lang string, key func(ShiftResult) string) []string {
+	seen := make(map[string]bool)
+	for _, sr := range sc.Operands {
+		if sr.Valid[lang] {
+			seen[key(sr)] = true
+		}
+	}
+	var names []string
+	for _, name := range sc.Types {
+		if seen[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ChartShifts type checks, under each of langs, or ShiftLangs when there are none, every
+// primitive in opts.Types shifted by every other, and each of ShiftConstants shifted by a uint
+// and assigned to every primitive, see ShiftChart. The probes are type checked with go/types
+// whatever opts.Engine is, since only it can be told which language version to check under.
+func ChartShifts(opts Options, langs []string) (ShiftChart, error) {
+	opts = opts.WithDefaults()
+	if len(langs) == 0 {
+		langs = ShiftLangs
+	}
+	for _, lang := range langs {
+		err := checkLangVersion(lang)
+		if err != nil {
+			return ShiftChart{}, err
+		}
+	}
+
+	var sc ShiftChart
+	sc.Langs = langs
+	for _, name := range opts.Types {
+		if _, ok := Lookup(name); ok {
+			sc.Types = append(sc.Types, name)
+		}
+	}
+
+	var src bytes.Buffer
+	src.WriteString("package conversions\n\nvar s uint\n\n")
+	for i, name := range sc.Types {
+		_, _ = fmt.Fprintf(&src, "var v%03d %s\n", i, name)
+	}
+
+	// NOTE: Each probe is on a line of its own, so the errors can be told apart by line.
+	line := strings.Count(src.String(), "\n") + 1
+	src.WriteString("\nfunc probe() {\n")
+	line += 2
+	operands := make(map[int]int)
+	for i, operand := range sc.Types {
+		for j, count := range sc.Types {
+			var sr ShiftResult
+			sr.Operand = operand
+			sr.Count = count
+			sr.Valid = make(map[string]bool, len(langs))
+			sr.Reasons = make(map[string]string)
+			sc.Operands = append(sc.Operands, sr)
+			operands[line] = len(sc.Operands) - 1
+			_, _ = fmt.Fprintf(&src, "\t_ = v%03d << v%03d\n", i, j)
+			line++
+		}
+	}
+	untyped := make(map[int]int)
+	for _, constant := range ShiftConstants {
+		for _, name := range sc.Types {
+			var us UntypedShift
+			us.Constant = constant
+			us.Type = name
+			us.Valid = make(map[string]bool, len(langs))
+			us.Reasons = make(map[string]string)
+			sc.Untyped = append(sc.Untyped, us)
+			untyped[line] = len(sc.Untyped) - 1
+			_, _ = fmt.Fprintf(&src, "\tvar _ %s = %s << s\n", name, constant)
+			line++
+		}
+	}
+	src.WriteString("}\n")
+
+	const filename = "shifts.go"
+	for _, lang := range langs {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, filename, src.Bytes(), parser.AllErrors)
+		if err != nil {
+			return ShiftChart{}, errors.Wrap(err, "parsing shifts")
+		}
+
+		reasons := make(map[int]string)
+		var unexpected []string
+		var conf types.Config
+		conf.Importer = importer.Default()
+		conf.GoVersion = lang
+		if opts.GOARCH != "" {
+			conf.Sizes = types.SizesFor("gc", opts.GOARCH)
+		}
+		conf.Error = func(err error) {
+			te, ok := err.(types.Error)
+			if !ok {
+				unexpected = append(unexpected, err.Error())
+				return
+			}
+			l := fset.Position(te.Pos).Line
+			_, isOperand := operands[l]
+			_, isUntyped := untyped[l]
+			if !isOperand && !isUntyped {
+				unexpected = append(unexpected, te.Msg)
+				return
+			}
+			// NOTE: A shift may report a second error, e.g. for both of its operands, the first is kept.
+			if _, ok := reasons[l]; !ok {
+				reasons[l] = te.Msg
+			}
+		}
+		// NOTE: The returned error is only the first of the errors already collected by conf.Error.
+		_, _ = conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+		if len(unexpected) > 0 {
+			return ShiftChart{}, errors.Errorf("type checking shifts under %s: %s", lang, strings.Join(unexpected, "; "))
+		}
+
+		for l, i := range operands {
+			reason, invalid := reasons[l]
+			sc.Operands[i].Valid[lang] = !invalid
+			if invalid {
+				sc.Operands[i].Reasons[lang] = reason
+			}
+		}
+		for l, i := range untyped {
+			reason, invalid := reasons[l]
+			sc.Untyped[i].Valid[lang] = !invalid
+			if invalid {
+				sc.Untyped[i].Reasons[lang] = reason
+			}
+		}
+	}
+	return sc, nil
+}
+
+// differs reports whether valid holds both true and false.
+func differs(valid map[string]bool) bool {
+	var seen, first bool
+	for _, v := range valid {
+		if !seen {
+			seen, first = true, v
+			continue
+		}
+		if v != first {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package conversions
+
+import (
+	"github.com/pkg/errors"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+)
+
+const (
+	// snippetFile is the file name positions in a snippet are reported against.
+	snippetFile = "snippet.go"
+)
+
+// Snippet type checks the go code in src and returns every conversion in it. src may be a
+// whole file, a list of declarations without a package clause, or a list of statements, in
+// which case it is wrapped in a package and a function. The wrapping never adds lines before
+// src, so the line of every Conversion is its line in src.
+func Snippet(src []byte) ([]Conversion, error) {
+	var lp loadedPackage
+	lp.fset = token.NewFileSet()
+
+	// NOTE: Without a package clause, src is parsed first as declarations and then as statements,
+	// and the error from parsing it as statements is the one reported.
+	wraps := [][2]string{{"", ""}}
+	_, err := parser.ParseFile(token.NewFileSet(), snippetFile, src, parser.PackageClauseOnly)
+	if err != nil {
+		wraps = [][2]string{
+			{"package snippet; ", ""},
+			{"package snippet; func _() { ", "\n}"},
+		}
+	}
+
+	var f *ast.File
+	for _, wrap := range wraps {
+		f, err = parser.ParseFile(lp.fset, snippetFile, wrap[0]+string(src)+wrap[1], 0)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing snippet")
+	}
+	lp.files = []*ast.File{f}
+
+	lp.check(f.Name.Name, build.Default.GOARCH)
+
+	return lp.conversions(), nil
+}
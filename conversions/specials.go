@@ -0,0 +1,155 @@
+package conversions
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type (
+	// SpecialValue is a floating point value with special conversion semantics, e.g. NaN.
+	SpecialValue struct {
+		Name string
+		// Expr is an expression for the value, which is converted to the float type before use.
+		Expr string
+	}
+
+	// SpecialValueResult is what converting a SpecialValue of the float type From to To resulted
+	// in on Platform. Go leaves converting out of range floats to integers implementation
+	// defined, so Result can differ between platforms.
+	SpecialValueResult struct {
+		// Platform is the GOOS/GOARCH the conversion ran on.
+		Platform string
+		From     string
+		Value    string
+		To       string
+		// Result is the converted value, formatted with fmt.
+		Result string `json:",omitempty"`
+		// Panic is what the conversion panicked with, if it did.
+		Panic string `json:",omitempty"`
+	}
+)
+
+var (
+	// SpecialsTemplate is the template the special value probe program is generated from.
+	//go:embed template/specials.tmpl
+	SpecialsTemplate string
+)
+
+// SpecialValues returns the special values of the float type i: NaN, both infinities, negative
+// zero, and its smallest and largest subnormal values.
+func SpecialValues(i Info) []SpecialValue {
+	name := "Float" + bitsSuffix(i)
+	largestSubnormal := "math.Float64frombits(0x000fffffffffffff)"
+	if i.Bits == 32 {
+		largestSubnormal = "math.Float32frombits(0x007fffff)"
+	}
+	return []SpecialValue{
+		{Name: "NaN", Expr: "math.NaN()"},
+		{Name: "+Inf", Expr: "math.Inf(1)"},
+		{Name: "-Inf", Expr: "math.Inf(-1)"},
+		{Name: "-0", Expr: "math.Copysign(0, -1)"},
+		{Name: "smallest subnormal", Expr: "math.SmallestNonzero" + name},
+		{Name: "largest subnormal", Expr: largestSubnormal},
+	}
+}
+
+// AnalyzeSpecialValues generates and runs a program which converts the SpecialValues of every
+// float type to every other float and integer type, recording what each conversion results in.
+// The program is run once for each of platforms, given as GOOS/GOARCH, which must be able to run
+// on this machine, e.g. linux/386 on linux/amd64. No platforms means just this one. Since the
+// probes must actually run, this requires EngineBuild.
+func AnalyzeSpecialValues(ctx context.Context, opts Options, platforms []string) ([]SpecialValueResult, error) {
+	opts = opts.WithDefaults()
+	if opts.Engine != EngineBuild {
+		return nil, errors.Errorf("special value probes have to be run, which the %s engine can't do", opts.Engine)
+	}
+
+	type Float struct {
+		Name   string
+		Values []SpecialValue
+	}
+	type Data struct {
+		Now     string
+		App     string
+		Floats  []Float
+		Targets []string
+	}
+	var data Data
+	data.Now = time.Now().Format(time.RFC3339)
+	data.App = os.Args[0]
+	for _, name := range opts.Types {
+		info, ok := Lookup(name)
+		if !ok || !info.IsNumeric() || info.Kind == KindComplex {
+			continue
+		}
+		data.Targets = append(data.Targets, name)
+		if info.Kind == KindFloat {
+			var f Float
+			f.Name = name
+			f.Values = SpecialValues(info)
+			data.Floats = append(data.Floats, f)
+		}
+	}
+
+	t, err := parseCached("specials.tmpl", SpecialsTemplate, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing template")
+	}
+	var src bytes.Buffer
+	err = t.Execute(&src, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "executing template")
+	}
+
+	probeFile := filepath.Join(opts.OutputDir, "specials", "main.go")
+	err = WriteFileAtomic(probeFile, src.Bytes(), 0o644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "writing probe file %q", probeFile)
+	}
+
+	err = WriteProbeModule(opts.OutputDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "writing probe module")
+	}
+
+	if len(platforms) == 0 {
+		platforms = []string{""}
+	}
+	var results []SpecialValueResult
+	for _, platform := range platforms {
+		cmd := probeCommand(opts.OutputDir, "run", "./specials")
+		if platform != "" {
+			goos, goarch, ok := strings.Cut(platform, "/")
+			if !ok {
+				return nil, errors.Errorf("platform %q is not of the form GOOS/GOARCH", platform)
+			}
+			cmd.Env = append(cmd.Env, "GOOS="+goos, "GOARCH="+goarch)
+		}
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		err = runCommand(ctx, cmd)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "running probes on %q: %s", platform, stderr.String())
+		}
+
+		var platformResults []SpecialValueResult
+		err = json.Unmarshal(stdout.Bytes(), &platformResults)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding probe results on %q", platform)
+		}
+		results = append(results, platformResults...)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,71 @@
+package conversions
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"os"
+	"reflect"
+)
+
+type (
+	// State records which shards of an analysis have completed along with their Results,
+	// so an interrupted analysis can be resumed rather than started over.
+	State struct {
+		Types  []string         `json:"types"`
+		Engine string           `json:"engine"`
+		Shards map[int][]Result `json:"shards"`
+	}
+)
+
+// newState creates an empty State for an analysis configured by opts.
+func newState(opts Options) *State {
+	var s State
+	s.Types = opts.Types
+	s.Engine = opts.Engine
+	s.Shards = make(map[int][]Result)
+	return &s
+}
+
+// LoadState reads the State previously saved to stateFile. A missing stateFile
+// is not an error, it just means there is nothing to resume, so a nil State is returned.
+func LoadState(stateFile string) (*State, error) {
+	b, err := os.ReadFile(stateFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading state file %q", stateFile)
+	}
+
+	var s State
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decoding state file %q", stateFile)
+	}
+	if s.Shards == nil {
+		s.Shards = make(map[int][]Result)
+	}
+
+	return &s, nil
+}
+
+// Save writes s to stateFile. The file is written in full to a temporary file first
+// and then renamed into place, so an interruption never leaves a half-written state behind.
+func (s *State) Save(stateFile string) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return errors.Wrap(err, "encoding state")
+	}
+
+	err = WriteFileAtomic(stateFile, b, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "writing state file %q", stateFile)
+	}
+
+	return nil
+}
+
+// Matches reports whether s was recorded for an analysis configured by opts.
+func (s *State) Matches(opts Options) bool {
+	return reflect.DeepEqual(s.Types, opts.Types) && s.Engine == opts.Engine
+}
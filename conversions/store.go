@@ -0,0 +1,153 @@
+package conversions
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// StoreDir is the scheme of a DirStore location, e.g. dir://./releases, the scheme a location
+	// without one is taken to have.
+	StoreDir = "dir"
+)
+
+type (
+	// Store keeps documents by key, e.g. the Cache of every analysis or the analysis of each go
+	// release kept by serve, so they can live somewhere other than files on the local disk, such
+	// as a database shared by every instance of a server. Keys are names without path separators,
+	// e.g. cache.json or go1.23.4.json.
+	Store interface {
+		// Get returns the document kept as key, reporting false when there's none.
+		Get(ctx context.Context, key string) ([]byte, bool, error)
+		// Put keeps b as key, replacing whatever was kept as it, so that Get never returns half
+		// of either.
+		Put(ctx context.Context, key string, b []byte) error
+		// Keys lists the key of every document kept, sorted.
+		Keys(ctx context.Context) ([]string, error)
+	}
+
+	// StoreOpener opens the Store at location, a location given to OpenStore with its scheme
+	// and :// cut off.
+	StoreOpener func(location string) (Store, error)
+
+	// DirStore keeps every document as a file in Dir, named by its key.
+	DirStore struct {
+		Dir string
+	}
+)
+
+var (
+	// storesMu guards stores.
+	storesMu sync.RWMutex
+	// stores are the StoreOpeners of every scheme OpenStore opens.
+	stores = map[string]StoreOpener{
+		StoreDir: func(location string) (Store, error) {
+			var ds DirStore
+			ds.Dir = location
+			return ds, nil
+		},
+	}
+)
+
+// RegisterStore makes the Stores opened by open available to OpenStore as locations with
+// scheme, e.g. redis://host:6379/0, for kinds of Store which aren't built in. It is an error to
+// register a scheme twice.
+func RegisterStore(scheme string, open StoreOpener) error {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+
+	if scheme == "" {
+		return errors.New("store scheme must not be empty")
+	}
+	if open == nil {
+		return errors.Errorf("store %q must not be nil", scheme)
+	}
+	if _, ok := stores[scheme]; ok {
+		return errors.Errorf("store %q is already registered", scheme)
+	}
+	stores[scheme] = open
+	return nil
+}
+
+// OpenStore opens the Store at location, scheme://rest, with the StoreOpener registered for
+// scheme. A location without a scheme is a directory, opened as a DirStore.
+func OpenStore(location string) (Store, error) {
+	storesMu.RLock()
+	defer storesMu.RUnlock()
+
+	scheme, rest := StoreDir, location
+	if i := strings.Index(location, "://"); i >= 0 {
+		scheme, rest = location[:i], location[i+len("://"):]
+	}
+	open, ok := stores[scheme]
+	if !ok {
+		var schemes []string
+		for s := range stores {
+			schemes = append(schemes, s)
+		}
+		sort.Strings(schemes)
+		return nil, errors.Errorf("unknown store %q, expected one of %s", scheme, strings.Join(schemes, ", "))
+	}
+	s, err := open(rest)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening %s store %q", scheme, rest)
+	}
+	return s, nil
+}
+
+// checkKey returns an error unless key is a name a Store can keep a document as.
+func checkKey(key string) error {
+	if key == "" || key == "." || key == ".." || strings.ContainsAny(key, "/\\\x00\n\r") {
+		return errors.Errorf("%q is not a valid store key", key)
+	}
+	return nil
+}
+
+// Get implements Store.
+func (ds DirStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	err := checkKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+	b, err := os.ReadFile(filepath.Join(ds.Dir, key))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "reading %q", key)
+	}
+	return b, true, nil
+}
+
+// Put implements Store. Documents are written by way of a temporary file, see WriteFileAtomic.
+func (ds DirStore) Put(_ context.Context, key string, b []byte) error {
+	err := checkKey(key)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(filepath.Join(ds.Dir, key), b, 0o644)
+}
+
+// Keys implements Store. A Dir which doesn't exist keeps nothing.
+func (ds DirStore) Keys(_ context.Context) ([]string, error) {
+	entries, err := os.ReadDir(ds.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %q", ds.Dir)
+	}
+	var keys []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			keys = append(keys, entry.Name())
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
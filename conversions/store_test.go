@@ -0,0 +1,82 @@
+package conversions
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestStore round trips documents through every built in Store, and checks that a key nothing
+// was kept as is missing rather than an error.
+func TestStore(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		StoreDir: func(t *testing.T) Store {
+			s, err := OpenStore(filepath.Join(t.TempDir(), "store"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+	}
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			s := open(t)
+			ctx := context.Background()
+
+			keys, err := s.Keys(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(keys) != 0 {
+				t.Errorf("an empty store has keys %v", keys)
+			}
+			_, ok, err := s.Get(ctx, "missing.json")
+			if err != nil || ok {
+				t.Errorf("getting a missing key: got %t, %v, expected false, nil", ok, err)
+			}
+
+			docs := map[string]string{
+				"go1.23.4.json": `{"Types":["int8"]}`,
+				"cache.json":    `{}`,
+				"empty.json":    ``,
+			}
+			for key, doc := range docs {
+				err = s.Put(ctx, key, []byte(doc))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			err = s.Put(ctx, "cache.json", []byte(`{"Results":[]}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			docs["cache.json"] = `{"Results":[]}`
+
+			for key, doc := range docs {
+				b, ok, err := s.Get(ctx, key)
+				if err != nil || !ok || string(b) != doc {
+					t.Errorf("getting %q: got %q, %t, %v, expected %q", key, b, ok, err, doc)
+				}
+			}
+			keys, err = s.Keys(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []string{"cache.json", "empty.json", "go1.23.4.json"}; !reflect.DeepEqual(keys, want) {
+				t.Errorf("got keys %v, expected %v", keys, want)
+			}
+
+			for _, key := range []string{"", "..", "a/b.json", "a\\b.json"} {
+				if err := s.Put(ctx, key, nil); err == nil {
+					t.Errorf("putting %q: expected an error", key)
+				}
+			}
+		})
+	}
+
+	_, err := OpenStore("sqlite://./conversions.db")
+	if err == nil {
+		t.Error("opening an unregistered scheme: expected an error")
+	}
+}
@@ -0,0 +1,194 @@
+package conversions
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"go/types"
+	"strings"
+)
+
+const (
+	// VerbSample is the value of a StringType every verb is tried on.
+	VerbSample = "red"
+	// VerbStringer is what the String method of a StringType is taken to return for VerbSample,
+	// rather than calling the type's own.
+	VerbStringer = "Red"
+)
+
+type (
+	// StringType is a defined type whose underlying type is string, e.g. `type Color string`.
+	StringType struct {
+		// Package is the name of the package the StringType is declared in.
+		Package string
+		Name    string
+		// Stringer is whether the type has a String method with a value receiver, which fmt calls
+		// for values and pointers alike. PointerStringer is whether only *Name has one, which
+		// fmt only calls for pointers.
+		Stringer        bool
+		PointerStringer bool
+		// ConvertsFrom and ConvertsTo are the types a value of the type converts from and to: the
+		// primitives StringTypes was given, in their order, then the other StringTypes of the
+		// package, by name.
+		ConvertsFrom []string
+		ConvertsTo   []string
+		// Verbs are how fmt formats a value of the type with each of FmtVerbs.
+		Verbs []VerbAdvisory
+	}
+
+	// VerbAdvisory is how fmt formats a value of a StringType with a single verb.
+	VerbAdvisory struct {
+		Verb string
+		// Output is what fmt prints for a value of VerbSample, whose String method, if it has
+		// one, returns VerbStringer.
+		Output string
+		// Expected is whether Output is what the verb is for: the value's text, as String returns
+		// it when there's one, quoted with %q or in hex with %x. Advice is what to do instead
+		// when it isn't.
+		Expected bool
+		Advice   string `json:",omitempty"`
+	}
+
+	// fmtString, fmtStringer, and fmtPointerStringer stand in for a StringType without a String
+	// method, with one, and with one only its pointer has, to find out what fmt does with each.
+	fmtString          string
+	fmtStringer        string
+	fmtPointerStringer string
+)
+
+var (
+	// FmtVerbs are the verbs StringTypes tries every StringType with.
+	FmtVerbs = []string{"%s", "%q", "%v", "%x", "%d"}
+)
+
+// String implements fmt.Stringer.
+func (fmtStringer) String() string {
+	return VerbStringer
+}
+
+// String implements fmt.Stringer.
+func (*fmtPointerStringer) String() string {
+	return VerbStringer
+}
+
+// StringTypes type checks the package in dir and reports every StringType declared in it, in
+// the order of their names, with which of primitives and the package's other StringTypes it
+// converts from and to, and what fmt does with each of FmtVerbs for a value of it.
+func StringTypes(dir string, primitives []string) ([]StringType, error) {
+	lp, err := loadPackage(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	scope := lp.pkg.Scope()
+
+	var candidates []types.Type
+	var names []string
+	for _, p := range primitives {
+		tn, ok := types.Universe.Lookup(p).(*types.TypeName)
+		if !ok {
+			return nil, errors.Errorf("unknown primitive %q", p)
+		}
+		candidates = append(candidates, tn.Type())
+		names = append(names, p)
+	}
+
+	var sts []StringType
+	var defined []*types.Named
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || tn.IsAlias() {
+			continue
+		}
+		named, ok := tn.Type().(*types.Named)
+		if !ok {
+			continue
+		}
+		basic, ok := named.Underlying().(*types.Basic)
+		if !ok || basic.Kind() != types.String {
+			continue
+		}
+
+		var st StringType
+		st.Package = lp.pkg.Name()
+		st.Name = name
+		st.Stringer = hasStringMethod(named)
+		st.PointerStringer = !st.Stringer && hasStringMethod(types.NewPointer(named))
+		st.Verbs = verbAdvisories(st)
+		sts = append(sts, st)
+		defined = append(defined, named)
+	}
+	// NOTE: Scope names are sorted already, so sts and defined are in the order of their names.
+	for _, d := range defined {
+		candidates = append(candidates, d)
+		names = append(names, d.Obj().Name())
+	}
+
+	for i := range sts {
+		for j, c := range candidates {
+			if c == defined[i] {
+				continue
+			}
+			if types.ConvertibleTo(c, defined[i]) {
+				sts[i].ConvertsFrom = append(sts[i].ConvertsFrom, names[j])
+			}
+			if types.ConvertibleTo(defined[i], c) {
+				sts[i].ConvertsTo = append(sts[i].ConvertsTo, names[j])
+			}
+		}
+	}
+
+	return sts, nil
+}
+
+// hasStringMethod reports whether the method set of t has a String method implementing fmt.Stringer.
+func hasStringMethod(t types.Type) bool {
+	sel := types.NewMethodSet(t).Lookup(nil, "String")
+	if sel == nil {
+		return false
+	}
+	sig, ok := sel.Obj().Type().(*types.Signature)
+	if !ok || sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+		return false
+	}
+	basic, ok := sig.Results().At(0).Type().(*types.Basic)
+	return ok && basic.Kind() == types.String
+}
+
+// verbAdvisories formats a stand-in for st with each of FmtVerbs, and advises against those
+// which don't print what they're for.
+func verbAdvisories(st StringType) []VerbAdvisory {
+	var value interface{}
+	standIn := fmt.Sprintf("%T", fmtString(""))
+	switch {
+	case st.Stringer:
+		value = fmtStringer(VerbSample)
+		standIn = fmt.Sprintf("%T", fmtStringer(""))
+	case st.PointerStringer:
+		value = fmtPointerStringer(VerbSample)
+		standIn = fmt.Sprintf("%T", fmtPointerStringer(""))
+	default:
+		value = fmtString(VerbSample)
+	}
+	qualified := st.Package + "." + st.Name
+
+	var vas []VerbAdvisory
+	for _, verb := range FmtVerbs {
+		var va VerbAdvisory
+		va.Verb = verb
+		va.Output = strings.ReplaceAll(fmt.Sprintf(verb, value), standIn, qualified)
+		text := VerbSample
+		if st.Stringer {
+			text = VerbStringer
+		}
+		va.Expected = va.Output == fmt.Sprintf(verb, text)
+		switch {
+		case strings.Contains(va.Output, "%!"):
+			va.Expected = false
+			va.Advice = fmt.Sprintf("%s doesn't format strings, go vet reports it, use %%s or %%q", verb)
+		case st.PointerStringer && verb != "%x":
+			va.Expected = false
+			va.Advice = fmt.Sprintf("only *%s has a String method, so fmt ignores it for values, give it a value receiver or pass a pointer", st.Name)
+		}
+		vas = append(vas, va)
+	}
+	return vas
+}
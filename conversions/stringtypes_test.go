@@ -0,0 +1,46 @@
+package conversions
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestStringTypesConvert checks that every StringType is given the types it converts from and to
+// itself, rather than those of string alone.
+func TestStringTypesConvert(t *testing.T) {
+	dir := t.TempDir()
+	src := `package colors
+
+type Color string
+
+type Shade Color
+
+type Hex []byte
+`
+	err := os.WriteFile(filepath.Join(dir, "colors.go"), []byte(src), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sts, err := StringTypes(dir, []string{"int8", "float32", "string"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sts) != 2 {
+		t.Fatalf("found %d string types, expected Color and Shade", len(sts))
+	}
+	for _, st := range sts {
+		other := "Shade"
+		if st.Name == "Shade" {
+			other = "Color"
+		}
+		if want := []string{"int8", "string", other}; !reflect.DeepEqual(st.ConvertsFrom, want) {
+			t.Errorf("%s converts from %v, expected %v", st.Name, st.ConvertsFrom, want)
+		}
+		if want := []string{"string", other}; !reflect.DeepEqual(st.ConvertsTo, want) {
+			t.Errorf("%s converts to %v, expected %v", st.Name, st.ConvertsTo, want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package conversions
+
+import (
+	"fmt"
+)
+
+type (
+	// Suggestion is code doing what a conversion which doesn't compile can't, e.g. strconv.Itoa
+	// rather than converting an int to a string. Code reads the From value v and assigns the To
+	// value to out, and, when it can fail, an error to err.
+	Suggestion struct {
+		From string
+		To   string
+		// Rule is the name of the rule in the rules table Code was made by, e.g. "parse-int".
+		Rule string
+		Code string
+		// Imports are the packages Code uses.
+		Imports []string `json:",omitempty"`
+		// Note is what Code does that the conversion might have been expected to, or not to.
+		Note string
+	}
+
+	// suggestionRule makes a Suggestion for every illegal conversion it matches.
+	suggestionRule struct {
+		name    string
+		match   func(from, to Info) bool
+		code    func(from, to Info) string
+		imports []string
+		note    string
+	}
+)
+
+var (
+	// suggestionRules are the rules Suggest picks the first match of, for every pair of kinds a
+	// conversion between isn't legal.
+	suggestionRules = []suggestionRule{
+		{
+			name:  "compare",
+			match: func(from, to Info) bool { return to.Kind == KindBool && (from.IsNumeric() || from.Kind == KindComplex) },
+			code:  func(from, to Info) string { return "out = v != 0" },
+			note:  "compare to zero rather than converting, since a number has no truth value of its own",
+		},
+		{
+			name:    "parse-bool",
+			match:   func(from, to Info) bool { return from.Kind == KindString && to.Kind == KindBool },
+			code:    func(from, to Info) string { return "out, err = strconv.ParseBool(v)" },
+			imports: []string{"strconv"},
+			note:    "accepts 1, t, T, TRUE, true, True, 0, f, F, FALSE, false, and False, and fails on anything else",
+		},
+		{
+			name:  "branch",
+			match: func(from, to Info) bool { return from.Kind == KindBool && (to.IsNumeric() || to.Kind == KindComplex) },
+			code:  func(from, to Info) string { return "if v {\n\tout = 1\n}" },
+			note:  "branch rather than converting, since a bool has no numeric value of its own",
+		},
+		{
+			name:    "format-bool",
+			match:   func(from, to Info) bool { return from.Kind == KindBool && to.Kind == KindString },
+			code:    func(from, to Info) string { return "out = strconv.FormatBool(v)" },
+			imports: []string{"strconv"},
+			note:    "formats true or false",
+		},
+		{
+			name:  "parse-int",
+			match: func(from, to Info) bool { return from.Kind == KindString && to.Kind == KindInt },
+			code: func(from, to Info) string {
+				return fmt.Sprintf("n, err := strconv.ParseInt(v, 10, %d)\nout = %s(n)", to.Bits, to.Name)
+			},
+			imports: []string{"strconv"},
+			note:    "parses the digits, failing on anything which isn't a number that fits",
+		},
+		{
+			name:  "parse-uint",
+			match: func(from, to Info) bool { return from.Kind == KindString && to.Kind == KindUint },
+			code: func(from, to Info) string {
+				return fmt.Sprintf("n, err := strconv.ParseUint(v, 10, %d)\nout = %s(n)", to.Bits, to.Name)
+			},
+			imports: []string{"strconv"},
+			note:    "parses the digits, failing on anything which isn't a number that fits",
+		},
+		{
+			name:  "parse-float",
+			match: func(from, to Info) bool { return from.Kind == KindString && to.Kind == KindFloat },
+			code: func(from, to Info) string {
+				return fmt.Sprintf("f, err := strconv.ParseFloat(v, %d)\nout = %s(f)", to.Bits, to.Name)
+			},
+			imports: []string{"strconv"},
+			note:    "parses the number, failing on anything which isn't one",
+		},
+		{
+			name:  "parse-complex",
+			match: func(from, to Info) bool { return from.Kind == KindString && to.Kind == KindComplex },
+			code: func(from, to Info) string {
+				return fmt.Sprintf("c, err := strconv.ParseComplex(v, %d)\nout = %s(c)", to.Bits, to.Name)
+			},
+			imports: []string{"strconv"},
+			note:    "parses the number, e.g. (1+2i), failing on anything which isn't one",
+		},
+		{
+			name:  "format-float",
+			match: func(from, to Info) bool { return from.Kind == KindFloat && to.Kind == KindString },
+			code: func(from, to Info) string {
+				return fmt.Sprintf("out = strconv.FormatFloat(float64(v), 'g', -1, %d)", from.Bits)
+			},
+			imports: []string{"strconv"},
+			note:    "formats the fewest digits which parse back to the same value",
+		},
+		{
+			name:    "sprintf",
+			match:   func(from, to Info) bool { return from.Kind == KindComplex && to.Kind == KindString },
+			code:    func(from, to Info) string { return `out = fmt.Sprintf("%g", v)` },
+			imports: []string{"fmt"},
+			note:    "formats both parts, e.g. (1+2i)",
+		},
+		{
+			name:  "round-real",
+			match: func(from, to Info) bool { return from.Kind == KindComplex && to.IsInteger() },
+			code: func(from, to Info) string {
+				return fmt.Sprintf("out = %s(math.Round(float64(real(v))))", to.Name)
+			},
+			imports: []string{"math"},
+			note:    "drops the imaginary part and rounds the real part, which may still not fit, see the helpers command",
+		},
+		{
+			name:  "real",
+			match: func(from, to Info) bool { return from.Kind == KindComplex && to.Kind == KindFloat },
+			code:  func(from, to Info) string { return fmt.Sprintf("out = %s(real(v))", to.Name) },
+			note:  "drops the imaginary part",
+		},
+		{
+			name:  "complex",
+			match: func(from, to Info) bool { return from.IsNumeric() && to.Kind == KindComplex },
+			code: func(from, to Info) string {
+				return fmt.Sprintf("out = %s(complex(float64(v), 0))", to.Name)
+			},
+			note: "makes v the real part, with an imaginary part of zero",
+		},
+	}
+)
+
+// Suggest returns the Suggestion for converting a value of the primitive from to the primitive
+// to, which isn't legal, by the first rule in the rules table which matches them, reporting false
+// when none does, e.g. for a conversion which is legal.
+func Suggest(from, to string) (Suggestion, bool) {
+	fromInfo, fromOK := Lookup(from)
+	toInfo, toOK := Lookup(to)
+	if !fromOK || !toOK {
+		return Suggestion{}, false
+	}
+	for _, rule := range suggestionRules {
+		if !rule.match(fromInfo, toInfo) {
+			continue
+		}
+		var s Suggestion
+		s.From = from
+		s.To = to
+		s.Rule = rule.name
+		s.Code = rule.code(fromInfo, toInfo)
+		s.Imports = rule.imports
+		s.Note = rule.note
+		return s, true
+	}
+	return Suggestion{}, false
+}
+
+// Suggestions returns the Suggestion for every Result of m which isn't convertible, in the order
+// of m.Results, leaving out those without one.
+func Suggestions(m Matrix) []Suggestion {
+	var ss []Suggestion
+	for _, result := range m.Results {
+		if result.Convertible {
+			continue
+		}
+		if s, ok := Suggest(result.From, result.To); ok {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
@@ -0,0 +1,85 @@
+package conversions
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+// TestSuggestions type checks every Suggestion Suggest makes for Primitives, so a rule which
+// stops compiling fails here rather than suggesting broken code.
+func TestSuggestions(t *testing.T) {
+	var ss []Suggestion
+	for _, from := range Primitives {
+		for _, to := range Primitives {
+			if s, ok := Suggest(from, to); ok {
+				ss = append(ss, s)
+			}
+		}
+	}
+	if len(ss) == 0 {
+		t.Fatal("no suggestions were made")
+	}
+
+	text, err := os.ReadFile(filepath.Join("testdata", "suggestions.tmpl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := template.New("suggestions.tmpl").Parse(string(text))
+	if err != nil {
+		t.Fatalf("parsing template: %v", err)
+	}
+	type Data struct {
+		Suggestions []Suggestion
+	}
+	var data Data
+	data.Suggestions = ss
+	var src bytes.Buffer
+	err = tmpl.Execute(&src, data)
+	if err != nil {
+		t.Fatalf("executing template: %v", err)
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "suggestions.go", src.Bytes(), parser.AllErrors)
+	if err != nil {
+		t.Fatalf("parsing suggestions: %v", err)
+	}
+	var conf types.Config
+	conf.Importer = importer.Default()
+	conf.Error = func(err error) {
+		t.Errorf("suggestion doesn't compile: %s", describeSuggestionError(f, ss, err))
+	}
+	_, _ = conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+}
+
+// describeSuggestionError describes err, reported type checking f, as an error in whichever of
+// ss it's in.
+func describeSuggestionError(f *ast.File, ss []Suggestion, err error) string {
+	te, ok := err.(types.Error)
+	if !ok {
+		return err.Error()
+	}
+	for _, decl := range f.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || te.Pos < fd.Pos() || te.Pos > fd.End() {
+			continue
+		}
+		i, convErr := strconv.Atoi(strings.TrimPrefix(fd.Name.Name, "suggestion"))
+		if convErr != nil || i >= len(ss) {
+			break
+		}
+		return fmt.Sprintf("%s -> %s (%s): %s", ss[i].From, ss[i].To, ss[i].Rule, te.Msg)
+	}
+	return te.Error()
+}
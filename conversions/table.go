@@ -0,0 +1,237 @@
+package conversions
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// FormatTable renders the Matrix as a grid with a row per from type and a column per to type,
+	// split into blocks of columns which fit the width of the terminal.
+	FormatTable = "table"
+
+	// tableGap separates the columns of a FormatTable report.
+	tableGap = "  "
+)
+
+type (
+	// TableReporter implements FormatTable. Matrices wider than Width are split into blocks of
+	// columns, like ps wraps its output, each repeating the row headers so every cell can still
+	// be read off against its from type.
+	TableReporter struct {
+		// Width is the most columns of text a line may take up. When it is 0 the COLUMNS
+		// environment variable is used, or failing that the width of the terminal being written
+		// to. A negative Width, or writing anywhere but a terminal, never splits the Matrix.
+		Width int
+	}
+
+	// tableRows renders the rows of a FormatTable report. Since the first block of columns needs
+	// every row before the next can start, rows are held until the report is closed.
+	tableRows struct {
+		w       io.Writer
+		width   int
+		types   []string
+		pivoted bool
+		catalog Catalog
+		verbal  bool
+		theme   Theme
+		froms   []string
+		cells   map[string]map[string]string
+		// classified are the Results with a Width or any Columns, listed after the grid.
+		classified []Result
+		// provenance is stamped after everything else, see Stamper.
+		provenance Provenance
+	}
+)
+
+// Render implements Reporter.
+func (r TableReporter) Render(ctx context.Context, m Matrix, w io.Writer) error {
+	return RenderRows(ctx, r, m, w)
+}
+
+// Rows implements RowReporter.
+func (r TableReporter) Rows(_ context.Context, types []string, w io.Writer) (RowWriter, error) {
+	var tr tableRows
+	tr.w = w
+	tr.width = r.Width
+	if tr.width == 0 {
+		tr.width = widthOf(w)
+	}
+	tr.types = types
+	tr.cells = make(map[string]map[string]string)
+	return &tr, nil
+}
+
+// Pivot implements Pivoter.
+func (tr *tableRows) Pivot() {
+	tr.pivoted = true
+}
+
+// Localize implements Localizer.
+func (tr *tableRows) Localize(c Catalog) {
+	tr.catalog = c
+}
+
+// Verbalize implements Verbalizer.
+func (tr *tableRows) Verbalize() {
+	tr.verbal = true
+}
+
+// Style implements Styler.
+func (tr *tableRows) Style(t Theme) {
+	tr.theme = t
+}
+
+// Stamp implements Stamper.
+func (tr *tableRows) Stamp(p Provenance) {
+	tr.provenance = p
+}
+
+// Row implements RowWriter.
+func (tr *tableRows) Row(_ context.Context, from string, row []Result) error {
+	cells := make(map[string]string, len(row))
+	for _, result := range row {
+		column := result.To
+		if tr.pivoted {
+			column = result.From
+		}
+		cells[column] = cell(result, tr.verbal, tr.theme)
+		if annotated(result) {
+			tr.classified = append(tr.classified, result)
+		}
+	}
+	tr.froms = append(tr.froms, from)
+	tr.cells[from] = cells
+	return nil
+}
+
+// Close implements RowWriter.
+func (tr *tableRows) Close() error {
+	headerWidth := displayWidth(corner(tr.catalog, tr.pivoted))
+	for _, from := range tr.froms {
+		if w := displayWidth(from); w > headerWidth {
+			headerWidth = w
+		}
+	}
+
+	var b strings.Builder
+	for i, block := range tr.blocks(headerWidth) {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(pad(corner(tr.catalog, tr.pivoted), headerWidth))
+		for _, to := range block {
+			b.WriteString(tableGap + pad(to, tr.columnWidth(to)))
+		}
+		b.WriteString("\n")
+		for _, from := range tr.froms {
+			b.WriteString(pad(from, headerWidth))
+			for _, to := range block {
+				// NOTE: Results filtered out of the row are left blank.
+				b.WriteString(tableGap + pad(tr.cells[from][to], tr.columnWidth(to)))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if len(tr.classified) > 0 {
+		columns := append([]string{widthColumn}, Classifiers()...)
+		b.WriteString("\n---------- " + tr.catalog.Message(MessageClassified, strings.Join(columns, ", ")) + " ----------\n")
+		for _, result := range tr.classified {
+			_, _ = fmt.Fprintf(&b, "%10s -> %-10s %s\n", result.From, result.To, describeAnnotations(result))
+		}
+	}
+	if tr.provenance != nil {
+		b.WriteString("\n" + stamp(tr.provenance, ""))
+	}
+
+	// NOTE: Padding is trimmed off the end of each line, it only makes them wrap sooner.
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	_, err := io.WriteString(tr.w, strings.Join(lines, "\n"))
+	return err
+}
+
+// blocks splits the columns of the report into blocks which, after row headers headerWidth
+// wide, fit in tr.width. Every block has at least one column, however narrow tr.width is.
+func (tr *tableRows) blocks(headerWidth int) [][]string {
+	if tr.width <= 0 {
+		return [][]string{tr.types}
+	}
+
+	var blocks [][]string
+	var block []string
+	used := headerWidth
+	for _, to := range tr.types {
+		w := displayWidth(tableGap) + tr.columnWidth(to)
+		if len(block) > 0 && used+w > tr.width {
+			blocks = append(blocks, block)
+			block = nil
+			used = headerWidth
+		}
+		block = append(block, to)
+		used += w
+	}
+	if len(block) > 0 || len(blocks) == 0 {
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// columnWidth is how wide the column of the type to is, wide enough for its name and a cell.
+func (tr *tableRows) columnWidth(to string) int {
+	theme := tr.theme.WithDefaults()
+	widest := theme.Yes
+	if displayWidth(theme.No) > displayWidth(widest) {
+		widest = theme.No
+	}
+	if tr.verbal {
+		widest = WordLossy
+	}
+	if w := displayWidth(to); w > displayWidth(widest) {
+		return w
+	}
+	return displayWidth(widest)
+}
+
+// pad pads s with spaces to width columns.
+func pad(s string, width int) string {
+	if n := width - displayWidth(s); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+	return s
+}
+
+// displayWidth is how many columns s takes up in a terminal, where the emoji cells, those of a
+// Theme included, and the CJK characters of localized headings, are two wide.
+func displayWidth(s string) int {
+	width := utf8.RuneCountInString(s)
+	width += strings.Count(s, "✅") + strings.Count(s, "❌")
+	for _, r := range s {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) || r >= 0x1f300 && r <= 0x1faff {
+			width++
+		}
+	}
+	return width
+}
+
+// widthOf is the width a FormatTable report written to w wraps at, from the COLUMNS environment
+// variable, or the size of the terminal w is, or 0 when neither is known.
+func widthOf(w io.Writer) int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return 0
+	}
+	return terminalWidth(f)
+}
@@ -0,0 +1,12 @@
+//go:build !(linux || darwin || freebsd)
+
+package conversions
+
+import (
+	"os"
+)
+
+// terminalWidth is 0 on platforms it can't be asked of, so only COLUMNS splits the report.
+func terminalWidth(_ *os.File) int {
+	return 0
+}
@@ -0,0 +1,21 @@
+//go:build linux || darwin || freebsd
+
+package conversions
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// terminalWidth is the number of columns of the terminal f is, or 0 when f isn't a terminal.
+func terminalWidth(f *os.File) int {
+	var size struct {
+		rows, cols, xpixel, ypixel uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size)))
+	if errno != 0 {
+		return 0
+	}
+	return int(size.cols)
+}
@@ -0,0 +1,94 @@
+package conversions
+
+import (
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+)
+
+const (
+	// PairSeparator separates the from and to types in the string form of a pair, e.g. int64->int32.
+	PairSeparator = "->"
+)
+
+type (
+	// TagRule selects which pairs a tag applies to. A tag applies to a pair when either of its
+	// types is one of Types, or when the pair itself is one of Pairs.
+	TagRule struct {
+		Types []string `json:"types"`
+		Pairs []string `json:"pairs"`
+	}
+
+	// Tags maps user-defined tag names, e.g. "api-boundary" or "db-layer", to the
+	// TagRule selecting which pairs they apply to.
+	Tags map[string]TagRule
+)
+
+// For returns the sorted names of every tag that applies to the pair from -> to.
+func (t Tags) For(from, to string) []string {
+	var tags []string
+	for name, rule := range t {
+		if rule.applies(from, to) {
+			tags = append(tags, name)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Names returns the sorted names of every tag.
+func (t Tags) Names() []string {
+	var names []string
+	for name := range t {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that every type and pair referenced by t is one of types.
+func (t Tags) Validate(types []string) error {
+	for _, name := range t.Names() {
+		rule := t[name]
+		for _, typ := range rule.Types {
+			if !contains(types, typ) {
+				return errors.Errorf("tag %q references unknown type %q", name, typ)
+			}
+		}
+		for _, pair := range rule.Pairs {
+			from, to, err := ParsePair(pair)
+			if err != nil {
+				return errors.Wrapf(err, "tag %q", name)
+			}
+			if !contains(types, from) || !contains(types, to) {
+				return errors.Errorf("tag %q references unknown pair %q", name, pair)
+			}
+		}
+	}
+	return nil
+}
+
+// ParsePair splits the string form of a pair, e.g. int64->int32, into its from and to types.
+func ParsePair(pair string) (string, string, error) {
+	pieces := strings.Split(pair, PairSeparator)
+	if len(pieces) != 2 {
+		return "", "", errors.Errorf("malformed pair %q, expected the form from%sto", pair, PairSeparator)
+	}
+	from := strings.TrimSpace(pieces[0])
+	to := strings.TrimSpace(pieces[1])
+	return from, to, nil
+}
+
+// applies reports whether r selects the pair from -> to.
+func (r TagRule) applies(from, to string) bool {
+	if contains(r.Types, from) || contains(r.Types, to) {
+		return true
+	}
+	for _, pair := range r.Pairs {
+		f, t, err := ParsePair(pair)
+		if err == nil && f == from && t == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package conversions
+
+import (
+	"path/filepath"
+	"sync"
+	"text/template"
+)
+
+type (
+	// cachedTemplate is a template parsed by parseCached, along with the text it was parsed from.
+	cachedTemplate struct {
+		text string
+		t    *template.Template
+		err  error
+	}
+)
+
+var (
+	// templatesMu guards templates.
+	templatesMu sync.Mutex
+	// templates are the templates parsed by parseCached, by name.
+	templates = map[string]cachedTemplate{}
+)
+
+// parseCached parses text as the template named name, unless it already has, in which case it
+// returns the same template as before, so the shards of an analysis, and every analysis after
+// it, only pay for parsing once. A template is executed without being changed, which is safe
+// to do concurrently. When text changes, e.g. an edited template file, it's parsed again. Any
+// check to run on a newly parsed template is check, whose error is cached along with it.
+func parseCached(name string, text string, check func(t *template.Template) error) (*template.Template, error) {
+	templatesMu.Lock()
+	defer templatesMu.Unlock()
+
+	if ct, ok := templates[name]; ok && ct.text == text {
+		return ct.t, ct.err
+	}
+
+	var ct cachedTemplate
+	ct.text = text
+	ct.t, ct.err = template.New(filepath.Base(name)).Parse(text)
+	if ct.err == nil && check != nil {
+		ct.err = check(ct.t)
+	}
+	if ct.err != nil {
+		ct.t = nil
+	}
+	templates[name] = ct
+	return ct.t, ct.err
+}
@@ -0,0 +1,36 @@
+package conversions
+
+import (
+	"testing"
+	"text/template"
+)
+
+// BenchmarkParseCached gets DefaultTemplate from parseCached once it has already been parsed, as
+// every shard of an analysis after the first does, and every analysis after that. parse is
+// parsing it afresh every time, for what that saves them.
+func BenchmarkParseCached(b *testing.B) {
+	b.Run("hit", func(b *testing.B) {
+		_, err := parseCached("conversions.tmpl", DefaultTemplate, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, err = parseCached("conversions.tmpl", DefaultTemplate, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("parse", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err := template.New("conversions.tmpl").Parse(DefaultTemplate)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,344 @@
+package conversions
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+type (
+	// TemplateLintError is returned when a template references data or functions outside what
+	// it is executed with, see TemplateData. It lists every problem found rather than just the
+	// first, as executing the template would.
+	TemplateLintError struct {
+		Problems []TemplateProblem
+	}
+
+	// TemplateProblem is a reference in a template to something it isn't given.
+	TemplateProblem struct {
+		// Location is where the reference is, as name:line:column.
+		Location string
+		// Message describes what's wrong with it.
+		Message string
+	}
+
+	// templateLinter follows the type of dot, and of every variable, through a template.
+	templateLinter struct {
+		t        *template.Template
+		tree     *parse.Tree
+		vars     []templateVar
+		linted   map[string]bool
+		problems []TemplateProblem
+	}
+
+	// templateVar is a variable declared in a template, along with its type, nil if unknown.
+	templateVar struct {
+		name string
+		typ  reflect.Type
+	}
+)
+
+var (
+	// templateDataType is the type of the data templates are executed with.
+	templateDataType = reflect.TypeOf(TemplateData{})
+	// stringType, intType, and boolType are the types of some of the values a template computes.
+	stringType = reflect.TypeOf("")
+	intType    = reflect.TypeOf(0)
+	boolType   = reflect.TypeOf(false)
+)
+
+// LintTemplate checks, without executing it, that t only references the fields of TemplateData
+// and the functions text/template provides, returning a *TemplateLintError locating every
+// reference that doesn't. Templates t invokes are checked against the data they're passed.
+// Anything whose type can't be known without executing t, e.g. what call returns, is assumed
+// to be fine.
+func LintTemplate(t *template.Template) error {
+	var l templateLinter
+	l.t = t
+	l.linted = make(map[string]bool)
+	l.lint(t.Name(), templateDataType)
+
+	// NOTE: Templates which are defined but never invoked are checked with nothing known about
+	// their data, which still catches unknown variables and fields of the data's fields.
+	var names []string
+	for _, associated := range t.Templates() {
+		names = append(names, associated.Name())
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		l.lint(name, nil)
+	}
+
+	if len(l.problems) == 0 {
+		return nil
+	}
+	var e TemplateLintError
+	e.Problems = l.problems
+	return &e
+}
+
+// Error implements error.
+func (e *TemplateLintError) Error() string {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "template references %d things it isn't given", len(e.Problems))
+	for _, p := range e.Problems {
+		_, _ = fmt.Fprintf(&b, "\n\t%s: %s", p.Location, p.Message)
+	}
+	return b.String()
+}
+
+// lint checks the template named name, executed with a dot of type dot, unless it has already
+// been checked.
+func (l *templateLinter) lint(name string, dot reflect.Type) {
+	t := l.t.Lookup(name)
+	if l.linted[name] || t == nil || t.Tree == nil || t.Tree.Root == nil {
+		return
+	}
+	l.linted[name] = true
+
+	tree, vars := l.tree, l.vars
+	l.tree = t.Tree
+	l.vars = []templateVar{{name: "$", typ: dot}}
+	l.walk(t.Tree.Root, dot)
+	l.tree, l.vars = tree, vars
+}
+
+// walk checks node, where dot is of type dot.
+func (l *templateLinter) walk(node parse.Node, dot reflect.Type) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			l.walk(child, dot)
+		}
+	case *parse.ActionNode:
+		l.declare(n.Pipe, l.pipe(n.Pipe, dot))
+	case *parse.IfNode:
+		scope := len(l.vars)
+		l.declare(n.Pipe, l.pipe(n.Pipe, dot))
+		l.walk(n.List, dot)
+		l.vars = l.vars[:scope]
+		l.walk(n.ElseList, dot)
+	case *parse.WithNode:
+		scope := len(l.vars)
+		typ := l.pipe(n.Pipe, dot)
+		l.declare(n.Pipe, typ)
+		l.walk(n.List, typ)
+		l.vars = l.vars[:scope]
+		l.walk(n.ElseList, dot)
+	case *parse.RangeNode:
+		scope := len(l.vars)
+		typ := l.pipe(n.Pipe, dot)
+		key, elem := rangeTypes(typ)
+		switch len(n.Pipe.Decl) {
+		case 1:
+			l.vars = append(l.vars, templateVar{name: n.Pipe.Decl[0].Ident[0], typ: elem})
+		case 2:
+			l.vars = append(l.vars, templateVar{name: n.Pipe.Decl[0].Ident[0], typ: key})
+			l.vars = append(l.vars, templateVar{name: n.Pipe.Decl[1].Ident[0], typ: elem})
+		}
+		l.walk(n.List, elem)
+		l.vars = l.vars[:scope]
+		l.walk(n.ElseList, dot)
+	case *parse.TemplateNode:
+		var typ reflect.Type
+		if n.Pipe != nil {
+			typ = l.pipe(n.Pipe, dot)
+		}
+		l.lint(n.Name, typ)
+	}
+}
+
+// declare records the variables pipe declares, as being of type typ.
+func (l *templateLinter) declare(pipe *parse.PipeNode, typ reflect.Type) {
+	if pipe == nil || pipe.IsAssign {
+		return
+	}
+	for _, v := range pipe.Decl {
+		l.vars = append(l.vars, templateVar{name: v.Ident[0], typ: typ})
+	}
+}
+
+// pipe checks pipe, returning the type it evaluates to, nil if unknown.
+func (l *templateLinter) pipe(pipe *parse.PipeNode, dot reflect.Type) reflect.Type {
+	if pipe == nil {
+		return nil
+	}
+	var typ reflect.Type
+	for _, cmd := range pipe.Cmds {
+		typ = l.command(cmd, dot)
+	}
+	return typ
+}
+
+// command checks cmd, returning the type it evaluates to, nil if unknown.
+func (l *templateLinter) command(cmd *parse.CommandNode, dot reflect.Type) reflect.Type {
+	if len(cmd.Args) == 0 {
+		return nil
+	}
+	var args []reflect.Type
+	for _, arg := range cmd.Args[1:] {
+		args = append(args, l.operand(arg, dot))
+	}
+	if ident, ok := cmd.Args[0].(*parse.IdentifierNode); ok {
+		return funcResult(ident.Ident, args)
+	}
+	return l.operand(cmd.Args[0], dot)
+}
+
+// operand checks the operand node, returning the type it evaluates to, nil if unknown.
+func (l *templateLinter) operand(node parse.Node, dot reflect.Type) reflect.Type {
+	switch n := node.(type) {
+	case *parse.DotNode:
+		return dot
+	case *parse.FieldNode:
+		return l.fields(n, dot, "", n.Ident)
+	case *parse.VariableNode:
+		return l.fields(n, l.lookup(n.Ident[0]), n.Ident[0], n.Ident[1:])
+	case *parse.ChainNode:
+		var typ reflect.Type
+		if pipe, ok := n.Node.(*parse.PipeNode); ok {
+			typ = l.pipe(pipe, dot)
+		} else {
+			typ = l.operand(n.Node, dot)
+		}
+		return l.fields(n, typ, "", n.Field)
+	case *parse.PipeNode:
+		return l.pipe(n, dot)
+	case *parse.IdentifierNode:
+		return funcResult(n.Ident, nil)
+	case *parse.StringNode:
+		return stringType
+	case *parse.BoolNode:
+		return boolType
+	default:
+		return nil
+	}
+}
+
+// fields checks that each of names selects a field of the one before, starting from a value of
+// type typ, recording a problem at node if one doesn't. prefix is what the selection starts
+// from, e.g. a variable, or "" for dot.
+func (l *templateLinter) fields(node parse.Node, typ reflect.Type, prefix string, names []string) reflect.Type {
+	selected := prefix
+	for _, name := range names {
+		if typ == nil {
+			return nil
+		}
+		for typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		switch typ.Kind() {
+		case reflect.Struct:
+			f, ok := typ.FieldByName(name)
+			if !ok || f.PkgPath != "" {
+				l.problem(node, "%s.%s isn't given to templates, %s has %s", selected, name, describeData(selected, typ), strings.Join(fieldNames(typ), ", "))
+				return nil
+			}
+			typ = f.Type
+		case reflect.Map:
+			typ = typ.Elem()
+		case reflect.Interface:
+			return nil
+		default:
+			l.problem(node, "%s.%s selects a field of a %s, which has none", selected, name, typ)
+			return nil
+		}
+		selected += "." + name
+	}
+	return typ
+}
+
+// lookup returns the type of the innermost variable named name, nil if unknown.
+func (l *templateLinter) lookup(name string) reflect.Type {
+	for i := len(l.vars) - 1; i >= 0; i-- {
+		if l.vars[i].name == name {
+			return l.vars[i].typ
+		}
+	}
+	// NOTE: The parser already rejects undefined variables.
+	return nil
+}
+
+// problem records a problem at node.
+func (l *templateLinter) problem(node parse.Node, format string, args ...interface{}) {
+	location, _ := l.tree.ErrorContext(node)
+	var p TemplateProblem
+	p.Location = location
+	p.Message = fmt.Sprintf(format, args...)
+	l.problems = append(l.problems, p)
+}
+
+// rangeTypes returns the types of the key and element ranging over a value of type typ yields,
+// nil if unknown.
+func rangeTypes(typ reflect.Type) (reflect.Type, reflect.Type) {
+	if typ == nil {
+		return nil, nil
+	}
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		return intType, typ.Elem()
+	case reflect.Map:
+		return typ.Key(), typ.Elem()
+	case reflect.Int:
+		return intType, intType
+	default:
+		return nil, nil
+	}
+}
+
+// funcResult returns the type the text/template function named name returns given arguments
+// of types args, nil if unknown.
+func funcResult(name string, args []reflect.Type) reflect.Type {
+	switch name {
+	case "print", "printf", "println", "html", "js", "urlquery":
+		return stringType
+	case "len":
+		return intType
+	case "not", "eq", "ne", "lt", "le", "gt", "ge":
+		return boolType
+	case "index":
+		if len(args) == 0 {
+			return nil
+		}
+		typ := args[0]
+		for range args[1:] {
+			_, typ = rangeTypes(typ)
+		}
+		return typ
+	case "slice":
+		if len(args) > 0 {
+			return args[0]
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// fieldNames lists the exported fields of the struct type typ.
+func fieldNames(typ reflect.Type) []string {
+	var names []string
+	for i := 0; i < typ.NumField(); i++ {
+		if f := typ.Field(i); f.PkgPath == "" {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+// describeData names the value of struct type typ selected by selected.
+func describeData(selected string, typ reflect.Type) string {
+	if typ == templateDataType {
+		return "the data"
+	}
+	if selected == "" {
+		return "dot"
+	}
+	return selected
+}
@@ -0,0 +1,200 @@
+package conversions
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+const (
+	// TemplateSchema is the version of TemplateData templates are executed with. A template
+	// declares the version it was written against with a comment, {{/* schema: 2 */}}, and one
+	// written against an older version is adapted to this one, see MigrateTemplate.
+	TemplateSchema = 2
+)
+
+type (
+	// TemplateRename is a field of TemplateData which a version of it renamed.
+	TemplateRename struct {
+		// Schema is the TemplateSchema which renamed From to To.
+		Schema int
+		From   string
+		To     string
+		// Reason is why it was renamed, and which references to From became To.
+		Reason string
+	}
+
+	// TemplateMigrationError is returned for a template written against an older TemplateSchema
+	// which can't be adapted to the current one, listing every reference to a field since renamed.
+	TemplateMigrationError struct {
+		// Schema is the TemplateSchema the template was written against.
+		Schema   int
+		Problems []TemplateProblem
+	}
+
+	// templateMigrator renames the ranges over Primitives of a schema 1 template.
+	templateMigrator struct {
+		tree *parse.Tree
+		// sources and targets are how many ranges have been renamed to either.
+		sources int
+		targets int
+		// unrenamed are the ranges over Primitives which weren't renamed.
+		unrenamed []TemplateProblem
+	}
+)
+
+var (
+	// TemplateRenames are the fields of TemplateData renamed by every TemplateSchema, oldest first.
+	TemplateRenames = []TemplateRename{
+		{Schema: 2, From: "Primitives", To: "Sources", Reason: "ranged over for the types converted from, since each shard only converts from some of them"},
+		{Schema: 2, From: "Primitives", To: "Targets", Reason: "ranged over for the types converted to, since a cached analysis only converts to the types it hasn't checked"},
+	}
+
+	// templateSchemaRegexp matches the comment a template declares its TemplateSchema with.
+	templateSchemaRegexp = regexp.MustCompile(`\{\{-?\s*/\*\s*schema:\s*(\d+)\s*\*/\s*-?\}\}`)
+	// templateFieldsAdded are the fields of TemplateData added by every TemplateSchema after the first.
+	templateFieldsAdded = map[int][]string{
+		2: {"Sources", "Targets"},
+	}
+)
+
+// TemplateSchemaOf returns the TemplateSchema the template text was written against, as it
+// declares with a comment, e.g. {{/* schema: 2 */}}. A template without one is taken to have been
+// written against the newest TemplateSchema whose fields it references, or the first when it
+// references none of them. It is an error to declare a TemplateSchema newer than this one.
+func TemplateSchemaOf(text string) (int, error) {
+	if match := templateSchemaRegexp.FindStringSubmatch(text); match != nil {
+		schema, err := strconv.Atoi(match[1])
+		if err != nil || schema < 1 || schema > TemplateSchema {
+			return 0, errors.Errorf("template declares schema %s, expected 1 to %d", match[1], TemplateSchema)
+		}
+		return schema, nil
+	}
+
+	for schema := TemplateSchema; schema > 1; schema-- {
+		for _, field := range templateFieldsAdded[schema] {
+			if regexp.MustCompile(`\.` + field + `\b`).MatchString(text) {
+				return schema, nil
+			}
+		}
+	}
+	return 1, nil
+}
+
+// MigrateTemplate adapts t, written against schema, to the current TemplateSchema by renaming
+// its references to fields renamed since, returning which of TemplateRenames it applied. A
+// template written against schema 1 ranges over Primitives for the types converted from and,
+// within that, for the types converted to, which become Sources and Targets. When t can't be
+// adapted, e.g. it ranges over Primitives only once, it returns a *TemplateMigrationError
+// locating every reference to rename by hand.
+func MigrateTemplate(t *template.Template, schema int) ([]TemplateRename, error) {
+	if schema >= TemplateSchema {
+		return nil, nil
+	}
+
+	var names []string
+	for _, associated := range t.Templates() {
+		names = append(names, associated.Name())
+	}
+	sort.Strings(names)
+
+	var m templateMigrator
+	for _, name := range names {
+		associated := t.Lookup(name)
+		if associated == nil || associated.Tree == nil || associated.Tree.Root == nil {
+			continue
+		}
+		m.tree = associated.Tree
+		m.walk(associated.Tree.Root, nil)
+	}
+
+	if m.sources > 0 && m.targets > 0 {
+		return TemplateRenames, nil
+	}
+	if len(m.unrenamed) == 0 {
+		return nil, nil
+	}
+	var e TemplateMigrationError
+	e.Schema = schema
+	e.Problems = m.unrenamed
+	return nil, &e
+}
+
+// Error implements error.
+func (e *TemplateMigrationError) Error() string {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "template was written against schema %d, and references fields renamed by schema %d", e.Schema, TemplateSchema)
+	for _, p := range e.Problems {
+		_, _ = fmt.Fprintf(&b, "\n\t%s: %s", p.Location, p.Message)
+	}
+	return b.String()
+}
+
+// walk renames every range over Primitives within node which is nested in another, outer, to
+// Targets, and the range it's nested in to Sources.
+func (m *templateMigrator) walk(node parse.Node, outer *parse.RangeNode) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			m.walk(child, outer)
+		}
+	case *parse.IfNode:
+		m.walk(n.List, outer)
+		m.walk(n.ElseList, outer)
+	case *parse.WithNode:
+		m.walk(n.List, outer)
+		m.walk(n.ElseList, outer)
+	case *parse.RangeNode:
+		field := primitivesField(n.Pipe)
+		switch {
+		case field == nil:
+			m.walk(n.List, outer)
+		case outer != nil:
+			field[len(field)-1] = "Targets"
+			m.targets++
+			m.walk(n.List, outer)
+		default:
+			targets := m.targets
+			m.walk(n.List, n)
+			if m.targets > targets {
+				outerField := primitivesField(n.Pipe)
+				outerField[len(outerField)-1] = "Sources"
+				m.sources++
+			} else {
+				location, _ := m.tree.ErrorContext(n)
+				var p TemplateProblem
+				p.Location = location
+				p.Message = "Primitives is every type analyzed, range over Sources for the types converted from and Targets for those converted to"
+				m.unrenamed = append(m.unrenamed, p)
+			}
+		}
+		m.walk(n.ElseList, outer)
+	}
+}
+
+// primitivesField returns the identifiers of the field pipe ranges over when it's Primitives of
+// the data, .Primitives or $.Primitives, or nil when it's anything else.
+func primitivesField(pipe *parse.PipeNode) []string {
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return nil
+	}
+	switch arg := pipe.Cmds[0].Args[0].(type) {
+	case *parse.FieldNode:
+		if len(arg.Ident) == 1 && arg.Ident[0] == "Primitives" {
+			return arg.Ident
+		}
+	case *parse.VariableNode:
+		if len(arg.Ident) == 2 && arg.Ident[0] == "$" && arg.Ident[1] == "Primitives" {
+			return arg.Ident
+		}
+	}
+	return nil
+}
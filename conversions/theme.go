@@ -0,0 +1,155 @@
+package conversions
+
+import (
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// ThemeDefault marks pairs with emoji, and leaves their color to them.
+	ThemeDefault = "default"
+	// ThemeColorblind marks pairs with shapes told apart without color, colored from the
+	// Okabe-Ito palette, which those with any common color vision deficiency can tell apart.
+	ThemeColorblind = "colorblind"
+	// ThemeASCII marks pairs with plain ASCII, for terminals and fonts without emoji.
+	ThemeASCII = "ascii"
+)
+
+type (
+	// Theme is how the cells of a report mark each pair: the symbol, and in HTML the color, of a
+	// pair which always preserves the value, one which compiles but may change it, and one which
+	// doesn't compile. Reports which only mark whether a pair compiles use Yes and No. Anything
+	// left empty is taken from ThemeDefault.
+	Theme struct {
+		Yes   string `json:"yes"`
+		Lossy string `json:"lossy"`
+		No    string `json:"no"`
+		// YesColor, LossyColor, and NoColor are CSS colors, e.g. #0072b2, left to the symbol when empty.
+		YesColor   string `json:"yesColor"`
+		LossyColor string `json:"lossyColor"`
+		NoColor    string `json:"noColor"`
+	}
+
+	// Styler is implemented by RowWriters which can mark pairs with a Theme other than
+	// ThemeDefault, see Matrix.Theme. Style is called, if at all, before any rows are written.
+	Styler interface {
+		Style(t Theme)
+	}
+)
+
+var (
+	// themesMu guards themes.
+	themesMu sync.RWMutex
+	// themes are the registered themes, by name.
+	themes = map[string]Theme{
+		ThemeDefault: {
+			Yes:   "✅",
+			Lossy: "⚠️",
+			No:    "❌",
+		},
+		ThemeColorblind: {
+			Yes:        "✔",
+			Lossy:      "▲",
+			No:         "✖",
+			YesColor:   "#0072b2",
+			LossyColor: "#e69f00",
+			NoColor:    "#d55e00",
+		},
+		ThemeASCII: {
+			Yes:   "Y",
+			Lossy: "~",
+			No:    "N",
+		},
+	}
+)
+
+// RegisterTheme makes t available as name to LookupTheme, for themes which aren't built in. It
+// is an error to register a name twice.
+func RegisterTheme(name string, t Theme) error {
+	themesMu.Lock()
+	defer themesMu.Unlock()
+
+	if name == "" {
+		return errors.New("theme name must not be empty")
+	}
+	if _, ok := themes[name]; ok {
+		return errors.Errorf("theme %q is already registered", name)
+	}
+	themes[name] = t
+	return nil
+}
+
+// LookupTheme returns the Theme registered as name, or ThemeDefault when name is empty, with
+// anything it leaves empty taken from ThemeDefault.
+func LookupTheme(name string) (Theme, error) {
+	themesMu.RLock()
+	defer themesMu.RUnlock()
+
+	if name == "" {
+		name = ThemeDefault
+	}
+	t, ok := themes[name]
+	if !ok {
+		var names []string
+		for n := range themes {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return Theme{}, errors.Errorf("unknown theme %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	return t.withDefaults(themes[ThemeDefault]), nil
+}
+
+// WithDefaults returns a copy of t with every symbol it leaves empty taken from ThemeDefault.
+func (t Theme) WithDefaults() Theme {
+	themesMu.RLock()
+	defer themesMu.RUnlock()
+
+	return t.withDefaults(themes[ThemeDefault])
+}
+
+// withDefaults returns a copy of t with every symbol it leaves empty taken from d.
+func (t Theme) withDefaults(d Theme) Theme {
+	if t.Yes == "" {
+		t.Yes = d.Yes
+	}
+	if t.Lossy == "" {
+		t.Lossy = d.Lossy
+	}
+	if t.No == "" {
+		t.No = d.No
+	}
+	return t
+}
+
+// Symbol marks result with Yes when it always preserves the value, Lossy when it compiles but
+// may change it, and No when it doesn't compile, see Word.
+func (t Theme) Symbol(result Result) string {
+	switch Word(result) {
+	case WordYes:
+		return t.Yes
+	case WordLossy:
+		return t.Lossy
+	default:
+		return t.No
+	}
+}
+
+// Compiles marks result with Yes when it compiles and No when it doesn't.
+func (t Theme) Compiles(result Result) string {
+	if result.Convertible {
+		return t.Yes
+	}
+	return t.No
+}
+
+// Legend is legend, a MessageLegend in any locale, with ThemeDefault's symbols replaced by t's.
+func (t Theme) Legend(legend string) string {
+	themesMu.RLock()
+	d := themes[ThemeDefault]
+	themesMu.RUnlock()
+
+	return strings.NewReplacer(d.Yes, t.Yes, d.Lossy, t.Lossy, d.No, t.No).Replace(legend)
+}
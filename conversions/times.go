@@ -0,0 +1,69 @@
+package conversions
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+type (
+	// TimeAdvisory is a conversion involving time which compiles, and usually works, but silently
+	// gets the unit wrong or overflows past some date or duration.
+	TimeAdvisory struct {
+		From string
+		To   string
+		// Risk is what goes wrong, and past which limit.
+		Risk string
+		// Helper is the function the helpers command generates to do the conversion with an
+		// explicit unit, and check it.
+		Helper string
+	}
+)
+
+// TimeAdvisories lists the conversions between time.Duration, time.Time, and the integers and
+// floats they're stored as which are easy to get wrong. Every limit is worked out from the time
+// package itself rather than written down.
+func TimeAdvisories() []TimeAdvisory {
+	const layout = "2006-01-02T15:04:05Z"
+	maxDuration := time.Duration(math.MaxInt64)
+	maxSeconds := int64(maxDuration / time.Second)
+	exactNanoseconds := time.Duration(1 << 53)
+	millis := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+
+	var advisories []TimeAdvisory
+	add := func(from, to, helper, risk string, args ...interface{}) {
+		var a TimeAdvisory
+		a.From = from
+		a.To = to
+		a.Risk = fmt.Sprintf(risk, args...)
+		a.Helper = helper
+		advisories = append(advisories, a)
+	}
+
+	add("time.Duration", "int64", "DurationToInt64",
+		"int64(d) is a number of nanoseconds, not the seconds or milliseconds an int64 field usually holds, "+
+			"and d / time.Millisecond quietly drops any fraction of a millisecond")
+	add("int64", "time.Duration", "Int64ToDuration",
+		"time.Duration(n) reads n as nanoseconds, and time.Duration(n) * time.Second wraps around without "+
+			"a word once n passes %d seconds (%s)", maxSeconds, maxDuration)
+	add("float64", "time.Duration", "Float64ToDuration",
+		"time.Duration(f) reads f as nanoseconds and truncates it, and time.Duration(f * float64(time.Second)) "+
+			"is implementation defined for NaN, the infinities, and anything past %s", maxDuration)
+	add("time.Duration", "float64", "DurationToFloat64",
+		"float64(d) is only exact up to %s (2^53 nanoseconds), beyond which it, and d.Seconds(), start "+
+			"losing nanoseconds", exactNanoseconds)
+	add("int64", "int32", "TimeToUnix32",
+		"a Unix timestamp in seconds only fits an int32 from %s to %s, an int32 column or field overflows "+
+			"in 2038, and a uint32 one, which lasts until %s, can't hold anything before 1970",
+		time.Unix(math.MinInt32, 0).UTC().Format(layout), time.Unix(math.MaxInt32, 0).UTC().Format(layout),
+		time.Unix(math.MaxUint32, 0).UTC().Format(layout))
+	add("time.Time", "int64", "TimeToUnix",
+		"t.UnixNano() is undefined outside %s to %s, and an int64 that's a Unix timestamp says nothing of "+
+			"whether it's seconds, milliseconds, or nanoseconds",
+		time.Unix(0, math.MinInt64).UTC().Format(layout), time.Unix(0, math.MaxInt64).UTC().Format(layout))
+	add("int64", "time.Time", "UnixToTime",
+		"time.Unix(v, 0) with v in milliseconds, e.g. %d for the start of 2024, lands in the year %d",
+		millis, time.Unix(millis, 0).UTC().Year())
+
+	return advisories
+}
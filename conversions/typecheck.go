@@ -0,0 +1,63 @@
+package conversions
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// TypeCheck type checks the generated go code in src entirely in memory using go/types,
+// rather than shelling out to the go compiler. Just like Compile, it expects the code to
+// fail type checking and records the conversion errors into a ConversionFailures.
+// filename is only used for positions in error messages. With opts.Strict set, any
+// error that isn't a conversion failure is returned as a *DiagnosticError instead.
+func TypeCheck(_ context.Context, opts Options, filename string, src []byte) (ConversionFailures, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.AllErrors)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing generated code %q", filename)
+	}
+
+	var msgs []string
+	var conf types.Config
+	conf.Importer = importer.Default()
+	if opts.GOARCH != "" {
+		conf.Sizes = types.SizesFor("gc", opts.GOARCH)
+	}
+	conf.GoVersion = opts.LangVersion
+	conf.Error = func(err error) {
+		msgs = append(msgs, err.Error())
+	}
+
+	// NOTE: The returned error is only the first of the errors already collected by conf.Error,
+	// and we expect there to be plenty of those.
+	_, _ = conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+
+	output := strings.Join(msgs, "\n")
+	cfs, unparsed := ParseFailures(output)
+	err = saveDebugOutput(opts, filename, -1, output, cfs, unparsed)
+	if err != nil {
+		return nil, err
+	}
+	err = unrecognizedPhrasing(unparsed)
+	if err != nil {
+		return nil, errors.Wrapf(err, "type checking %q", filename)
+	}
+	if opts.Strict && len(unparsed) > 0 {
+		var de DiagnosticError
+		de.File = filename
+		de.ExitCode = -1
+		de.Output = output
+		de.Unparsed = unparsed
+		de.Reason = fmt.Sprintf("%d type checking errors could not be parsed", len(unparsed))
+		return nil, &de
+	}
+
+	return cfs, nil
+}
@@ -0,0 +1,157 @@
+package conversions
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+)
+
+type (
+	// TypeData is what go/types knows about one of a Matrix's types, for tools built on its json
+	// report, see Detailer.
+	TypeData struct {
+		Name string
+		// Kind is the Kind of a primitive, e.g. int or float, or what any other type is, e.g.
+		// array, slice, pointer, or struct. It's empty for a type go/types can't make sense of.
+		Kind string
+		// Bits is the size of the type in bits, 0 when it depends on the platform, as it does for
+		// int, or the type isn't a primitive.
+		Bits int
+		// Signed is whether the type is a signed integer, a float, or a complex number.
+		Signed bool
+		// Underlying is the type the type is defined as, the same as Name unless it's a defined type.
+		Underlying string
+		// Alias is whether Name is an alias, e.g. byte, and AliasOf the type it's an alias for.
+		Alias   bool
+		AliasOf string `json:",omitempty"`
+		// Package is the path of the package the type is declared in, empty when it's predeclared.
+		Package string `json:",omitempty"`
+	}
+
+	// Detailer is implemented by RowWriters which can follow the Matrix with the TypeData of each
+	// of its types, see Matrix.Verbose. Detail is called, if at all, before any rows are written.
+	Detailer interface {
+		Detail()
+	}
+)
+
+var (
+	// typePackages are the paths of the packages types are qualified with by a name other than
+	// the last element of their path.
+	typePackages = map[string]string{
+		"big": "math/big",
+	}
+)
+
+// DescribeTypes returns the TypeData of each of types, in the same order.
+func DescribeTypes(types []string) []TypeData {
+	tds := make([]TypeData, 0, len(types))
+	for _, name := range types {
+		tds = append(tds, DescribeType(name))
+	}
+	return tds
+}
+
+// DescribeType returns the TypeData of the type written as name, e.g. int32, [4]byte, or
+// *big.Int. A type go/types can't make sense of is only given its Name.
+func DescribeType(name string) TypeData {
+	var td TypeData
+	td.Name = name
+	t, ok := evalType(name)
+	if !ok {
+		return td
+	}
+
+	td.Underlying = types.TypeString(t.Underlying(), func(p *types.Package) string { return p.Name() })
+	td.Kind = kindOf(t)
+	if basic, ok := t.Underlying().(*types.Basic); ok {
+		// NOTE: A defined type, e.g. time.Duration, has the size and Kind of the primitive it's defined as.
+		if info, ok := Lookup(basic.Name()); ok {
+			td.Kind = string(info.Kind)
+			td.Bits = info.Bits
+		}
+		td.Signed = basic.Info()&types.IsNumeric != 0 && basic.Info()&types.IsUnsigned == 0
+	}
+	if info, ok := Lookup(name); ok && info.AliasOf != "" {
+		td.Alias = true
+		td.AliasOf = info.AliasOf
+		td.Underlying = info.AliasOf
+	}
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil {
+		td.Package = named.Obj().Pkg().Path()
+	}
+	if ptr, ok := t.(*types.Pointer); ok {
+		if named, ok := ptr.Elem().(*types.Named); ok && named.Obj().Pkg() != nil {
+			td.Package = named.Obj().Pkg().Path()
+		}
+	}
+	return td
+}
+
+// evalType type checks the type written as name, importing any package it's qualified by.
+func evalType(name string) (types.Type, bool) {
+	expr, err := parser.ParseExpr(name)
+	if err != nil {
+		return nil, false
+	}
+	pkg := types.NewPackage("main", "main")
+	imp := importer.Default()
+	ok := true
+	ast.Inspect(expr, func(n ast.Node) bool {
+		sel, isSel := n.(*ast.SelectorExpr)
+		if !isSel {
+			return true
+		}
+		x, isIdent := sel.X.(*ast.Ident)
+		if !isIdent || pkg.Scope().Lookup(x.Name) != nil {
+			return true
+		}
+		path, known := typePackages[x.Name]
+		if !known {
+			path = x.Name
+		}
+		imported, err := imp.Import(path)
+		if err != nil {
+			ok = false
+			return false
+		}
+		pkg.Scope().Insert(types.NewPkgName(token.NoPos, pkg, x.Name, imported))
+		return true
+	})
+	if !ok {
+		return nil, false
+	}
+	tv, err := types.Eval(token.NewFileSet(), pkg, token.NoPos, name)
+	if err != nil || !tv.IsType() {
+		return nil, false
+	}
+	return tv.Type, true
+}
+
+// kindOf is what t is, e.g. array or pointer, when it isn't defined as a primitive.
+func kindOf(t types.Type) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return u.Name()
+	case *types.Array:
+		return "array"
+	case *types.Slice:
+		return "slice"
+	case *types.Pointer:
+		return "pointer"
+	case *types.Map:
+		return "map"
+	case *types.Chan:
+		return "chan"
+	case *types.Struct:
+		return "struct"
+	case *types.Signature:
+		return "func"
+	case *types.Interface:
+		return "interface"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,252 @@
+package conversions
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// UsagesColumn is the column UsagesClassifier fills in, see RegisterClassifier.
+	UsagesColumn = "usages"
+)
+
+type (
+	// Usage is a conversion between primitives found in real code, e.g. int64(n) where n is an
+	// int in some open-source repo.
+	Usage struct {
+		From string
+		To   string
+		// File is the file the conversion is in, relative to the corpus, with forward slashes.
+		File string
+		Line int
+		// Snippet is the line the conversion is on, without its indentation.
+		Snippet string
+	}
+
+	// usagePackage is the files of a package in a single directory of the corpus.
+	usagePackage struct {
+		name  string
+		files []*ast.File
+	}
+)
+
+// FindUsages type checks the Go code under dir, a corpus such as a directory of checked out
+// open-source repos, and returns every conversion it makes between two different types among
+// names, sorted by pair, then file and line. Conversions of untyped constants, e.g. int64(1), are
+// left out, as are directories the go command ignores: testdata, vendor, and those starting with
+// . or _. Each package is type checked on its own, against the standard library only, so a
+// conversion of a value whose type comes from another module can't be told apart and is left
+// out too.
+func FindUsages(ctx context.Context, dir string, names []string) ([]Usage, error) {
+	wanted := make(map[string]bool)
+	for _, t := range names {
+		wanted[t] = true
+	}
+
+	fset := token.NewFileSet()
+	packages := make(map[string]*usagePackage)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != dir && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") {
+			return nil
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			// NOTE: Code which doesn't parse, e.g. written for a newer version of Go, is skipped
+			// rather than failing the whole corpus.
+			return nil
+		}
+		key := filepath.Dir(path) + " " + f.Name.Name
+		p, ok := packages[key]
+		if !ok {
+			p = new(usagePackage)
+			p.name = f.Name.Name
+			packages[key] = p
+		}
+		p.files = append(p.files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "walking %q", dir)
+	}
+
+	keys := make([]string, 0, len(packages))
+	for key := range packages {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var conf types.Config
+	// NOTE: One importer is shared by every package, so the standard library is only loaded once.
+	conf.Importer = importer.Default()
+	conf.Error = func(error) {}
+	var usages []Usage
+	for _, key := range keys {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		p := packages[key]
+		var info types.Info
+		info.Types = make(map[ast.Expr]types.TypeAndValue)
+		_, _ = conf.Check(p.name, fset, p.files, &info)
+		for _, f := range p.files {
+			usages = append(usages, fileUsages(fset, dir, f, &info, wanted)...)
+		}
+	}
+
+	sort.SliceStable(usages, func(i, j int) bool {
+		if usages[i].From != usages[j].From {
+			return usages[i].From < usages[j].From
+		}
+		if usages[i].To != usages[j].To {
+			return usages[i].To < usages[j].To
+		}
+		if usages[i].File != usages[j].File {
+			return usages[i].File < usages[j].File
+		}
+		return usages[i].Line < usages[j].Line
+	})
+	return usages, nil
+}
+
+// fileUsages returns every conversion between two different types in wanted that f, parsed from
+// under dir, makes according to info.
+func fileUsages(fset *token.FileSet, dir string, f *ast.File, info *types.Info, wanted map[string]bool) []Usage {
+	var usages []Usage
+	var lines []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 || call.Ellipsis.IsValid() {
+			return true
+		}
+		fun, ok := info.Types[call.Fun]
+		if !ok || !fun.IsType() {
+			return true
+		}
+		arg, ok := info.Types[call.Args[0]]
+		if !ok || arg.Type == nil {
+			return true
+		}
+		to, toOK := fun.Type.(*types.Basic)
+		from, fromOK := arg.Type.(*types.Basic)
+		if !toOK || !fromOK || from.Info()&types.IsUntyped != 0 {
+			return true
+		}
+		if from.Name() == to.Name() || !wanted[from.Name()] || !wanted[to.Name()] {
+			return true
+		}
+
+		position := fset.Position(call.Pos())
+		var u Usage
+		u.From = from.Name()
+		u.To = to.Name()
+		u.File = position.Filename
+		if rel, err := filepath.Rel(dir, position.Filename); err == nil {
+			u.File = filepath.ToSlash(rel)
+		}
+		u.Line = position.Line
+		if lines == nil {
+			lines = sourceLines(fset, f)
+		}
+		if position.Line <= len(lines) {
+			u.Snippet = strings.TrimSpace(lines[position.Line-1])
+		}
+		usages = append(usages, u)
+		return true
+	})
+	return usages
+}
+
+// sourceLines returns the lines of the file f was parsed from, or none when it can't be read.
+func sourceLines(fset *token.FileSet, f *ast.File) []string {
+	b, err := os.ReadFile(fset.Position(f.Package).Filename)
+	if err != nil {
+		return []string{}
+	}
+	return strings.Split(string(b), "\n")
+}
+
+// RepresentativeUsages returns at most n of usages converting from to to, picking one from each
+// repo, the first directory of the corpus, before picking a second from any, so they show how
+// the conversion is used across the corpus rather than in a single repo.
+func RepresentativeUsages(usages []Usage, from, to string, n int) []Usage {
+	var repos []string
+	byRepo := make(map[string][]Usage)
+	for _, u := range usages {
+		if u.From != from || u.To != to {
+			continue
+		}
+		repo := strings.SplitN(u.File, "/", 2)[0]
+		if _, ok := byRepo[repo]; !ok {
+			repos = append(repos, repo)
+		}
+		byRepo[repo] = append(byRepo[repo], u)
+	}
+
+	var picked []Usage
+	for round := 0; len(picked) < n; round++ {
+		more := false
+		for _, repo := range repos {
+			if len(picked) >= n || round >= len(byRepo[repo]) {
+				continue
+			}
+			picked = append(picked, byRepo[repo][round])
+			more = true
+		}
+		if !more {
+			break
+		}
+	}
+
+	sort.SliceStable(picked, func(i, j int) bool {
+		if picked[i].File != picked[j].File {
+			return picked[i].File < picked[j].File
+		}
+		return picked[i].Line < picked[j].Line
+	})
+	return picked
+}
+
+// UsagesClassifier returns a ClassifierFunc filling in UsagesColumn with how many times each
+// pair is made among usages, e.g. "12 in the corpus", so every report shows how common each
+// conversion is in real code:
+//
+//	usages, err := conversions.FindUsages(ctx, dir, opts.Types)
+//	...
+//	err = conversions.RegisterClassifier(conversions.UsagesColumn, conversions.UsagesClassifier(usages))
+func UsagesClassifier(usages []Usage) ClassifierFunc {
+	counts := make(map[string]int)
+	for _, u := range usages {
+		counts[u.From+PairSeparator+u.To]++
+	}
+	return func(p Pair) string {
+		count := counts[p.From+PairSeparator+p.To]
+		if count == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%d in the corpus", count)
+	}
+}
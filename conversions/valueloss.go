@@ -0,0 +1,116 @@
+package conversions
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type (
+	// ValueLoss is what converting a single Value of From to To resulted in when it was run.
+	ValueLoss struct {
+		From  string
+		Value string
+		To    string
+		// Result is the converted value, formatted with fmt.
+		Result string
+		// Exact is whether Result is numerically the same as Value, or both are NaN.
+		Exact bool
+	}
+)
+
+var (
+	// ValuesTemplate is the template the value loss probe program is generated from.
+	//go:embed template/values.tmpl
+	ValuesTemplate string
+)
+
+// AnalyzeValueLoss generates and runs a program which converts the values opts.Values generates
+// of every integer and float type to every other, recording whether each comes out exactly. Exact
+// says whether a conversion can lose information at all, while this says whether it does for
+// values that matter, such as IDs near 2^53 converted to a float64. Since the probes must
+// actually run, this requires EngineBuild.
+func AnalyzeValueLoss(ctx context.Context, opts Options) ([]ValueLoss, error) {
+	opts = opts.WithDefaults()
+	if opts.Engine != EngineBuild {
+		return nil, errors.Errorf("value loss probes have to be run, which the %s engine can't do", opts.Engine)
+	}
+
+	type Type struct {
+		Name   string
+		Values []string
+	}
+	type Data struct {
+		Now     string
+		App     string
+		Types   []Type
+		Targets []string
+	}
+	var data Data
+	data.Now = time.Now().Format(time.RFC3339)
+	data.App = os.Args[0]
+	for _, name := range opts.Types {
+		info, ok := Lookup(name)
+		if !ok || !info.IsNumeric() {
+			continue
+		}
+		data.Targets = append(data.Targets, name)
+		values, err := opts.Values.Values(info)
+		if err != nil {
+			return nil, errors.Wrapf(err, "generating %s values", name)
+		}
+		if len(values) == 0 {
+			continue
+		}
+		var t Type
+		t.Name = name
+		t.Values = values
+		data.Types = append(data.Types, t)
+	}
+
+	t, err := parseCached("values.tmpl", ValuesTemplate, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing template")
+	}
+	var src bytes.Buffer
+	err = t.Execute(&src, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "executing template")
+	}
+
+	probeFile := filepath.Join(opts.OutputDir, "values", "main.go")
+	err = WriteFileAtomic(probeFile, src.Bytes(), 0o644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "writing probe file %q", probeFile)
+	}
+
+	err = WriteProbeModule(opts.OutputDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "writing probe module")
+	}
+
+	cmd := probeCommand(opts.OutputDir, "run", "./values")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = runCommand(ctx, cmd)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "running probes: %s", stderr.String())
+	}
+
+	var results []ValueLoss
+	err = json.Unmarshal(stdout.Bytes(), &results)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding probe results")
+	}
+
+	return results, nil
+}
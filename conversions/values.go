@@ -0,0 +1,197 @@
+package conversions
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// GeneratorBoundaries generates the ProbeValues of each type.
+	GeneratorBoundaries = "boundaries"
+	// GeneratorRandom generates DefaultRandomValues values of each type at random, always the
+	// same ones, see RandomGenerator.
+	GeneratorRandom = "random"
+	// GeneratorCorpus generates values given by the user, see Corpus.
+	GeneratorCorpus = "corpus"
+
+	// DefaultRandomValues is how many values GeneratorRandom generates of each type.
+	DefaultRandomValues = 8
+)
+
+type (
+	// ValueGenerator supplies the values runtime probes, such as AnalyzeCodecs and
+	// AnalyzeValueLoss, try for each type, so values that matter to a codebase, e.g. IDs near
+	// 2^53 or currency amounts, can drive which conversions are found to lose information.
+	ValueGenerator interface {
+		// Values returns Go expressions for values of the primitive type i, each of which is
+		// converted to i before use, or none if the generator has nothing to try for it.
+		Values(i Info) ([]string, error)
+	}
+
+	// ValueGeneratorFunc adapts a function to a ValueGenerator.
+	ValueGeneratorFunc func(i Info) ([]string, error)
+
+	// Corpus is a ValueGenerator of user specified values, Go expressions such as 0.1 or
+	// 1<<53 + 1, by the name of the type they are values of.
+	Corpus map[string][]string
+
+	// randomGenerator implements RandomGenerator.
+	randomGenerator struct {
+		n    int
+		seed int64
+	}
+
+	// combinedGenerator implements CombineGenerators.
+	combinedGenerator []ValueGenerator
+)
+
+var (
+	// generatorsMu guards generators.
+	generatorsMu sync.RWMutex
+	// generators are the registered ValueGenerators by name.
+	generators = map[string]ValueGenerator{
+		GeneratorBoundaries: ValueGeneratorFunc(func(i Info) ([]string, error) { return ProbeValues(i), nil }),
+		GeneratorRandom:     RandomGenerator(DefaultRandomValues, 1),
+	}
+)
+
+// Values implements ValueGenerator.
+func (f ValueGeneratorFunc) Values(i Info) ([]string, error) {
+	return f(i)
+}
+
+// Values implements ValueGenerator, returning the values listed for i's name.
+func (c Corpus) Values(i Info) ([]string, error) {
+	return c[i.Name], nil
+}
+
+// RegisterGenerator makes g available by name to LookupGenerator. It is an error to register
+// the same name twice, and GeneratorCorpus is reserved for the user's Corpus.
+func RegisterGenerator(name string, g ValueGenerator) error {
+	generatorsMu.Lock()
+	defer generatorsMu.Unlock()
+
+	if name == "" {
+		return errors.New("generator name must not be empty")
+	}
+	if _, ok := generators[name]; ok || name == GeneratorCorpus {
+		return errors.Errorf("generator %q is already registered", name)
+	}
+	generators[name] = g
+	return nil
+}
+
+// LookupGenerator returns the ValueGenerator registered as name.
+func LookupGenerator(name string) (ValueGenerator, error) {
+	generatorsMu.RLock()
+	defer generatorsMu.RUnlock()
+
+	g, ok := generators[name]
+	if !ok {
+		return nil, errors.Errorf("unknown value generator %q, expected one of %s", name, strings.Join(generatorNames(), ", "))
+	}
+	return g, nil
+}
+
+// Generators returns the names of every registered ValueGenerator, sorted.
+func Generators() []string {
+	generatorsMu.RLock()
+	defer generatorsMu.RUnlock()
+
+	return generatorNames()
+}
+
+// generatorNames returns the names of every registered ValueGenerator, sorted. generatorsMu must be held.
+func generatorNames() []string {
+	var names []string
+	for name := range generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CombineGenerators returns a ValueGenerator of the values of every one of gs, in order,
+// without the ones more than one of them generates.
+func CombineGenerators(gs ...ValueGenerator) ValueGenerator {
+	return combinedGenerator(gs)
+}
+
+// Values implements ValueGenerator.
+func (cg combinedGenerator) Values(i Info) ([]string, error) {
+	var values []string
+	for _, g := range cg {
+		vs, err := g.Values(i)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, vs...)
+	}
+	return Dedupe(values), nil
+}
+
+// RandomGenerator returns a ValueGenerator of n values of each type, spread over its whole
+// range, chosen at random from seed so the same ones are generated every time.
+func RandomGenerator(n int, seed int64) ValueGenerator {
+	var rg randomGenerator
+	rg.n = n
+	rg.seed = seed
+	return rg
+}
+
+// Values implements ValueGenerator.
+func (rg randomGenerator) Values(i Info) ([]string, error) {
+	// NOTE: Seeded per type, so one type's values don't depend on which others were generated.
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(i.Name))
+	r := rand.New(rand.NewSource(rg.seed ^ int64(h.Sum64())))
+
+	var values []string
+	for len(values) < rg.n {
+		switch i.Kind {
+		case KindBool:
+			values = append(values, strconv.FormatBool(r.Intn(2) == 1))
+		case KindString:
+			b := make([]byte, r.Intn(8))
+			_, _ = r.Read(b)
+			values = append(values, strconv.Quote(string(b)))
+		case KindComplex:
+			values = append(values, fmt.Sprintf("complex(%s, %s)", randomFloat(r, i.Bits/2), randomFloat(r, i.Bits/2)))
+		case KindFloat:
+			values = append(values, randomFloat(r, i.Bits))
+		case KindInt:
+			// NOTE: Sized for the narrowest platform, so every value compiles on every one.
+			bits := i.MinBits()
+			v := int64(r.Uint64()) >> (64 - r.Intn(bits) - 1)
+			values = append(values, strconv.FormatInt(v, 10))
+		case KindUint:
+			bits := i.MinBits()
+			v := r.Uint64() >> (64 - r.Intn(bits) - 1)
+			values = append(values, strconv.FormatUint(v, 10))
+		default:
+			return nil, nil
+		}
+	}
+	return values, nil
+}
+
+// randomFloat returns a finite float of bits bits, of any magnitude, chosen with r.
+func randomFloat(r *rand.Rand, bits int) string {
+	// NOTE: One short of the largest exponent, so it can't round up to infinity.
+	exponent := 37
+	if bits == 64 {
+		exponent = 307
+	}
+	f := r.NormFloat64() * math.Pow(10, float64(r.Intn(2*exponent)-exponent))
+	if bits == 32 {
+		return strconv.FormatFloat(float64(float32(f)), 'g', -1, 32)
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
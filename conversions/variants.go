@@ -0,0 +1,194 @@
+package conversions
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type (
+	// TagSet is a combination of build tags a package is analyzed under. A tag naming a GOOS or
+	// GOARCH, e.g. windows or 386, analyzes the package for that platform instead.
+	TagSet []string
+
+	// Conversion is a single conversion expression found in a package analyzed under a TagSet.
+	Conversion struct {
+		// Pos is where the conversion is, with a file name relative to the package directory.
+		Pos token.Position
+		// Expr is the source of the conversion expression.
+		Expr string
+		// From and To are the types involved, followed by their underlying type when it differs.
+		From string
+		To   string
+		// Legal is whether the conversion compiles.
+		Legal bool
+		// Lossy is whether the conversion compiles but may change the numeric value being converted.
+		Lossy bool
+	}
+
+	// VariantDiff is a conversion which differs between the TagSets a package was analyzed under.
+	VariantDiff struct {
+		Pos  token.Position
+		Expr string
+		// Variants holds the Conversion found under each TagSet, by TagSet.String. A TagSet
+		// the conversion isn't compiled under has no entry.
+		Variants map[string]Conversion
+	}
+)
+
+var (
+	// knownGOOS are the GOOS values a TagSet can select.
+	knownGOOS = []string{"aix", "android", "darwin", "dragonfly", "freebsd", "illumos", "ios", "js", "linux", "netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows"}
+	// knownGOARCH are the GOARCH values a TagSet can select.
+	knownGOARCH = []string{"386", "amd64", "arm", "arm64", "loong64", "mips", "mips64", "mips64le", "mipsle", "ppc64", "ppc64le", "riscv64", "s390x", "wasm"}
+)
+
+// ParseTagSets parses tag sets separated by semicolons, each being a comma separated list of
+// build tags, e.g. "a,b;c". An empty tag set analyzes the package without any extra tags.
+func ParseTagSets(s string) []TagSet {
+	var sets []TagSet
+	for _, set := range strings.Split(s, ";") {
+		var ts TagSet
+		for _, t := range strings.Split(set, ",") {
+			t = strings.TrimSpace(t)
+			if t != "" {
+				ts = append(ts, t)
+			}
+		}
+		sets = append(sets, ts)
+	}
+	return sets
+}
+
+// String implements fmt.Stringer.
+func (ts TagSet) String() string {
+	if len(ts) == 0 {
+		return "(no tags)"
+	}
+	return strings.Join(ts, ",")
+}
+
+// AnalyzeVariants type checks the package in dir under every one of tagSets and reports each
+// conversion whose legality or involved types differ between them, or which is only compiled
+// under some of them. This catches conversions that are only safe because of a platform or
+// tag specific type definition.
+func AnalyzeVariants(ctx context.Context, dir string, tagSets []TagSet) ([]VariantDiff, error) {
+	found := make(map[token.Position]map[string]Conversion)
+	for _, ts := range tagSets {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		conversions, err := PackageConversions(dir, ts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "analyzing under %s", ts)
+		}
+		for _, c := range conversions {
+			if found[c.Pos] == nil {
+				found[c.Pos] = make(map[string]Conversion)
+			}
+			found[c.Pos][ts.String()] = c
+		}
+	}
+
+	var positions []token.Position
+	for pos := range found {
+		positions = append(positions, pos)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		a, b := positions[i], positions[j]
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	var diffs []VariantDiff
+	for _, pos := range positions {
+		variants := found[pos]
+		var first Conversion
+		same := len(variants) == len(tagSets)
+		for _, c := range variants {
+			first = c
+			break
+		}
+		for _, c := range variants {
+			if c.From != first.From || c.To != first.To || c.Legal != first.Legal {
+				same = false
+			}
+		}
+		if same {
+			continue
+		}
+
+		var d VariantDiff
+		d.Pos = pos
+		d.Expr = first.Expr
+		d.Variants = variants
+		diffs = append(diffs, d)
+	}
+
+	return diffs, nil
+}
+
+// PackageConversions type checks the package in dir under ts and returns every conversion in it.
+func PackageConversions(dir string, ts TagSet) ([]Conversion, error) {
+	lp, err := loadPackage(dir, ts)
+	if err != nil {
+		return nil, err
+	}
+	return lp.conversions(), nil
+}
+
+// conversions returns every conversion in the files of lp.
+func (lp *loadedPackage) conversions() []Conversion {
+	var conversions []Conversion
+	for _, f := range lp.files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) != 1 {
+				return true
+			}
+			fun, ok := lp.info.Types[call.Fun]
+			if !ok || !fun.IsType() {
+				return true
+			}
+			arg, ok := lp.info.Types[call.Args[0]]
+			if !ok || arg.Type == nil {
+				return true
+			}
+
+			var c Conversion
+			c.Pos = lp.fset.Position(call.Pos())
+			c.Pos.Filename = filepath.Base(c.Pos.Filename)
+			c.Expr = types.ExprString(call)
+			c.From = describeType(arg.Type, lp.qualifier)
+			c.To = describeType(fun.Type, lp.qualifier)
+			c.Legal = !lp.illegal[call.Args[0].Pos()]
+			from, fromOK := basicInfo(arg.Type)
+			to, toOK := basicInfo(fun.Type)
+			// NOTE: The compiler already rejects constants which don't fit.
+			c.Lossy = c.Legal && arg.Value == nil && fromOK && toOK && from.IsNumeric() && to.IsNumeric() && !Exact(from, to)
+			conversions = append(conversions, c)
+			return true
+		})
+	}
+	return conversions
+}
+
+// describeType names t, qualified by qualifier, followed by its underlying type when that is different.
+func describeType(t types.Type, qualifier types.Qualifier) string {
+	name := types.TypeString(t, qualifier)
+	underlying := types.TypeString(t.Underlying(), qualifier)
+	if name == underlying {
+		return name
+	}
+	return name + " (" + underlying + ")"
+}
@@ -0,0 +1,233 @@
+package conversions
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// VetColumn is the column VetClassifier fills in, see RegisterClassifier.
+	VetColumn = "vet"
+)
+
+type (
+	// VetFinding is a conversion which compiles, but which go vet reports nonetheless, e.g. int
+	// to string, which makes a rune of the value rather than its digits.
+	VetFinding struct {
+		From string
+		To   string
+		// Analyzer is the go vet analyzer which reported the conversion, e.g. stringintconv.
+		Analyzer string
+		Message  string
+	}
+
+	// vetDiagnostic is a single diagnostic in the output of go vet -json.
+	vetDiagnostic struct {
+		Posn    string `json:"posn"`
+		Message string `json:"message"`
+	}
+)
+
+var (
+	// VetTemplate is the template the go vet probes are generated from.
+	//go:embed template/vet.tmpl
+	VetTemplate string
+
+	// vetConversionRegexp extracts the type converted to and the field converted from out of a
+	// line of the go vet probe, e.g. "_ = string(p.int)".
+	vetConversionRegexp = regexp.MustCompile(`_ = (\w+)\(p\.(\w+)\)`)
+)
+
+// Vet runs go vet over a probe making every conversion between primitives m says is
+// convertible, and returns what it reports about each, sorted by pair. It runs the
+// go command whatever opts.Engine is, under opts.LangVersion and opts.Toolchain, in a vet
+// directory under opts.OutputDir.
+func Vet(ctx context.Context, opts Options, m Matrix) ([]VetFinding, error) {
+	opts = opts.WithDefaults()
+	err := checkLangVersion(opts.LangVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	var primitives []string
+	seen := make(map[string]bool)
+	var results []Result
+	for _, result := range m.Results {
+		_, fromOK := Lookup(result.From)
+		_, toOK := Lookup(result.To)
+		if !result.Convertible || !fromOK || !toOK {
+			continue
+		}
+		results = append(results, result)
+		for _, t := range []string{result.From, result.To} {
+			if !seen[t] {
+				seen[t] = true
+				primitives = append(primitives, t)
+			}
+		}
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	t, err := parseCached("vet.tmpl", VetTemplate, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing template")
+	}
+	type Data struct {
+		Now        string
+		App        string
+		Primitives []string
+		Results    []Result
+	}
+	var data Data
+	data.Now = time.Now().Format(time.RFC3339)
+	data.App = os.Args[0]
+	data.Primitives = primitives
+	data.Results = results
+	var src bytes.Buffer
+	err = t.Execute(&src, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "executing template")
+	}
+
+	// NOTE: The package is vetted rather than the file, so it's held to the go directive of the
+	// probe module, and so to opts.LangVersion.
+	dir := filepath.Join(opts.OutputDir, "vet")
+	err = writeProbeModule(dir, opts.LangVersion)
+	if err != nil {
+		return nil, errors.Wrap(err, "writing probe module")
+	}
+	file := filepath.Join(dir, "vet.go")
+	err = WriteFileAtomic(file, src.Bytes(), 0o644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "writing %q", file)
+	}
+
+	cmd := probeCommand(dir, "vet", "-json", ".")
+	if opts.GOARCH != "" {
+		cmd.Env = append(cmd.Env, "GOARCH="+opts.GOARCH)
+	}
+	if opts.Toolchain != "" {
+		cmd.Env = append(cmd.Env, "GOTOOLCHAIN="+opts.Toolchain)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = runCommand(ctx, cmd)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "running go vet: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	return parseVet(stdout.String(), strings.Split(src.String(), "\n"))
+}
+
+// parseVet extracts a VetFinding for every diagnostic go vet -json printed in output about a
+// conversion in the probe whose lines are lines.
+func parseVet(output string, lines []string) ([]VetFinding, error) {
+	var findings []VetFinding
+	dec := json.NewDecoder(strings.NewReader(stripVetHeaders(output)))
+	for dec.More() {
+		var packages map[string]map[string]json.RawMessage
+		err := dec.Decode(&packages)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding go vet output")
+		}
+		for _, analyzers := range packages {
+			for analyzer, raw := range analyzers {
+				var diagnostics []vetDiagnostic
+				// NOTE: An analyzer which failed reports an object with an error instead.
+				if json.Unmarshal(raw, &diagnostics) != nil {
+					continue
+				}
+				for _, d := range diagnostics {
+					f, ok := vetFinding(analyzer, d, lines)
+					if ok {
+						findings = append(findings, f)
+					}
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].From != findings[j].From {
+			return findings[i].From < findings[j].From
+		}
+		if findings[i].To != findings[j].To {
+			return findings[i].To < findings[j].To
+		}
+		return findings[i].Analyzer < findings[j].Analyzer
+	})
+	return findings, nil
+}
+
+// stripVetHeaders removes the "# package" lines go vet -json may print ahead of each package's
+// diagnostics.
+func stripVetHeaders(output string) string {
+	var kept []string
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, "#") {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// vetFinding is the VetFinding for the diagnostic d analyzer reported at a conversion among
+// lines, reporting false when it isn't at one.
+func vetFinding(analyzer string, d vetDiagnostic, lines []string) (VetFinding, bool) {
+	// NOTE: posn is file:line:column, and the file may itself contain colons.
+	parts := strings.Split(d.Posn, ":")
+	if len(parts) < 3 {
+		return VetFinding{}, false
+	}
+	line, err := strconv.Atoi(parts[len(parts)-2])
+	if err != nil || line < 1 || line > len(lines) {
+		return VetFinding{}, false
+	}
+	matches := vetConversionRegexp.FindStringSubmatch(lines[line-1])
+	if matches == nil {
+		return VetFinding{}, false
+	}
+
+	var f VetFinding
+	f.From = matches[2]
+	f.To = matches[1]
+	f.Analyzer = analyzer
+	f.Message = d.Message
+	return f, true
+}
+
+// VetClassifier returns a ClassifierFunc filling in VetColumn with what go vet reports about
+// each pair among findings, e.g. "stringintconv: conversion from int to string yields a string
+// of one rune, not a string of digits", so every report annotates the conversions which
+// compile but vet flags anyway:
+//
+//	findings, err := conversions.Vet(ctx, opts, m)
+//	...
+//	err = conversions.RegisterClassifier(conversions.VetColumn, conversions.VetClassifier(findings))
+func VetClassifier(findings []VetFinding) ClassifierFunc {
+	byPair := make(map[string][]string)
+	for _, f := range findings {
+		key := f.From + PairSeparator + f.To
+		byPair[key] = append(byPair[key], fmt.Sprintf("%s: %s", f.Analyzer, f.Message))
+	}
+	return func(p Pair) string {
+		return strings.Join(byPair[p.From+PairSeparator+p.To], "; ")
+	}
+}
@@ -0,0 +1,346 @@
+package conversions
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type (
+	// yamlLine is a line of a YAML document, without its indentation.
+	yamlLine struct {
+		number int
+		indent int
+		text   string
+	}
+
+	// yamlParser decodes the block structure of a YAML document a line at a time.
+	yamlParser struct {
+		lines []yamlLine
+		next  int
+	}
+)
+
+var (
+	// yamlIntRegexp and yamlFloatRegexp match the plain scalars YAML resolves to numbers.
+	yamlIntRegexp   = regexp.MustCompile(`^[-+]?(0|[1-9][0-9]*)$`)
+	yamlFloatRegexp = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+)
+
+// DecodeYAML decodes the YAML document b into the same values encoding/json decodes JSON into
+// with UseNumber: maps, slices, strings, bools, json.Numbers, and nil. Only the subset of YAML
+// sample documents are written in is understood: block mappings and sequences, plain and quoted
+// scalars, literal and folded block scalars, and flow collections of scalars. Anchors, aliases,
+// tags, and multiple documents are rejected rather than decoded wrongly.
+func DecodeYAML(b []byte) (interface{}, error) {
+	var p yamlParser
+	for i, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		text := strings.TrimLeft(line, " ")
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if strings.HasPrefix(text, "\t") {
+			return nil, errors.Errorf("line %d: YAML can't be indented with tabs", i+1)
+		}
+		if text == "---" && len(p.lines) == 0 {
+			continue
+		}
+		if text == "---" || text == "..." {
+			return nil, errors.Errorf("line %d: only a single YAML document is supported", i+1)
+		}
+
+		var l yamlLine
+		l.number = i + 1
+		l.indent = len(line) - len(text)
+		l.text = text
+		p.lines = append(p.lines, l)
+	}
+
+	v, err := p.node(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.next < len(p.lines) {
+		return nil, errors.Errorf("line %d: unexpected indentation", p.lines[p.next].number)
+	}
+	return v, nil
+}
+
+// node decodes the block starting at the next line, if it is indented by at least indent.
+func (p *yamlParser) node(indent int) (interface{}, error) {
+	if p.next >= len(p.lines) || p.lines[p.next].indent < indent {
+		return nil, nil
+	}
+	l := p.lines[p.next]
+	if isSequenceItem(l.text) {
+		return p.sequence(l.indent)
+	}
+	if _, _, ok := splitMappingEntry(l.text); ok {
+		return p.mapping(l.indent)
+	}
+	p.next++
+	return yamlScalar(stripComment(l.text), l.number)
+}
+
+// sequence decodes the block sequence whose items are indented by indent.
+func (p *yamlParser) sequence(indent int) (interface{}, error) {
+	items := []interface{}{}
+	for p.next < len(p.lines) && p.lines[p.next].indent == indent && isSequenceItem(p.lines[p.next].text) {
+		l := p.lines[p.next]
+		rest := strings.TrimLeft(strings.TrimPrefix(l.text, "-"), " ")
+		if rest == "" {
+			p.next++
+			item, err := p.node(indent + 1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+
+		// NOTE: What follows the dash is a node of its own, indented to where it starts, so a
+		// mapping can carry on in the lines below it.
+		p.lines[p.next].indent = indent + len(l.text) - len(rest)
+		p.lines[p.next].text = rest
+		item, err := p.node(indent + 1)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// mapping decodes the block mapping whose keys are indented by indent.
+func (p *yamlParser) mapping(indent int) (interface{}, error) {
+	m := make(map[string]interface{})
+	for p.next < len(p.lines) && p.lines[p.next].indent == indent {
+		l := p.lines[p.next]
+		key, rest, ok := splitMappingEntry(l.text)
+		if !ok {
+			return nil, errors.Errorf("line %d: expected a key", l.number)
+		}
+		key, err := yamlKey(key, l.number)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m[key]; ok {
+			return nil, errors.Errorf("line %d: duplicate key %q", l.number, key)
+		}
+		p.next++
+
+		rest = stripComment(rest)
+		switch {
+		case rest == "":
+			// NOTE: A sequence may be indented as far as the key it belongs to.
+			if p.next < len(p.lines) && p.lines[p.next].indent == indent && isSequenceItem(p.lines[p.next].text) {
+				m[key], err = p.sequence(indent)
+			} else {
+				m[key], err = p.node(indent + 1)
+			}
+		case strings.HasPrefix(rest, "|") || strings.HasPrefix(rest, ">"):
+			m[key], err = p.blockScalar(indent, rest)
+		default:
+			m[key], err = yamlScalar(rest, l.number)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// blockScalar decodes the literal (|) or folded (>) block scalar, introduced by header, made up
+// of the lines after it indented further than indent.
+func (p *yamlParser) blockScalar(indent int, header string) (interface{}, error) {
+	var lines []string
+	for p.next < len(p.lines) && p.lines[p.next].indent > indent {
+		lines = append(lines, p.lines[p.next].text)
+		p.next++
+	}
+	// NOTE: Blank lines and any indentation beyond the first line's are lost, which doesn't
+	// matter to inferring that it's a string.
+	sep := "\n"
+	if strings.HasPrefix(header, ">") {
+		sep = " "
+	}
+	s := strings.Join(lines, sep)
+	if !strings.HasSuffix(header, "-") && len(lines) > 0 {
+		s += "\n"
+	}
+	return s, nil
+}
+
+// yamlKey decodes the key of a mapping entry.
+func yamlKey(key string, number int) (string, error) {
+	v, err := yamlScalar(key, number)
+	if err != nil {
+		return "", err
+	}
+	switch k := v.(type) {
+	case string:
+		return k, nil
+	case json.Number:
+		return k.String(), nil
+	case bool:
+		return strconv.FormatBool(k), nil
+	default:
+		return "", errors.Errorf("line %d: unsupported key %q", number, key)
+	}
+}
+
+// yamlScalar decodes the scalar, or flow collection of scalars, s.
+func yamlScalar(s string, number int) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d: decoding %s", number, s)
+		}
+		return v, nil
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return nil, errors.Errorf("line %d: unterminated string %s", number, s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case strings.HasPrefix(s, "["):
+		return flowCollection(s, "[", "]", number)
+	case strings.HasPrefix(s, "{"):
+		return flowCollection(s, "{", "}", number)
+	case strings.HasPrefix(s, "&"), strings.HasPrefix(s, "*"), strings.HasPrefix(s, "!"):
+		return nil, errors.Errorf("line %d: anchors, aliases, and tags aren't supported", number)
+	}
+
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if yamlIntRegexp.MatchString(s) || yamlFloatRegexp.MatchString(s) {
+		return json.Number(strings.TrimPrefix(s, "+")), nil
+	}
+	return s, nil
+}
+
+// flowCollection decodes the flow sequence or mapping s, delimited by open and close, whose
+// entries are all scalars.
+func flowCollection(s, open, close string, number int) (interface{}, error) {
+	if !strings.HasSuffix(s, close) {
+		return nil, errors.Errorf("line %d: flow collections have to be on one line", number)
+	}
+	inner := strings.TrimSpace(s[len(open) : len(s)-len(close)])
+	var entries []string
+	if inner != "" {
+		entries = splitOutsideQuotes(inner, ',')
+	}
+
+	if open == "[" {
+		items := []interface{}{}
+		for _, entry := range entries {
+			entry = strings.TrimSpace(entry)
+			if strings.HasPrefix(entry, "[") || strings.HasPrefix(entry, "{") {
+				return nil, errors.Errorf("line %d: nested flow collections aren't supported", number)
+			}
+			item, err := yamlScalar(entry, number)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+
+	m := make(map[string]interface{})
+	for _, entry := range entries {
+		key, value, ok := splitMappingEntry(strings.TrimSpace(entry))
+		if !ok {
+			return nil, errors.Errorf("line %d: expected a key in %q", number, entry)
+		}
+		key, err := yamlKey(key, number)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{") {
+			return nil, errors.Errorf("line %d: nested flow collections aren't supported", number)
+		}
+		m[key], err = yamlScalar(value, number)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// isSequenceItem reports whether text is an item of a block sequence.
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitMappingEntry splits text into the key and value of a mapping entry, reporting false if it
+// isn't one. The key ends at the first colon after any quoted key followed by a space or the end.
+func splitMappingEntry(text string) (string, string, bool) {
+	i := quotedLength(text)
+	for ; i < len(text); i++ {
+		switch {
+		case text[i] == '#' && i > 0 && text[i-1] == ' ':
+			return "", "", false
+		case text[i] == ':' && (i == len(text)-1 || text[i+1] == ' '):
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// stripComment removes any comment from the end of the scalar text.
+func stripComment(text string) string {
+	for i := quotedLength(text); i < len(text); i++ {
+		if text[i] == '#' && (i == 0 || text[i-1] == ' ') {
+			return strings.TrimSpace(text[:i])
+		}
+	}
+	return text
+}
+
+// quotedLength is the length of the quoted string text starts with, or 0 if it isn't quoted.
+func quotedLength(text string) int {
+	if text == "" || (text[0] != '"' && text[0] != '\'') {
+		return 0
+	}
+	for i := 1; i < len(text); i++ {
+		switch {
+		case text[0] == '"' && text[i] == '\\':
+			i++
+		case text[i] == text[0]:
+			return i + 1
+		}
+	}
+	return len(text)
+}
+
+// splitOutsideQuotes splits s at every sep outside quotes.
+func splitOutsideQuotes(s string, sep byte) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
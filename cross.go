@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"os"
+	"strings"
+)
+
+// Cross analyzes every primitive against every other primitive once for each of archs, as
+// GOARCH values, and reports the pairs which behave differently depending on the architecture.
+// With -format json the whole combined matrix is written to stdout instead.
+func Cross(ctx context.Context, archs []string) error {
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	p, err := ProvenanceFor(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "determining provenance")
+	}
+
+	switch *reportFormat {
+	case "", "json":
+	default:
+		return errors.Errorf("cross architecture reports can only be logged or rendered as json, not %q", *reportFormat)
+	}
+
+	cm, err := conversions.AnalyzeCross(ctx, opts, archs)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+
+	if *reportFormat == "json" {
+		type Report struct {
+			Provenance Provenance              `json:"provenance"`
+			Matrix     conversions.CrossMatrix `json:"matrix"`
+		}
+		var r Report
+		r.Provenance = p
+		r.Matrix = cm
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(r)
+		if err != nil {
+			return errors.Wrap(err, "encoding report")
+		}
+		return nil
+	}
+
+	var differ int
+	for _, cr := range cm.Results {
+		if !cr.Differs() {
+			continue
+		}
+		differ++
+		verdicts := make([]string, 0, len(cm.Archs))
+		for _, arch := range cm.Archs {
+			verdicts = append(verdicts, fmt.Sprintf("%s %s", arch, crossVerdict(cr, arch)))
+		}
+		logrus.Warnf("%s -> %s depends on the architecture: %s", cr.From, cr.To, strings.Join(verdicts, ", "))
+	}
+	logrus.Infof("%d of %d conversions behave differently across %s", differ, len(cm.Results), strings.Join(cm.Archs, ", "))
+
+	return nil
+}
+
+// crossVerdict describes what converting cr.From to cr.To does on arch.
+func crossVerdict(cr conversions.CrossResult, arch string) string {
+	switch {
+	case !cr.Convertible[arch]:
+		return "❌ does not compile"
+	case cr.Exact[arch]:
+		return "✅ always exact"
+	default:
+		return "⚠️ may lose information"
+	}
+}
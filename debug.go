@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// DebugRunFile is the file in a run's debug artifacts describing how the run was configured.
+	DebugRunFile = "run.json"
+)
+
+type (
+	// DebugRun is how a run saving debug artifacts was configured, so the artifacts can be
+	// reproduced.
+	DebugRun struct {
+		// Args are the command line the run was started with.
+		Args       []string
+		Provenance Provenance
+		Types      []string
+		GOARCH     string `json:",omitempty"`
+		Toolchain  string `json:",omitempty"`
+		Strict     bool
+	}
+)
+
+// SaveDebugRun writes the DebugRun of an analysis configured by opts, whose results have
+// provenance p, to opts.DebugDir.
+func SaveDebugRun(opts conversions.Options, p Provenance) error {
+	var dr DebugRun
+	dr.Args = os.Args
+	dr.Provenance = p
+	dr.Types = opts.Types
+	dr.GOARCH = opts.GOARCH
+	dr.Toolchain = opts.Toolchain
+	dr.Strict = opts.Strict
+
+	b, err := json.MarshalIndent(dr, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encoding run")
+	}
+	file := filepath.Join(opts.DebugDir, DebugRunFile)
+	err = conversions.WriteFileAtomic(file, b, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "writing %q", file)
+	}
+	return nil
+}
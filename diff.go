@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"io"
+	"os"
+	"strings"
+)
+
+// Diff compares two -format json reports, given as before and after arguments, and reports
+// every pair whose verdict or reason changed. With -format markdown the changes are written to
+// stdout as a table to paste, or post, as a pull request comment, and with -format json as
+// the conversions.Changes.
+func Diff(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	format := fs.String("format", *reportFormat, "render the changes to stdout as markdown, for a pull request comment, or json, rather than logging them")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+	if fs.NArg() != 2 {
+		return errors.Errorf("expected the before and after reports, got %d arguments", fs.NArg())
+	}
+
+	before, err := LoadBaseline(fs.Arg(0))
+	if err != nil {
+		return errors.Wrap(err, "loading before")
+	}
+	after, err := LoadBaseline(fs.Arg(1))
+	if err != nil {
+		return errors.Wrap(err, "loading after")
+	}
+	changes := conversions.Diff(before, after)
+
+	switch *format {
+	case "":
+		for _, c := range changes {
+			logrus.Warnf("%s -> %s: %s %s -> %s %s", c.From, c.To, verdict(c.Before), verdictName(c.Before), verdict(c.After), verdictName(c.After))
+		}
+		logrus.Infof("%d conversions changed", len(changes))
+	case conversions.FormatMarkdown:
+		err = RenderMarkdownDiff(changes, os.Stdout)
+		if err != nil {
+			return errors.Wrap(err, "rendering changes")
+		}
+	case conversions.FormatJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(changes)
+		if err != nil {
+			return errors.Wrap(err, "encoding changes")
+		}
+	default:
+		return errors.Errorf("changes can only be logged or rendered as markdown or json, not %q", *format)
+	}
+
+	return nil
+}
+
+// RenderMarkdownDiff writes changes to w as a markdown table, a row per changed pair with its
+// verdict before and after and the reason it doesn't convert, sized for a pull request comment.
+func RenderMarkdownDiff(changes []conversions.Change, w io.Writer) error {
+	var b strings.Builder
+	if len(changes) == 0 {
+		b.WriteString("### go-conversions: no conversions changed\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	plural := "s"
+	if len(changes) == 1 {
+		plural = ""
+	}
+	_, _ = fmt.Fprintf(&b, "### go-conversions: %d conversion%s changed\n\n", len(changes), plural)
+	b.WriteString("| From | To | Before | After | Reason |\n")
+	b.WriteString("|---|---|:---:|:---:|---|\n")
+	for _, c := range changes {
+		// NOTE: Pipes would otherwise end the cell early.
+		reason := strings.ReplaceAll(c.Reason(), "|", "\\|")
+		_, _ = fmt.Fprintf(&b, "| `%s` | `%s` | %s | %s | %s |\n", c.From, c.To, verdict(c.Before), verdict(c.After), reason)
+	}
+	b.WriteString("\n✅ compiles, ❌ does not compile, ➖ not analyzed\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// verdict is the emoji for whether result is convertible, or ➖ when there is no result.
+func verdict(result *conversions.Result) string {
+	switch {
+	case result == nil:
+		return "➖"
+	case result.Convertible:
+		return "✅"
+	default:
+		return "❌"
+	}
+}
+
+// verdictName is what verdict's emoji means, for logging.
+func verdictName(result *conversions.Result) string {
+	switch {
+	case result == nil:
+		return "not analyzed"
+	case result.Convertible:
+		return "compiles"
+	default:
+		return "does not compile"
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go/parser"
+	"go/token"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MinGoVersion is the oldest go toolchain this program is known to work with.
+	MinGoVersion = "go1.19"
+)
+
+type (
+	// Check is a single pre-flight check run by Doctor. Run returns a short description of
+	// what it found when the check passes, and Fix describes how to resolve it when it does not.
+	Check struct {
+		Name string
+		Run  func(ctx context.Context, opts conversions.Options) (string, error)
+		Fix  string
+	}
+)
+
+var (
+	// Checks contains every check run by Doctor, in the order they are run.
+	Checks = []Check{
+		{
+			Name: "go is on PATH",
+			Run:  checkGoOnPath,
+			Fix:  "install go from https://go.dev/dl/ and make sure its bin directory is on your PATH",
+		},
+		{
+			Name: "go toolchain is compatible",
+			Run:  checkGoVersion,
+			Fix:  fmt.Sprintf("upgrade to %s or newer", MinGoVersion),
+		},
+		{
+			Name: "engine is known",
+			Run:  checkEngine,
+			Fix:  fmt.Sprintf("pass -engine %s, -engine %s, or -engine %s with -remote-url", conversions.EngineBuild, conversions.EngineTypes, conversions.EngineRemote),
+		},
+		{
+			Name: "output directory is writable",
+			Run:  checkOutputDir,
+			Fix:  fmt.Sprintf("make %s writable, or run from a directory where it can be created", OutputDir),
+		},
+		{
+			Name: "go build cache is writable",
+			Run:  checkBuildCache,
+			Fix:  "point GOCACHE at a writable directory, e.g. GOCACHE=$(mktemp -d)",
+		},
+		{
+			Name: "compiler error limit is measurable",
+			Run:  checkErrorLimit,
+			Fix:  "make sure the compiler reports conversion errors, or pass -max-errors to skip measuring",
+		},
+		{
+			Name: "template is valid",
+			Run:  checkTemplate,
+			Fix:  "compare your template against conversions/template/conversions.tmpl",
+		},
+		{
+			Name: "saved state can be resumed",
+			Run:  checkState,
+			Fix:  fmt.Sprintf("run without -resume, or delete %s to start over", StateFile),
+		},
+	}
+
+	// goVersionRegexp extracts the major and minor version out of a go version such as go1.19.2.
+	goVersionRegexp = regexp.MustCompile(`^go(\d+)\.(\d+)`)
+)
+
+// Doctor runs every one of the Checks against the current environment and configuration,
+// printing how to fix each one that fails so a long analysis doesn't fail midway.
+func Doctor(ctx context.Context) error {
+	opts, err := Options()
+	if err != nil {
+		logrus.Errorf("❌ configuration is valid: %v", err)
+		logrus.Errorf("   fix: correct the -config file or -exclude-types flag")
+		return errors.Wrap(err, "configuring")
+	}
+	logrus.Infof("✅ configuration is valid: %d types", len(opts.Types))
+	opts = opts.WithDefaults()
+
+	var failed int
+	for _, check := range Checks {
+		found, err := check.Run(ctx, opts)
+		if err != nil {
+			failed++
+			logrus.Errorf("❌ %s: %v", check.Name, err)
+			logrus.Errorf("   fix: %s", check.Fix)
+			continue
+		}
+		logrus.Infof("✅ %s: %s", check.Name, found)
+	}
+
+	if failed > 0 {
+		return errors.Errorf("%d of %d checks failed", failed, len(Checks))
+	}
+
+	return nil
+}
+
+// checkGoOnPath checks that the go command can be found.
+func checkGoOnPath(_ context.Context, opts conversions.Options) (string, error) {
+	if opts.Engine == conversions.EngineRemote {
+		return fmt.Sprintf("not used by the %s engine", opts.Engine), nil
+	}
+
+	path, err := exec.LookPath("go")
+	if err != nil {
+		return "", errors.Wrap(err, "looking up go")
+	}
+	return path, nil
+}
+
+// checkGoVersion checks that the go toolchain is at least MinGoVersion.
+func checkGoVersion(ctx context.Context, opts conversions.Options) (string, error) {
+	if opts.Engine == conversions.EngineRemote {
+		return fmt.Sprintf("not used by the %s engine", opts.Engine), nil
+	}
+
+	version, err := goEnv(ctx, "GOVERSION")
+	if err != nil {
+		return "", err
+	}
+
+	newEnough, err := goVersionAtLeast(version, MinGoVersion)
+	if err != nil {
+		return "", err
+	}
+	if !newEnough {
+		return "", errors.Errorf("found %s but need %s or newer", version, MinGoVersion)
+	}
+
+	return version, nil
+}
+
+// checkEngine checks that opts.Engine is one the conversions package knows about.
+func checkEngine(_ context.Context, opts conversions.Options) (string, error) {
+	switch opts.Engine {
+	case conversions.EngineBuild, conversions.EngineTypes:
+		return opts.Engine, nil
+	case conversions.EngineRemote:
+		u, err := url.Parse(opts.RemoteURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return "", errors.Errorf("the %s engine needs an absolute -remote-url, got %q", opts.Engine, opts.RemoteURL)
+		}
+		return fmt.Sprintf("%s at %s", opts.Engine, opts.RemoteURL), nil
+	default:
+		return "", errors.Errorf("unknown engine %q", opts.Engine)
+	}
+}
+
+// checkOutputDir checks that files can be created in opts.OutputDir.
+func checkOutputDir(_ context.Context, opts conversions.Options) (string, error) {
+	err := checkWritable(opts.OutputDir)
+	if err != nil {
+		return "", err
+	}
+	return opts.OutputDir, nil
+}
+
+// checkBuildCache checks that go build is able to write to its build cache.
+func checkBuildCache(ctx context.Context, opts conversions.Options) (string, error) {
+	if opts.Engine != conversions.EngineBuild {
+		return fmt.Sprintf("not used by the %s engine", opts.Engine), nil
+	}
+
+	dir, err := goEnv(ctx, "GOCACHE")
+	if err != nil {
+		return "", err
+	}
+	if dir == "" || dir == "off" {
+		return "", errors.New("the go build cache is disabled")
+	}
+
+	err = checkWritable(dir)
+	if err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// checkErrorLimit measures how many errors the compiler reports per file, which shards are sized by.
+func checkErrorLimit(ctx context.Context, opts conversions.Options) (string, error) {
+	if opts.MaxErrors != 0 {
+		return fmt.Sprintf("%d, set by -max-errors", opts.MaxErrors), nil
+	}
+
+	limit, err := conversions.MeasureErrorLimit(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+	if limit < 0 {
+		return "no limit", nil
+	}
+	return fmt.Sprintf("%d errors per file", limit), nil
+}
+
+// checkTemplate checks that the template renders into go code which parses.
+func checkTemplate(ctx context.Context, opts conversions.Options) (string, error) {
+	var shard conversions.Shard
+	shard.Sources = opts.Types
+	src, err := conversions.Render(ctx, opts, shard)
+	if err != nil {
+		return "", err
+	}
+
+	fset := token.NewFileSet()
+	_, err = parser.ParseFile(fset, "conversions.go", src, parser.AllErrors)
+	if err != nil {
+		return "", errors.Wrap(err, "parsing generated code")
+	}
+
+	name := opts.TemplateFile
+	if name == "" {
+		name = "built-in template"
+	}
+	return name, nil
+}
+
+// checkState checks that the state saved by a previous run matches this run when resuming.
+func checkState(_ context.Context, opts conversions.Options) (string, error) {
+	if !opts.Resume {
+		return "not resuming", nil
+	}
+
+	state, err := conversions.LoadState(opts.StateFile)
+	if err != nil {
+		return "", err
+	}
+	if state == nil {
+		return "nothing to resume, a fresh analysis will be started", nil
+	}
+	if !state.Matches(opts) {
+		return "", errors.Errorf("%s was saved by an analysis of different types or with a different engine", opts.StateFile)
+	}
+
+	return fmt.Sprintf("%d of %d shards already completed", len(state.Shards), len(opts.Types)), nil
+}
+
+// checkWritable checks that a file can be created in dir, creating dir if needed.
+func checkWritable(dir string) error {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return errors.Wrapf(err, "creating %q", dir)
+	}
+
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return errors.Wrapf(err, "creating a file in %q", dir)
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+
+	return nil
+}
+
+// goEnv returns the value of the go environment variable key as reported by `go env`.
+func goEnv(ctx context.Context, key string) (string, error) {
+	cmd := exec.CommandContext(ctx, "go", "env", key)
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	err := cmd.Run()
+	if err != nil {
+		return "", errors.Wrapf(err, "running go env %s", key)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// goVersionAtLeast reports whether the go version is the same as or newer than min.
+func goVersionAtLeast(version, min string) (bool, error) {
+	major, minor, err := parseGoVersion(version)
+	if err != nil {
+		return false, err
+	}
+	minMajor, minMinor, err := parseGoVersion(min)
+	if err != nil {
+		return false, err
+	}
+
+	if major != minMajor {
+		return major > minMajor, nil
+	}
+	return minor >= minMinor, nil
+}
+
+// parseGoVersion extracts the major and minor version out of a go version such as go1.19.2.
+func parseGoVersion(version string) (int, int, error) {
+	matches := goVersionRegexp.FindStringSubmatch(version)
+	if matches == nil {
+		return 0, 0, errors.Errorf("unrecognized go version %q", version)
+	}
+
+	major, _ := strconv.Atoi(matches[1])
+	minor, _ := strconv.Atoi(matches[2])
+
+	return major, minor, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/Insulince/go-conversions/enums"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"path/filepath"
+	"strings"
+)
+
+// Enums reports which primitives every enum-style type in a user package, an integer type with
+// a block of iota constants, can be converted to and from, and generates a FromInt validator,
+// String method, and Parse function for each into the package.
+func Enums(ctx context.Context, args []string) error {
+	var outputFile string
+	fs := flag.NewFlagSet("enums", flag.ContinueOnError)
+	fs.StringVar(&outputFile, "out", "", "file to write the generated helpers to (default "+enums.DefaultFile+" in the package)")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+	if outputFile == "" {
+		outputFile = filepath.Join(dir, enums.DefaultFile)
+	}
+
+	es, err := conversions.Enums(dir, filepath.Base(outputFile))
+	if err != nil {
+		return errors.Wrapf(err, "finding enums in %q", dir)
+	}
+	if len(es) == 0 {
+		logrus.Infof("no enums found in %s", dir)
+		return nil
+	}
+
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	m, err := conversions.Analyze(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+
+	for _, e := range es {
+		var constants []string
+		for _, c := range e.Constants {
+			constants = append(constants, c.Name+"="+c.Value)
+		}
+		logrus.Infof("---------- %s (%s): %s ----------\n", e.Name, e.Underlying, strings.Join(constants, ", "))
+
+		// NOTE: A defined type converts exactly as the type it is defined as does.
+		var from, to, neither []string
+		for _, typ := range m.Types {
+			if m.Convertible(typ, e.Underlying) {
+				from = append(from, typ)
+			}
+			if m.Convertible(e.Underlying, typ) {
+				to = append(to, typ)
+			}
+			if !m.Convertible(typ, e.Underlying) && !m.Convertible(e.Underlying, typ) {
+				neither = append(neither, typ)
+			}
+		}
+		logrus.Infof("converts from: %s", strings.Join(from, ", "))
+		logrus.Infof("converts to:   %s", strings.Join(to, ", "))
+		logrus.Infof("never:         %s", strings.Join(neither, ", "))
+		logrus.Infof("converting to %s compiles whatever the value, use %sFromInt to reject values which aren't one of its constants", e.Name, e.Name)
+	}
+
+	err = enums.Generate(ctx, es, outputFile)
+	if err != nil {
+		return errors.Wrap(err, "generating enum helpers")
+	}
+
+	logrus.Infof("generated helpers for %d enums in %s", len(es), outputFile)
+
+	return nil
+}
@@ -0,0 +1,69 @@
+// Package enums generates validating conversions, String methods, and Parse functions for the
+// enum-style types, integer types with a block of iota constants, declared in a user package.
+package enums
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"go/format"
+	"os"
+	"text/template"
+	"time"
+)
+
+const (
+	// DefaultFile is the name of the file written alongside the package's own files when no other
+	// output file is given.
+	DefaultFile = "enums_conversions.go"
+)
+
+var (
+	// EnumsTemplate is the template the enum helpers are generated from.
+	//go:embed template/enums.tmpl
+	EnumsTemplate string
+)
+
+// Generate writes a NameFromInt validator, a String method, and a ParseName function for every
+// one of enums, all of which must be declared in the same package, to outputFile. Anything an
+// Enum already has is left out.
+func Generate(_ context.Context, enums []conversions.Enum, outputFile string) error {
+	if len(enums) == 0 {
+		return errors.New("no enums to generate helpers for")
+	}
+
+	type Data struct {
+		Now     string
+		App     string
+		Package string
+		Enums   []conversions.Enum
+	}
+	var data Data
+	data.Now = time.Now().Format(time.RFC3339)
+	data.App = os.Args[0]
+	data.Package = enums[0].Package
+	data.Enums = enums
+
+	t, err := template.New("enums.tmpl").Parse(EnumsTemplate)
+	if err != nil {
+		return errors.Wrap(err, "parsing template")
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, data)
+	if err != nil {
+		return errors.Wrap(err, "executing template")
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "formatting generated code")
+	}
+
+	err = conversions.WriteFileAtomic(outputFile, src, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "writing %q", outputFile)
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go/format"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const (
+	// DefaultExamplesDir is where the examples command writes its examples when -out is not set.
+	DefaultExamplesDir = "./examples"
+)
+
+type (
+	// Example is a standalone program demonstrating a conversion which either does not compile
+	// or silently changes the value being converted.
+	Example struct {
+		From string
+		To   string
+		// Compiles is whether the example compiles, i.e. whether the conversion is lossy rather than illegal.
+		Compiles bool
+		// Value is an expression for a From value that is changed by converting it to To.
+		Value string
+	}
+)
+
+var (
+	// exampleTemplate is the program generated for each Example. Every example is a single
+	// main package with no dependencies beyond the standard library, so it can be pasted
+	// straight into the Go Playground.
+	exampleTemplate = template.Must(template.New("example").Parse(`{{if $.Compiles -}}
+// Converting from {{$.From}} to {{$.To}} compiles, but it does not always preserve the value being converted.
+{{- else -}}
+// Converting from {{$.From}} to {{$.To}} does not compile, running this will fail with:
+//
+//	cannot convert v (variable of type {{$.From}}) to type {{$.To}}
+{{- end}}
+package main
+
+import (
+	"fmt"{{if $.UsesMath}}
+	"math"{{end}}
+)
+
+func main() {
+{{- if $.Compiles}}
+	v := {{$.From}}({{$.Value}})
+{{- else}}
+	var v {{$.From}}
+{{- end}}
+	fmt.Println(v, "->", {{$.To}}(v))
+}
+`))
+)
+
+// Examples writes a runnable, playground-ready example program for every pair of primitives
+// which either can't be converted, or can be converted but may lose information doing so.
+func Examples(ctx context.Context, args []string) error {
+	var outputDir string
+	fs := flag.NewFlagSet("examples", flag.ContinueOnError)
+	fs.StringVar(&outputDir, "out", DefaultExamplesDir, "directory to write the examples to")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	m, err := conversions.Analyze(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+
+	p, err := ProvenanceFor(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "determining provenance")
+	}
+
+	examples := ExamplesFor(m)
+	var index strings.Builder
+	index.WriteString("# Conversion examples\n\n")
+	index.WriteString("```\n" + p.Comment("") + "```\n\n")
+	for _, example := range examples {
+		name := fmt.Sprintf("%s_to_%s", example.From, example.To)
+		outputFile := filepath.Join(outputDir, name, "main.go")
+		err := writeExample(outputFile, example)
+		if err != nil {
+			return errors.Wrapf(err, "writing example for %s -> %s", example.From, example.To)
+		}
+
+		verdict := "❌ does not compile"
+		if example.Compiles {
+			verdict = "⚠️ may lose information"
+		}
+		_, _ = fmt.Fprintf(&index, "- [`%s -> %s`](%s/main.go) %s\n", example.From, example.To, name, verdict)
+	}
+
+	indexFile := filepath.Join(outputDir, "README.md")
+	err = conversions.WriteFileAtomic(indexFile, []byte(index.String()), 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "writing index %q", indexFile)
+	}
+
+	logrus.Infof("wrote %d examples to %s", len(examples), outputDir)
+
+	return nil
+}
+
+// ExamplesFor lists an Example for every pair in m which either can't be converted or may lose
+// information when converted.
+func ExamplesFor(m conversions.Matrix) []Example {
+	var examples []Example
+	for _, result := range m.Results {
+		var example Example
+		example.From = result.From
+		example.To = result.To
+		if !result.Convertible {
+			examples = append(examples, example)
+			continue
+		}
+
+		value, ok := lossyValue(result.From, result.To)
+		if !ok {
+			continue
+		}
+		example.Compiles = true
+		example.Value = value
+		examples = append(examples, example)
+	}
+	return examples
+}
+
+// UsesMath reports whether the example needs to import the math package.
+func (e Example) UsesMath() bool {
+	return strings.Contains(e.Value, "math.")
+}
+
+// lossyValue returns an expression for a value of from which is changed by converting it to to,
+// reporting false if the conversion never loses information.
+func lossyValue(from, to string) (string, bool) {
+	fromInfo, ok := conversions.Lookup(from)
+	if !ok || !fromInfo.IsNumeric() {
+		return "", false
+	}
+	toInfo, ok := conversions.Lookup(to)
+	if !ok || !toInfo.IsNumeric() {
+		return "", false
+	}
+	if conversions.Exact(fromInfo, toInfo) {
+		return "", false
+	}
+
+	switch {
+	case fromInfo.Kind == conversions.KindInt && toInfo.Kind == conversions.KindUint:
+		return "-1", true
+	case fromInfo.IsInteger() && toInfo.IsInteger():
+		return maxValue(fromInfo), true
+	case toInfo.IsInteger():
+		return "1.5", true
+	default:
+		return fmt.Sprintf("1<<%d + 1", toInfo.Mantissa()), true
+	}
+}
+
+// maxValue returns an expression for the largest value of the integer type i.
+func maxValue(i conversions.Info) string {
+	name := i.Canonical()
+	if name == "uintptr" {
+		return "^uintptr(0)"
+	}
+	return "math.Max" + exportedName(name)
+}
+
+// renderExample generates the program for example.
+func renderExample(example Example) ([]byte, error) {
+	var buf bytes.Buffer
+	err := exampleTemplate.Execute(&buf, example)
+	if err != nil {
+		return nil, errors.Wrap(err, "executing template")
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "formatting generated code")
+	}
+
+	return src, nil
+}
+
+// writeExample generates the program for example and writes it to outputFile.
+func writeExample(outputFile string, example Example) error {
+	src, err := renderExample(example)
+	if err != nil {
+		return errors.Wrap(err, "rendering")
+	}
+
+	err = conversions.WriteFileAtomic(outputFile, src, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "writing %q", outputFile)
+	}
+
+	return nil
+}
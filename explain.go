@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"strings"
+)
+
+// Explain analyzes converting a single pair of primitives, given as from and to, and logs its
+// verdict, why it doesn't compile if it doesn't along with what the compiler said, and the code to
+// write instead, see conversions.Suggest.
+func Explain(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: explain <from> <to>")
+	}
+	from, to := args[0], args[1]
+	for _, t := range args {
+		if _, ok := conversions.Lookup(t); !ok {
+			return errors.Errorf("unknown primitive %q", t)
+		}
+	}
+
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+	// NOTE: Only the pair is analyzed, whatever -types and the config say.
+	opts.Types = []string{from}
+	if to != from {
+		opts.Types = append(opts.Types, to)
+	}
+
+	m, err := conversions.Analyze(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+	result, ok := m.Result(from, to)
+	if !ok {
+		return errors.Errorf("%s -> %s wasn't analyzed", from, to)
+	}
+
+	logrus.Infof("%s -> %s is %s", from, to, conversions.Word(result))
+	if result.Width != "" {
+		logrus.Infof("it's %s", result.Width)
+	}
+	if result.Err != nil {
+		logrus.Infof("because %s", result.Err.Reason)
+		if result.Err.CompilerMessage != "" {
+			logrus.Infof("the compiler says: %s", result.Err.CompilerMessage)
+		}
+	}
+
+	suggestions := conversions.Suggestions(m.Filter(func(p conversions.Pair) bool {
+		return p.From == from && p.To == to
+	}))
+	for _, s := range suggestions {
+		logrus.Infof("instead (%s), %s: %s", s.Rule, s.Note, oneLine(s.Code))
+		if len(s.Imports) > 0 {
+			logrus.Infof("which imports %s", strings.Join(s.Imports, ", "))
+		}
+	}
+
+	return nil
+}
+
+// oneLine joins the statements on each line of code with semicolons, so it can be logged on a
+// single line, e.g. "if v { out = 1 }".
+func oneLine(code string) string {
+	var b strings.Builder
+	for i, line := range strings.Split(code, "\n") {
+		line = strings.TrimSpace(line)
+		if i > 0 {
+			if strings.HasSuffix(b.String(), "{") || strings.HasPrefix(line, "}") {
+				b.WriteString(" ")
+			} else {
+				b.WriteString("; ")
+			}
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// ExportJSON exports the type map, along with what each type converts to, as JSON.
+	ExportJSON = "json"
+	// ExportTypeScript exports the type map as TypeScript type aliases.
+	ExportTypeScript = "typescript"
+	// ExportPython exports the type map as Python type aliases.
+	ExportPython = "python"
+)
+
+type (
+	// ExportedType maps a go primitive to its closest TypeScript and Python equivalents.
+	ExportedType struct {
+		Go     string           `json:"go"`
+		Kind   conversions.Kind `json:"kind"`
+		Bits   int              `json:"bits,omitempty"`
+		Signed bool             `json:"signed"`
+		// TypeScript and Python are the types a value should be represented as in those languages.
+		TypeScript string `json:"typescript"`
+		Python     string `json:"python"`
+		// Notes describes anything which is lost or must be enforced by hand in the other languages.
+		Notes string `json:"notes"`
+		// ConvertibleTo lists the go types this type can be converted to.
+		ConvertibleTo []string `json:"convertibleTo"`
+	}
+)
+
+// Export writes a map of every primitive to its TypeScript and Python equivalents, with notes on
+// width and signedness, for teams generating cross-language bindings from the same matrix data.
+func Export(ctx context.Context, args []string) error {
+	var format, outputFile string
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.StringVar(&format, "format", ExportJSON, fmt.Sprintf("one of %s, %s, or %s", ExportJSON, ExportTypeScript, ExportPython))
+	fs.StringVar(&outputFile, "out", "", "file to write the export to (default stdout)")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	m, err := conversions.Analyze(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+
+	p, err := ProvenanceFor(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "determining provenance")
+	}
+
+	var w io.Writer = os.Stdout
+	var f *conversions.AtomicFile
+	if outputFile != "" {
+		f, err = conversions.CreateAtomic(outputFile, 0o644)
+		if err != nil {
+			return errors.Wrapf(err, "creating output file %q", outputFile)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+
+	types := ExportedTypes(m)
+	switch format {
+	case ExportJSON:
+		err = exportJSON(w, p, types)
+	case ExportTypeScript:
+		err = exportTypeScript(w, p, types)
+	case ExportPython:
+		err = exportPython(w, p, types)
+	default:
+		return errors.Errorf("unknown export format %q", format)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "exporting %s", format)
+	}
+	if f != nil {
+		err = f.Commit()
+		if err != nil {
+			return errors.Wrapf(err, "committing output file %q", outputFile)
+		}
+	}
+
+	return nil
+}
+
+// ExportedTypes maps every type in m to its TypeScript and Python equivalents.
+func ExportedTypes(m conversions.Matrix) []ExportedType {
+	var types []ExportedType
+	for _, from := range m.Types {
+		info, ok := conversions.Lookup(from)
+		if !ok {
+			continue
+		}
+
+		var t ExportedType
+		t.Go = info.Name
+		t.Kind = info.Kind
+		t.Bits = info.Bits
+		t.Signed = info.Kind == conversions.KindInt || info.Kind == conversions.KindFloat || info.Kind == conversions.KindComplex
+		t.TypeScript, t.Python, t.Notes = foreignTypes(info)
+		for _, to := range m.Types {
+			if m.Convertible(from, to) {
+				t.ConvertibleTo = append(t.ConvertibleTo, to)
+			}
+		}
+		types = append(types, t)
+	}
+	return types
+}
+
+// foreignTypes returns the TypeScript and Python types a value of the go type i should be
+// represented as, along with notes on what is lost or must be enforced by hand.
+func foreignTypes(i conversions.Info) (string, string, string) {
+	width := fmt.Sprintf("%d-bit", i.Bits)
+	if i.Bits == 0 {
+		width = "32 or 64-bit, depending on the platform,"
+	}
+
+	switch i.Kind {
+	case conversions.KindBool:
+		return "boolean", "bool", ""
+	case conversions.KindString:
+		return "string", "str", "go strings are arbitrary bytes, usually but not necessarily UTF-8, while TypeScript strings are UTF-16 and Python strings are unicode code points"
+	case conversions.KindFloat:
+		if i.Bits == 32 {
+			return "number", "float", "TypeScript numbers and Python floats are 64-bit, so round to float32 precision before sending values back"
+		}
+		return "number", "float", ""
+	case conversions.KindComplex:
+		return "[number, number]", "complex", fmt.Sprintf("%s complex number, TypeScript has no complex type so it is represented as a [real, imaginary] tuple", width)
+	}
+
+	signedness := "unsigned"
+	if i.Kind == conversions.KindInt {
+		signedness = "signed"
+	}
+	notes := fmt.Sprintf("%s %s integer, Python's int is unbounded so the range must be enforced by hand", signedness, width)
+	if i.MaxBits() > 32 {
+		// NOTE: Numbers are float64s, so they only hold integers exactly up to 2^53.
+		return "bigint", "int", notes + ", and TypeScript numbers lose precision beyond 2^53 so bigint is used"
+	}
+	return "number", "int", notes
+}
+
+// exportJSON writes types as indented JSON, along with their provenance p.
+func exportJSON(w io.Writer, p Provenance, types []ExportedType) error {
+	type Export struct {
+		Provenance Provenance     `json:"provenance"`
+		Types      []ExportedType `json:"types"`
+	}
+	var export Export
+	export.Provenance = p
+	export.Types = types
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(export)
+}
+
+// exportTypeScript writes types as TypeScript type aliases, one per go type, stamped with p.
+func exportTypeScript(w io.Writer, p Provenance, types []ExportedType) error {
+	var b strings.Builder
+	b.WriteString("// DO NOT EDIT - Generated code\n// Generated by go-conversions\n//\n")
+	b.WriteString(p.Comment("// "))
+	for _, t := range types {
+		_, _ = fmt.Fprintf(&b, "\n/** go %s", t.Go)
+		if t.Notes != "" {
+			_, _ = fmt.Fprintf(&b, ": %s", t.Notes)
+		}
+		_, _ = fmt.Fprintf(&b, ". */\nexport type Go%s = %s;\n", exportedName(t.Go), t.TypeScript)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// exportPython writes types as Python type aliases, one per go type, stamped with p.
+func exportPython(w io.Writer, p Provenance, types []ExportedType) error {
+	var b strings.Builder
+	b.WriteString("# DO NOT EDIT - Generated code\n# Generated by go-conversions\n#\n")
+	b.WriteString(p.Comment("# "))
+	for _, t := range types {
+		_, _ = fmt.Fprintf(&b, "\n# go %s", t.Go)
+		if t.Notes != "" {
+			_, _ = fmt.Fprintf(&b, ": %s", t.Notes)
+		}
+		_, _ = fmt.Fprintf(&b, ".\nGo%s = %s\n", exportedName(t.Go), t.Python)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// exportedName capitalizes the first letter of name.
+func exportedName(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"os"
+	"path/filepath"
+)
+
+type (
+	// multiRows writes every row to the RowWriter of each of several formats, each writing to a
+	// file of its own.
+	multiRows struct {
+		writers []conversions.RowWriter
+		files   []*conversions.AtomicFile
+	}
+)
+
+// reportEach renders the report of m once in each of formats, each to the file reportFile
+// names for it.
+func reportEach(ctx context.Context, m conversions.Matrix, ropts ReportOptions, formats []string) error {
+	// NOTE: Every format is looked up first, so a typo doesn't leave the others half written.
+	for _, format := range formats {
+		_, err := conversions.LookupReporter(format)
+		if err != nil {
+			return errors.Wrap(err, "looking up reporter")
+		}
+	}
+
+	for _, format := range formats {
+		file, err := reportFile(format)
+		if err != nil {
+			return err
+		}
+		f, err := conversions.CreateAtomic(file, 0o644)
+		if err != nil {
+			return errors.Wrapf(err, "creating %s report %q", format, file)
+		}
+
+		sub := ropts
+		sub.Format = format
+		sub.Output = f
+		err = Report(ctx, m, sub)
+		if err != nil {
+			_ = f.Close()
+			return errors.Wrapf(err, "reporting %s", format)
+		}
+		err = f.Commit()
+		if err != nil {
+			return errors.Wrapf(err, "committing %s report %q", format, file)
+		}
+		logrus.Infof("wrote the %s report to %s", format, file)
+	}
+
+	return nil
+}
+
+// reportEachRows starts the report configured by ropts in each of formats, each writing to the
+// file reportFile names for it, as reportRows does for one. It reports false, without creating
+// any files, unless every format can be written a row at a time.
+func reportEachRows(ctx context.Context, types []string, ropts ReportOptions, formats []string) (conversions.RowWriter, bool, error) {
+	rows := !ropts.GroupByTag && !ropts.reordered()
+	for _, format := range formats {
+		r, err := conversions.LookupReporter(format)
+		if err != nil {
+			return nil, false, errors.Wrap(err, "looking up reporter")
+		}
+		_, ok := r.(conversions.RowReporter)
+		rows = rows && ok
+	}
+	if !rows {
+		return nil, false, nil
+	}
+
+	mr := &multiRows{}
+	for _, format := range formats {
+		file, err := reportFile(format)
+		if err != nil {
+			mr.discard()
+			return nil, false, err
+		}
+		f, err := conversions.CreateAtomic(file, 0o644)
+		if err != nil {
+			mr.discard()
+			return nil, false, errors.Wrapf(err, "creating %s report %q", format, file)
+		}
+		mr.files = append(mr.files, f)
+
+		sub := ropts
+		sub.Format = format
+		sub.Output = f
+		rw, _, err := reportRows(ctx, types, sub)
+		if err != nil {
+			mr.discard()
+			return nil, false, err
+		}
+		mr.writers = append(mr.writers, rw)
+		logrus.Infof("writing the %s report to %s", format, file)
+	}
+
+	return mr, true, nil
+}
+
+// reportFile is the file the report in format is written to when -format lists more than one:
+// the one the config's reports name for it, or one named after the format in -report-dir.
+func reportFile(format string) (string, error) {
+	c := config
+	if file, ok := c.Reports[format]; ok {
+		return file, nil
+	}
+
+	ext, ok := reportExtensions[format]
+	if !ok {
+		ext = ".txt"
+	}
+	err := os.MkdirAll(*reportDir, 0o755)
+	if err != nil {
+		return "", errors.Wrapf(err, "creating report directory %q", *reportDir)
+	}
+	// NOTE: text and table would otherwise both be matrix.txt.
+	name := "matrix" + ext
+	if ext == ".txt" {
+		name = "matrix." + format + ext
+	}
+	return filepath.Join(*reportDir, name), nil
+}
+
+// Pivot implements conversions.Pivoter, passing it on to every RowWriter.
+func (mr *multiRows) Pivot() {
+	for _, rw := range mr.writers {
+		if p, ok := rw.(conversions.Pivoter); ok {
+			p.Pivot()
+		}
+	}
+}
+
+// Row implements conversions.RowWriter.
+func (mr *multiRows) Row(ctx context.Context, from string, row []conversions.Result) error {
+	for _, rw := range mr.writers {
+		err := rw.Row(ctx, from, row)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close implements conversions.RowWriter, closing every RowWriter and then committing its
+// file, or discarding every file if any RowWriter fails to close.
+func (mr *multiRows) Close() error {
+	var first error
+	for _, rw := range mr.writers {
+		err := rw.Close()
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	if first != nil {
+		mr.discard()
+		return first
+	}
+	for _, f := range mr.files {
+		err := f.Commit()
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// discard discards every file not yet committed, leaving the reports written last time as they were.
+func (mr *multiRows) discard() {
+	for _, f := range mr.files {
+		_ = f.Close()
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const (
+	// GoldenFile is the name of the test file the golden command generates.
+	GoldenFile = "matrix_test.go"
+)
+
+var (
+	// goldenTemplate is the test generated by the golden command. It embeds the Matrix as a row of
+	// cells per type converted from, so a regenerated file diffs a cell at a time in review too.
+	goldenTemplate = template.Must(template.New("golden").Parse(`// DO NOT EDIT - Generated code
+{{$.Provenance}}
+package {{$.Package}}
+
+import (
+	"context"
+	"github.com/Insulince/go-conversions/conversions"
+	"strings"
+	"testing"
+)
+
+// goldenTypes are the types in goldenMatrix, in the order of the cells in each of its rows.
+var goldenTypes = []string{
+{{- range $.Types}}
+	{{printf "%q" .}},
+{{- end}}
+}
+
+// goldenMatrix is whether each type converts to each of goldenTypes, ✅ if it does, ❌ if it doesn't.
+var goldenMatrix = map[string]string{
+{{- range $.Rows}}
+	{{printf "%q" .From}}: "{{.Cells}}",
+{{- end}}
+}
+
+// TestConversionMatrix fails, listing every cell that changed, when the conversions between
+// goldenTypes no longer compile as they did when this file was generated.
+func TestConversionMatrix(t *testing.T) {
+	var opts conversions.Options
+	opts.Types = goldenTypes
+	opts.Engine = {{printf "%q" $.Engine}}
+{{- with $.GOARCH}}
+	opts.GOARCH = {{printf "%q" .}}
+{{- end}}
+	opts.OutputDir = t.TempDir()
+	m, err := conversions.Analyze(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("analyzing: %v", err)
+	}
+
+	var diff strings.Builder
+	for _, from := range goldenTypes {
+		cells := []rune(goldenMatrix[from])
+		for i, to := range goldenTypes {
+			want := i < len(cells) && cells[i] == '✅'
+			if got := m.Convertible(from, to); got != want {
+				diff.WriteString("\n\t" + from + " -> " + to + ": " + goldenCell(want) + " expected, " + goldenCell(got) + " now")
+			}
+		}
+	}
+	if diff.Len() > 0 {
+		t.Errorf("the conversion matrix changed, regenerate %s with the golden command if that's expected:%s", {{printf "%q" $.File}}, diff.String())
+	}
+}
+
+// goldenCell renders whether a conversion compiles as it is in goldenMatrix.
+func goldenCell(convertible bool) string {
+	if convertible {
+		return "✅"
+	}
+	return "❌"
+}
+`))
+)
+
+// Golden analyzes every primitive against every other primitive and writes the Matrix out as
+// a go test, to be checked into the project depending on it, which fails with every cell that
+// differs when the conversions no longer compile the same way, e.g. after a go upgrade.
+func Golden(ctx context.Context, args []string) error {
+	var outputDir, pkg string
+	fs := flag.NewFlagSet("golden", flag.ContinueOnError)
+	fs.StringVar(&outputDir, "out", ".", "directory to write "+GoldenFile+" to")
+	fs.StringVar(&pkg, "package", "", "package of the generated test (default the package already in -out, or its directory name)")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+	opts = opts.WithDefaults()
+	if opts.Engine == conversions.EngineRemote {
+		return errors.Errorf("the generated test can't reach the remote build service, use -engine %s or %s", conversions.EngineBuild, conversions.EngineTypes)
+	}
+	if opts.TemplateFile != "" {
+		// NOTE: The template lives in this project, not the one the test is generated for.
+		logrus.Warnf("the generated test checks with the default template, not %s", opts.TemplateFile)
+	}
+
+	if pkg == "" {
+		pkg, err = packageIn(outputDir)
+		if err != nil {
+			return errors.Wrapf(err, "determining the package in %q", outputDir)
+		}
+	}
+
+	m, err := conversions.Analyze(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+
+	p, err := ProvenanceFor(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "determining provenance")
+	}
+
+	src, err := renderGolden(m, pkg, opts, p)
+	if err != nil {
+		return errors.Wrap(err, "rendering")
+	}
+
+	outputFile := filepath.Join(outputDir, GoldenFile)
+	err = conversions.WriteFileAtomic(outputFile, src, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "writing %q", outputFile)
+	}
+
+	logrus.Infof("wrote the %d cell matrix to %s", len(m.Results), outputFile)
+
+	return nil
+}
+
+// renderGolden renders the golden test of m, in package pkg, checked as configured by opts.
+func renderGolden(m conversions.Matrix, pkg string, opts conversions.Options, p Provenance) ([]byte, error) {
+	type Row struct {
+		From  string
+		Cells string
+	}
+	type Data struct {
+		Provenance string
+		Package    string
+		File       string
+		Engine     string
+		GOARCH     string
+		Types      []string
+		Rows       []Row
+	}
+	var data Data
+	data.Provenance = p.Comment("// ")
+	data.Package = pkg
+	data.File = GoldenFile
+	data.Engine = opts.Engine
+	data.GOARCH = opts.GOARCH
+	data.Types = m.Types
+	for _, from := range m.Types {
+		var row Row
+		row.From = from
+		for _, to := range m.Types {
+			if m.Convertible(from, to) {
+				row.Cells += "✅"
+			} else {
+				row.Cells += "❌"
+			}
+		}
+		data.Rows = append(data.Rows, row)
+	}
+
+	var buf bytes.Buffer
+	err := goldenTemplate.Execute(&buf, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "executing template")
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "formatting")
+	}
+
+	return src, nil
+}
+
+// packageIn is the name of the package whose go files are in dir, without any _test suffix, or
+// the name of dir itself when it has none.
+func packageIn(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", errors.Wrap(err, "resolving directory")
+	}
+
+	files, err := filepath.Glob(filepath.Join(abs, "*.go"))
+	if err != nil {
+		return "", errors.Wrap(err, "listing go files")
+	}
+	for _, file := range files {
+		f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.PackageClauseOnly)
+		if err != nil {
+			return "", errors.Wrapf(err, "parsing %q", file)
+		}
+		return strings.TrimSuffix(f.Name.Name, "_test"), nil
+	}
+
+	// NOTE: Directory names aren't always valid identifiers, e.g. go-conversions.
+	name := strings.Map(func(r rune) rune {
+		if r == '_' || ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return -1
+	}, strings.ToLower(filepath.Base(abs)))
+	if !token.IsIdentifier(name) {
+		return "", errors.Errorf("%q isn't a valid package name, set -package", filepath.Base(abs))
+	}
+	return name, nil
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/Insulince/go-conversions/helpers"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	// GosecOverflowRule is the gosec rule flagging integer conversions which may overflow.
+	GosecOverflowRule = "G115"
+)
+
+type (
+	// Finding is a single G115 finding from gosec or golangci-lint, annotated with what this
+	// program knows about the conversion it flags.
+	Finding struct {
+		File   string
+		Line   int
+		Column int
+		Text   string
+		From   string
+		To     string
+		// Exact is whether every From value converts to To unchanged on every platform, making the finding a false positive.
+		Exact bool
+		// Loss describes how the conversion can change a value, empty when Exact.
+		Loss string
+		// Helper is the generated helper function to replace the conversion with, empty when there is none.
+		Helper string
+	}
+
+	// gosecReport is the subset of gosec's JSON output read by ParseFindings.
+	gosecReport struct {
+		Issues []struct {
+			RuleID  string `json:"rule_id"`
+			Details string `json:"details"`
+			File    string `json:"file"`
+			Line    string `json:"line"`
+			Column  string `json:"column"`
+		} `json:"Issues"`
+	}
+
+	// golangciReport is the subset of golangci-lint's JSON output read by ParseFindings.
+	golangciReport struct {
+		Issues []struct {
+			FromLinter string `json:"FromLinter"`
+			Text       string `json:"Text"`
+			Pos        struct {
+				Filename string `json:"Filename"`
+				Line     int    `json:"Line"`
+				Column   int    `json:"Column"`
+			} `json:"Pos"`
+		} `json:"Issues"`
+	}
+)
+
+var (
+	// overflowPairRegexp extracts the from and to types out of a G115 finding, e.g.
+	// "integer overflow conversion int64 -> int32".
+	overflowPairRegexp = regexp.MustCompile(`(\w+) -> (\w+)`)
+)
+
+// Gosec reads the JSON output of gosec or golangci-lint given by -in and writes a markdown
+// remediation report annotating every G115 integer overflow finding with whether the conversion
+// can actually lose information, how, and which generated helper to use instead.
+func Gosec(ctx context.Context, args []string) error {
+	var inputFile, outputFile string
+	fs := flag.NewFlagSet("gosec", flag.ContinueOnError)
+	fs.StringVar(&inputFile, "in", "", "JSON output of gosec -fmt json or golangci-lint run --out-format json (default stdin)")
+	fs.StringVar(&outputFile, "out", "", "file to write the remediation report to (default stdout)")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	var r io.Reader = os.Stdin
+	if inputFile != "" {
+		f, err := os.Open(inputFile)
+		if err != nil {
+			return errors.Wrapf(err, "opening findings %q", inputFile)
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return errors.Wrap(err, "reading findings")
+	}
+
+	findings, err := ParseFindings(b)
+	if err != nil {
+		return errors.Wrap(err, "parsing findings")
+	}
+
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	m, err := conversions.Analyze(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+
+	Annotate(m, findings)
+
+	var w io.Writer = os.Stdout
+	var f *conversions.AtomicFile
+	if outputFile != "" {
+		f, err = conversions.CreateAtomic(outputFile, 0o644)
+		if err != nil {
+			return errors.Wrapf(err, "creating output file %q", outputFile)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+
+	err = writeRemediation(w, findings)
+	if err != nil {
+		return errors.Wrap(err, "writing remediation report")
+	}
+	if f != nil {
+		err = f.Commit()
+		if err != nil {
+			return errors.Wrapf(err, "committing output file %q", outputFile)
+		}
+	}
+
+	logrus.Infof("annotated %d %s findings", len(findings), GosecOverflowRule)
+
+	return nil
+}
+
+// ParseFindings extracts every G115 finding out of b, which is either gosec's or golangci-lint's JSON output.
+func ParseFindings(b []byte) ([]Finding, error) {
+	var gr gosecReport
+	err := json.Unmarshal(b, &gr)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding JSON")
+	}
+
+	var findings []Finding
+	for _, issue := range gr.Issues {
+		if issue.RuleID != GosecOverflowRule {
+			continue
+		}
+		var f Finding
+		f.File = issue.File
+		_, _ = fmt.Sscan(issue.Line, &f.Line)
+		_, _ = fmt.Sscan(issue.Column, &f.Column)
+		f.Text = issue.Details
+		findings = append(findings, f)
+	}
+
+	// NOTE: Both formats have a top level Issues field, but only golangci-lint's issues have a FromLinter.
+	var lr golangciReport
+	err = json.Unmarshal(b, &lr)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding JSON")
+	}
+	for _, issue := range lr.Issues {
+		if issue.FromLinter != "gosec" || !strings.HasPrefix(issue.Text, GosecOverflowRule) {
+			continue
+		}
+		var f Finding
+		f.File = issue.Pos.Filename
+		f.Line = issue.Pos.Line
+		f.Column = issue.Pos.Column
+		f.Text = issue.Text
+		findings = append(findings, f)
+	}
+
+	for i := range findings {
+		matches := overflowPairRegexp.FindStringSubmatch(findings[i].Text)
+		if matches == nil {
+			continue
+		}
+		findings[i].From = matches[1]
+		findings[i].To = matches[2]
+	}
+
+	return findings, nil
+}
+
+// Annotate fills in what m, and the helpers generated from it, say about the conversion each finding flags.
+func Annotate(m conversions.Matrix, findings []Finding) {
+	names := make(map[string]string)
+	for _, h := range helpers.Helpers(m) {
+		names[h.From.Name+conversions.PairSeparator+h.To.Name] = h.Name
+	}
+
+	for i := range findings {
+		f := &findings[i]
+		from, ok := conversions.Lookup(f.From)
+		if !ok {
+			continue
+		}
+		to, ok := conversions.Lookup(f.To)
+		if !ok {
+			continue
+		}
+		f.Exact = from.IsNumeric() && to.IsNumeric() && conversions.Exact(from, to)
+		if !f.Exact {
+			f.Loss = lossDescription(from, to)
+		}
+		f.Helper = names[f.From+conversions.PairSeparator+f.To]
+	}
+}
+
+// lossDescription describes how converting a value of the numeric type from to the numeric type to can change it.
+func lossDescription(from, to conversions.Info) string {
+	switch {
+	case from.IsInteger() && to.IsInteger():
+		if from.Kind == conversions.KindInt && to.Kind == conversions.KindUint {
+			if to.MinBits() >= from.MaxBits() {
+				return "negative values wrap around"
+			}
+			return fmt.Sprintf("negative values, and values above the largest %s, wrap around", to.Name)
+		}
+		return fmt.Sprintf("values outside the range of %s wrap around", to.Name)
+	case from.IsInteger():
+		return fmt.Sprintf("integers with a magnitude beyond 2^%d are rounded to the nearest %s", to.Mantissa(), to.Name)
+	case to.IsInteger():
+		return fmt.Sprintf("the fractional part is truncated, and values outside the range of %s are implementation-specific", to.Name)
+	default:
+		return fmt.Sprintf("values are rounded to %s precision, and values beyond its range become ±Inf", to.Name)
+	}
+}
+
+// writeRemediation writes findings as a markdown remediation report.
+func writeRemediation(w io.Writer, findings []Finding) error {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "# %s remediation\n\n", GosecOverflowRule)
+	_, _ = fmt.Fprintf(&b, "| location | conversion | verdict | fix |\n|---|---|---|---|\n")
+	for _, f := range findings {
+		conversion := "`" + f.From + " -> " + f.To + "`"
+		if f.From == "" {
+			conversion = f.Text
+		}
+
+		var verdict, fix string
+		switch {
+		case f.From == "":
+			verdict = "unrecognized finding"
+		case f.Exact:
+			verdict = "✅ false positive, every value converts exactly on every platform"
+			fix = "suppress with `//nolint:gosec`"
+		default:
+			verdict = "⚠️ " + f.Loss
+		}
+		if f.Helper != "" && !f.Exact {
+			fix = "use `conv." + f.Helper + "`, which returns an error instead"
+		}
+
+		_, _ = fmt.Fprintf(&b, "| %s:%d:%d | %s | %s | %s |\n", f.File, f.Line, f.Column, conversion, verdict, fix)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
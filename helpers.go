@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/Insulince/go-conversions/helpers"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"os"
+)
+
+// Helpers analyzes every primitive against every other primitive and generates a library
+// of checked conversion functions for the numeric pairs that can be converted, along with
+// a test suite proving they don't allocate.
+func Helpers(ctx context.Context, args []string) error {
+	var hopts helpers.Options
+	fs := flag.NewFlagSet("helpers", flag.ContinueOnError)
+	fs.StringVar(&hopts.OutputDir, "out", helpers.DefaultOutputDir, "directory to write the generated package to")
+	fs.StringVar(&hopts.Package, "package", "", fmt.Sprintf("name of the generated package (default %q)", helpers.DefaultPackage))
+	fs.StringVar(&hopts.ImportPath, "import-path", "", "import path of the generated package, e.g. example.com/org/repo/conv, written as its import comment")
+	fs.StringVar(&hopts.Header, "header", "", "text commented out below the generated notice of every file, e.g. an ownership line")
+	licenseFile := fs.String("license-file", "", "file whose text is commented out at the top of every generated file")
+	fs.StringVar(&hopts.Style, "style", helpers.StyleFunctions, fmt.Sprintf("%s generates a function per pair of types, %s a few generic functions instead, %s the functions checking integers against math's bounds constants, %s the generic functions constrained by golang.org/x/exp/constraints", helpers.StyleFunctions, helpers.StyleGeneric, helpers.StyleBounds, helpers.StyleConstraints))
+	fs.BoolVar(&hopts.Big, "big", false, "also generate helpers converting to and from *big.Int, *big.Float, and *big.Rat")
+	fs.BoolVar(&hopts.Parse, "parse", false, "also generate helpers parsing strings as each integer and float, accepting 0x, 0b, and 0o prefixes, and optionally underscores and surrounding white space")
+	fs.StringVar(&hopts.Pointers, "pointers", "", "also generate helpers converting a *From to a *To and dereferencing pointers, treating nil as pointing to zero (zero), as an error (error), or converting it to nil (nil)")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	err = configureHelpers(&hopts, *licenseFile)
+	if err != nil {
+		return errors.Wrap(err, "configuring helpers")
+	}
+
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	m, err := conversions.Analyze(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+
+	err = helpers.Generate(ctx, m, hopts)
+	if err != nil {
+		return errors.Wrap(err, "generating helpers")
+	}
+
+	logrus.Infof("generated %d helpers in %s", len(helpers.Helpers(m)), hopts.OutputDir)
+	if hopts.Style == helpers.StyleConstraints {
+		logrus.Infof("the generated package imports golang.org/x/exp/constraints, run go get golang.org/x/exp in its module if it doesn't already require it")
+	}
+
+	return nil
+}
+
+// configureHelpers fills in whatever hopts and licenseFile, as set by flags, leave unset from
+// the helpers section of the config, and reads the license text.
+func configureHelpers(hopts *helpers.Options, licenseFile string) error {
+	c := config
+	if hopts.Package == "" {
+		hopts.Package = c.Helpers.Package
+	}
+	if hopts.ImportPath == "" {
+		hopts.ImportPath = c.Helpers.ImportPath
+	}
+	if hopts.Header == "" {
+		hopts.Header = c.Helpers.Header
+	}
+	if licenseFile == "" {
+		licenseFile = c.Helpers.LicenseFile
+	}
+
+	if licenseFile != "" {
+		b, err := os.ReadFile(licenseFile)
+		if err != nil {
+			return errors.Wrapf(err, "reading license file %q", licenseFile)
+		}
+		hopts.License = string(b)
+	}
+
+	return nil
+}
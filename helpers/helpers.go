@@ -0,0 +1,712 @@
+// Package helpers generates a library of checked conversion functions for every pair of
+// numeric types a conversions.Matrix reports as convertible, along with a test suite proving
+// they behave and don't allocate.
+package helpers
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"go/format"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	// DefaultPackage is the name of the generated package when Options.Package is not set.
+	DefaultPackage = "conv"
+	// DefaultOutputDir is the directory the generated package is written to when Options.OutputDir is not set.
+	DefaultOutputDir = "./conv"
+
+	// StyleFunctions generates a checked function for every pair of types, e.g. Int64ToInt32.
+	StyleFunctions = "functions"
+	// StyleGeneric generates a handful of generic functions, e.g. Convert[int32](v), which work out
+	// how to check each conversion from the types they are instantiated with.
+	StyleGeneric = "generic"
+	// StyleBounds generates the same functions as StyleFunctions, but checks conversions between
+	// integers by comparing against math's bounds constants, e.g. v > math.MaxInt32, rather than
+	// by converting back.
+	StyleBounds = "bounds"
+	// StyleConstraints generates the same functions as StyleGeneric, but constrained by
+	// golang.org/x/exp/constraints' Integer and Float, which the generated package then imports,
+	// rather than by a union of its own.
+	StyleConstraints = "constraints"
+
+	// CheckNone means every value of the From type can be represented exactly by the To type.
+	CheckNone = "none"
+	// CheckInteger means the integer conversion is checked by converting back and comparing signs.
+	CheckInteger = "integer"
+	// CheckIntegerToFloat means the integer to float conversion is checked against the From type's
+	// upper bound before converting back.
+	CheckIntegerToFloat = "integer-to-float"
+	// CheckFloatToInteger means the float is checked against the To type's bounds and for a fractional part.
+	CheckFloatToInteger = "float-to-integer"
+	// CheckFloat means the float conversion is checked by converting back, allowing NaN through.
+	CheckFloat = "float"
+
+	// NilZero makes the pointer helpers treat a nil pointer as pointing to the zero value.
+	NilZero = "zero"
+	// NilError makes the pointer helpers return ErrNilPointer for a nil pointer.
+	NilError = "error"
+	// NilPassthrough makes the pointer helpers convert a nil pointer to a nil pointer, and
+	// dereference it as the zero value, which is as close as a value gets.
+	NilPassthrough = "nil"
+)
+
+type (
+	// Options configures the generated helper library.
+	Options struct {
+		// Package is the name of the generated package. Defaults to DefaultPackage.
+		Package string
+		// ImportPath, when set, is the generated package's import path, e.g.
+		// "example.com/org/repo/conv", stated in an import comment so that go build refuses to
+		// compile it when vendored or copied to anywhere else.
+		ImportPath string
+		// Header is any text, e.g. an ownership or lint directive, commented out below the
+		// notice every generated file starts with.
+		Header string
+		// License is the license text, commented out at the very top of every generated file.
+		License string
+		// OutputDir is the directory the generated package is written to. Defaults to DefaultOutputDir.
+		OutputDir string
+		// Style is the kind of package generated, one of StyleFunctions, StyleGeneric, StyleBounds,
+		// or StyleConstraints. Defaults to StyleFunctions.
+		Style string
+		// Big is whether helpers converting between the integers and floats and math/big's
+		// *big.Int, *big.Float, and *big.Rat are generated too, see conversions.BigConversions.
+		Big bool
+		// Parse is whether functions parsing strings as each of the integers and floats are
+		// generated too, accepting base prefixes and, optionally, underscores and white space.
+		Parse bool
+		// Pointers, when set, generates functions converting a *From to a *To and dereferencing a
+		// *T to a T too, handling nil pointers as it says, one of NilZero, NilError, or NilPassthrough.
+		Pointers string
+	}
+
+	// Helper describes a single generated conversion function.
+	Helper struct {
+		Name string
+		// SliceName is the name of the functions converting a slice of From to a slice of To,
+		// failing fast, and on a best-effort basis with a BestEffort suffix.
+		SliceName string
+		// MapKeysName and MapValuesName are the names of the functions converting the keys, or
+		// the values, of a map from From to To.
+		MapKeysName   string
+		MapValuesName string
+		// PtrName is the name of the function converting a *From to a *To.
+		PtrName string
+		From    conversions.Info
+		To      conversions.Info
+		// Check is how the conversion is checked for loss, one of the Check constants.
+		Check string
+		// Min and MaxPlusOne are expressions for the bounds of the integer side of a conversion
+		// between an integer and a float.
+		Min        string
+		MaxPlusOne string
+		// Below and Above are conditions comparing v against the bounds constants in math, true
+		// when it is below the smallest To, or above the largest, for a conversion between
+		// integers. Either is empty when no From on any platform can be.
+		Below string
+		Above string
+		// FormatValue is an expression formatting v as a string for error messages.
+		FormatValue string
+		// FailingValue is a literal From value which can't be converted to To on any platform,
+		// and FailingText is how that value is formatted in the resulting *RangeError. Both are
+		// empty when there is no such value, or it can't be written portably.
+		FailingValue string
+		FailingText  string
+		// Precision is how much the conversion can round, for one to a float that isn't exact.
+		Precision *conversions.Precision
+	}
+
+	// BigHelper describes a single generated function converting to or from a math/big type.
+	BigHelper struct {
+		Name string
+		From string
+		To   string
+		// Primitive is the primitive converted to or from, the zero Info when both From and To
+		// are math/big types.
+		Primitive conversions.Info
+		// Exact is whether every From converts to To exactly, so the error is always nil.
+		Exact bool
+		// FormatValue is an expression formatting v, the From, as a string for error messages.
+		FormatValue string
+	}
+
+	// ParseHelper describes a single generated function parsing a string as a number.
+	ParseHelper struct {
+		Name string
+		Info conversions.Info
+		// Func is the strconv function parsing it, and BitSize the bit size it's given, 0 for
+		// the size of int.
+		Func    string
+		BitSize string
+	}
+
+	// BigType describes a primitive the math/big helpers convert, for their tests.
+	BigType struct {
+		Info conversions.Info
+		// Min and Max are expressions for the smallest and largest values of the type.
+		Min string
+		Max string
+		// Mantissa is the number of bits in the mantissa of a float.
+		Mantissa int
+	}
+)
+
+var (
+	// HelpersTemplate is the template the helper functions are generated from.
+	//go:embed template/helpers.tmpl
+	HelpersTemplate string
+	// SlicesTemplate is the template the slice converting helper functions are generated from.
+	//go:embed template/slices.tmpl
+	SlicesTemplate string
+	// SliceTestsTemplate is the template the slice converting helper functions' tests are generated from.
+	//go:embed template/slices_test.tmpl
+	SliceTestsTemplate string
+	// MapsTemplate is the template the map converting helper functions are generated from.
+	//go:embed template/maps.tmpl
+	MapsTemplate string
+	// MapTestsTemplate is the template the map converting helper functions' tests are generated from.
+	//go:embed template/maps_test.tmpl
+	MapTestsTemplate string
+	// TestsTemplate is the template the helper functions' test suite is generated from.
+	//go:embed template/helpers_test.tmpl
+	TestsTemplate string
+	// ExamplesTemplate is the template the helper functions' runnable examples are generated from.
+	//go:embed template/examples_test.tmpl
+	ExamplesTemplate string
+	// GenericTemplate is the template the generic helper functions are generated from.
+	//go:embed template/generic.tmpl
+	GenericTemplate string
+	// GenericTestsTemplate is the template the generic helper functions' tests are generated from.
+	//go:embed template/generic_test.tmpl
+	GenericTestsTemplate string
+	// TimeTemplate is the template the helper functions converting time.Durations and time.Times
+	// with an explicit unit are generated from.
+	//go:embed template/time.tmpl
+	TimeTemplate string
+	// TimeTestsTemplate is the template the time helper functions' tests are generated from.
+	//go:embed template/time_test.tmpl
+	TimeTestsTemplate string
+	// BigTemplate is the template the helper functions converting to and from math/big's types
+	// are generated from.
+	//go:embed template/big.tmpl
+	BigTemplate string
+	// BigTestsTemplate is the template the math/big helper functions' tests are generated from.
+	//go:embed template/big_test.tmpl
+	BigTestsTemplate string
+	// ParseTemplate is the template the helper functions parsing strings as numbers are generated from.
+	//go:embed template/parse.tmpl
+	ParseTemplate string
+	// ParseTestsTemplate is the template the string parsing helper functions' tests are generated from.
+	//go:embed template/parse_test.tmpl
+	ParseTestsTemplate string
+	// PointersTemplate is the template the helper functions converting and dereferencing pointers
+	// are generated from.
+	//go:embed template/pointers.tmpl
+	PointersTemplate string
+	// PointerTestsTemplate is the template the pointer helper functions' tests are generated from.
+	//go:embed template/pointers_test.tmpl
+	PointerTestsTemplate string
+)
+
+// Generate writes the helper library for every convertible numeric pair in m, with functions
+// converting single values, slices, and map keys and values, and time.Durations and time.Times
+// with an explicit unit, along with its test suite, to opts.OutputDir. With StyleGeneric the
+// library is a few generic functions constrained to the types m reports as all convertible to
+// one another, rather than a function per pair. With opts.Big, functions converting to and
+// from math/big's types are generated as well, with opts.Parse, functions parsing strings as
+// numbers, and with opts.Pointers, functions converting and dereferencing pointers, in any style.
+func Generate(_ context.Context, m conversions.Matrix, opts Options) error {
+	opts = opts.WithDefaults()
+
+	type Data struct {
+		Now     string
+		App     string
+		Package string
+		// ImportPath, Header, and License are as set in Options.
+		ImportPath string
+		Header     string
+		License    string
+		Helpers    []Helper
+		Sources    []string
+		Targets    []string
+		// Constraint is the type set of the generic functions.
+		Constraint []string
+		// Bounds is whether integers are checked against math's bounds constants, see StyleBounds,
+		// and Constraints whether the generic functions are constrained by
+		// golang.org/x/exp/constraints, see StyleConstraints.
+		Bounds      bool
+		Constraints bool
+		// UsesMath and UsesStrconv are whether the generated code needs to import those packages.
+		UsesMath    bool
+		UsesStrconv bool
+		// BigHelpers and BigTypes are the math/big helpers, when Options.Big is set, and the
+		// primitives they convert, and BigUsesStrconv whether they need to import strconv.
+		BigHelpers     []BigHelper
+		BigTypes       []BigType
+		BigUsesStrconv bool
+		// ParseHelpers are the string parsing helpers, when Options.Parse is set.
+		ParseHelpers []ParseHelper
+		// Pointers is how the pointer helpers handle nil, see Options.Pointers, Generic whether
+		// they're generic, and PointerTypes the types dereferenced when they aren't.
+		Pointers     string
+		Generic      bool
+		PointerTypes []string
+	}
+	var data Data
+	data.Now = time.Now().Format(time.RFC3339)
+	data.App = os.Args[0]
+	data.Package = opts.Package
+	data.ImportPath = opts.ImportPath
+	data.Header = strings.TrimSpace(opts.Header)
+	data.License = strings.TrimSpace(opts.License)
+	data.Helpers = Helpers(m)
+	data.Constraint = Constraint(m)
+	data.Bounds = opts.Style == StyleBounds
+	data.Constraints = opts.Style == StyleConstraints
+	for _, h := range data.Helpers {
+		data.Sources = appendUnique(data.Sources, h.From.Name)
+		data.Targets = appendUnique(data.Targets, h.To.Name)
+		data.UsesMath = data.UsesMath || strings.HasPrefix(h.MaxPlusOne, "math.")
+		if data.Bounds {
+			data.UsesMath = data.UsesMath || strings.Contains(h.Below+h.Above, "math.")
+		}
+		data.UsesStrconv = data.UsesStrconv || h.Check != CheckNone
+	}
+	if opts.Big {
+		data.BigHelpers = BigHelpers(m)
+		data.BigTypes = BigTypes(m)
+		for _, h := range data.BigHelpers {
+			data.BigUsesStrconv = data.BigUsesStrconv || strings.HasPrefix(h.FormatValue, "strconv.")
+		}
+	}
+
+	if opts.Parse {
+		data.ParseHelpers = ParseHelpers(m)
+	}
+
+	switch opts.Pointers {
+	case "", NilZero, NilError, NilPassthrough:
+	default:
+		return errors.Errorf("unknown nil pointer handling %q, expected %s, %s, or %s", opts.Pointers, NilZero, NilError, NilPassthrough)
+	}
+	data.Pointers = opts.Pointers
+	data.Generic = opts.Style == StyleGeneric || opts.Style == StyleConstraints
+	for _, t := range append(append([]string(nil), data.Sources...), data.Targets...) {
+		data.PointerTypes = appendUnique(data.PointerTypes, t)
+	}
+
+	err := os.MkdirAll(opts.OutputDir, 0o755)
+	if err != nil {
+		return errors.Wrapf(err, "creating output directory %q", opts.OutputDir)
+	}
+
+	type File struct {
+		name string
+		tmpl string
+	}
+	var files []File
+	switch opts.Style {
+	case StyleFunctions, StyleBounds:
+		files = []File{
+			{name: "helpers.go", tmpl: HelpersTemplate},
+			{name: "helpers_test.go", tmpl: TestsTemplate},
+			{name: "examples_test.go", tmpl: ExamplesTemplate},
+			{name: "slices.go", tmpl: SlicesTemplate},
+			{name: "slices_test.go", tmpl: SliceTestsTemplate},
+			{name: "maps.go", tmpl: MapsTemplate},
+			{name: "maps_test.go", tmpl: MapTestsTemplate},
+		}
+	case StyleGeneric, StyleConstraints:
+		files = []File{
+			{name: "generic.go", tmpl: GenericTemplate},
+			{name: "generic_test.go", tmpl: GenericTestsTemplate},
+		}
+	default:
+		return errors.Errorf("unknown style %q", opts.Style)
+	}
+	// NOTE: Time helpers take a unit rather than a type, so are the same in any style.
+	files = append(files, File{name: "time.go", tmpl: TimeTemplate}, File{name: "time_test.go", tmpl: TimeTestsTemplate})
+	if opts.Big {
+		files = append(files, File{name: "big.go", tmpl: BigTemplate}, File{name: "big_test.go", tmpl: BigTestsTemplate})
+	}
+	if opts.Parse {
+		files = append(files, File{name: "parse.go", tmpl: ParseTemplate}, File{name: "parse_test.go", tmpl: ParseTestsTemplate})
+	}
+	if opts.Pointers != "" {
+		files = append(files, File{name: "pointers.go", tmpl: PointersTemplate}, File{name: "pointers_test.go", tmpl: PointerTestsTemplate})
+	}
+	for _, file := range files {
+		outputFile := filepath.Join(opts.OutputDir, file.name)
+		err := generateFile(outputFile, file.tmpl, data)
+		if err != nil {
+			return errors.Wrapf(err, "generating %q", outputFile)
+		}
+	}
+
+	return nil
+}
+
+// Helpers lists a Helper for every pair of distinct numeric types m reports as convertible.
+func Helpers(m conversions.Matrix) []Helper {
+	var helpers []Helper
+	for _, result := range m.Results {
+		if !result.Convertible {
+			continue
+		}
+		from, ok := conversions.Lookup(result.From)
+		if !ok || !from.IsNumeric() {
+			continue
+		}
+		to, ok := conversions.Lookup(result.To)
+		if !ok || !to.IsNumeric() {
+			continue
+		}
+		if from.Canonical() == to.Canonical() {
+			continue
+		}
+
+		var h Helper
+		h.Name = exported(from.Name) + "To" + exported(to.Name)
+		h.SliceName = exported(from.Name) + "sTo" + exported(to.Name) + "s"
+		h.MapKeysName = exported(from.Name) + "KeysTo" + exported(to.Name) + "Keys"
+		h.MapValuesName = exported(from.Name) + "ValuesTo" + exported(to.Name) + "Values"
+		h.PtrName = exported(from.Name) + "PtrTo" + exported(to.Name) + "Ptr"
+		h.From = from
+		h.To = to
+		h.Check = check(from, to)
+		switch h.Check {
+		case CheckIntegerToFloat:
+			h.Min, h.MaxPlusOne = bounds(from)
+		case CheckFloatToInteger:
+			h.Min, h.MaxPlusOne = bounds(to)
+		case CheckInteger:
+			h.Below, h.Above = rangeChecks(from, to)
+		}
+		h.FormatValue = formatValue(from)
+		h.FailingValue, h.FailingText = failingValue(from, to, h.Check)
+		if to.Kind == conversions.KindFloat && h.Check != CheckNone {
+			p := conversions.PrecisionOf(from, to)
+			h.Precision = &p
+		}
+		helpers = append(helpers, h)
+	}
+	return helpers
+}
+
+// BigHelpers describes the helper functions to generate for every conversion between the
+// integers and floats in m and math/big's types, and between math/big's types themselves.
+// Aliases are left out, since they would have the same helpers as the types they alias.
+func BigHelpers(m conversions.Matrix) []BigHelper {
+	var helpers []BigHelper
+	for _, bc := range conversions.BigConversions(m.Types) {
+		var h BigHelper
+		h.Name = bc.Helper
+		h.From = bc.From
+		h.To = bc.To
+		h.Exact = bc.Exact
+		primitive := bc.From
+		if conversions.IsBig(primitive) {
+			primitive = bc.To
+		}
+		if info, ok := conversions.Lookup(primitive); ok {
+			if info.AliasOf != "" {
+				continue
+			}
+			h.Primitive = info
+		}
+		switch bc.From {
+		case conversions.BigInt:
+			h.FormatValue = "v.String()"
+		case conversions.BigFloat:
+			h.FormatValue = "v.Text('g', -1)"
+		case conversions.BigRat:
+			h.FormatValue = "v.RatString()"
+		default:
+			h.FormatValue = formatValue(h.Primitive)
+		}
+		helpers = append(helpers, h)
+	}
+	return helpers
+}
+
+// ParseHelpers lists a ParseHelper for every integer and float in m, other than aliases, which
+// parse the same as the types they alias.
+func ParseHelpers(m conversions.Matrix) []ParseHelper {
+	var phs []ParseHelper
+	for _, typ := range m.Types {
+		info, ok := conversions.Lookup(typ)
+		if !ok || !info.IsNumeric() || info.AliasOf != "" {
+			continue
+		}
+		var ph ParseHelper
+		ph.Name = "Parse" + exported(typ)
+		ph.Info = info
+		ph.BitSize = strconv.Itoa(info.Bits)
+		switch info.Kind {
+		case conversions.KindFloat:
+			ph.Func = "ParseFloat"
+		case conversions.KindInt:
+			ph.Func = "ParseInt"
+		default:
+			ph.Func = "ParseUint"
+		}
+		phs = append(phs, ph)
+	}
+	return phs
+}
+
+// BigTypes describes the integers and floats in m which BigHelpers converts.
+func BigTypes(m conversions.Matrix) []BigType {
+	var bts []BigType
+	for _, typ := range m.Types {
+		info, ok := conversions.Lookup(typ)
+		if !ok || !info.IsNumeric() || info.Kind == conversions.KindComplex || info.AliasOf != "" {
+			continue
+		}
+		var bt BigType
+		bt.Info = info
+		switch {
+		case info.Kind == conversions.KindFloat:
+			bt.Min, bt.Max = "-math.Max"+exported(typ), "math.Max"+exported(typ)
+			bt.Mantissa = info.Mantissa()
+		case typ == "uintptr":
+			bt.Min, bt.Max = "0", "^uintptr(0)"
+		case info.Kind == conversions.KindInt:
+			bt.Min, bt.Max = "math.Min"+exported(typ), "math.Max"+exported(typ)
+		default:
+			bt.Min, bt.Max = "0", "math.Max"+exported(typ)
+		}
+		bts = append(bts, bt)
+	}
+	return bts
+}
+
+// Constraint lists the numeric types in m which m reports as convertible to and from every
+// other one, making up the type set the generic helpers accept. Aliases are left out, since
+// they would overlap with the types they alias.
+func Constraint(m conversions.Matrix) []string {
+	var constraint []string
+	for _, typ := range m.Types {
+		info, ok := conversions.Lookup(typ)
+		if !ok || !info.IsNumeric() || info.AliasOf != "" {
+			continue
+		}
+		satisfied := true
+		for _, other := range constraint {
+			satisfied = satisfied && m.Convertible(typ, other) && m.Convertible(other, typ)
+		}
+		if satisfied {
+			constraint = append(constraint, typ)
+		}
+	}
+	return constraint
+}
+
+// WithDefaults returns a copy of opts with every unset field given its default value.
+func (opts Options) WithDefaults() Options {
+	if opts.Package == "" {
+		opts.Package = DefaultPackage
+	}
+	if opts.OutputDir == "" {
+		opts.OutputDir = DefaultOutputDir
+	}
+	if opts.Style == "" {
+		opts.Style = StyleFunctions
+	}
+	return opts
+}
+
+// check determines how a conversion from from to to must be checked for loss.
+func check(from, to conversions.Info) string {
+	if conversions.Exact(from, to) {
+		return CheckNone
+	}
+
+	switch {
+	case from.IsInteger() && to.IsInteger():
+		return CheckInteger
+	case from.IsInteger() && to.Kind == conversions.KindFloat:
+		return CheckIntegerToFloat
+	case from.Kind == conversions.KindFloat && to.IsInteger():
+		return CheckFloatToInteger
+	default:
+		return CheckFloat
+	}
+}
+
+// bounds returns expressions for the smallest value of the integer type i and one more than
+// its largest value, both of which are exactly representable as a float64.
+func bounds(i conversions.Info) (string, string) {
+	name := i.Canonical()
+	switch name {
+	case "uintptr":
+		return "0", "maxUintptrPlusOne"
+	case "int", "int8", "int16", "int32", "int64":
+		suffix := exported(name)
+		return "math.Min" + suffix, "math.Max" + suffix + " + 1"
+	default:
+		return "0", "math.Max" + exported(name) + " + 1"
+	}
+}
+
+// rangeChecks returns the Below and Above conditions of a Helper converting the integer type
+// from to the integer type to. v is widened to a 64 bit integer first, since to's bounds
+// needn't be constants of from, and only compared against the upper bound as an unsigned one
+// once any negative v has been ruled out.
+func rangeChecks(from, to conversions.Info) (string, string) {
+	// NOTE: Converting a 64 bit v to itself would only be flagged as redundant.
+	widen := func(wide string) string {
+		if from.Canonical() == wide {
+			return "v"
+		}
+		return wide + "(v)"
+	}
+
+	var below, above string
+	if from.Kind == conversions.KindInt {
+		switch {
+		case to.Kind == conversions.KindUint:
+			below = "v < 0"
+		case from.MaxBits() > to.MinBits():
+			below = widen("int64") + " < math.Min" + exported(to.Canonical())
+		}
+	}
+
+	// NOTE: A signed type holds one bit less of magnitude than an unsigned one of the same size.
+	magnitude := func(i conversions.Info, bits int) int {
+		if i.Kind == conversions.KindInt {
+			return bits - 1
+		}
+		return bits
+	}
+	if magnitude(from, from.MaxBits()) > magnitude(to, to.MinBits()) {
+		max := "math.Max" + exported(to.Canonical())
+		if to.Canonical() == "uintptr" {
+			max = "uint64(^uintptr(0))"
+		}
+		widened := widen("uint64")
+		if from.Kind == conversions.KindInt && to.Kind == conversions.KindInt {
+			widened = widen("int64")
+		}
+		above = widened + " > " + max
+	}
+	return below, above
+}
+
+// failingValue returns a literal value of from which can't be converted to to on any platform,
+// along with how the generated code formats it, or two empty strings when there is no such value.
+func failingValue(from, to conversions.Info, check string) (string, string) {
+	switch check {
+	case CheckInteger:
+		if from.Kind == conversions.KindInt && to.Kind == conversions.KindUint {
+			return "-1", "-1"
+		}
+		// NOTE: One more than the largest value of to, so long as from can hold it everywhere.
+		bits := to.MaxBits()
+		if to.Kind == conversions.KindInt {
+			bits--
+		}
+		if !holds(from, bits) {
+			return "", ""
+		}
+		v := new(big.Int).Lsh(big.NewInt(1), uint(bits)).String()
+		return v, v
+	case CheckIntegerToFloat:
+		// NOTE: The smallest integer the float can't represent exactly.
+		bits := to.Mantissa()
+		if !holds(from, bits) {
+			return "", ""
+		}
+		v := new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), uint(bits)), big.NewInt(1)).String()
+		return v, v
+	case CheckFloatToInteger:
+		return "1.5", "1.5"
+	case CheckFloat:
+		v := float64(int64(1)<<to.Mantissa() + 1)
+		return "1<<" + strconv.Itoa(to.Mantissa()) + " + 1", strconv.FormatFloat(v, 'g', -1, from.Bits)
+	default:
+		return "", ""
+	}
+}
+
+// holds reports whether the integer type i can hold 1<<bits on every platform.
+func holds(i conversions.Info, bits int) bool {
+	available := i.MinBits()
+	if i.Kind == conversions.KindInt {
+		available--
+	}
+	return bits < available
+}
+
+// formatValue returns an expression formatting a value v of type i as a string.
+func formatValue(i conversions.Info) string {
+	switch i.Kind {
+	case conversions.KindInt:
+		return "strconv.FormatInt(int64(v), 10)"
+	case conversions.KindUint:
+		return "strconv.FormatUint(uint64(v), 10)"
+	default:
+		return "strconv.FormatFloat(float64(v), 'g', -1, " + strconv.Itoa(i.Bits) + ")"
+	}
+}
+
+// comment turns every line of text into a line comment.
+func comment(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("// "+strings.TrimRight(line, "\r"), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// exported capitalizes the first letter of name so it can be used in an exported identifier.
+func exported(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// appendUnique appends s to ss if it is not already in it.
+func appendUnique(ss []string, s string) []string {
+	for _, v := range ss {
+		if v == s {
+			return ss
+		}
+	}
+	return append(ss, s)
+}
+
+// generateFile executes tmpl with data, formats the result as go code, and writes it to outputFile.
+func generateFile(outputFile, tmpl string, data interface{}) error {
+	funcs := template.FuncMap{"exported": exported, "comment": comment}
+	t, err := template.New(filepath.Base(outputFile)).Funcs(funcs).Parse(tmpl)
+	if err != nil {
+		return errors.Wrap(err, "parsing template")
+	}
+
+	var buf bytes.Buffer
+	err = t.Execute(&buf, data)
+	if err != nil {
+		return errors.Wrap(err, "executing template")
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "formatting generated code")
+	}
+
+	err = conversions.WriteFileAtomic(outputFile, src, 0o644)
+	if err != nil {
+		return errors.Wrap(err, "writing generated code")
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+type (
+	// Hooks are shell commands, run with `sh -c`, before and after every command. Each is run
+	// with the metadata of the run in GO_CONVERSIONS_* environment variables, see hookEnv, and
+	// anything it prints goes to stderr so it never mixes with a report written to stdout.
+	Hooks struct {
+		// Pre are run, in order, before anything is generated. A pre hook can replace the types
+		// to analyze by writing them, comma or newline separated, to the file named by
+		// GO_CONVERSIONS_TYPES_FILE, e.g. to fetch the type list from a service.
+		Pre []string `json:"pre"`
+		// Post are run, in order, once the command has finished and reported, even if it failed.
+		// GO_CONVERSIONS_STATUS is "succeeded" or "failed", with GO_CONVERSIONS_ERROR set to why.
+		Post []string `json:"post"`
+	}
+)
+
+// RunPreHooks runs every pre hook in hooks before command, returning the types they wrote
+// to GO_CONVERSIONS_TYPES_FILE, if any.
+func RunPreHooks(ctx context.Context, hooks Hooks, command string) ([]string, error) {
+	if len(hooks.Pre) == 0 {
+		return nil, nil
+	}
+
+	f, err := os.CreateTemp("", "go-conversions-types")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating types file")
+	}
+	typesFile := f.Name()
+	_ = f.Close()
+	defer func() { _ = os.Remove(typesFile) }()
+
+	env, err := hookEnv(ctx, "pre", command)
+	if err != nil {
+		return nil, err
+	}
+	env = append(env, "GO_CONVERSIONS_TYPES_FILE="+typesFile)
+	err = runHooks(ctx, hooks.Pre, env)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(typesFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading types file %q", typesFile)
+	}
+	return splitList(strings.ReplaceAll(string(b), "\n", ",")), nil
+}
+
+// RunPostHooks runs every post hook in hooks after command, which failed with cmdErr if it is set.
+func RunPostHooks(ctx context.Context, hooks Hooks, command string, cmdErr error) error {
+	if len(hooks.Post) == 0 {
+		return nil
+	}
+
+	env, err := hookEnv(ctx, "post", command)
+	if err != nil {
+		return err
+	}
+	if cmdErr != nil {
+		env = append(env, "GO_CONVERSIONS_STATUS=failed", "GO_CONVERSIONS_ERROR="+cmdErr.Error())
+	} else {
+		env = append(env, "GO_CONVERSIONS_STATUS=succeeded")
+	}
+
+	// NOTE: Post hooks are usually uploading or notifying, so they still run if we're interrupted.
+	return runHooks(context.Background(), hooks.Post, env)
+}
+
+// runHooks runs each of hooks in turn with env added to the environment, stopping at the first which fails.
+func runHooks(ctx context.Context, hooks []string, env []string) error {
+	for _, hook := range hooks {
+		cmd := exec.CommandContext(ctx, "sh", "-c", hook)
+		cmd.Env = append(os.Environ(), env...)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		err := cmd.Run()
+		if err != nil {
+			return errors.Wrapf(err, "running hook %q", hook)
+		}
+	}
+	return nil
+}
+
+// hookEnv is the metadata of the run, as environment variables, passed to the hooks of stage
+// around command:
+//
+//	GO_CONVERSIONS_HOOK           pre or post
+//	GO_CONVERSIONS_COMMAND        the command being run, e.g. run or helpers
+//	GO_CONVERSIONS_ENGINE         the engine, as set by -engine
+//	GO_CONVERSIONS_FORMAT         the report format, as set by -format
+//	GO_CONVERSIONS_OUTPUT_DIR     where the generated go code is written
+//	GO_CONVERSIONS_VERSION        the version of this program
+//	GO_CONVERSIONS_REVISION       the VCS revision this program was built from, if known
+//	GO_CONVERSIONS_ANALYZED_WITH  the go version whose rules the results reflect
+func hookEnv(ctx context.Context, stage, command string) ([]string, error) {
+	var opts conversions.Options
+	opts.Engine = *engine
+	opts.RemoteURL = *remoteURL
+	p, err := ProvenanceFor(ctx, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "determining provenance")
+	}
+
+	return []string{
+		"GO_CONVERSIONS_HOOK=" + stage,
+		"GO_CONVERSIONS_COMMAND=" + command,
+		"GO_CONVERSIONS_ENGINE=" + p.Engine,
+		"GO_CONVERSIONS_FORMAT=" + *reportFormat,
+		"GO_CONVERSIONS_OUTPUT_DIR=" + OutputDir,
+		"GO_CONVERSIONS_VERSION=" + p.Version,
+		"GO_CONVERSIONS_REVISION=" + p.Revision,
+		"GO_CONVERSIONS_ANALYZED_WITH=" + p.AnalyzedWith,
+	}, nil
+}
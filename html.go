@@ -0,0 +1,600 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/Insulince/go-conversions/helpers"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"html/template"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DefaultHTMLDir is where the html command writes its pages when -out is not set.
+	DefaultHTMLDir = "./html"
+	// typesDir is the directory, relative to the root of the HTML output, the per-type pages are written to.
+	typesDir = "types"
+)
+
+type (
+	// Page is the data every HTML page's layout needs.
+	Page struct {
+		Title string
+		// Root is the relative path from the page to the root of the HTML output.
+		Root string
+		// Provenance is stamped in the footer of the page, when set.
+		Provenance conversions.Provenance
+		// Site is whether the page is part of the full site, which links every page from a navigation bar.
+		Site bool
+		// Lang is the language the page is in, English when empty.
+		Lang string
+		// Accessible is whether the page marks whether pairs compile with words rather than emoji.
+		Accessible bool
+		// Theme is the symbols and colors the page marks pairs with.
+		Theme conversions.Theme
+	}
+
+	// TypePage is everything the HTML detail page for a single type shows.
+	TypePage struct {
+		Info conversions.Info
+		// To and From are every pair with Info as the source and target respectively.
+		To   []PairDetail
+		From []PairDetail
+		// Helpers are the generated helper functions converting from Info.
+		Helpers []helpers.Helper
+		// Corpus is whether conversions were looked for in a corpus of real code, see conversions.FindUsages.
+		Corpus bool
+		// UsageCount is how many conversions from Info were found in the corpus.
+		UsageCount int
+	}
+
+	// htmlReporter renders the matrix page of the HTML report as a conversions.RowReporter, so
+	// it is written a row at a time no matter how big the Matrix is.
+	htmlReporter struct {
+		Page Page
+	}
+
+	// htmlRows renders the rows of the matrix page for an htmlReporter.
+	htmlRows struct {
+		t       *template.Template
+		w       io.Writer
+		page    Page
+		types   []string
+		pivoted bool
+		catalog conversions.Catalog
+		verbal  bool
+		// started is whether the matrix has begun.
+		started bool
+	}
+
+	// PairDetail describes a single conversion on a TypePage.
+	PairDetail struct {
+		From        string
+		To          string
+		Convertible bool
+		// Exact is whether every From value converts to To unchanged.
+		Exact bool
+		// Width is whether the conversion is widening, narrowing, or reinterpreting, see
+		// conversions.Classify.
+		Width string
+		// Example is a runnable program demonstrating the conversion failing or losing information,
+		// empty if there is nothing to demonstrate.
+		Example string
+		// Suggestion is the code to write instead of a conversion which doesn't compile, its Code
+		// empty if there is none, see conversions.Suggest.
+		Suggestion conversions.Suggestion
+		// UsageCount is how many times the conversion is made in the corpus, and Usages a few of
+		// them from as many repos as there are, see conversions.RepresentativeUsages.
+		UsageCount int
+		Usages     []conversions.Usage
+	}
+)
+
+var (
+	// htmlTemplates are the pages of the HTML report. Every page is a "layout" around its own "content".
+	htmlTemplates = template.Must(template.New("layout").Funcs(template.FuncMap{
+		"typePage": typePage,
+	}).Parse(`{{define "layout"}}{{template "header" $}}{{template "content" $}}{{template "footer" $}}{{end}}
+
+{{define "header"}}<!DOCTYPE html>
+<html lang="{{or $.Lang "en"}}">
+<head>
+<meta charset="utf-8">
+<title>{{$.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.5em; text-align: center; }
+td.pair { text-align: left; }
+a { text-decoration: none; }
+pre { background: #f6f8fa; padding: 1em; overflow-x: auto; }
+td code { white-space: pre; text-align: left; display: block; }
+footer { margin-top: 2em; color: #666; font-size: 0.8em; white-space: pre-line; }
+{{with $.Theme.YesColor}}.yes { color: {{.}}; }
+{{end}}{{with $.Theme.LossyColor}}.lossy { color: {{.}}; }
+{{end}}{{with $.Theme.NoColor}}.no { color: {{.}}; }
+{{end}}</style>
+</head>
+<body>
+{{if $.Site}}<nav><a href="{{$.Root}}index.html">Matrix</a> · <a href="{{$.Root}}cookbook.html">Cookbook</a> · <a href="{{$.Root}}history.html">History</a></nav>
+{{end}}{{end}}
+
+{{define "footer"}}{{with $.Provenance}}
+<footer>{{range .Lines}}{{.}}
+{{end}}</footer>{{end}}
+</body>
+</html>
+{{end}}
+
+{{define "matrix-begin"}}<h1>{{$.Heading}}</h1>
+<p>{{$.Rows}} {{$.Legend}}</p>
+<table>
+<tr><th>{{$.Corner}}</th>{{range $.Types}}<th><a href="{{typePage $.Root .}}">{{.}}</a></th>{{end}}</tr>
+{{end}}
+
+{{define "matrix-row"}}<tr><th><a href="{{typePage $.Root $.From}}">{{$.From}}</a></th>
+{{- range $.Cells}}<td>{{if .To}}<a href="{{typePage $.Root .From}}#to-{{.To}}" class="{{.Class}}" title="{{.From}} -> {{.To}}{{if .Width}}, {{.Width}}{{end}}{{if .Columns}}; {{.Columns}}{{end}}"{{if .Label}} aria-label="{{.Label}}{{if .Width}}, {{.Width}}{{end}}{{if .Columns}}; {{.Columns}}{{end}}"{{end}}>{{.Symbol}}</a>{{end}}</td>{{end}}</tr>
+{{end}}
+
+{{define "matrix-end"}}</table>
+{{end}}
+
+{{define "type"}}{{$name := $.Type.Info.Name}}<p><a href="{{$.Root}}index.html">← matrix</a></p>
+<h1>{{$name}}</h1>
+<p>{{$.Type.Info.Kind}}{{if $.Type.Info.Bits}}, {{$.Type.Info.Bits}} bits{{else if $.Type.Info.IsNumeric}}, 32 or 64 bits depending on the platform{{end}}{{if $.Type.Info.AliasOf}}, an alias of <a href="{{typePage $.Root $.Type.Info.AliasOf}}">{{$.Type.Info.AliasOf}}</a>{{end}}.</p>
+
+<h2>Converting {{$name}} values to</h2>
+<table>
+<tr><th>to</th><th>compiles</th><th>preserves the value</th><th>width</th><th>instead</th>{{if $.Type.Corpus}}<th>in the corpus</th>{{end}}</tr>
+{{range $.Type.To}}<tr id="to-{{.To}}"><td class="pair"><a href="{{typePage $.Root .To}}">{{.To}}</a></td><td>{{if $.Accessible}}{{if .Convertible}}YES{{else}}NO{{end}}{{else if .Convertible}}<span class="yes">{{$.Theme.Yes}}</span>{{else}}<span class="no">{{$.Theme.No}}</span>{{end}}</td><td>{{if not .Convertible}}-{{else if $.Accessible}}{{if .Exact}}YES, always{{else}}LOSSY, not always{{end}}{{else if .Exact}}<span class="yes">{{$.Theme.Yes}}</span> always{{else}}<span class="lossy">{{$.Theme.Lossy}}</span> not always{{end}}</td><td>{{or .Width "-"}}</td><td>{{if .Suggestion.Code}}<code title="{{.Suggestion.Note}}">{{.Suggestion.Code}}</code>{{else}}-{{end}}</td>{{if $.Type.Corpus}}<td>{{if .UsageCount}}<a href="#usages-{{.To}}">{{.UsageCount}}</a>{{else}}-{{end}}</td>{{end}}</tr>
+{{end}}</table>
+
+<h2>Converting to {{$name}} from</h2>
+<table>
+<tr><th>from</th><th>compiles</th><th>preserves the value</th><th>width</th><th>instead</th>{{if $.Type.Corpus}}<th>in the corpus</th>{{end}}</tr>
+{{range $.Type.From}}<tr id="from-{{.From}}"><td class="pair"><a href="{{typePage $.Root .From}}">{{.From}}</a></td><td>{{if $.Accessible}}{{if .Convertible}}YES{{else}}NO{{end}}{{else if .Convertible}}<span class="yes">{{$.Theme.Yes}}</span>{{else}}<span class="no">{{$.Theme.No}}</span>{{end}}</td><td>{{if not .Convertible}}-{{else if $.Accessible}}{{if .Exact}}YES, always{{else}}LOSSY, not always{{end}}{{else if .Exact}}<span class="yes">{{$.Theme.Yes}}</span> always{{else}}<span class="lossy">{{$.Theme.Lossy}}</span> not always{{end}}</td><td>{{or .Width "-"}}</td><td>{{if .Suggestion.Code}}<code title="{{.Suggestion.Note}}">{{.Suggestion.Code}}</code>{{else}}-{{end}}</td>{{if $.Type.Corpus}}<td>{{if .UsageCount}}<a href="{{typePage $.Root .From}}#usages-{{.To}}">{{.UsageCount}}</a>{{else}}-{{end}}</td>{{end}}</tr>
+{{end}}</table>
+
+{{if $.Type.Helpers}}<h2>Helpers</h2>
+<p>Generated by <code>go-conversions helpers</code>, each returns a <code>*RangeError</code> rather than silently changing the value.</p>
+<ul>
+{{range $.Type.Helpers}}<li><code>func {{.Name}}(v {{.From.Name}}) ({{.To.Name}}, error)</code>{{if eq .Check "none"}}, never fails{{end}}</li>
+{{end}}</ul>
+{{end}}
+<h2>Examples</h2>
+{{range $.Type.To}}{{if .Example}}<h3 id="example-{{.To}}">{{.From}} -> {{.To}}</h3>
+<pre>{{.Example}}</pre>
+{{end}}{{else}}<p>Nothing to demonstrate, every conversion from {{$name}} either compiles and preserves the value or is shown above.</p>
+{{end}}{{if $.Type.Corpus}}
+<h2>In the wild</h2>
+{{if $.Type.UsageCount}}{{range $.Type.To}}{{if .Usages}}<h3 id="usages-{{.To}}">{{.From}} -> {{.To}}, {{.UsageCount}} in the corpus</h3>
+<ul>
+{{range .Usages}}<li>{{.File}}:{{.Line}} <code>{{.Snippet}}</code></li>
+{{end}}</ul>
+{{end}}{{end}}{{else}}<p>No conversion from {{$name}} was found in the corpus.</p>
+{{end}}{{end}}{{end}}
+`))
+)
+
+// init registers the matrix page of the HTML report as the html format.
+func init() {
+	err := conversions.RegisterReporter("html", htmlReporter{})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// HTML writes an HTML report of the conversion matrix, with a detail page for every type
+// linked from the matrix cells, to the directory given by -out.
+func HTML(ctx context.Context, args []string) error {
+	var outputDir string
+	fs := flag.NewFlagSet("html", flag.ContinueOnError)
+	fs.StringVar(&outputDir, "out", DefaultHTMLDir, "directory to write the HTML report to")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	// NOTE: Looked up before analyzing, so a typo doesn't cost a whole analysis.
+	_, err = conversions.LookupCatalog(*locale)
+	if err != nil {
+		return errors.Wrap(err, "looking up -locale")
+	}
+	t, err := reportTheme()
+	if err != nil {
+		return errors.Wrap(err, "looking up -theme")
+	}
+
+	m, err := conversions.Analyze(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+	m.Locale = *locale
+	m.Accessible = *accessible
+	m.Theme = t
+
+	p, err := ProvenanceFor(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "determining provenance")
+	}
+
+	err = WriteHTML(ctx, outputDir, m, p)
+	if err != nil {
+		return errors.Wrap(err, "writing HTML")
+	}
+
+	logrus.Infof("wrote HTML report for %d types to %s", len(m.Types), outputDir)
+
+	return nil
+}
+
+// WriteHTML writes the matrix page for m as index.html in dir, along with a detail page for
+// every type in m, each stamped with p.
+func WriteHTML(ctx context.Context, dir string, m conversions.Matrix, p Provenance) error {
+	return writeHTML(ctx, dir, m, p, false)
+}
+
+// writeHTML writes the matrix and per-type pages for m to dir, with navigation to the rest of
+// the site when site is set.
+func writeHTML(ctx context.Context, dir string, m conversions.Matrix, p Provenance, site bool) error {
+	var r htmlReporter
+	r.Page.Provenance = p
+	r.Page.Site = site
+	err := writeFile(filepath.Join(dir, "index.html"), func(w io.Writer) error {
+		return conversions.RenderRows(ctx, r, m, w)
+	})
+	if err != nil {
+		return errors.Wrap(err, "writing matrix page")
+	}
+
+	allHelpers := helpers.Helpers(m)
+	allSuggestions := conversions.Suggestions(m)
+	for _, typ := range m.Types {
+		page, err := TypePageFor(m, typ, allHelpers, allSuggestions, corpusUsages)
+		if err != nil {
+			return errors.Wrapf(err, "building page for %s", typ)
+		}
+
+		type Type struct {
+			Page
+			Type TypePage
+		}
+		var t Type
+		t.Title = typ + " - Go primitive conversions"
+		t.Root = "../"
+		t.Provenance = p
+		t.Site = site
+		t.Accessible = m.Accessible
+		t.Theme = m.Theme.WithDefaults()
+		t.Type = page
+		err = writePage(htmlTemplates, filepath.Join(dir, typesDir, typ+".html"), "type", t)
+		if err != nil {
+			return errors.Wrapf(err, "writing page for %s", typ)
+		}
+	}
+
+	return nil
+}
+
+// TypePageFor gathers everything m, and the helpers and suggestions made from it, say about typ,
+// along with how it's converted in the corpus allUsages were found in, if any.
+func TypePageFor(m conversions.Matrix, typ string, allHelpers []helpers.Helper, allSuggestions []conversions.Suggestion, allUsages []conversions.Usage) (TypePage, error) {
+	var page TypePage
+	info, ok := conversions.Lookup(typ)
+	if !ok {
+		return TypePage{}, errors.Errorf("unknown type %q", typ)
+	}
+	page.Info = info
+
+	suggestions := make(map[string]conversions.Suggestion)
+	for _, s := range allSuggestions {
+		suggestions[s.From+conversions.PairSeparator+s.To] = s
+	}
+	page.Corpus = allUsages != nil
+	usageCounts := make(map[string]int)
+	for _, u := range allUsages {
+		usageCounts[u.From+conversions.PairSeparator+u.To]++
+	}
+
+	for _, other := range m.Types {
+		to, err := pairDetail(m, typ, other)
+		if err != nil {
+			return TypePage{}, errors.Wrapf(err, "describing %s -> %s", typ, other)
+		}
+		to.Suggestion = suggestions[typ+conversions.PairSeparator+other]
+		to.UsageCount = usageCounts[typ+conversions.PairSeparator+other]
+		to.Usages = conversions.RepresentativeUsages(allUsages, typ, other, usagesPerPair)
+		page.UsageCount += to.UsageCount
+		page.To = append(page.To, to)
+
+		from, err := pairDetail(m, other, typ)
+		if err != nil {
+			return TypePage{}, errors.Wrapf(err, "describing %s -> %s", other, typ)
+		}
+		from.Suggestion = suggestions[other+conversions.PairSeparator+typ]
+		from.UsageCount = usageCounts[other+conversions.PairSeparator+typ]
+		page.From = append(page.From, from)
+	}
+
+	for _, h := range allHelpers {
+		if h.From.Name == typ {
+			page.Helpers = append(page.Helpers, h)
+		}
+	}
+
+	return page, nil
+}
+
+// pairDetail describes converting from to to as recorded by m.
+func pairDetail(m conversions.Matrix, from, to string) (PairDetail, error) {
+	var pd PairDetail
+	pd.From = from
+	pd.To = to
+	pd.Convertible = m.Convertible(from, to)
+	pd.Exact = pd.Convertible && conversions.ExactNames(from, to)
+	if pd.Convertible {
+		pd.Width = conversions.ClassifyNames(from, to)
+	}
+
+	var example Example
+	example.From = from
+	example.To = to
+	if pd.Convertible {
+		value, ok := lossyValue(from, to)
+		if !ok {
+			return pd, nil
+		}
+		example.Compiles = true
+		example.Value = value
+	}
+	src, err := renderExample(example)
+	if err != nil {
+		return PairDetail{}, errors.Wrap(err, "rendering example")
+	}
+	pd.Example = string(src)
+
+	return pd, nil
+}
+
+// resultSymbol is the symbol theme shows in the matrix for result, its conversions.Word when verbal.
+func resultSymbol(result conversions.Result, verbal bool, theme conversions.Theme) string {
+	if verbal {
+		return conversions.Word(result)
+	}
+	return theme.Symbol(result)
+}
+
+// resultClass is the class styling the cell of result with its Theme's color, see conversions.Word.
+func resultClass(result conversions.Result) string {
+	return strings.ToLower(conversions.Word(result))
+}
+
+// Render implements conversions.Reporter.
+func (r htmlReporter) Render(ctx context.Context, m conversions.Matrix, w io.Writer) error {
+	return conversions.RenderRows(ctx, r, m, w)
+}
+
+// Rows implements conversions.RowReporter.
+func (r htmlReporter) Rows(_ context.Context, types []string, w io.Writer) (conversions.RowWriter, error) {
+	// NOTE: Executed templates can't be cloned, and every other page clones htmlTemplates.
+	t, err := htmlTemplates.Clone()
+	if err != nil {
+		return nil, errors.Wrap(err, "cloning templates")
+	}
+
+	hr := &htmlRows{t: t, w: w, page: r.Page, types: types}
+	hr.page.Theme = hr.page.Theme.WithDefaults()
+	return hr, nil
+}
+
+// Pivot implements conversions.Pivoter.
+func (hr *htmlRows) Pivot() {
+	hr.pivoted = true
+}
+
+// Localize implements conversions.Localizer.
+func (hr *htmlRows) Localize(c conversions.Catalog) {
+	hr.catalog = c
+}
+
+// Verbalize implements conversions.Verbalizer.
+func (hr *htmlRows) Verbalize() {
+	hr.verbal = true
+}
+
+// Style implements conversions.Styler.
+func (hr *htmlRows) Style(t conversions.Theme) {
+	hr.page.Theme = t.WithDefaults()
+}
+
+// Stamp implements conversions.Stamper.
+func (hr *htmlRows) Stamp(p conversions.Provenance) {
+	hr.page.Provenance = p
+}
+
+// start begins the page and the matrix, unless they already have been, which waits for the
+// first row so that they are labeled for a Pivoted Matrix, and in the right locale.
+func (hr *htmlRows) start() error {
+	if hr.started {
+		return nil
+	}
+	hr.started = true
+
+	if hr.page.Title == "" {
+		hr.page.Title = hr.catalog.Message(conversions.MessageTitle)
+	}
+	if hr.page.Lang == "" {
+		hr.page.Lang = hr.catalog.Message(conversions.MessageLang)
+	}
+	err := hr.t.ExecuteTemplate(hr.w, "header", hr.page)
+	if err != nil {
+		return errors.Wrap(err, "executing header")
+	}
+
+	type Begin struct {
+		Page
+		Types   []string
+		Heading string
+		Rows    string
+		Legend  string
+		Corner  string
+	}
+	var begin Begin
+	begin.Page = hr.page
+	begin.Types = hr.types
+	begin.Heading = hr.catalog.Message(conversions.MessageTitle)
+	begin.Rows = hr.catalog.Message(conversions.MessageRows)
+	begin.Corner = hr.catalog.Message(conversions.MessageCorner)
+	if hr.pivoted {
+		begin.Rows = hr.catalog.Message(conversions.MessageRowsPivoted)
+		begin.Corner = hr.catalog.Message(conversions.MessageCornerPivoted)
+	}
+	begin.Legend = hr.page.Theme.Legend(hr.catalog.Message(conversions.MessageLegend))
+	if hr.verbal {
+		begin.Legend = hr.catalog.Message(conversions.MessageLegendAccessible)
+	}
+	err = hr.t.ExecuteTemplate(hr.w, "matrix-begin", begin)
+	if err != nil {
+		return errors.Wrap(err, "executing matrix-begin")
+	}
+	return nil
+}
+
+// Row implements conversions.RowWriter.
+func (hr *htmlRows) Row(_ context.Context, from string, row []conversions.Result) error {
+	err := hr.start()
+	if err != nil {
+		return err
+	}
+
+	type Cell struct {
+		// From and To are empty for a pair with no Result.
+		From   string
+		To     string
+		Symbol string
+		// Class styles the cell with the color its Theme gives it.
+		Class string
+		// Label describes the cell to screen readers, when the report is accessible.
+		Label string
+		// Width is the classification of a convertible numeric pair, see conversions.Classify.
+		Width string
+		// Columns describes the pair's conversions.Result.Columns, if it has any.
+		Columns string
+	}
+	type Row struct {
+		Root  string
+		From  string
+		Cells []Cell
+	}
+	results := make(map[string]conversions.Result, len(row))
+	for _, result := range row {
+		if hr.pivoted {
+			results[result.From] = result
+		} else {
+			results[result.To] = result
+		}
+	}
+
+	var data Row
+	data.Root = hr.page.Root
+	data.From = from
+	for _, column := range hr.types {
+		var cell Cell
+		if result, ok := results[column]; ok {
+			cell.From = result.From
+			cell.To = result.To
+			cell.Symbol = resultSymbol(result, hr.verbal, hr.page.Theme)
+			cell.Class = resultClass(result)
+			if hr.verbal {
+				cell.Label = result.From + " to " + result.To + ": " + cell.Symbol
+			}
+			if result.Convertible {
+				cell.Width = result.Width
+			}
+			cell.Columns = conversions.DescribeColumns(result)
+		}
+		data.Cells = append(data.Cells, cell)
+	}
+
+	err = hr.t.ExecuteTemplate(hr.w, "matrix-row", data)
+	if err != nil {
+		return errors.Wrapf(err, "executing matrix-row for %s", from)
+	}
+	return nil
+}
+
+// Close implements conversions.RowWriter.
+func (hr *htmlRows) Close() error {
+	err := hr.start()
+	if err != nil {
+		return err
+	}
+	err = hr.t.ExecuteTemplate(hr.w, "matrix-end", hr.page)
+	if err != nil {
+		return errors.Wrap(err, "executing matrix-end")
+	}
+	err = hr.t.ExecuteTemplate(hr.w, "footer", hr.page)
+	if err != nil {
+		return errors.Wrap(err, "executing footer")
+	}
+	return nil
+}
+
+// typePage is the path of the detail page for typ, relative to a page whose path to the root is root.
+func typePage(root, typ string) string {
+	return root + typesDir + "/" + typ + ".html"
+}
+
+// writePage executes the template in templates with the given name inside the layout with data
+// and writes it to outputFile.
+func writePage(templates *template.Template, outputFile, name string, data interface{}) error {
+	t, err := templates.Clone()
+	if err != nil {
+		return errors.Wrap(err, "cloning templates")
+	}
+	_, err = t.New("content").Parse(`{{template "` + name + `" .}}`)
+	if err != nil {
+		return errors.Wrap(err, "parsing content")
+	}
+
+	return writeFile(outputFile, func(w io.Writer) error {
+		return t.ExecuteTemplate(w, "layout", data)
+	})
+}
+
+// writeFile creates outputFile, along with its directory, and writes it with write through a
+// buffer. outputFile is left as it was unless write succeeds.
+func writeFile(outputFile string, write func(w io.Writer) error) error {
+	f, err := conversions.CreateAtomic(outputFile, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "creating %q", outputFile)
+	}
+	defer func() { _ = f.Close() }()
+
+	bw := bufio.NewWriter(f)
+	err = write(bw)
+	if err != nil {
+		return errors.Wrapf(err, "writing %q", outputFile)
+	}
+	err = bw.Flush()
+	if err != nil {
+		return errors.Wrapf(err, "flushing %q", outputFile)
+	}
+
+	err = f.Commit()
+	if err != nil {
+		return errors.Wrapf(err, "committing %q", outputFile)
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// SampleJSON reads the sample document as JSON.
+	SampleJSON = "json"
+	// SampleYAML reads the sample document as YAML, see conversions.DecodeYAML for what's supported.
+	SampleYAML = "yaml"
+)
+
+// Infer reads a sample JSON or YAML document, e.g. a config file or a record from an ETL feed,
+// infers the go type of each of its fields from their values, and reports which primitives
+// those values convert to exactly or, given a struct, whether they convert safely to the fields
+// they decode into.
+func Infer(_ context.Context, args []string) error {
+	var format, target string
+	var asJSON bool
+	fs := flag.NewFlagSet("infer", flag.ContinueOnError)
+	fs.StringVar(&format, "format", "", fmt.Sprintf("%s or %s (default from the file's extension, or %s for stdin)", SampleJSON, SampleYAML, SampleJSON))
+	fs.StringVar(&target, "struct", "", "struct the sample decodes into, as a package directory and type name, e.g. ./config.Config")
+	fs.BoolVar(&asJSON, "json", false, "write the fields as JSON rather than logging them")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	sample := "-"
+	if fs.NArg() > 0 {
+		sample = fs.Arg(0)
+	}
+	doc, err := readSample(sample, format)
+	if err != nil {
+		return errors.Wrapf(err, "reading sample %q", sample)
+	}
+
+	var targets map[string]conversions.FieldTarget
+	if target != "" {
+		i := strings.LastIndex(target, ".")
+		if i <= 0 || i == len(target)-1 {
+			return errors.Errorf("-struct %q isn't a package directory and type name, e.g. ./config.Config", target)
+		}
+		targets, err = conversions.StructTargets(target[:i], target[i+1:])
+		if err != nil {
+			return errors.Wrapf(err, "loading %s", target)
+		}
+	}
+
+	fits := conversions.FitFields(conversions.InferFields(doc), targets)
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(fits)
+		if err != nil {
+			return errors.Wrap(err, "encoding fields")
+		}
+		return nil
+	}
+
+	logFits(fits, target)
+
+	return nil
+}
+
+// readSample decodes the sample document in file, or stdin when file is -, as format, or the
+// format its extension names when format is empty.
+func readSample(file, format string) (interface{}, error) {
+	var b []byte
+	var err error
+	if file == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading")
+	}
+
+	if format == "" {
+		format = SampleJSON
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".yaml", ".yml":
+			format = SampleYAML
+		}
+	}
+
+	switch format {
+	case SampleJSON:
+		dec := json.NewDecoder(bytes.NewReader(b))
+		// NOTE: Numbers are kept as written, a float64 would lose the integers that don't fit one.
+		dec.UseNumber()
+		var doc interface{}
+		err = dec.Decode(&doc)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding JSON")
+		}
+		return doc, nil
+	case SampleYAML:
+		doc, err := conversions.DecodeYAML(b)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding YAML")
+		}
+		return doc, nil
+	default:
+		return nil, errors.Errorf("unknown sample format %q", format)
+	}
+}
+
+// logFits logs how each sample field fits, into the struct target when there is one.
+func logFits(fits []conversions.FieldFit, target string) {
+	var unsafe int
+	for _, fit := range fits {
+		f := fit.Sample
+		typ := f.Type
+		if typ == "" {
+			typ = "mixed"
+		}
+
+		switch {
+		case target == "":
+			fitsIn := "nothing"
+			if len(fit.Fits) > 0 {
+				fitsIn = strings.Join(fit.Fits, ", ")
+			}
+			logrus.Infof("%s (%s, %d values): fits exactly in %s", f.Path, typ, len(f.Values), fitsIn)
+		case fit.Target == nil:
+			logrus.Warnf("%s (%s): %s has no field it decodes into", f.Path, typ, target)
+		case !fit.Legal:
+			unsafe++
+			logrus.Errorf("%s (%s) -> %s %s: ❌ %q doesn't convert", f.Path, typ, fit.Target.Field, fit.Target.Type, fit.Unsafe)
+		case !fit.Safe:
+			unsafe++
+			logrus.Warnf("%s (%s) -> %s %s: ⚠️ %q doesn't fit exactly", f.Path, typ, fit.Target.Field, fit.Target.Type, fit.Unsafe)
+		default:
+			logrus.Infof("%s (%s) -> %s %s: ✅ all %d values fit", f.Path, typ, fit.Target.Field, fit.Target.Type, len(f.Values))
+		}
+	}
+
+	if target != "" {
+		logrus.Infof("%d of %d fields don't convert safely to %s", unsafe, len(fits), target)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"os"
+	"strings"
+)
+
+// Langs analyzes every primitive against every other primitive, and slices against arrays,
+// once under each of langs, as Go language versions, and reports the pairs which only convert
+// under some of them. With -format json the whole combined matrix is written to stdout instead.
+func Langs(ctx context.Context, langs []string) error {
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	p, err := ProvenanceFor(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "determining provenance")
+	}
+	// NOTE: Every one of langs is analyzed under, not whatever -lang says.
+	p.LangVersion = ""
+
+	switch *reportFormat {
+	case "", "json":
+	default:
+		return errors.Errorf("language version reports can only be logged or rendered as json, not %q", *reportFormat)
+	}
+
+	lm, err := conversions.AnalyzeLangs(ctx, opts, langs)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+
+	if *reportFormat == "json" {
+		type Report struct {
+			Provenance Provenance             `json:"provenance"`
+			Matrix     conversions.LangMatrix `json:"matrix"`
+		}
+		var r Report
+		r.Provenance = p
+		r.Matrix = lm
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(r)
+		if err != nil {
+			return errors.Wrap(err, "encoding report")
+		}
+		return nil
+	}
+
+	var differ int
+	for _, lr := range lm.Results {
+		if !lr.Differs() {
+			continue
+		}
+		differ++
+		verdicts := make([]string, 0, len(lm.Langs))
+		for _, lang := range lm.Langs {
+			verdict := "❌ does not compile"
+			if lr.Convertible[lang] {
+				verdict = "✅ compiles"
+			}
+			verdicts = append(verdicts, fmt.Sprintf("%s %s", lang, verdict))
+		}
+		logrus.Warnf("%s -> %s depends on the language version: %s", lr.From, lr.To, strings.Join(verdicts, ", "))
+	}
+	logrus.Infof("%d of %d conversions behave differently across %s", differ, len(lm.Results), strings.Join(lm.Langs, ", "))
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"os"
+	"strings"
+)
+
+const (
+	// LockFile is where the Lock of the last run is written, and -frozen checks against.
+	LockFile = "conversions.lock"
+)
+
+type (
+	// Lock records everything results depend on besides this program's own version, so a team
+	// can check every member's run against one committed to the repo and be sure they're
+	// comparing like for like.
+	Lock struct {
+		// Toolchain is the go version whose rules the results reflect, see Provenance.AnalyzedWith.
+		Toolchain string `json:"toolchain"`
+		// Engine is the engine the results were analyzed with.
+		Engine string `json:"engine"`
+		// LangVersion is the Go language version the results were analyzed under, if set.
+		LangVersion string `json:"langVersion,omitempty"`
+		// GOARCH is the architecture the results were analyzed for, if set.
+		GOARCH string `json:"goarch,omitempty"`
+		// TypesHash is the SHA-256 of the types analyzed, in the order they're analyzed in, so
+		// listing them in another order doesn't change it.
+		TypesHash string `json:"typesHash"`
+		// ConfigHash is the SHA-256 of the config, as decoded and encoded again, so reformatting
+		// the file doesn't change it.
+		ConfigHash string `json:"configHash"`
+	}
+)
+
+// LockFor is the Lock of a run analyzing as opts, whose Provenance is p, configured by c.
+func LockFor(opts conversions.Options, p Provenance, c Config) (Lock, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return Lock{}, errors.Wrap(err, "encoding config")
+	}
+
+	var l Lock
+	l.Toolchain = p.AnalyzedWith
+	l.Engine = p.Engine
+	l.LangVersion = p.LangVersion
+	l.GOARCH = opts.GOARCH
+	types := conversions.SortTypes(conversions.Dedupe(opts.WithDefaults().Types))
+	l.TypesHash = hash([]byte(strings.Join(types, ",")))
+	l.ConfigHash = hash(b)
+	return l, nil
+}
+
+// LoadLock reads the Lock in lockFile.
+func LoadLock(lockFile string) (Lock, error) {
+	b, err := os.ReadFile(lockFile)
+	if err != nil {
+		return Lock{}, errors.Wrapf(err, "reading lock file %q", lockFile)
+	}
+	var l Lock
+	err = json.Unmarshal(b, &l)
+	if err != nil {
+		return Lock{}, errors.Wrapf(err, "decoding lock file %q", lockFile)
+	}
+	return l, nil
+}
+
+// Save writes l to lockFile.
+func (l Lock) Save(lockFile string) error {
+	b, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encoding lock")
+	}
+	err = conversions.WriteFileAtomic(lockFile, append(b, '\n'), 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "writing lock file %q", lockFile)
+	}
+	return nil
+}
+
+// Diff describes every way l differs from locked, the Lock it's checked against, or returns
+// nil if they're the same.
+func (l Lock) Diff(locked Lock) []string {
+	var diffs []string
+	field := func(name, got, want string) {
+		if got != want {
+			diffs = append(diffs, fmt.Sprintf("%s is %s, but was %s", name, orNone(got), orNone(want)))
+		}
+	}
+	field("the toolchain", l.Toolchain, locked.Toolchain)
+	field("the engine", l.Engine, locked.Engine)
+	field("the language version", l.LangVersion, locked.LangVersion)
+	field("GOARCH", l.GOARCH, locked.GOARCH)
+	if l.TypesHash != locked.TypesHash {
+		diffs = append(diffs, "the types analyzed differ")
+	}
+	if l.ConfigHash != locked.ConfigHash {
+		diffs = append(diffs, "the config differs")
+	}
+	return diffs
+}
+
+// hash is the SHA-256 of b, in hex, prefixed by the algorithm.
+func hash(b []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(b))
+}
+
+// orNone is s, or "none" when it's empty.
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
@@ -3,64 +3,192 @@ package main
 import (
 	"bytes"
 	"context"
+	"flag"
 	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"io"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path/filepath"
-	"regexp"
 	"strings"
-	"text/template"
+	"syscall"
 	"time"
 )
 
 const (
-	// TemplateFile is the location of the template file to be generated.
-	TemplateFile = "./template/conversions.tmpl"
-	// OutputFile is the location to put the generated go code.
-	OutputFile = "./output/conversions.go"
+	// OutputDir is the location to put the generated go code.
+	OutputDir = "./output"
+	// StateFile is the location the progress of an analysis is saved to so it can be resumed.
+	StateFile = "./output/state.json"
+	// CacheFile is the location the results of every analysis run with -cache are kept.
+	CacheFile = "./output/cache.json"
 )
 
 type (
-	// ConversionFailure is a type for marrying the two types in a conversion failure as reported
-	// by the go compiler.
-	ConversionFailure struct {
-		From string
-		To   string
+	// ReportOptions configures what Report reports and how.
+	ReportOptions struct {
+		// Tag limits the report to pairs with this tag, when set.
+		Tag string
+		// GroupByTag reports a section per tag in Tags rather than a section per type. Only the
+		// logged report has sections, so it can't be combined with Format.
+		GroupByTag bool
+		// Tags are the names of every configured tag.
+		Tags []string
+		// Provenance is stamped on the report, when set: logged ahead of a logged report, and
+		// handed to a report rendered by Format, see conversions.Stamper.
+		Provenance Provenance
+		// Format is the name of the registered conversions.Reporter to render the report with.
+		// The report is logged when it is not set. A comma separated list of names renders the
+		// report once in each, to the file reportFile names for it, ignoring Output.
+		Format string
+		// Output is where a report rendered by Format is written. Defaults to os.Stdout.
+		Output io.Writer
+		// Sort is the order of the rows and columns, one of the conversions.Sort constants.
+		// Defaults to conversions.SortFamily.
+		Sort string
+		// Pivot makes the rows the types converted to, and the columns the types converted from.
+		Pivot bool
+		// Only limits the report to pairs every one of these comma separated conversions.Predicates
+		// is true for, when set, e.g. narrowing,cross-sign.
+		Only string
+		// Keep also limits the report to pairs it's true for, when set, e.g. to those
+		// conversions.Listed by the check command.
+		Keep func(conversions.Pair) bool
+		// Locale is the locale of the headings and legends of a report rendered by Format, see
+		// conversions.LookupCatalog. Defaults to English.
+		Locale string
+		// Accessible marks every pair in the report with a conversions.Word rather than by emoji
+		// alone, see conversions.Verbalizer.
+		Accessible bool
+		// Compare follows the report with a section comparing each pair to how C, Java, and Rust
+		// convert it, see conversions.Comparer.
+		Compare bool
+		// Theme is the symbols, and in HTML the colors, the report marks each pair with, see
+		// conversions.Styler. Defaults to conversions.ThemeDefault.
+		Theme conversions.Theme
+		// Verbose follows the report with what go/types knows about every type, see
+		// conversions.Detailer.
+		Verbose bool
 	}
 
-	// ConversionFailures is a helper type around a []ConversionFailure to allow easier searching
-	// through a []ConversionFailure.
-	ConversionFailures []ConversionFailure
+	// logRows logs a report a row at a time.
+	logRows struct {
+		pivoted bool
+		verbal  bool
+		theme   conversions.Theme
+		// compared are the Results logged so far, kept when they're to be compared to other
+		// languages once the last row has been logged.
+		compare  bool
+		compared []conversions.Result
+	}
+
+	// taggedRows filters the rows written to a conversions.RowWriter down to the Results with a tag.
+	taggedRows struct {
+		conversions.RowWriter
+		tag string
+	}
+
+	// onlyRows filters the rows written to a conversions.RowWriter down to the Results whose
+	// conversions.Pair keep is true for.
+	onlyRows struct {
+		conversions.RowWriter
+		keep func(conversions.Pair) bool
+	}
 )
 
 var (
-	// Primitives contains the list of all primitives in golang, as reported by the builtin package.
-	// I suppose even this could be extracted from the builtin package itself via some code introspection,
-	// but for now I hardcoded the list since the list of built-in primitives is unlikely to change
-	// frequently, if at all.
-	Primitives = []string{
-		"bool",
-		"uint8",
-		"uint16",
-		"uint32",
-		"uint64",
-		"int8",
-		"int16",
-		"int32",
-		"int64",
-		"float32",
-		"float64",
-		"complex64",
-		"complex128",
-		"string",
-		"int",
-		"uint",
-		"uintptr",
-		"byte", // NOTE(justin): is also a type alias for uint8
-		"rune", // NOTE(justin): is also a type alias for int32
-	}
+	// engine is the conversions engine used to check the generated go code, as set by the -engine flag.
+	engine = flag.String("engine", conversions.EngineBuild, `how to check the generated go code: "build" compiles it from disk with go build, "types" type checks it in memory without writing anything, "remote" submits it to the build service at -remote-url`)
+	// remoteURL is the build service the remote engine submits the generated go code to, as set by the -remote-url flag.
+	remoteURL = flag.String("remote-url", "", "URL of the HTTP build service used by -engine remote")
+	// maxErrors is the number of errors the compiler reports per file, as set by the -max-errors flag.
+	maxErrors = flag.Int("max-errors", 0, "errors the compiler reports per file, which shards are sized to stay below (default measured at startup, negative for no limit)")
+	// useCache is whether to reuse the results of earlier analyses, as set by the -cache flag.
+	useCache = flag.Bool("cache", false, "reuse the results cached by earlier runs with -cache, only checking pairs involving types they didn't analyze")
+	// cacheStore is where -cache keeps its results rather than CacheFile, as set by the -cache-store flag.
+	cacheStore = flag.String("cache-store", "", "where -cache keeps its results, a directory or a location such as redis://cache:6379/0 for a store registered with conversions.RegisterStore (default "+CacheFile+")")
+	// resume is whether to continue a previously interrupted analysis, as set by the -resume flag.
+	resume = flag.Bool("resume", false, "continue the analysis interrupted by a previous run rather than starting over")
+	// configFile is the location of the Config file, as set by the -config flag.
+	configFile = flag.String("config", "", "path to a JSON config file (default "+DefaultConfigFile+" if it exists)")
+	// typeList are the comma separated primitives to analyze, as set by the -types flag.
+	typeList = flag.String("types", "", "comma separated list of primitives to analyze, e.g. int8,int16,int32,int64 (default every primitive)")
+	// excludeTypes are the comma separated types to leave out of the analysis, as set by the -exclude-types flag.
+	excludeTypes = flag.String("exclude-types", "", "comma separated list of types to leave out, e.g. complex64,complex128,uintptr")
+	// tag limits the report to pairs with this tag, as set by the -tag flag.
+	tag = flag.String("tag", "", "only report pairs with this tag from the config file")
+	// only are the comma separated conversions.Predicates the report is limited to, as set by the -only flag.
+	only = flag.String("only", "", "only report pairs every one of these comma separated predicates is true for: lossy, cross-sign, widening, narrowing, or reinterpreting")
+	// groupByTag is whether to group the report by tag rather than by type, as set by the -group-by-tag flag.
+	groupByTag = flag.Bool("group-by-tag", false, "group the report by the tags from the config file rather than by type")
+	// sortBy is the order of the report's rows and columns, as set by the -sort flag.
+	sortBy = flag.String("sort", conversions.SortFamily, fmt.Sprintf("order the report's rows and columns by %s (kind, then size), %s, or %s (most convertible first)", conversions.SortFamily, conversions.SortName, conversions.SortDegree))
+	// locale is the locale of the report's headings and legends, as set by the -locale flag.
+	locale = flag.String("locale", "", "render the report's headings and legends in this locale, e.g. en, de, ja, or zh")
+	// accessible is whether reports mark every pair with a word rather than by emoji alone, and
+	// logs aren't colored, as set by the -accessible flag.
+	accessible = flag.Bool("accessible", false, "mark every pair in the report as YES, NO, or LOSSY rather than by emoji alone, and never color the logs, for screen readers and monochrome terminals")
+	// theme is the symbols and colors the report marks each pair with, as set by the -theme flag.
+	theme = flag.String("theme", "", fmt.Sprintf("mark each pair in the report with the symbols, and in HTML the colors, of this theme: %s, %s, %s, or one from the config file's themes", conversions.ThemeDefault, conversions.ThemeColorblind, conversions.ThemeASCII))
+	// verbose is whether to follow the report with what go/types knows about every type, as set by the -verbose flag.
+	verbose = flag.Bool("verbose", false, "follow the report with the kind, size, signedness, underlying type, alias, and package of every type, as go/types reports them (json reports only)")
+	// vet is whether to annotate every conversion which compiles with what go vet reports about it, as set by the -vet flag.
+	vet = flag.Bool("vet", false, "run go vet over every conversion which compiles, under -lang, and annotate those it reports in a vet column of every report")
+	// usagesDir is the corpus of Go code to find conversions between the analyzed types in, as set by the -usages flag.
+	usagesDir = flag.String("usages", "", "type check the Go code under this directory, e.g. a checkout of open-source repos, for conversions between the analyzed types, counting each pair's in a usages column of every report and showing a few of each on the HTML type pages")
+	// compare is whether the report is followed by a comparison of each pair to C, Java, and
+	// Rust, as set by the -compare flag.
+	compare = flag.Bool("compare", false, "follow the report with how C, Java, and Rust convert each pair of fixed-size integers and floats, for developers coming from them (logged, text, and markdown reports only)")
+	// pivot is which types the report's rows are, as set by the -pivot flag.
+	pivot = flag.String("pivot", "from", "make the report's rows the types converted from, or to")
+	// reportFormat is the registered conversions.Reporter to render the report with, as set by the -format flag.
+	reportFormat = flag.String("format", "", "render the report to stdout with this registered reporter, e.g. text, table, json, csv, markdown, or html, rather than logging it, or to a file for each of a comma separated list of them")
+	// reportDir is where the reports in each of a list of -format are written, as set by the -report-dir flag.
+	reportDir = flag.String("report-dir", ".", "directory the report in each of a list of -format is written to, as matrix.json, matrix.md, and so on, unless the config's reports name a file for it")
+	// cross are the comma separated GOARCH values to analyze and compare in one run, as set by the -cross flag.
+	cross = flag.String("cross", "", "comma separated GOARCH values to analyze for and compare, e.g. amd64,386,arm64, reporting the conversions which differ between them")
+	// lang is the Go language version to analyze under, as set by the -lang flag.
+	lang = flag.String("lang", "", "Go language version, e.g. go1.19, to analyze under as a go directive would, rejecting conversions newer versions introduced (default the toolchain's own)")
+	// langs are the comma separated language versions to analyze and compare in one run, as set by the -langs flag.
+	langs = flag.String("langs", "", "comma separated Go language versions to analyze under and compare, e.g. go1.16,go1.19,go1.20, reporting the conversions, slices to arrays included, which differ between them")
+	// values are the comma separated value generators runtime probes try values from, as set by the -values flag.
+	values = flag.String("values", conversions.GeneratorBoundaries, fmt.Sprintf("comma separated value generators for runtime probes and constants to try values from: %s (each type's edge cases), %s, or %s (the config's)", conversions.GeneratorBoundaries, conversions.GeneratorRandom, conversions.GeneratorCorpus))
+	// eventsFile is where to write an NDJSON stream of the analysis' progress, as set by the -events flag.
+	eventsFile = flag.String("events", "", "file to write a stream of JSON events to, one per line, as each stage, shard, and pair of the analysis completes")
+	// pipe is whether to read the types from stdin and write the report to stdout without writing any files, as set by the -pipe flag.
+	pipe = flag.Bool("pipe", false, "read the types to analyze from stdin, one per line or as a JSON array, and write the report, json unless -format says otherwise, to stdout without writing any files, type checking in memory unless -engine remote is given")
+	// config is the Config file, loaded once by Main so every command sees the same one however long it runs.
+	config Config
+	// hookTypes are the types written by the pre hooks, which replace the configured types when set.
+	hookTypes []string
+	// corpusUsages are the conversions found under -usages, shown on the HTML type pages.
+	corpusUsages []conversions.Usage
+	// pipeTypes are the types read from stdin for -pipe, which replace the configured types when set.
+	pipeTypes []string
+	// events receives the progress of every analysis, when -events is set.
+	events func(conversions.Event)
+	// publish is the s3:// or gs:// URL to upload the rendered report to, as set by the -publish flag.
+	publish = flag.String("publish", "", "upload the report rendered by -format to this s3://bucket/prefix or gs://bucket/prefix, named by its SHA-256")
+	// notifyWebhook is the URL to post a Notification to, as set by the -notify-webhook flag.
+	notifyWebhook = flag.String("notify-webhook", "", "URL, e.g. a Slack incoming webhook, to post a summary to when the results differ from -baseline or exceed -max-lossy")
+	// baselineFile is a previous -format json report to compare the results against, as set by the -baseline flag.
+	baselineFile = flag.String("baseline", "", "a previous -format json report to compare the results against for -notify-webhook")
+	// maxLossy is the most lossy conversions allowed before notifying, as set by the -max-lossy flag.
+	maxLossy = flag.Int("max-lossy", -1, "notify -notify-webhook when more than this many conversions may lose information (default no limit)")
+	// debugArtifacts is where every run saves the generated go code it checks and what the compiler made of it, as set by the -debug-artifacts flag.
+	debugArtifacts = flag.String("debug-artifacts", "", "directory to save the generated go code, the full compiler output, and what that output was parsed into, in a subdirectory per run, for bug reports about misparsed diagnostics")
+	// lockFile is where the Lock of every run is written, and -frozen checks against, as set by the -lock flag.
+	lockFile = flag.String("lock", LockFile, "file to record the toolchain, engine, types, and config of every run in, for -frozen to check later runs against, or empty to not record them")
+	// frozen is whether to refuse to run unless the environment matches -lock, as set by the -frozen flag.
+	frozen = flag.Bool("frozen", false, "refuse to run unless the toolchain, engine, language version, GOARCH, types, and config all match those recorded in -lock, so a team compares like for like")
+	// strict is whether to fail on any compiler output which can't be accounted for, as set by the -strict flag.
+	strict = flag.Bool("strict", false, "fail on any unparsed compiler output, unexpected exit status, or partial shard instead of accepting a possibly incomplete matrix")
+	// retries is how many more times a shard is checked when it fails for a reason unrelated to the code, as set by the -retries flag.
+	retries = flag.Int("retries", 0, "check a shard again up to this many times when checking it fails for a reason unrelated to the code, e.g. the compiler being killed for running out of memory, rather than failing the run")
+	// retryBackoff is how long to wait before retrying a shard the first time, as set by the -retry-backoff flag.
+	retryBackoff = flag.Duration("retry-backoff", conversions.DefaultRetryBackoff, "how long to wait before retrying a shard the first time, doubling every time after it")
 )
 
 // main is the main function for this program, but it is only responsible
@@ -71,8 +199,20 @@ func main() {
 		logrus.Infof("execution took %v", duration)
 	}(time.Now())
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	flag.Parse()
+	if *accessible {
+		// NOTE: Color is never the only signal, but a monochrome terminal shows escape codes as is.
+		logrus.SetFormatter(&logrus.TextFormatter{DisableColors: true})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// NOTE: Restore the default signal behavior once we've started shutting down,
+		// so a second interrupt kills the program immediately.
+		<-ctx.Done()
+		stop()
+	}()
 
 	logrus.Info("starting")
 
@@ -85,129 +225,819 @@ func main() {
 	logrus.Info("done")
 }
 
-// Main is the main driver function for this application.
+// Main is the main driver function for this application. It runs the command
+// named by the first non-flag argument, defaulting to Run when there is none, between
+// the pre and post hooks in the Config file.
 func Main(ctx context.Context) error {
-	err := Generate(ctx)
+	if *eventsFile != "" {
+		f, err := os.Create(*eventsFile)
+		if err != nil {
+			return errors.Wrapf(err, "creating events file %q", *eventsFile)
+		}
+		defer func() { _ = f.Close() }()
+		events = conversions.NDJSONEvents(f)
+	}
+
+	var err error
+	config, err = LoadConfig(*configFile)
 	if err != nil {
-		return errors.Wrap(err, "generating")
+		return errors.Wrap(err, "loading config")
+	}
+	c := config
+	command := flag.Arg(0)
+	if command == "" {
+		command = "run"
 	}
 
-	cfs, err := Compile(ctx)
+	if *pipe {
+		if len(c.Hooks.Pre) > 0 {
+			return errors.New("-pipe reads the types from stdin, so pre hooks can't write them")
+		}
+		pipeTypes, err = ReadTypes(os.Stdin)
+		if err != nil {
+			return errors.Wrap(err, "reading stdin")
+		}
+	}
+
+	hookTypes, err = RunPreHooks(ctx, c.Hooks, command)
 	if err != nil {
-		return errors.Wrap(err, "compiling")
+		return errors.Wrap(err, "running pre hooks")
 	}
 
-	err = Report(ctx, cfs)
+	if *vet {
+		err = RegisterVet(ctx)
+		if err != nil {
+			return errors.Wrap(err, "running go vet")
+		}
+	}
+
+	if *usagesDir != "" {
+		err = RegisterUsages(ctx, *usagesDir)
+		if err != nil {
+			return errors.Wrapf(err, "finding usages under %q", *usagesDir)
+		}
+	}
+
+	err = dispatch(ctx, command)
+
+	postErr := RunPostHooks(ctx, c.Hooks, command, err)
 	if err != nil {
-		return errors.Wrap(err, "reporting results")
+		return err
+	}
+	if postErr != nil {
+		return errors.Wrap(postErr, "running post hooks")
 	}
 
 	return nil
 }
 
-// Generate executes the template and generates the go code for it.
-// The templated file is assumed to be at TemplateFile, and the generated
-// go code will be sent to OutputFile.
-func Generate(_ context.Context) error {
-	t, err := template.ParseFiles(TemplateFile)
+// dispatch runs the command named command.
+func dispatch(ctx context.Context, command string) error {
+	switch command {
+	case "run":
+		if *cross != "" {
+			return Cross(ctx, splitList(*cross))
+		}
+		if *langs != "" {
+			return Langs(ctx, splitList(*langs))
+		}
+		return Run(ctx)
+	case "doctor":
+		return Doctor(ctx)
+	case "helpers":
+		return Helpers(ctx, flag.Args()[1:])
+	case "examples":
+		return Examples(ctx, flag.Args()[1:])
+	case "export":
+		return Export(ctx, flag.Args()[1:])
+	case "codecs":
+		return Codecs(ctx)
+	case "version":
+		return Version(ctx)
+	case "html":
+		return HTML(ctx, flag.Args()[1:])
+	case "site":
+		return Site(ctx, flag.Args()[1:])
+	case "gosec":
+		return Gosec(ctx, flag.Args()[1:])
+	case "variants":
+		return Variants(ctx, flag.Args()[1:])
+	case "methods":
+		return Methods(ctx, flag.Args()[1:])
+	case "assertions":
+		return Assertions(ctx, flag.Args()[1:])
+	case "audit":
+		return Audit(ctx, flag.Args()[1:])
+	case "rewrite":
+		return Rewrite(ctx, flag.Args()[1:])
+	case "specials":
+		return Specials(ctx, flag.Args()[1:])
+	case "enums":
+		return Enums(ctx, flag.Args()[1:])
+	case "mappers":
+		return Mappers(ctx, flag.Args()[1:])
+	case "strings":
+		return Strings(ctx, flag.Args()[1:])
+	case "stdin":
+		return Stdin(ctx, flag.Args()[1:])
+	case "verify":
+		return Verify(ctx, flag.Args()[1:])
+	case "arrays":
+		return Arrays(ctx, flag.Args()[1:])
+	case "serve":
+		return Serve(ctx, flag.Args()[1:])
+	case "golden":
+		return Golden(ctx, flag.Args()[1:])
+	case "infer":
+		return Infer(ctx, flag.Args()[1:])
+	case "times":
+		return Times(ctx, flag.Args()[1:])
+	case "big":
+		return Big(ctx, flag.Args()[1:])
+	case "apis":
+		return APIs(ctx, flag.Args()[1:])
+	case "constraints":
+		return Constraints(ctx, flag.Args()[1:])
+	case "diff":
+		return Diff(ctx, flag.Args()[1:])
+	case "values":
+		return Values(ctx)
+	case "constants":
+		return Constants(ctx, flag.Args()[1:])
+	case "contexts":
+		return Contexts(ctx)
+	case "shifts":
+		return Shifts(ctx)
+	case "check":
+		return CheckPairs(ctx, flag.Args()[1:])
+	case "explain":
+		return Explain(ctx, flag.Args()[1:])
+	default:
+		return errors.Errorf("unknown command %q", command)
+	}
+}
+
+// Run analyzes every primitive against every other primitive and reports the results.
+func Run(ctx context.Context) error {
+	opts, err := Options()
 	if err != nil {
-		return errors.Wrapf(err, "parsing template file %q", TemplateFile)
+		return errors.Wrap(err, "configuring")
 	}
+	// NOTE: The report is started with the types in the order their rows are analyzed in.
+	opts = opts.WithDefaults()
 
-	f, err := os.Create(OutputFile)
+	p, err := ProvenanceFor(ctx, opts)
 	if err != nil {
-		return errors.Wrapf(err, "creating output file %q", OutputFile)
+		return errors.Wrap(err, "determining provenance")
+	}
+	c := config
+	lock, err := LockFor(opts, p, c)
+	if err != nil {
+		return errors.Wrap(err, "locking")
+	}
+	if *frozen {
+		locked, err := LoadLock(*lockFile)
+		if err != nil {
+			return errors.Wrap(err, "-frozen checks against the lock file, run without it to write one")
+		}
+		if diffs := lock.Diff(locked); len(diffs) > 0 {
+			return errors.Errorf("refusing to run, as the environment doesn't match %s: %s", *lockFile, strings.Join(diffs, "; "))
+		}
+	}
+	if opts.DebugDir != "" {
+		err = SaveDebugRun(opts, p)
+		if err != nil {
+			return errors.Wrap(err, "saving debug artifacts")
+		}
+		logrus.Infof("saving debug artifacts to %s", opts.DebugDir)
 	}
-	defer func() { _ = f.Close() }()
 
-	type Data struct {
-		Now        string
-		App        string
-		Primitives []string
+	var ropts ReportOptions
+	ropts.Provenance = p
+	ropts.Format = *reportFormat
+	if *pipe && ropts.Format == "" {
+		// NOTE: A pipeline reads the report from stdout, which the logged report never goes to.
+		ropts.Format = conversions.FormatJSON
+	}
+	ropts.Tag = *tag
+	ropts.Only = *only
+	ropts.Locale = *locale
+	ropts.Accessible = *accessible
+	ropts.Compare = *compare
+	ropts.Verbose = *verbose
+	ropts.Theme, err = reportTheme()
+	if err != nil {
+		return errors.Wrap(err, "looking up -theme")
+	}
+	_, err = conversions.LookupCatalog(ropts.Locale)
+	if err != nil {
+		return errors.Wrap(err, "looking up -locale")
+	}
+	ropts.GroupByTag = *groupByTag
+	if ropts.GroupByTag && ropts.Format != "" {
+		return errors.Errorf("-group-by-tag only groups the logged report, not one rendered by -format %s", ropts.Format)
+	}
+	ropts.Tags = opts.Tags.Names()
+	if _, ok := opts.Tags[ropts.Tag]; ropts.Tag != "" && !ok {
+		if len(ropts.Tags) == 0 {
+			return errors.Errorf("unknown -tag %q, the config file has no tags", ropts.Tag)
+		}
+		return errors.Errorf("unknown -tag %q, expected one of %s", ropts.Tag, strings.Join(ropts.Tags, ", "))
+	}
+	ropts.Sort = *sortBy
+	switch *sortBy {
+	case conversions.SortFamily, conversions.SortName, conversions.SortDegree:
+	default:
+		return errors.Errorf("unknown -sort %q, expected %s, %s, or %s", *sortBy, conversions.SortFamily, conversions.SortName, conversions.SortDegree)
+	}
+	switch *pivot {
+	case "from":
+	case "to":
+		ropts.Pivot = true
+	default:
+		return errors.Errorf("unknown -pivot %q, expected from or to", *pivot)
+	}
+	var published bytes.Buffer
+	if *publish != "" {
+		if ropts.Format == "" {
+			return errors.New("-publish needs -format to render the published report with")
+		}
+		if len(splitList(ropts.Format)) > 1 {
+			return errors.New("-publish only publishes the report rendered by a single -format")
+		}
+		ropts.Output = io.MultiWriter(os.Stdout, &published)
+	}
+	var baseline *conversions.Matrix
+	if *baselineFile != "" {
+		b, err := LoadBaseline(*baselineFile)
+		if err != nil {
+			return errors.Wrap(err, "loading baseline")
+		}
+		baseline = &b
 	}
-	var data Data
-	data.Now = time.Now().Format(time.RFC3339)
-	data.App = os.Args[0]
-	data.Primitives = Primitives
 
-	err = t.Execute(f, data)
+	// NOTE: The report is started before analyzing, so a typo in -format doesn't cost a whole analysis.
+	rw, ok, err := reportRows(ctx, opts.Types, ropts)
 	if err != nil {
-		return errors.Wrap(err, "executing template")
+		return errors.Wrap(err, "starting report")
+	}
+	if mr, isMulti := rw.(*multiRows); isMulti {
+		// NOTE: Reports to files are only committed when closed, so a failed analysis leaves them as they were.
+		defer mr.discard()
+	}
+
+	var m conversions.Matrix
+	m.Types = opts.Types
+	var checked int
+	if ok {
+		// NOTE: Reported a row at a time as the analysis goes, so the Matrix is never held in
+		// memory unless a notification needs it.
+		err = conversions.AnalyzeRows(ctx, opts, func(from string, row []conversions.Result) error {
+			checked += len(row)
+			if *notifyWebhook != "" {
+				m.Results = append(m.Results, row...)
+			}
+			return rw.Row(ctx, from, row)
+		})
+	} else {
+		err = conversions.AnalyzeStream(ctx, opts, func(result conversions.Result) error {
+			checked++
+			m.Results = append(m.Results, result)
+			return nil
+		})
+	}
+	if err != nil && ctx.Err() != nil {
+		total := len(m.Types) * len(m.Types)
+		logrus.Warnf("interrupted, shut down all in-flight compilations after checking %d of %d conversions", checked, total)
+		logrus.Warnf("progress was saved to %s, run again with -resume to pick up where this left off", StateFile)
+		if opts.CacheFile != "" {
+			logrus.Warnf("the conversions checked were saved to the cache, so runs with -cache won't check them again")
+		}
+		return errors.Wrap(ctx.Err(), "analyzing")
+	}
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+
+	if ok {
+		err = rw.Close()
+	} else {
+		conversions.SortResults(m.Results)
+		err = Report(ctx, m, ropts)
+	}
+	if err != nil {
+		return errors.Wrap(err, "reporting results")
+	}
+
+	// NOTE: A frozen run leaves the lock file as it was, and -pipe never writes any files.
+	if !*frozen && !*pipe && *lockFile != "" {
+		err = lock.Save(*lockFile)
+		if err != nil {
+			return errors.Wrap(err, "saving lock")
+		}
+	}
+
+	if *publish != "" {
+		u, err := Publish(ctx, *publish, ropts.Format, published.Bytes())
+		if err != nil {
+			return errors.Wrap(err, "publishing report")
+		}
+		logrus.Infof("published report to %s", u)
+	}
+
+	if *notifyWebhook != "" {
+		n, ok := NotificationFor(m, baseline, *maxLossy, p)
+		if ok {
+			err = Notify(ctx, *notifyWebhook, n)
+			if err != nil {
+				return errors.Wrap(err, "notifying")
+			}
+			logrus.Info("posted notification to the webhook")
+		}
 	}
 
 	return nil
 }
 
-// Compile compiles the generated go code located at OutputFile, expecting it
-// to fail compilation and throw errors. It records these compilation errors
-// into a ConversionFailures and returns them.
-func Compile(_ context.Context) (ConversionFailures, error) {
-	command := fmt.Sprintf("go build -gcflags=-e -o /dev/null %s", OutputFile)
-	pieces := strings.Split(command, " ")
-	program := pieces[0]
-	args := pieces[1:]
+// logRetries returns an Options.Events which warns about every shard retried, and logs how many
+// were, and how many times, once the analysis finishes, passing every Event on to next, if set.
+func logRetries(next func(conversions.Event)) func(conversions.Event) {
+	var retried int
+	shards := make(map[int]bool)
+	return func(e conversions.Event) {
+		switch e.Type {
+		case conversions.EventShardRetried:
+			retried++
+			shards[e.Shard.Index] = true
+			logrus.Warnf("retrying shard %d, attempt %d failed for a reason unrelated to the code: %s", e.Shard.Index, e.Attempt, e.Err)
+		case conversions.EventAnalysisFinished:
+			if retried > 0 {
+				logrus.Infof("retried %d shards, %d times in all", len(shards), retried)
+			}
+		}
+		if next != nil {
+			next(e)
+		}
+	}
+}
 
-	cmd := exec.Command(program, args...)
+// Options builds the conversions.Options for an analysis as configured by the Config file
+// and the command line flags.
+func Options() (conversions.Options, error) {
+	c := config
 
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
+	var err error
+	var opts conversions.Options
+	opts.OutputDir = OutputDir
+	opts.Engine = *engine
+	opts.RemoteURL = *remoteURL
+	opts.MaxErrors = *maxErrors
+	opts.StateFile = StateFile
+	opts.Resume = *resume
+	if *useCache {
+		opts.CacheFile = CacheFile
+	}
+	if *useCache && *cacheStore != "" {
+		opts.CacheStore, err = conversions.OpenStore(*cacheStore)
+		if err != nil {
+			return conversions.Options{}, errors.Wrap(err, "opening cache store")
+		}
+	}
+	opts.Tags = c.Tags
+	opts.Strict = *strict
+	opts.Retries = *retries
+	opts.RetryBackoff = *retryBackoff
+	opts.LangVersion = *lang
+	var generators []conversions.ValueGenerator
+	for _, name := range splitList(*values) {
+		if name == conversions.GeneratorCorpus {
+			generators = append(generators, c.Corpus)
+			continue
+		}
+		g, err := conversions.LookupGenerator(name)
+		if err != nil {
+			return conversions.Options{}, errors.Wrap(err, "looking up value generator")
+		}
+		generators = append(generators, g)
+	}
+	opts.Values = conversions.CombineGenerators(generators...)
+	opts.Events = logRetries(events)
+	if *debugArtifacts != "" {
+		opts.DebugDir = filepath.Join(*debugArtifacts, time.Now().UTC().Format("20060102T150405.000Z"))
+	}
 
-	err := cmd.Run()
-	if err != nil && err.Error() != "exit status 2" {
-		// NOTE(justin): We expect to get an exit status 2 since we expect the compiler to complain.
-		// If we got some other error, this will be triggered.
-		return nil, errors.Wrap(err, "unexpected error while running compilation command")
+	types := conversions.Primitives
+	configured := append(c.Types, splitList(*typeList)...)
+	if len(hookTypes) > 0 {
+		configured = hookTypes
+	}
+	if len(pipeTypes) > 0 {
+		configured = pipeTypes
+	}
+	if len(configured) > 0 {
+		for _, t := range configured {
+			if _, ok := conversions.Lookup(t); !ok {
+				return conversions.Options{}, errors.Errorf("unknown primitive %q", t)
+			}
+		}
+		for _, d := range conversions.Duplicates(configured) {
+			logrus.Warn(d)
+		}
+		types = conversions.Dedupe(configured)
 	}
 
-	stderrContent := stderr.String()
-	stderrLines := strings.Split(stderrContent, "\n")
+	excluded := append(c.ExcludeTypes, splitList(*excludeTypes)...)
+	opts.Types, err = conversions.Exclude(types, excluded)
+	if err != nil {
+		return conversions.Options{}, errors.Wrap(err, "excluding types")
+	}
 
-	var conversionErrs []string
-	for _, stderrLine := range stderrLines {
-		if strings.Contains(stderrLine, "cannot convert") {
-			conversionErrs = append(conversionErrs, stderrLine)
-		}
+	err = opts.Tags.Validate(opts.Types)
+	if err != nil {
+		return conversions.Options{}, errors.Wrap(err, "validating tags")
 	}
 
-	var cfs ConversionFailures
-	re := regexp.MustCompile(".+ p.(.+) \\(.+\\) to type (.+)")
-	for _, conversionErr := range conversionErrs {
-		matches := re.FindStringSubmatch(conversionErr)
-		from := matches[1]
-		to := matches[2]
-		var conversionFailure ConversionFailure
-		conversionFailure.From = from
-		conversionFailure.To = to
-		cfs = append(cfs, conversionFailure)
+	if *pipe {
+		err = pipeOptions(&opts)
+		if err != nil {
+			return conversions.Options{}, err
+		}
 	}
 
-	return cfs, nil
+	return opts, nil
+}
+
+// reportTheme is the conversions.Theme reports are marked with, the one -theme names, or else the
+// config file's theme, which either may name one of the config file's themes.
+func reportTheme() (conversions.Theme, error) {
+	c := config
+	name := *theme
+	if name == "" {
+		name = c.Theme
+	}
+	return c.ThemeFor(name)
 }
 
 // Report iterates over every primitive type against every primitive type and
-// reports if a ConversionFailure exists for that conversion or not.
-func Report(_ context.Context, cfs ConversionFailures) error {
-	for _, outerPrimitive := range Primitives {
-		logrus.Infof("---------- converting %s values ----------\n", outerPrimitive)
-		for _, innerPrimitive := range Primitives {
-			var compatible string
-			if !cfs.Contains(outerPrimitive, innerPrimitive) {
-				compatible = "✅"
-			} else {
-				compatible = "❌"
+// reports if m records that conversion as possible or not. When ropts.Format is
+// set the report is rendered to stdout by that registered conversions.Reporter instead.
+func Report(ctx context.Context, m conversions.Matrix, ropts ReportOptions) error {
+	if ropts.GroupByTag && ropts.Format != "" {
+		return errors.Errorf("-group-by-tag only groups the logged report, not one rendered by -format %s", ropts.Format)
+	}
+	if formats := splitList(ropts.Format); len(formats) > 1 {
+		return reportEach(ctx, m, ropts, formats)
+	}
+
+	if ropts.reordered() {
+		// NOTE: Pivoted first, so sorting by degree counts the rows as they're reported.
+		if ropts.Pivot {
+			m = m.Pivot()
+		}
+		var err error
+		m, err = m.Sort(ropts.Sort)
+		if err != nil {
+			return errors.Wrap(err, "sorting")
+		}
+		// NOTE: m is in order now, so it can be reported a row at a time like any other.
+		ropts.Sort = ""
+		ropts.Pivot = false
+	}
+
+	rw, ok, err := reportRows(ctx, m.Types, ropts)
+	if err != nil {
+		return errors.Wrap(err, "starting report")
+	}
+	if ok {
+		if p, ok := rw.(conversions.Pivoter); ok && m.Pivoted {
+			p.Pivot()
+		}
+		rows := make(map[string][]conversions.Result)
+		for _, result := range m.Results {
+			rows[m.RowOf(result)] = append(rows[m.RowOf(result)], result)
+		}
+		for _, from := range m.Types {
+			err := rw.Row(ctx, from, rows[from])
+			if err != nil {
+				return errors.Wrapf(err, "reporting %s", from)
+			}
+		}
+		return rw.Close()
+	}
+
+	if ropts.Only != "" || ropts.Keep != nil {
+		keep, err := ropts.only()
+		if err != nil {
+			return err
+		}
+		m = m.Filter(keep)
+	}
+
+	if ropts.Format != "" {
+		r, err := conversions.LookupReporter(ropts.Format)
+		if err != nil {
+			return errors.Wrap(err, "looking up reporter")
+		}
+		if ropts.Tag != "" {
+			var tagged []conversions.Result
+			for _, result := range m.Results {
+				if hasTag(result, ropts.Tag) {
+					tagged = append(tagged, result)
+				}
 			}
-			logrus.Infof("%10s -> %-10s %s ", outerPrimitive, innerPrimitive, compatible)
+			m.Results = tagged
 		}
+		m.Locale = ropts.Locale
+		m.Accessible = ropts.Accessible
+		m.Compare = ropts.Compare
+		m.Verbose = ropts.Verbose
+		m.Theme = ropts.Theme
+		if ropts.Provenance != (Provenance{}) {
+			m.Provenance = ropts.Provenance
+		}
+		err = r.Render(ctx, m, ropts.output())
+		if err != nil {
+			return errors.Wrapf(err, "rendering %s", ropts.Format)
+		}
+		return nil
+	}
+
+	for _, line := range ropts.Provenance.Lines() {
+		logrus.Info(line)
+	}
+	var compared []conversions.Result
+	for _, t := range ropts.Tags {
+		if ropts.Tag != "" && t != ropts.Tag {
+			continue
+		}
+		logrus.Infof("---------- tagged %s ----------\n", t)
+		for _, result := range m.Results {
+			if hasTag(result, t) {
+				reportResult(result, ropts.Accessible, ropts.Theme)
+				compared = append(compared, result)
+			}
+		}
+	}
+	if ropts.Compare {
+		return logComparisons(compared)
 	}
 
 	return nil
 }
 
-// Contains is a helper function for determining if cfs contains a ConversionFailure
-// that has it's From set to from and To set to to.
-func (cfs ConversionFailures) Contains(from, to string) bool {
-	for _, conversionFailure := range cfs {
-		if conversionFailure.From == from && conversionFailure.To == to {
+// reportRows starts the report configured by ropts for a Matrix of types, to be written a row
+// at a time. It reports false when the report needs the whole Matrix up front instead, either
+// because it is grouped by tag or its conversions.Reporter isn't a conversions.RowReporter.
+func reportRows(ctx context.Context, types []string, ropts ReportOptions) (conversions.RowWriter, bool, error) {
+	if formats := splitList(ropts.Format); len(formats) > 1 {
+		return reportEachRows(ctx, types, ropts, formats)
+	}
+
+	if ropts.GroupByTag || ropts.reordered() {
+		_, err := ropts.only()
+		if err != nil {
+			return nil, false, err
+		}
+		if ropts.Format != "" {
+			_, err := conversions.LookupReporter(ropts.Format)
+			if err != nil {
+				return nil, false, errors.Wrap(err, "looking up reporter")
+			}
+		}
+		return nil, false, nil
+	}
+
+	var rw conversions.RowWriter = &logRows{}
+	if ropts.Format != "" {
+		r, err := conversions.LookupReporter(ropts.Format)
+		if err != nil {
+			return nil, false, errors.Wrap(err, "looking up reporter")
+		}
+		rr, ok := r.(conversions.RowReporter)
+		if !ok {
+			return nil, false, nil
+		}
+		rw, err = rr.Rows(ctx, types, ropts.output())
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "rendering %s", ropts.Format)
+		}
+		if l, ok := rw.(conversions.Localizer); ok && ropts.Locale != "" {
+			c, err := conversions.LookupCatalog(ropts.Locale)
+			if err != nil {
+				return nil, false, errors.Wrap(err, "looking up -locale")
+			}
+			l.Localize(c)
+		}
+	} else {
+		for _, line := range ropts.Provenance.Lines() {
+			logrus.Info(line)
+		}
+	}
+	if v, ok := rw.(conversions.Verbalizer); ok && ropts.Accessible {
+		v.Verbalize()
+	}
+	if s, ok := rw.(conversions.Styler); ok && ropts.Theme != (conversions.Theme{}) {
+		s.Style(ropts.Theme)
+	}
+	if c, ok := rw.(conversions.Comparer); ok && ropts.Compare {
+		c.Compare()
+	}
+	if d, ok := rw.(conversions.Detailer); ok && ropts.Verbose {
+		d.Detail()
+	}
+	if s, ok := rw.(conversions.Stamper); ok && ropts.Provenance != (Provenance{}) {
+		s.Stamp(ropts.Provenance)
+	}
+
+	if ropts.Only != "" || ropts.Keep != nil {
+		keep, err := ropts.only()
+		if err != nil {
+			return nil, false, err
+		}
+		rw = onlyRows{RowWriter: rw, keep: keep}
+	}
+	if ropts.Tag != "" {
+		rw = taggedRows{RowWriter: rw, tag: ropts.Tag}
+	}
+
+	return rw, true, nil
+}
+
+// only is the predicate ropts.Only names, true for a conversions.Pair every one of its
+// conversions.Predicates, and ropts.Keep when set, is true for.
+func (ropts ReportOptions) only() (func(conversions.Pair) bool, error) {
+	var keeps []func(conversions.Pair) bool
+	if ropts.Keep != nil {
+		keeps = append(keeps, ropts.Keep)
+	}
+	for _, name := range splitList(ropts.Only) {
+		keep, err := conversions.LookupPredicate(name)
+		if err != nil {
+			return nil, errors.Wrap(err, "looking up -only")
+		}
+		keeps = append(keeps, keep)
+	}
+	return conversions.AllOf(keeps...), nil
+}
+
+// output is where ropts.Format renders the report to.
+func (ropts ReportOptions) output() io.Writer {
+	if ropts.Output == nil {
+		return os.Stdout
+	}
+	return ropts.Output
+}
+
+// reordered is whether ropts reports the rows and columns in anything but the order they're
+// analyzed in, so the whole Matrix is needed before any of it can be reported.
+func (ropts ReportOptions) reordered() bool {
+	return (ropts.Sort != "" && ropts.Sort != conversions.SortFamily) || ropts.Pivot
+}
+
+// Pivot implements conversions.Pivoter.
+func (lr *logRows) Pivot() {
+	lr.pivoted = true
+}
+
+// Verbalize implements conversions.Verbalizer.
+func (lr *logRows) Verbalize() {
+	lr.verbal = true
+}
+
+// Style implements conversions.Styler.
+func (lr *logRows) Style(t conversions.Theme) {
+	lr.theme = t
+}
+
+// Compare implements conversions.Comparer.
+func (lr *logRows) Compare() {
+	lr.compare = true
+}
+
+// Row implements conversions.RowWriter.
+func (lr *logRows) Row(_ context.Context, from string, row []conversions.Result) error {
+	// NOTE: A row filtered down to nothing, e.g. of a type -pairs only converts to, has nothing to head.
+	if len(row) == 0 {
+		return nil
+	}
+	heading := "converting " + from + " values"
+	if lr.pivoted {
+		heading = "converting to " + from
+	}
+	if canonical := conversions.CanonicalName(from); canonical != from {
+		logrus.Infof("---------- %s (an alias of %s, so the same as its row) ----------\n", heading, canonical)
+	} else {
+		logrus.Infof("---------- %s ----------\n", heading)
+	}
+	for _, result := range row {
+		reportResult(result, lr.verbal, lr.theme)
+	}
+	if lr.compare {
+		lr.compared = append(lr.compared, row...)
+	}
+	return nil
+}
+
+// Close implements conversions.RowWriter, logging the comparison to other languages, if any.
+func (lr *logRows) Close() error {
+	if !lr.compare {
+		return nil
+	}
+	return logComparisons(lr.compared)
+}
+
+// logComparisons logs how Go and each of conversions.ForeignLangs convert every one of results
+// they can be compared on.
+func logComparisons(results []conversions.Result) error {
+	fcs, err := conversions.CompareForeign(results)
+	if err != nil {
+		return errors.Wrap(err, "comparing with other languages")
+	}
+	logrus.Infof("---------- compared with %s ----------\n", strings.Join(conversions.ForeignLangs, ", "))
+	for _, fc := range fcs {
+		logrus.Infof("%s -> %s", fc.Result.From, fc.Result.To)
+		for _, f := range append([]conversions.Foreign{fc.Go}, fc.Foreign...) {
+			logrus.Infof("    %-4s %s", f.Lang, f.Describe())
+		}
+	}
+	return nil
+}
+
+// Row implements conversions.RowWriter, only passing on the Results with the tag.
+func (tr taggedRows) Row(ctx context.Context, from string, row []conversions.Result) error {
+	var tagged []conversions.Result
+	for _, result := range row {
+		if hasTag(result, tr.tag) {
+			tagged = append(tagged, result)
+		}
+	}
+	if f, ok := tr.RowWriter.(conversions.Filterer); ok {
+		f.Filtered(len(row) - len(tagged))
+	}
+	return tr.RowWriter.Row(ctx, from, tagged)
+}
+
+// Pivot implements conversions.Pivoter, passing it on to the wrapped RowWriter.
+func (tr taggedRows) Pivot() {
+	if p, ok := tr.RowWriter.(conversions.Pivoter); ok {
+		p.Pivot()
+	}
+}
+
+// Filtered implements conversions.Filterer, passing it on to the wrapped RowWriter.
+func (tr taggedRows) Filtered(n int) {
+	if f, ok := tr.RowWriter.(conversions.Filterer); ok {
+		f.Filtered(n)
+	}
+}
+
+// Row implements conversions.RowWriter, only passing on the Results ors.keep is true for.
+func (ors onlyRows) Row(ctx context.Context, from string, row []conversions.Result) error {
+	var kept []conversions.Result
+	for _, result := range row {
+		if ors.keep(conversions.PairOf(result)) {
+			kept = append(kept, result)
+		}
+	}
+	if f, ok := ors.RowWriter.(conversions.Filterer); ok {
+		f.Filtered(len(row) - len(kept))
+	}
+	return ors.RowWriter.Row(ctx, from, kept)
+}
+
+// Pivot implements conversions.Pivoter, passing it on to the wrapped RowWriter.
+func (ors onlyRows) Pivot() {
+	if p, ok := ors.RowWriter.(conversions.Pivoter); ok {
+		p.Pivot()
+	}
+}
+
+// reportResult reports a single line for result, marked with its conversions.Word when verbal,
+// or otherwise with the symbols of theme.
+func reportResult(result conversions.Result, verbal bool, theme conversions.Theme) {
+	compatible := theme.WithDefaults().Compiles(result)
+	if verbal {
+		compatible = conversions.Word(result)
+	}
+	var width string
+	if result.Convertible && result.Width != "" {
+		width = "(" + result.Width + ") "
+	}
+	var tags string
+	if len(result.Tags) > 0 {
+		tags = "[" + strings.Join(result.Tags, ", ") + "] "
+	}
+	var columns string
+	if described := conversions.DescribeColumns(result); described != "" {
+		columns = "{" + described + "}"
+	}
+	logrus.Infof("%10s -> %-10s %s %s%s%s", result.From, result.To, compatible, width, tags, columns)
+}
+
+// hasTag reports whether result has the tag t.
+func hasTag(result conversions.Result, t string) bool {
+	for _, resultTag := range result.Tags {
+		if resultTag == t {
 			return true
 		}
 	}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/Insulince/go-conversions/mappers"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"path/filepath"
+	"strings"
+)
+
+// Mappers generates the functions mapping each of the -structs pairs of a domain struct and a
+// transport struct declared in a user package, e.g. User:UserDTO, to one another, into the
+// package. Fields of the same type are assigned, those whose conversion always preserves the
+// value are converted, and those which may not fit are converted by the checked helpers
+// generated by the helpers command, which -helpers-import gives the import path of. It logs how
+// each field maps, and warns about those which can't be.
+func Mappers(ctx context.Context, args []string) error {
+	var structs, outputFile string
+	var opts mappers.Options
+	fs := flag.NewFlagSet("mappers", flag.ContinueOnError)
+	fs.StringVar(&structs, "structs", "", "comma separated domain:transport pairs of structs to map, e.g. User:UserDTO")
+	fs.StringVar(&outputFile, "out", "", "file to write the generated mappers to (default "+mappers.DefaultFile+" in the package)")
+	fs.StringVar(&opts.HelpersImport, "helpers-import", "", "import path of the package generated by the helpers command, e.g. example.com/conv")
+	fs.StringVar(&opts.HelpersPackage, "helpers-package", "", "name of that package, defaults to the last element of -helpers-import")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+	if structs == "" {
+		return errors.New("-structs must be given")
+	}
+
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+	if outputFile == "" {
+		outputFile = filepath.Join(dir, mappers.DefaultFile)
+	}
+
+	copts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	m, err := conversions.Analyze(ctx, copts)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+
+	var sms []conversions.StructMapping
+	for _, pair := range splitList(structs) {
+		i := strings.Index(pair, ":")
+		if i < 0 {
+			return errors.Errorf("expected a domain:transport pair of structs, got %q", pair)
+		}
+		sm, err := conversions.MapStructs(dir, pair[:i], pair[i+1:], m)
+		if err != nil {
+			return errors.Wrapf(err, "mapping %s", pair)
+		}
+
+		logrus.Infof("---------- %s <-> %s ----------\n", sm.Domain, sm.Transport)
+		for _, fm := range sm.Fields {
+			logMapping(sm.Domain, fm.Domain, fm.DomainType, sm.Transport, fm.Transport, fm.TransportType, fm.ToTransport)
+			logMapping(sm.Transport, fm.Transport, fm.TransportType, sm.Domain, fm.Domain, fm.DomainType, fm.ToDomain)
+		}
+		if len(sm.DomainOnly) > 0 {
+			logrus.Warnf("%s has no field for %s.%s, which is left out", sm.Transport, sm.Domain, strings.Join(sm.DomainOnly, ", "+sm.Domain+"."))
+		}
+		if len(sm.TransportOnly) > 0 {
+			logrus.Warnf("%s has no field for %s.%s, which is left out", sm.Domain, sm.Transport, strings.Join(sm.TransportOnly, ", "+sm.Transport+"."))
+		}
+		sms = append(sms, sm)
+	}
+
+	err = mappers.Generate(ctx, sms, outputFile, opts)
+	if err != nil {
+		return errors.Wrap(err, "generating mappers")
+	}
+
+	logrus.Infof("generated mappers for %d pairs of structs in %s", len(sms), outputFile)
+
+	return nil
+}
+
+// logMapping logs how the field source of the struct from, of type sourceType, maps to the
+// field target of the struct to, of type targetType, warning when it can't.
+func logMapping(from, source, sourceType, to, target, targetType string, fc conversions.FieldConversion) {
+	switch fc.Mapping {
+	case conversions.MappingAssign:
+		logrus.Infof("✅ %s.%s -> %s.%s: assigned as %s", from, source, to, target, sourceType)
+	case conversions.MappingConvert:
+		logrus.Infof("✅ %s.%s -> %s.%s: %s converts to %s exactly", from, source, to, target, sourceType, targetType)
+	case conversions.MappingChecked:
+		logrus.Infof("⚠️ %s.%s -> %s.%s: %s may not fit %s, checked by %s", from, source, to, target, sourceType, targetType, fc.Helper)
+	default:
+		logrus.Warnf("❌ %s.%s -> %s.%s: not mapped, since %s", from, source, to, target, fc.Reason)
+	}
+}
@@ -0,0 +1,178 @@
+// Package mappers generates the functions mapping a domain struct to a transport struct, e.g. a
+// DTO, and back, declared in a user package, checking every field which may not fit the other
+// side's type.
+package mappers
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"go/format"
+	"os"
+	"path"
+	"text/template"
+	"time"
+)
+
+const (
+	// DefaultFile is the name of the file written alongside the package's own files when no other
+	// output file is given.
+	DefaultFile = "mappers_conversions.go"
+)
+
+type (
+	// Options configures Generate.
+	Options struct {
+		// HelpersImport is the import path of the package generated by the helpers command, whose
+		// checked helpers convert the fields which may not fit, e.g. "example.com/org/repo/conv".
+		HelpersImport string
+		// HelpersPackage is the name of that package. Defaults to the last element of HelpersImport.
+		HelpersPackage string
+	}
+
+	// Func is a function mapping one struct of a conversions.StructMapping to the other.
+	Func struct {
+		Name string
+		From string
+		To   string
+		// Fields are how each field of To is set.
+		Fields []Field
+	}
+
+	// Field is how a Func sets a single field.
+	Field struct {
+		// Source and Target are the names of the field in From and To.
+		Source string
+		Target string
+		// Mapping is one of the conversions Mapping constants.
+		Mapping string
+		// Convert is the type the field is converted to, empty when it's assigned as is, or when
+		// the checked helper already returns it.
+		Convert string
+		// Helper and Arg are the checked helper a conversions.MappingChecked field is converted
+		// by, and what it's called with.
+		Helper string
+		Arg    string
+		// Reason is why a conversions.MappingNone field isn't mapped.
+		Reason string
+	}
+)
+
+var (
+	// MappersTemplate is the template the mapping functions are generated from.
+	//go:embed template/mappers.tmpl
+	MappersTemplate string
+)
+
+// Generate writes a DomainToTransport and TransportToDomain function for every one of sms, all of
+// which must be declared in the same package, to outputFile. Fields which may change value are
+// converted by the checked helpers opts names the package of, and the function returns the
+// error of the first which doesn't fit, prefixed with its name.
+func Generate(_ context.Context, sms []conversions.StructMapping, outputFile string, opts Options) error {
+	if len(sms) == 0 {
+		return errors.New("no structs to generate mappers for")
+	}
+	var checked bool
+	for _, sm := range sms {
+		checked = checked || sm.Checked()
+	}
+	if checked && opts.HelpersImport == "" {
+		return errors.New("the import path of the helpers must be given, since some fields may not fit")
+	}
+	if opts.HelpersPackage == "" {
+		opts.HelpersPackage = path.Base(opts.HelpersImport)
+	}
+
+	type Data struct {
+		Now            string
+		App            string
+		Package        string
+		HelpersImport  string
+		HelpersPackage string
+		Checked        bool
+		Funcs          []Func
+	}
+	var data Data
+	data.Now = time.Now().Format(time.RFC3339)
+	data.App = os.Args[0]
+	data.Package = sms[0].Package
+	data.HelpersImport = opts.HelpersImport
+	data.HelpersPackage = opts.HelpersPackage
+	data.Checked = checked
+	for _, sm := range sms {
+		data.Funcs = append(data.Funcs, toTransport(sm), toDomain(sm))
+	}
+
+	t, err := template.New("mappers.tmpl").Parse(MappersTemplate)
+	if err != nil {
+		return errors.Wrap(err, "parsing template")
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, data)
+	if err != nil {
+		return errors.Wrap(err, "executing template")
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "formatting generated code")
+	}
+
+	err = conversions.WriteFileAtomic(outputFile, src, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "writing %q", outputFile)
+	}
+
+	return nil
+}
+
+// toTransport is the Func mapping sm's Domain to its Transport.
+func toTransport(sm conversions.StructMapping) Func {
+	var fn Func
+	fn.Name = sm.Domain + "To" + sm.Transport
+	fn.From = sm.Domain
+	fn.To = sm.Transport
+	for _, fm := range sm.Fields {
+		fn.Fields = append(fn.Fields, field(fm.Domain, fm.DomainType, fm.DomainPrimitive, fm.Transport, fm.TransportType, fm.TransportPrimitive, fm.ToTransport))
+	}
+	return fn
+}
+
+// toDomain is the Func mapping sm's Transport to its Domain.
+func toDomain(sm conversions.StructMapping) Func {
+	var fn Func
+	fn.Name = sm.Transport + "To" + sm.Domain
+	fn.From = sm.Transport
+	fn.To = sm.Domain
+	for _, fm := range sm.Fields {
+		fn.Fields = append(fn.Fields, field(fm.Transport, fm.TransportType, fm.TransportPrimitive, fm.Domain, fm.DomainType, fm.DomainPrimitive, fm.ToDomain))
+	}
+	return fn
+}
+
+// field is how the field source, of type sourceType defined as sourcePrimitive, sets the field
+// target, of type targetType defined as targetPrimitive, as fc has it.
+func field(source, sourceType, sourcePrimitive, target, targetType, targetPrimitive string, fc conversions.FieldConversion) Field {
+	var f Field
+	f.Source = source
+	f.Target = target
+	f.Mapping = fc.Mapping
+	f.Reason = fc.Reason
+	switch fc.Mapping {
+	case conversions.MappingConvert:
+		f.Convert = targetType
+	case conversions.MappingChecked:
+		f.Helper = fc.Helper
+		f.Arg = "v." + source
+		// NOTE: The helper takes and returns the primitives, which a defined type always converts
+		// to and from exactly.
+		if sourceType != sourcePrimitive {
+			f.Arg = sourcePrimitive + "(" + f.Arg + ")"
+		}
+		if targetType != targetPrimitive {
+			f.Convert = targetType
+		}
+	}
+	return f
+}
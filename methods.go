@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"strings"
+)
+
+// Methods reports, for every named type with methods in a user package, which methods are
+// lost by converting it to its underlying type or another named type.
+func Methods(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("methods", flag.ContinueOnError)
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+
+	losses, err := conversions.MethodSetImpact(dir)
+	if err != nil {
+		return errors.Wrapf(err, "analyzing method sets of %q", dir)
+	}
+
+	var from string
+	for _, ml := range losses {
+		if ml.From != from {
+			from = ml.From
+			logrus.Infof("---------- converting %s values ----------\n", from)
+		}
+		logrus.Infof("%10s -> %-10s loses %s", ml.From, ml.To, strings.Join(ml.Lost, ", "))
+	}
+
+	logrus.Infof("%d conversions lose methods", len(losses))
+
+	return nil
+}
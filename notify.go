@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+type (
+	// Notification is posted as JSON to the -notify-webhook when an analysis differs from its
+	// -baseline or has more lossy conversions than -max-lossy. Text is a readable summary, which
+	// is all Slack's incoming webhooks display, while the rest is there for any other webhook.
+	Notification struct {
+		Text       string     `json:"text"`
+		Provenance Provenance `json:"provenance"`
+		// Gained and Lost are the pairs which became, and stopped being, convertible since the baseline.
+		Gained []string `json:"gained,omitempty"`
+		Lost   []string `json:"lost,omitempty"`
+		// Lossy is the number of pairs which convert but may lose information, and MaxLossy the
+		// most there may be before the notification is sent, or -1 when there is no limit.
+		Lossy    int `json:"lossy"`
+		MaxLossy int `json:"maxLossy"`
+	}
+)
+
+// LoadBaseline reads the Matrix rendered by a previous -format json report from baselineFile.
+func LoadBaseline(baselineFile string) (conversions.Matrix, error) {
+	b, err := os.ReadFile(baselineFile)
+	if err != nil {
+		return conversions.Matrix{}, errors.Wrapf(err, "reading baseline %q", baselineFile)
+	}
+
+	var m conversions.Matrix
+	err = json.Unmarshal(b, &m)
+	if err != nil {
+		return conversions.Matrix{}, errors.Wrapf(err, "decoding baseline %q", baselineFile)
+	}
+
+	return m, nil
+}
+
+// NotificationFor summarizes how m, produced as described by p, differs from baseline, when
+// it is set, and whether it has more than maxLossy lossy conversions, when that isn't negative.
+// It reports false when neither is the case, so there is nothing to notify about.
+func NotificationFor(m conversions.Matrix, baseline *conversions.Matrix, maxLossy int, p Provenance) (Notification, bool) {
+	var n Notification
+	n.Provenance = p
+	n.Lossy = len(m.Lossy())
+	n.MaxLossy = maxLossy
+	if baseline != nil {
+		gained, lost := conversions.Compare(*baseline, m)
+		n.Gained = pairNames(gained)
+		n.Lost = pairNames(lost)
+	}
+	breached := maxLossy >= 0 && n.Lossy > maxLossy
+	if len(n.Gained) == 0 && len(n.Lost) == 0 && !breached {
+		return Notification{}, false
+	}
+
+	var text strings.Builder
+	_, _ = fmt.Fprintf(&text, "go-conversions analyzed with %s:", p.AnalyzedWith)
+	if len(n.Gained) > 0 {
+		_, _ = fmt.Fprintf(&text, "\n• %d conversions now compile: %s", len(n.Gained), strings.Join(n.Gained, ", "))
+	}
+	if len(n.Lost) > 0 {
+		_, _ = fmt.Fprintf(&text, "\n• %d conversions no longer compile: %s", len(n.Lost), strings.Join(n.Lost, ", "))
+	}
+	if breached {
+		_, _ = fmt.Fprintf(&text, "\n• %d conversions may lose information, more than the %d allowed", n.Lossy, maxLossy)
+	}
+	n.Text = text.String()
+
+	return n, true
+}
+
+// Notify posts n as JSON to webhookURL.
+func Notify(ctx context.Context, webhookURL string, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return errors.Wrap(err, "encoding notification")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "creating request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// NOTE: Webhook URLs are secrets, so only the error is reported, never the URL.
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return errors.Wrap(err, "posting notification")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("webhook replied %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"io"
+	"os"
+)
+
+// CheckPairs analyzes only the pairs listed in the CSV file given by -pairs, or stdin when it's -,
+// and reports a verdict for each of them, logged or rendered with -format like any other
+// report. A pair can also say whether it's expected to be yes, lossy, or no, see
+// conversions.ParsePairs, and the check fails if any pair isn't what it's expected to be. Every
+// pair which doesn't compile is followed by the code to write instead, see conversions.Suggest.
+func CheckPairs(ctx context.Context, args []string) error {
+	var pairsFile string
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.StringVar(&pairsFile, "pairs", "", "CSV file of from,to pairs to check, each optionally followed by yes, lossy, or no as expected, or - for stdin")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+	if pairsFile == "" {
+		return errors.New("-pairs is required")
+	}
+
+	pcs, err := readPairs(pairsFile)
+	if err != nil {
+		return errors.Wrapf(err, "reading pairs %q", pairsFile)
+	}
+	if len(pcs) == 0 {
+		return errors.Errorf("no pairs to check in %q", pairsFile)
+	}
+
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+	// NOTE: Only the types the pairs need are analyzed, whatever -types and the config say.
+	opts.Types = conversions.PairTypes(pcs)
+	for _, t := range opts.Types {
+		if _, ok := conversions.Lookup(t); !ok {
+			return errors.Errorf("unknown primitive %q", t)
+		}
+	}
+
+	var ropts ReportOptions
+	ropts.Format = *reportFormat
+	ropts.Locale = *locale
+	ropts.Accessible = *accessible
+	ropts.Verbose = *verbose
+	ropts.Keep = conversions.Listed(pcs)
+	_, err = conversions.LookupCatalog(ropts.Locale)
+	if err != nil {
+		return errors.Wrap(err, "looking up -locale")
+	}
+	ropts.Theme, err = reportTheme()
+	if err != nil {
+		return errors.Wrap(err, "looking up -theme")
+	}
+	ropts.Provenance, err = ProvenanceFor(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "determining provenance")
+	}
+
+	m, err := conversions.Analyze(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+	results := make([]conversions.Result, 0, len(pcs))
+	for _, pc := range pcs {
+		result, ok := m.Result(pc.From, pc.To)
+		if !ok {
+			return errors.Errorf("line %d: %s -> %s wasn't analyzed", pc.Line, pc.From, pc.To)
+		}
+		results = append(results, result)
+	}
+
+	err = Report(ctx, m, ropts)
+	if err != nil {
+		return errors.Wrap(err, "reporting")
+	}
+
+	suggestions := conversions.Suggestions(m.Filter(ropts.Keep))
+	for _, s := range suggestions {
+		logrus.Infof("%s -> %s doesn't compile, instead: %s", s.From, s.To, oneLine(s.Code))
+	}
+
+	var unexpected int
+	for i, pc := range pcs {
+		if pc.Expect == "" {
+			continue
+		}
+		if got := conversions.Word(results[i]); got != pc.Expect {
+			unexpected++
+			var because string
+			if results[i].Err != nil {
+				because = ", as " + results[i].Err.Reason
+			}
+			logrus.Errorf("line %d: %s -> %s is %s, expected %s%s", pc.Line, pc.From, pc.To, got, pc.Expect, because)
+		}
+	}
+	if unexpected > 0 {
+		return errors.Errorf("%d of %d pairs aren't what they're expected to be", unexpected, len(pcs))
+	}
+
+	return nil
+}
+
+// readPairs parses the pairs in the file named name, or stdin when it's -.
+func readPairs(name string) ([]conversions.PairCheck, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, errors.Wrap(err, "opening pairs")
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+	return conversions.ParsePairs(r)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+)
+
+// ReadTypes reads a list of types from r, either as a JSON array of strings or one per line,
+// with commas allowed between them too, as a pre hook writes them.
+func ReadTypes(r io.Reader) ([]string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading types")
+	}
+
+	var types []string
+	if s := strings.TrimSpace(string(b)); strings.HasPrefix(s, "[") {
+		err = json.Unmarshal([]byte(s), &types)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding types")
+		}
+	} else {
+		types = splitList(strings.ReplaceAll(s, "\n", ","))
+	}
+	if len(types) == 0 {
+		return nil, errors.New("no types to analyze were given")
+	}
+	return types, nil
+}
+
+// pipeOptions configures opts for -pipe, so the analysis writes no files: the types engine
+// unless another engine which doesn't write any was asked for, nothing saved to resume from,
+// and nothing cached. Flags which would write files anyway are rejected rather than ignored.
+func pipeOptions(opts *conversions.Options) error {
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	switch {
+	case !explicit["engine"]:
+		opts.Engine = conversions.EngineTypes
+	case opts.Engine == conversions.EngineBuild:
+		return errors.New("-pipe writes no files, so it can't compile them with -engine build")
+	}
+	switch {
+	case *resume:
+		return errors.New("-pipe saves no progress, so there is nothing to -resume")
+	case *useCache:
+		return errors.New("-pipe writes no files, so it can't keep a -cache")
+	case *debugArtifacts != "":
+		return errors.New("-pipe writes no files, so it can't save -debug-artifacts")
+	case len(splitList(*reportFormat)) > 1:
+		return errors.New("-pipe writes the report to stdout, so it can only render a single -format")
+	}
+	opts.StateFile = ""
+	return nil
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// amzDateFormat is the format of the timestamp AWS signature version 4 signs requests with.
+	amzDateFormat = "20060102T150405Z"
+	// publishContentType is the Content-Type every report is uploaded with.
+	publishContentType = "application/octet-stream"
+)
+
+var (
+	// reportExtensions are the file extensions reports rendered by each built in format are
+	// written and published with, anything else gets .txt.
+	reportExtensions = map[string]string{
+		"json":     ".json",
+		"csv":      ".csv",
+		"markdown": ".md",
+		"html":     ".html",
+	}
+)
+
+// Publish uploads the rendered report b to target, an s3://bucket/prefix or gs://bucket/prefix
+// URL, named after its SHA-256 so that identical reports share a name and archived ones are never
+// overwritten. It returns the URL of the uploaded report.
+//
+// S3 uploads are signed with the credentials in AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and
+// AWS_SESSION_TOKEN, for the region in AWS_REGION (default us-east-1), and go to
+// AWS_ENDPOINT_URL instead of AWS when it is set, e.g. for MinIO. GCS uploads are authorized
+// with the OAuth token in GOOGLE_OAUTH_ACCESS_TOKEN, e.g. from `gcloud auth print-access-token`,
+// and go to STORAGE_EMULATOR_HOST instead of Google when it is set.
+func Publish(ctx context.Context, target string, format string, b []byte) (string, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing publish target %q", target)
+	}
+	if u.Host == "" {
+		return "", errors.Errorf("publish target %q has no bucket", target)
+	}
+
+	ext, ok := reportExtensions[format]
+	if !ok {
+		ext = ".txt"
+	}
+	sum := sha256.Sum256(b)
+	key := strings.Trim(u.Path, "/")
+	if key != "" {
+		key += "/"
+	}
+	key += hex.EncodeToString(sum[:]) + ext
+
+	switch u.Scheme {
+	case "s3":
+		err = publishS3(ctx, u.Host, key, b)
+	case "gs":
+		err = publishGCS(ctx, u.Host, key, b)
+	default:
+		return "", errors.Errorf("publish target %q is neither s3:// nor gs://", target)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return u.Scheme + "://" + u.Host + "/" + key, nil
+}
+
+// publishS3 uploads b as key in the S3 bucket.
+func publishS3(ctx context.Context, bucket, key string, b []byte) error {
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return errors.New("publishing to s3:// needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	// NOTE: Custom endpoints, like MinIO, are addressed by path since they rarely have DNS for every bucket.
+	objectURL := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, s3Escape(key))
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL"); endpoint != "" {
+		objectURL = strings.TrimSuffix(endpoint, "/") + "/" + s3Escape(bucket) + "/" + s3Escape(key)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, bytes.NewReader(b))
+	if err != nil {
+		return errors.Wrapf(err, "creating request to %q", objectURL)
+	}
+	sum := sha256.Sum256(b)
+	payloadHash := hex.EncodeToString(sum[:])
+	// NOTE: Every header is set before signing, since the signature only covers those already set.
+	req.Header.Set("Content-Type", publishContentType)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", time.Now().UTC().Format(amzDateFormat))
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+	signV4(req, payloadHash, region, "s3", accessKey, secretKey)
+
+	return doPublish(req)
+}
+
+// publishGCS uploads b as key in the GCS bucket.
+func publishGCS(ctx context.Context, bucket, key string, b []byte) error {
+	token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	endpoint := "https://storage.googleapis.com"
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		endpoint = "http://" + strings.TrimPrefix(host, "http://")
+	} else if token == "" {
+		return errors.New("publishing to gs:// needs GOOGLE_OAUTH_ACCESS_TOKEN, e.g. from gcloud auth print-access-token")
+	}
+
+	uploadURL := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s", endpoint, url.PathEscape(bucket), url.QueryEscape(key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, bytes.NewReader(b))
+	if err != nil {
+		return errors.Wrapf(err, "creating request to %q", uploadURL)
+	}
+	req.Header.Set("Content-Type", publishContentType)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return doPublish(req)
+}
+
+// doPublish sends the upload req, failing unless it is accepted.
+func doPublish(req *http.Request) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "uploading to %q", req.URL.Redacted())
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("uploading to %q: %s: %s", req.URL.Redacted(), resp.Status, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
+
+// signV4 signs req for service in region with AWS signature version 4, covering the host, every
+// header already set on req, and payloadHash, the hex SHA-256 of its body. X-Amz-Date must
+// already be set.
+func signV4(req *http.Request, payloadHash, region, service, accessKey, secretKey string) {
+	amzDate := req.Header.Get("X-Amz-Date")
+	date := amzDate[:8]
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	canonical.WriteString(req.Method + "\n")
+	canonical.WriteString(req.URL.EscapedPath() + "\n")
+	canonical.WriteString(canonicalQuery(req.URL.Query()) + "\n")
+	for _, name := range names {
+		canonical.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	canonical.WriteString("\n" + signedHeaders + "\n")
+	canonical.WriteString(payloadHash)
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	canonicalSum := sha256.Sum256([]byte(canonical.String()))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalSum[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query as AWS signature version 4 expects, sorted by key and then value.
+func canonicalQuery(query url.Values) string {
+	var pairs []string
+	for k, vs := range query {
+		for _, v := range vs {
+			pairs = append(pairs, strings.ReplaceAll(s3Escape(k), "/", "%2F")+"="+strings.ReplaceAll(s3Escape(v), "/", "%2F"))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// s3Escape percent-encodes every byte of s except the unreserved characters and /, as S3 expects
+// of object keys.
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', strings.IndexByte("-._~/", c) >= 0:
+			b.WriteByte(c)
+		default:
+			_, _ = fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// hmacSHA256 is the HMAC-SHA256 of data with key.
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,188 @@
+// Package report renders a conversions.Matrix in any of the formats the conversions command
+// reports in, for tools which embed the reports rather than running the command, e.g.
+//
+//	b, err := report.Markdown(m, report.Sorted(conversions.SortName), report.Accessible())
+package report
+
+import (
+	"bytes"
+	"context"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+)
+
+type (
+	// Option configures how a report is rendered.
+	Option func(o *options)
+
+	// options are what every Option given to a render function configures.
+	options struct {
+		ctx        context.Context
+		sort       string
+		pivot      bool
+		keep       func(conversions.Pair) bool
+		locale     string
+		accessible bool
+		compare    bool
+		verbose    bool
+		theme      conversions.Theme
+		provenance conversions.Provenance
+		width      int
+	}
+)
+
+// WithContext renders the report with ctx, which is handed to the conversions.Reporter.
+// Defaults to context.Background().
+func WithContext(ctx context.Context) Option {
+	return func(o *options) { o.ctx = ctx }
+}
+
+// Sorted orders the rows and columns of the report by one of conversions.SortFamily,
+// conversions.SortName, or conversions.SortDegree, see conversions.Matrix.Sort.
+func Sorted(by string) Option {
+	return func(o *options) { o.sort = by }
+}
+
+// Pivoted makes the rows of the report the types converted to, rather than from, see
+// conversions.Matrix.Pivot.
+func Pivoted() Option {
+	return func(o *options) { o.pivot = true }
+}
+
+// Only leaves every pair keep returns false for out of the report, e.g. conversions.NarrowingOnly,
+// see conversions.Matrix.Filter.
+func Only(keep func(conversions.Pair) bool) Option {
+	return func(o *options) { o.keep = keep }
+}
+
+// Locale renders the headings and legends of the report in locale, see conversions.LookupCatalog.
+func Locale(locale string) Option {
+	return func(o *options) { o.locale = locale }
+}
+
+// Accessible marks every pair with a word rather than by emoji alone, see conversions.Verbalizer.
+func Accessible() Option {
+	return func(o *options) { o.accessible = true }
+}
+
+// Compared follows the report with a section comparing each pair to conversions.ForeignLangs,
+// see conversions.Comparer.
+func Compared() Option {
+	return func(o *options) { o.compare = true }
+}
+
+// Verbose follows the report with the conversions.TypeData of every type, see conversions.Detailer.
+func Verbose() Option {
+	return func(o *options) { o.verbose = true }
+}
+
+// Themed marks each pair as t does, see conversions.LookupTheme.
+func Themed(t conversions.Theme) Option {
+	return func(o *options) { o.theme = t }
+}
+
+// Stamped stamps the report with what produced m, see conversions.Stamper.
+func Stamped(p conversions.Provenance) Option {
+	return func(o *options) { o.provenance = p }
+}
+
+// Width is the most columns of text a line of a Table may take up, see conversions.TableReporter.
+// It has no effect on any other format.
+func Width(columns int) Option {
+	return func(o *options) { o.width = columns }
+}
+
+// Text renders m as conversions.FormatText, a section per type with a line per pair.
+func Text(m conversions.Matrix, opts ...Option) ([]byte, error) {
+	return Render(conversions.FormatText, m, opts...)
+}
+
+// JSON renders m as conversions.FormatJSON, along with the Boundaries, Precisions, and Coverage
+// of its types.
+func JSON(m conversions.Matrix, opts ...Option) ([]byte, error) {
+	return Render(conversions.FormatJSON, m, opts...)
+}
+
+// CSV renders m as conversions.FormatCSV, a line per pair.
+func CSV(m conversions.Matrix, opts ...Option) ([]byte, error) {
+	return Render(conversions.FormatCSV, m, opts...)
+}
+
+// Markdown renders m as conversions.FormatMarkdown, a markdown table.
+func Markdown(m conversions.Matrix, opts ...Option) ([]byte, error) {
+	return Render(conversions.FormatMarkdown, m, opts...)
+}
+
+// Table renders m as conversions.FormatTable, a grid split into blocks of columns no wider than
+// Width.
+func Table(m conversions.Matrix, opts ...Option) ([]byte, error) {
+	return Render(conversions.FormatTable, m, opts...)
+}
+
+// Render renders m with the conversions.Reporter registered as format, which may be one of
+// conversions.Reporters registered by another package, see conversions.RegisterReporter.
+func Render(format string, m conversions.Matrix, opts ...Option) ([]byte, error) {
+	var o options
+	o.ctx = context.Background()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	r, err := conversions.LookupReporter(format)
+	if err != nil {
+		return nil, errors.Wrap(err, "looking up reporter")
+	}
+	if format == conversions.FormatTable {
+		var tr conversions.TableReporter
+		tr.Width = o.width
+		r = tr
+	}
+
+	m, err = o.apply(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = r.Render(o.ctx, m, &buf)
+	if err != nil {
+		return nil, errors.Wrapf(err, "rendering %s", format)
+	}
+	return buf.Bytes(), nil
+}
+
+// apply returns a copy of m arranged and marked to be reported as o configures.
+func (o options) apply(m conversions.Matrix) (conversions.Matrix, error) {
+	// NOTE: Pivoted first, so sorting by degree counts the rows as they're reported, as the
+	// conversions command does.
+	if o.pivot {
+		m = m.Pivot()
+	}
+	if o.sort != "" {
+		var err error
+		m, err = m.Sort(o.sort)
+		if err != nil {
+			return conversions.Matrix{}, errors.Wrap(err, "sorting")
+		}
+	}
+	if o.keep != nil {
+		m = m.Filter(o.keep)
+	}
+	if o.locale != "" {
+		_, err := conversions.LookupCatalog(o.locale)
+		if err != nil {
+			return conversions.Matrix{}, errors.Wrap(err, "looking up locale")
+		}
+		m.Locale = o.locale
+	}
+	m.Accessible = m.Accessible || o.accessible
+	m.Compare = m.Compare || o.compare
+	m.Verbose = m.Verbose || o.verbose
+	if o.theme != (conversions.Theme{}) {
+		m.Theme = o.theme
+	}
+	if o.provenance != nil {
+		m.Provenance = o.provenance
+	}
+	return m, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/Insulince/go-conversions/report"
+	"testing"
+)
+
+// TestReportSortPivot checks that the report package orders a pivoted report sorted by degree
+// the same as -sort degree -pivot to does, by how many types convert to each row.
+func TestReportSortPivot(t *testing.T) {
+	var opts conversions.Options
+	opts.Types = []string{"bool", "int8", "float64", "string"}
+	opts.Engine = conversions.EngineTypes
+	opts.OutputDir = t.TempDir()
+	ctx := context.Background()
+	m, err := conversions.Analyze(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ropts ReportOptions
+	ropts.Format = conversions.FormatMarkdown
+	ropts.Sort = conversions.SortDegree
+	ropts.Pivot = true
+	var cli bytes.Buffer
+	ropts.Output = &cli
+	err = Report(ctx, m, ropts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lib, err := report.Markdown(m, report.Sorted(conversions.SortDegree), report.Pivoted())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(lib, cli.Bytes()) {
+		t.Errorf("the report package rendered\n%s\nbut the command rendered\n%s", lib, cli.Bytes())
+	}
+}
+
+// TestReportGroupByTagFormat checks that grouping by tag, which only the logged report does, is
+// an error rather than silently ignored when the report is rendered by a Format.
+func TestReportGroupByTagFormat(t *testing.T) {
+	var m conversions.Matrix
+	m.Types = []string{"int8"}
+
+	var ropts ReportOptions
+	ropts.Format = conversions.FormatMarkdown
+	ropts.GroupByTag = true
+	ropts.Tags = []string{"api-boundary"}
+	var out bytes.Buffer
+	ropts.Output = &out
+	err := Report(context.Background(), m, ropts)
+	if err == nil {
+		t.Fatalf("reported with -group-by-tag and -format %s", ropts.Format)
+	}
+	if out.Len() > 0 {
+		t.Errorf("rendered %q before failing", out.String())
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go/build"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// diffContext is how many unchanged lines a hunk of a unified diff shows around its changes.
+	diffContext = 3
+)
+
+type (
+	// diffLine is a line of a unified diff, Op being ' ' for a line both sides have, '-' for one
+	// only the old side has, and '+' for one only the new side has.
+	diffLine struct {
+		Op   byte
+		Text string
+	}
+)
+
+// Rewrite rewrites the conversions which can lose information in the packages given, by
+// default the one in the working directory, to call the checked helpers generated by the helpers
+// command, which -helpers-import gives the import path of. A package ending in /... is every
+// package beneath it too. Conversions proven safe, and those the config turns off, are left
+// alone, as are those the error can't be checked for where they are, which are listed along
+// with why. With -dry-run, nothing is written, and the changes are written to stdout as a
+// unified diff instead.
+func Rewrite(_ context.Context, args []string) error {
+	var ropts conversions.RewriteOptions
+	var dryRun bool
+	fs := flag.NewFlagSet("rewrite", flag.ContinueOnError)
+	fs.StringVar(&ropts.HelpersImport, "helpers-import", "", "import path of the package generated by the helpers command, e.g. example.com/conv")
+	fs.StringVar(&ropts.HelpersPackage, "helpers-package", "", "name of that package, defaults to the last element of -helpers-import")
+	fs.BoolVar(&dryRun, "dry-run", false, "write the changes to stdout as a unified diff rather than to the files")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+	if ropts.HelpersImport == "" {
+		return errors.New("-helpers-import must be given")
+	}
+
+	c := config
+	err = c.Severities.Validate()
+	if err != nil {
+		return errors.Wrap(err, "validating config")
+	}
+	ropts.Skip = func(af conversions.AuditFinding) bool {
+		return severityOf(af, c.Severities) == conversions.SeverityOff
+	}
+
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	dirs, err := packageDirs(patterns)
+	if err != nil {
+		return errors.Wrap(err, "finding packages")
+	}
+
+	var rewritten, skipped int
+	for _, dir := range dirs {
+		frs, err := conversions.Rewrite(dir, ropts)
+		if err != nil {
+			return errors.Wrapf(err, "rewriting %q", dir)
+		}
+		for _, fr := range frs {
+			for _, sf := range fr.Skipped {
+				logrus.Warnf("%s: left %s alone, as %s", filepath.Join(dir, sf.Pos.String()), sf.Expr, sf.Reason)
+			}
+			skipped += len(fr.Skipped)
+			if len(fr.Rewritten) == 0 {
+				continue
+			}
+			rewritten += len(fr.Rewritten)
+
+			if dryRun {
+				fmt.Print(unifiedDiff(fr.Path, fr.Before, fr.After))
+				continue
+			}
+			info, err := os.Stat(fr.Path)
+			if err != nil {
+				return errors.Wrapf(err, "checking %q", fr.Path)
+			}
+			err = conversions.WriteFileAtomic(fr.Path, fr.After, info.Mode().Perm())
+			if err != nil {
+				return errors.Wrapf(err, "writing %q", fr.Path)
+			}
+			for _, af := range fr.Rewritten {
+				logrus.Infof("%s: rewrote %s to call a helper", filepath.Join(dir, af.Pos.String()), af.Expr)
+			}
+		}
+	}
+
+	logrus.Infof("%d conversions rewritten to call a helper, %d left alone", rewritten, skipped)
+	return nil
+}
+
+// packageDirs returns the directories of the packages patterns name. A pattern ending in /...
+// names the package in the directory before it and every package beneath it, leaving out
+// testdata and vendor directories, and those whose name starts with . or _, as go build does.
+func packageDirs(patterns []string) ([]string, error) {
+	var dirs []string
+	for _, pattern := range patterns {
+		if !strings.HasSuffix(pattern, "/...") {
+			dirs = append(dirs, pattern)
+			continue
+		}
+		root := strings.TrimSuffix(pattern, "/...")
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			name := d.Name()
+			if path != root && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			_, err = build.Default.ImportDir(path, 0)
+			var noGo *build.NoGoError
+			if errors.As(err, &noGo) {
+				return nil
+			}
+			dirs = append(dirs, path)
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "walking %q", root)
+		}
+	}
+	return dirs, nil
+}
+
+// unifiedDiff is the unified diff of changing the file at path from before to after.
+func unifiedDiff(path string, before, after []byte) string {
+	lines := diffLines(splitLines(before), splitLines(after))
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", path, path)
+	oldLine, newLine := 1, 1
+	for i := 0; i < len(lines); {
+		if lines[i].Op == ' ' {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		// NOTE: A hunk runs from the context before its first change to the context after its
+		// last, taking in any later change whose context would overlap.
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(lines) {
+			if lines[end].Op != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(lines) && lines[next].Op == ' ' {
+				next++
+			}
+			if next == len(lines) || next-end > 2*diffContext {
+				end += diffContext
+				if end > len(lines) {
+					end = len(lines)
+				}
+				break
+			}
+			end = next
+		}
+
+		oldStart, newStart := oldLine-(i-start), newLine-(i-start)
+		var oldCount, newCount int
+		for _, l := range lines[start:end] {
+			if l.Op != '+' {
+				oldCount++
+			}
+			if l.Op != '-' {
+				newCount++
+			}
+		}
+		// NOTE: An empty side starts at the line before the hunk, as diff -u writes it.
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, l := range lines[start:end] {
+			b.WriteByte(l.Op)
+			b.WriteString(l.Text)
+			if !strings.HasSuffix(l.Text, "\n") {
+				b.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		oldLine, newLine = oldStart+oldCount, newStart+newCount
+		if oldCount == 0 {
+			oldLine++
+		}
+		if newCount == 0 {
+			newLine++
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// splitLines splits src into its lines, each keeping its newline.
+func splitLines(src []byte) []string {
+	lines := strings.SplitAfter(string(src), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines is the shortest edit script from a to b, found with Myers' algorithm.
+func diffLines(a, b []string) []diffLine {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+	var d int
+search:
+	for d = 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	var reversed []diffLine
+	x, y := n, m
+	for ; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			reversed = append(reversed, diffLine{Op: ' ', Text: a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, diffLine{Op: '+', Text: b[y-1]})
+			y--
+		} else {
+			reversed = append(reversed, diffLine{Op: '-', Text: a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, diffLine{Op: ' ', Text: a[x-1]})
+		x--
+		y--
+	}
+
+	lines := make([]diffLine, len(reversed))
+	for i, l := range reversed {
+		lines[len(reversed)-1-i] = l
+	}
+	return lines
+}
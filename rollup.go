@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"html/template"
+	"path/filepath"
+	"strings"
+)
+
+type (
+	// RollupPage is everything the HTML rollup dashboard shows.
+	RollupPage struct {
+		Page
+		Rollup conversions.Rollup
+		// Matrices are the matrix of lossy conversions of each of Rollup.Packages, in the same order.
+		Matrices []RollupMatrix
+	}
+
+	// RollupMatrix is a package's lossy conversions as a grid, with a row for each primitive
+	// converted from and a column for each converted to.
+	RollupMatrix struct {
+		Dir  string
+		From []string
+		To   []string
+		// Counts are how many conversions there are from From[i] to To[j], by row and column.
+		Counts [][]int
+	}
+)
+
+var (
+	// rollupTemplates is the rollup dashboard, on top of htmlTemplates.
+	rollupTemplates = template.Must(template.Must(htmlTemplates.Clone()).Funcs(template.FuncMap{
+		"rank": rank,
+	}).Parse(`{{define "rollup"}}<h1>Lossy conversions by package</h1>
+<p>{{$.Rollup.Flagged}} conversions may lose information across {{len $.Rollup.Packages}} packages and {{$.Rollup.Lines}} lines, {{printf "%.2f" $.Rollup.Density}} for every thousand. {{$.Rollup.Proven}} more were proven safe, and {{$.Rollup.Off}} were turned off. Packages are ranked by how densely they convert lossily.</p>
+<table>
+<tr><th>#</th><th>package</th><th>lines</th><th>may lose information</th><th>per thousand lines</th><th>proven safe</th><th>turned off</th></tr>
+{{range $i, $p := $.Rollup.Packages}}<tr><td>{{rank $i}}</td><td class="pair">{{if $p.Pairs}}<a href="#package-{{$i}}">{{$p.Dir}}</a>{{else}}{{$p.Dir}}{{end}}</td><td>{{$p.Lines}}</td><td>{{$p.Flagged}}</td><td>{{printf "%.2f" $p.Density}}</td><td>{{$p.Proven}}</td><td>{{$p.Off}}</td></tr>
+{{end}}</table>
+{{range $i, $m := $.Matrices}}{{if $m.From}}
+<h2 id="package-{{$i}}">{{$m.Dir}}</h2>
+<table>
+<tr><th>from \ to</th>{{range $m.To}}<th>{{.}}</th>{{end}}</tr>
+{{range $r, $from := $m.From}}<tr><th>{{$from}}</th>{{range index $m.Counts $r}}<td>{{if .}}{{.}}{{end}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}{{end}}{{end}}
+`))
+)
+
+// rank is the 1-based rank of the package at index i of a Rollup's Packages.
+func rank(i int) int {
+	return i + 1
+}
+
+// logRollup logs every package of r, densest first, along with the pair of primitives it
+// converts lossily most often.
+func logRollup(r conversions.Rollup) {
+	logrus.Info("---------- lossy conversions by package ----------")
+	for _, pr := range r.Packages {
+		if pr.Flagged == 0 {
+			logrus.Infof("%s: none of its %d lines may lose information", pr.Dir, pr.Lines)
+			continue
+		}
+		top := pr.Pairs[0]
+		logrus.Warnf("%s: %d conversions may lose information in %d lines, %.2f for every thousand, most of them %s -> %s (%d×)", pr.Dir, pr.Flagged, pr.Lines, pr.Density, top.From, top.To, top.Count)
+	}
+	logrus.Infof("%d conversions may lose information across %d packages and %d lines, %.2f for every thousand", r.Flagged, len(r.Packages), r.Lines, r.Density)
+}
+
+// writeRollup writes r to rollupFile, as an HTML dashboard or as JSON, going by its extension.
+func writeRollup(rollupFile string, r conversions.Rollup) error {
+	switch strings.ToLower(filepath.Ext(rollupFile)) {
+	case ".json":
+		b, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "encoding rollup")
+		}
+		return conversions.WriteFileAtomic(rollupFile, append(b, '\n'), 0o644)
+	case ".html", ".htm":
+		var page RollupPage
+		page.Title = "Lossy conversions by package"
+		page.Rollup = r
+		for _, pr := range r.Packages {
+			page.Matrices = append(page.Matrices, rollupMatrix(pr))
+		}
+		return writePage(rollupTemplates, rollupFile, "rollup", page)
+	default:
+		return errors.Errorf("unknown rollup file extension %q, expected .html or .json", filepath.Ext(rollupFile))
+	}
+}
+
+// rollupMatrix lays the Pairs of pr out as a grid, with the primitives in the order
+// conversions.SortTypes puts them.
+func rollupMatrix(pr conversions.PackageRollup) RollupMatrix {
+	var rm RollupMatrix
+	rm.Dir = pr.Dir
+	for _, pc := range pr.Pairs {
+		rm.From = append(rm.From, pc.From)
+		rm.To = append(rm.To, pc.To)
+	}
+	rm.From = conversions.SortTypes(conversions.Dedupe(rm.From))
+	rm.To = conversions.SortTypes(conversions.Dedupe(rm.To))
+
+	rows := make(map[string]int, len(rm.From))
+	for i, from := range rm.From {
+		rows[from] = i
+	}
+	columns := make(map[string]int, len(rm.To))
+	for j, to := range rm.To {
+		columns[to] = j
+	}
+	rm.Counts = make([][]int, len(rm.From))
+	for i := range rm.Counts {
+		rm.Counts[i] = make([]int, len(rm.To))
+	}
+	for _, pc := range pr.Pairs {
+		rm.Counts[rows[pc.From]][columns[pc.To]] = pc.Count
+	}
+	return rm
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"github.com/Insulince/go-conversions/client"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultReleasesURL lists every go release as JSON.
+	DefaultReleasesURL = "https://go.dev/dl/?mode=json&include=all"
+	// DefaultReleasesDir is where serve stores the analysis of each go release when -store is not set.
+	DefaultReleasesDir = "./releases"
+	// minToolchain is the oldest go release GOTOOLCHAIN can switch to, older ones can't be analyzed.
+	minToolchain = "go1.21.0"
+)
+
+type (
+	// GoRelease is a go release as listed by go.dev/dl.
+	GoRelease struct {
+		Version string `json:"version"`
+		Stable  bool   `json:"stable"`
+	}
+
+	// StoredRelease is the analysis of a go release kept by serve.
+	StoredRelease struct {
+		Version    string             `json:"version"`
+		AnalyzedAt time.Time          `json:"analyzedAt"`
+		Matrix     conversions.Matrix `json:"matrix"`
+	}
+
+	// ReleaseDiff is how the conversions which compile changed from one go release to another.
+	ReleaseDiff struct {
+		From   string   `json:"from"`
+		To     string   `json:"to"`
+		Gained []string `json:"gained"`
+		Lost   []string `json:"lost"`
+	}
+
+	// releaseServer analyzes each new go release as it comes out and serves the results.
+	releaseServer struct {
+		opts        conversions.Options
+		store       conversions.Store
+		releasesURL string
+	}
+)
+
+var (
+	// releaseFileRegexp matches the file a release is stored in, and so the versions which can be requested.
+	releaseFileRegexp = regexp.MustCompile(`^go\d+\.\d+(\.\d+)?$`)
+)
+
+// Serve runs an HTTP server which watches for new go releases, analyzes each with that release's
+// toolchain as soon as it is out, and serves every stored analysis along with how they differ:
+//
+//	GET /releases            every analyzed release, oldest first
+//	GET /releases/<version>  the Matrix for one release
+//	GET /diff?from=&to=      what changed between two releases, by default the latest two
+//	GET /openapi.json        the OpenAPI document describing all of the above, see client.OpenAPI
+//
+// Each release's toolchain is fetched by the go command through GOTOOLCHAIN, so only the build
+// engine is supported. With -notify-webhook set, every new release which changes the matrix is
+// posted there.
+func Serve(ctx context.Context, args []string) error {
+	var addr, storeLocation, releasesURL string
+	var interval time.Duration
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.StringVar(&addr, "addr", ":8080", "address to listen on")
+	fs.StringVar(&storeLocation, "store", DefaultReleasesDir, "where to store the analysis of each release, a directory or a location such as redis://cache:6379/0 for a store registered with conversions.RegisterStore")
+	fs.StringVar(&releasesURL, "releases-url", DefaultReleasesURL, "URL listing every go release as JSON, in the format of go.dev/dl")
+	fs.DurationVar(&interval, "interval", time.Hour, "how often to check for a new release")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+	if opts.WithDefaults().Engine != conversions.EngineBuild {
+		return errors.Errorf("serve needs -engine %s to analyze with each release's toolchain", conversions.EngineBuild)
+	}
+	opts.StateFile = ""
+
+	store, err := conversions.OpenStore(storeLocation)
+	if err != nil {
+		return errors.Wrap(err, "opening store")
+	}
+
+	var rs releaseServer
+	rs.opts = opts
+	rs.store = store
+	rs.releasesURL = releasesURL
+
+	var server http.Server
+	server.Addr = addr
+	server.Handler = rs.handler()
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.ListenAndServe() }()
+	logrus.Infof("serving releases analyzed into %s on %s", storeLocation, addr)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := rs.poll(ctx)
+		if err != nil && ctx.Err() == nil {
+			// NOTE: A failed poll is retried on the next tick rather than taking the server down.
+			logrus.Errorf("checking for a new release: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case err := <-serveErr:
+			return errors.Wrap(err, "serving")
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = server.Shutdown(shutdownCtx)
+			return nil
+		}
+	}
+}
+
+// poll analyzes the latest stable go release, unless it already has been.
+func (rs releaseServer) poll(ctx context.Context) error {
+	latest, err := latestRelease(ctx, rs.releasesURL)
+	if err != nil {
+		return err
+	}
+	stored, err := rs.versions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, version := range stored {
+		if version == latest {
+			return nil
+		}
+	}
+	if goReleaseLess(latest, minToolchain) {
+		return errors.Errorf("%s is older than %s, the oldest release GOTOOLCHAIN can switch to", latest, minToolchain)
+	}
+
+	logrus.Infof("analyzing %s", latest)
+	opts := rs.opts
+	opts.Toolchain = latest
+	opts.OutputDir = filepath.Join(OutputDir, "serve")
+	m, err := conversions.Analyze(ctx, opts)
+	if err != nil {
+		return errors.Wrapf(err, "analyzing %s", latest)
+	}
+
+	var release StoredRelease
+	release.Version = latest
+	release.AnalyzedAt = time.Now().UTC()
+	release.Matrix = m
+	err = rs.save(ctx, release)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("stored the analysis of %s", latest)
+
+	if *notifyWebhook == "" || len(stored) == 0 {
+		return nil
+	}
+	previous, err := rs.load(ctx, stored[len(stored)-1])
+	if err != nil {
+		return err
+	}
+	p, err := ProvenanceFor(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "determining provenance")
+	}
+	p.AnalyzedWith = latest
+	n, ok := NotificationFor(m, &previous.Matrix, -1, p)
+	if !ok {
+		return nil
+	}
+	return Notify(ctx, *notifyWebhook, n)
+}
+
+// handler routes the requests Serve answers.
+func (rs releaseServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/releases", func(w http.ResponseWriter, r *http.Request) {
+		versions, err := rs.versions(r.Context())
+		if err != nil {
+			httpError(w, err, http.StatusInternalServerError)
+			return
+		}
+		// NOTE: Always a list, even with no releases, as the OpenAPI document says.
+		writeJSON(w, append([]string{}, versions...))
+	})
+	mux.HandleFunc("/releases/", func(w http.ResponseWriter, r *http.Request) {
+		release, err := rs.load(r.Context(), strings.TrimPrefix(r.URL.Path, "/releases/"))
+		if err != nil {
+			httpError(w, err, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, release)
+	})
+	mux.HandleFunc("/diff", func(w http.ResponseWriter, r *http.Request) {
+		diff, status, err := rs.diff(r.Context(), r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+		if err != nil {
+			httpError(w, err, status)
+			return
+		}
+		writeJSON(w, diff)
+	})
+	mux.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(client.OpenAPI)
+	})
+	return mux
+}
+
+// diff compares the releases from and to, defaulting to the latest two stored, returning the
+// HTTP status to fail with along with any error.
+func (rs releaseServer) diff(ctx context.Context, from, to string) (ReleaseDiff, int, error) {
+	if from == "" || to == "" {
+		versions, err := rs.versions(ctx)
+		if err != nil {
+			return ReleaseDiff{}, http.StatusInternalServerError, err
+		}
+		if len(versions) < 2 {
+			return ReleaseDiff{}, http.StatusNotFound, errors.Errorf("%d releases have been analyzed, at least 2 are needed to compare", len(versions))
+		}
+		if to == "" {
+			to = versions[len(versions)-1]
+		}
+		if from == "" {
+			from = versions[len(versions)-2]
+		}
+	}
+
+	before, err := rs.load(ctx, from)
+	if err != nil {
+		return ReleaseDiff{}, http.StatusNotFound, err
+	}
+	after, err := rs.load(ctx, to)
+	if err != nil {
+		return ReleaseDiff{}, http.StatusNotFound, err
+	}
+
+	var d ReleaseDiff
+	d.From = from
+	d.To = to
+	gained, lost := conversions.Compare(before.Matrix, after.Matrix)
+	d.Gained = append([]string{}, pairNames(gained)...)
+	d.Lost = append([]string{}, pairNames(lost)...)
+	return d, http.StatusOK, nil
+}
+
+// versions lists every stored release, oldest first.
+func (rs releaseServer) versions(ctx context.Context) ([]string, error) {
+	keys, err := rs.store.Keys(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing stored releases")
+	}
+
+	var versions []string
+	for _, key := range keys {
+		version := strings.TrimSuffix(key, ".json")
+		if releaseFileRegexp.MatchString(version) {
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return goReleaseLess(versions[i], versions[j]) })
+	return versions, nil
+}
+
+// load reads the stored analysis of the release version.
+func (rs releaseServer) load(ctx context.Context, version string) (StoredRelease, error) {
+	if !releaseFileRegexp.MatchString(version) {
+		return StoredRelease{}, errors.Errorf("%q is not a go release", version)
+	}
+	b, ok, err := rs.store.Get(ctx, version+".json")
+	if err != nil {
+		return StoredRelease{}, errors.Wrapf(err, "reading %s", version)
+	}
+	if !ok {
+		return StoredRelease{}, errors.Errorf("%s has not been analyzed", version)
+	}
+
+	var release StoredRelease
+	err = json.Unmarshal(b, &release)
+	if err != nil {
+		return StoredRelease{}, errors.Wrapf(err, "decoding %s", version)
+	}
+	return release, nil
+}
+
+// store saves the analysis of a release.
+func (rs releaseServer) save(ctx context.Context, release StoredRelease) error {
+	b, err := json.MarshalIndent(release, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encoding release")
+	}
+	err = rs.store.Put(ctx, release.Version+".json", b)
+	if err != nil {
+		return errors.Wrapf(err, "storing %s", release.Version)
+	}
+	return nil
+}
+
+// latestRelease fetches the newest stable go release listed at releasesURL.
+func latestRelease(ctx context.Context, releasesURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, releasesURL, nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "creating request to %q", releasesURL)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "listing releases")
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("listing releases: %s", resp.Status)
+	}
+
+	var releases []GoRelease
+	err = json.NewDecoder(resp.Body).Decode(&releases)
+	if err != nil {
+		return "", errors.Wrap(err, "decoding releases")
+	}
+
+	var latest string
+	for _, release := range releases {
+		if !release.Stable || !releaseFileRegexp.MatchString(release.Version) {
+			continue
+		}
+		if latest == "" || goReleaseLess(latest, release.Version) {
+			latest = release.Version
+		}
+	}
+	if latest == "" {
+		return "", errors.Errorf("no stable releases are listed at %q", releasesURL)
+	}
+	return latest, nil
+}
+
+// goReleaseLess reports whether the go release a, such as go1.23.4, came out before b.
+func goReleaseLess(a, b string) bool {
+	as := strings.Split(strings.TrimPrefix(a, "go"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "go"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var an, bn int
+		if i < len(as) {
+			an, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			bn, _ = strconv.Atoi(bs[i])
+		}
+		if an != bn {
+			return an < bn
+		}
+	}
+	return false
+}
+
+// writeJSON writes v as the JSON response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v)
+}
+
+// httpError responds with err as the JSON error message, and status.
+func httpError(w http.ResponseWriter, err error, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"os"
+	"strings"
+)
+
+// Shifts charts which primitives shift, and which they can be shifted by, under each of the
+// -langs language versions, go1.12 and go1.13 by default, either side of signed shift counts
+// being allowed, along with which untyped constants shifted by a variable assign to each
+// primitive. It logs the types valid as operands and counts under each language version, then
+// every shift which depends on it. With -format json the whole chart is written to stdout instead.
+func Shifts(_ context.Context) error {
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	switch *reportFormat {
+	case "", "json":
+	default:
+		return errors.Errorf("shift charts can only be logged or rendered as json, not %q", *reportFormat)
+	}
+
+	sc, err := conversions.ChartShifts(opts, splitList(*langs))
+	if err != nil {
+		return errors.Wrap(err, "charting shifts")
+	}
+
+	if *reportFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(sc)
+		if err != nil {
+			return errors.Wrap(err, "encoding shifts")
+		}
+		return nil
+	}
+
+	for _, lang := range sc.Langs {
+		logrus.Infof("under %s, %s shift, by %s", lang, strings.Join(sc.Shiftable(lang), ", "), strings.Join(sc.Counts(lang), ", "))
+	}
+
+	// NOTE: Shifts are grouped by their count, since it's what the language version changed.
+	var differ int
+	var counts []string
+	byCount := make(map[string][]conversions.ShiftResult)
+	for _, sr := range sc.Operands {
+		if !sr.Differs() {
+			continue
+		}
+		differ++
+		if _, ok := byCount[sr.Count]; !ok {
+			counts = append(counts, sr.Count)
+		}
+		byCount[sr.Count] = append(byCount[sr.Count], sr)
+	}
+	for _, count := range counts {
+		srs := byCount[count]
+		operands := make([]string, 0, len(srs))
+		for _, sr := range srs {
+			operands = append(operands, sr.Operand)
+		}
+		logrus.Warnf("shifting %s by %s depends on the language version: %s", strings.Join(operands, ", "), count, shiftVerdicts(sc.Langs, srs[0].Valid, srs[0].Reasons))
+	}
+	for _, us := range sc.Untyped {
+		if us.Differs() {
+			differ++
+			logrus.Warnf("var _ %s = %s << s depends on the language version: %s", us.Type, us.Constant, shiftVerdicts(sc.Langs, us.Valid, us.Reasons))
+			continue
+		}
+		lang := sc.Langs[len(sc.Langs)-1]
+		if !us.Valid[lang] {
+			logrus.Infof("var _ %s = %s << s never compiles: %s", us.Type, us.Constant, us.Reasons[lang])
+		}
+	}
+	logrus.Infof("%d of %d shifts behave differently across %s", differ, len(sc.Operands)+len(sc.Untyped), strings.Join(sc.Langs, ", "))
+
+	return nil
+}
+
+// shiftVerdicts describes whether a shift compiles under each of langs, with the reason it
+// doesn't where it doesn't.
+func shiftVerdicts(langs []string, valid map[string]bool, reasons map[string]string) string {
+	verdicts := make([]string, 0, len(langs))
+	for _, lang := range langs {
+		verdict := "✅ compiles"
+		if !valid[lang] {
+			verdict = fmt.Sprintf("❌ %s", reasons[lang])
+		}
+		verdicts = append(verdicts, fmt.Sprintf("%s %s", lang, verdict))
+	}
+	return strings.Join(verdicts, ", ")
+}
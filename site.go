@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/Insulince/go-conversions/helpers"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultSiteDir is where the site command writes the site when -out is not set.
+	DefaultSiteDir = "./public"
+	// historyFile is the file, relative to the root of the site, every build of the site is recorded in.
+	historyFile = "history.json"
+)
+
+type (
+	// Recipe is a single entry in the cookbook, showing how to do a conversion the compiler either
+	// rejects or performs lossily.
+	Recipe struct {
+		// ID is the anchor the recipe can be linked to by.
+		ID          string
+		Title       string
+		Description string
+		Code        string
+	}
+
+	// History records every build of the site, so readers can see how the matrix changed between
+	// go releases. It is kept in the site itself, so it grows as long as the site is redeployed
+	// from its previous contents.
+	History struct {
+		Entries []HistoryEntry `json:"entries"`
+		// Latest are the results of the most recent build, which the next build is compared against.
+		Latest []conversions.Result `json:"latest"`
+	}
+
+	// HistoryEntry is a single build of the site.
+	HistoryEntry struct {
+		Generated  string     `json:"generated"`
+		Provenance Provenance `json:"provenance"`
+		// Convertible is how many of the Total pairs could be converted.
+		Convertible int `json:"convertible"`
+		Total       int `json:"total"`
+		// Gained and Lost are the pairs which became convertible, or stopped being convertible,
+		// since the previous entry.
+		Gained []string `json:"gained,omitempty"`
+		Lost   []string `json:"lost,omitempty"`
+	}
+)
+
+var (
+	// siteTemplates are the pages only the full site has, on top of htmlTemplates.
+	siteTemplates = template.Must(template.Must(htmlTemplates.Clone()).Parse(`{{define "cookbook"}}<h1>Cookbook</h1>
+<p>How to do the conversions the compiler rejects, or performs without telling you it changed the value. The checked functions come from the package <code>go-conversions helpers</code> generates, imported here as <code>conv</code>.</p>
+<ul>
+{{range $.Recipes}}<li><a href="#{{.ID}}">{{.Title}}</a></li>
+{{end}}</ul>
+{{range $.Recipes}}<h2 id="{{.ID}}">{{.Title}}</h2>
+<p>{{.Description}}</p>
+<pre>{{.Code}}</pre>
+{{end}}
+<h2 id="signed-unsigned-boundaries">Signed and unsigned boundaries</h2>
+<p>Exactly which values survive converting between signed and unsigned integers unchanged, which come out with the opposite sign, and which are too large and wrap around, keeping only their low bits. Pairs involving <code>int</code>, <code>uint</code>, or <code>uintptr</code> are shown for both 32 and 64-bit platforms.</p>
+<table>
+<tr><th>from</th><th>to</th><th>platform</th><th>values</th><th>outcome</th></tr>
+{{range $.Boundaries}}{{$b := .}}{{range $i, $r := .Ranges}}<tr>{{if eq $i 0}}<td rowspan="{{len $b.Ranges}}">{{$b.From}}</td><td rowspan="{{len $b.Ranges}}">{{$b.To}}</td><td rowspan="{{len $b.Ranges}}">{{if $b.WordBits}}{{$b.WordBits}}-bit{{else}}any{{end}}</td>{{end}}<td class="pair">{{$r.Interval}}</td><td>{{if eq $r.Outcome "unchanged"}}<span class="yes">{{$.Theme.Yes}}</span>{{else}}<span class="lossy">{{$.Theme.Lossy}}</span>{{end}} {{$r.Outcome}}</td></tr>
+{{end}}{{end}}</table>
+{{end}}
+
+{{define "history"}}<h1>History</h1>
+<p>Every build of this site, newest first, along with the conversions that changed since the build before it.</p>
+<table>
+<tr><th>generated</th><th>go</th><th>revision</th><th>convertible</th><th>changes</th></tr>
+{{range $.Entries}}<tr><td>{{.Generated}}</td><td>{{.Provenance.AnalyzedWith}}</td><td>{{.Provenance.Version}}{{if .Provenance.Revision}} {{.Provenance.Revision}}{{end}}</td><td>{{.Convertible}} of {{.Total}}</td><td class="pair">{{range .Gained}}<span class="yes">{{$.Theme.Yes}}</span> {{.}}<br>{{end}}{{range .Lost}}<span class="no">{{$.Theme.No}}</span> {{.}}<br>{{end}}{{if not (or .Gained .Lost)}}-{{end}}</td></tr>
+{{end}}</table>
+{{end}}
+`))
+)
+
+// Site writes the full multi-page HTML reference, i.e. the matrix, per-type pages, cookbook,
+// and version history, to the directory given by -out, ready to be hosted as is by GitHub Pages.
+func Site(ctx context.Context, args []string) error {
+	var outputDir string
+	fs := flag.NewFlagSet("site", flag.ContinueOnError)
+	fs.StringVar(&outputDir, "out", DefaultSiteDir, "directory to write the site to")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	// NOTE: Looked up before analyzing, so a typo doesn't cost a whole analysis.
+	_, err = conversions.LookupCatalog(*locale)
+	if err != nil {
+		return errors.Wrap(err, "looking up -locale")
+	}
+	t, err := reportTheme()
+	if err != nil {
+		return errors.Wrap(err, "looking up -theme")
+	}
+
+	m, err := conversions.Analyze(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+	m.Locale = *locale
+	m.Accessible = *accessible
+	m.Theme = t
+
+	p, err := ProvenanceFor(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "determining provenance")
+	}
+
+	err = writeHTML(ctx, outputDir, m, p, true)
+	if err != nil {
+		return errors.Wrap(err, "writing HTML")
+	}
+
+	type Cookbook struct {
+		Page
+		Recipes    []Recipe
+		Boundaries []conversions.Boundary
+	}
+	var cookbook Cookbook
+	cookbook.Title = "Cookbook - Go primitive conversions"
+	cookbook.Provenance = p
+	cookbook.Site = true
+	cookbook.Theme = m.Theme.WithDefaults()
+	cookbook.Recipes = Recipes(m)
+	cookbook.Boundaries = conversions.Boundaries(m.Types)
+	err = writePage(siteTemplates, filepath.Join(outputDir, "cookbook.html"), "cookbook", cookbook)
+	if err != nil {
+		return errors.Wrap(err, "writing cookbook")
+	}
+
+	historyPath := filepath.Join(outputDir, historyFile)
+	history, err := LoadHistory(historyPath)
+	if err != nil {
+		return errors.Wrap(err, "loading history")
+	}
+	history.Record(m, p, time.Now())
+	err = history.Save(historyPath)
+	if err != nil {
+		return errors.Wrap(err, "saving history")
+	}
+
+	type HistoryPage struct {
+		Page
+		Entries []HistoryEntry
+	}
+	var historyPage HistoryPage
+	historyPage.Title = "History - Go primitive conversions"
+	historyPage.Provenance = p
+	historyPage.Site = true
+	historyPage.Theme = m.Theme.WithDefaults()
+	for i := len(history.Entries) - 1; i >= 0; i-- {
+		historyPage.Entries = append(historyPage.Entries, history.Entries[i])
+	}
+	err = writePage(siteTemplates, filepath.Join(outputDir, "history.html"), "history", historyPage)
+	if err != nil {
+		return errors.Wrap(err, "writing history")
+	}
+
+	// NOTE: Stops GitHub Pages from running the site through Jekyll, which it doesn't need.
+	noJekyll := filepath.Join(outputDir, ".nojekyll")
+	err = conversions.WriteFileAtomic(noJekyll, nil, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "writing %q", noJekyll)
+	}
+
+	logrus.Infof("wrote site for %d types to %s", len(m.Types), outputDir)
+
+	return nil
+}
+
+// Recipes lists a Recipe for every helper which checks its conversion, followed by recipes for
+// converting every numeric and bool type in m to and from strings, which the compiler either
+// rejects or, for integers, turns into a rune.
+func Recipes(m conversions.Matrix) []Recipe {
+	var recipes []Recipe
+	for _, h := range helpers.Helpers(m) {
+		if h.Check == helpers.CheckNone {
+			continue
+		}
+		var r Recipe
+		r.ID = h.Name
+		r.Title = fmt.Sprintf("Convert %s to %s without changing it", withArticle(h.From.Name), withArticle(h.To.Name))
+		r.Description = fmt.Sprintf("%s(v) compiles, but silently changes values %s can't represent. Check the conversion instead:", h.To.Name, withArticle(h.To.Name))
+		r.Code = fmt.Sprintf("r, err := conv.%s(v)\nif err != nil {\n\t// v can't be represented as a %s, err is a *conv.RangeError.\n}", h.Name, h.To.Name)
+		recipes = append(recipes, r)
+	}
+
+	for _, typ := range m.Types {
+		info, ok := conversions.Lookup(typ)
+		if !ok || info.AliasOf != "" {
+			continue
+		}
+		format, parse, ok := stringFuncs(info)
+		if !ok {
+			continue
+		}
+
+		var r Recipe
+		r.ID = "format-" + typ
+		r.Title = fmt.Sprintf("Format %s as a string", withArticle(typ))
+		r.Description = fmt.Sprintf("Use strconv rather than string(v), which %s.", stringConversion(m, typ))
+		r.Code = "s := " + format
+		recipes = append(recipes, r)
+
+		r.ID = "parse-" + typ
+		r.Title = fmt.Sprintf("Parse %s from a string", withArticle(typ))
+		r.Description = fmt.Sprintf("%s(s) never compiles for a string s, parse it with strconv, which reports malformed and out of range values.", typ)
+		r.Code = parse
+		recipes = append(recipes, r)
+	}
+
+	return recipes
+}
+
+// stringConversion describes what converting a typ value to a string with string(v) does according to m.
+func stringConversion(m conversions.Matrix, typ string) string {
+	if m.Convertible(typ, "string") {
+		return "compiles but interprets v as a unicode code point rather than formatting its digits"
+	}
+	return "does not compile"
+}
+
+// stringFuncs returns the code formatting a value v of the type i as a string, and the code
+// parsing a string s into a value v of i, reporting false if i has no such strconv functions.
+func stringFuncs(i conversions.Info) (string, string, bool) {
+	size := strconv.Itoa(i.MaxBits())
+	if i.Bits == 0 && i.Name != "uintptr" {
+		// NOTE: strconv.IntSize is the size of int and uint on this platform.
+		size = "strconv.IntSize"
+	}
+	switch i.Kind {
+	case conversions.KindBool:
+		return "strconv.FormatBool(v)", "v, err := strconv.ParseBool(s)", true
+	case conversions.KindInt:
+		return "strconv.FormatInt(int64(v), 10)", parseCode(i, "strconv.ParseInt(s, 10, "+size+")"), true
+	case conversions.KindUint:
+		return "strconv.FormatUint(uint64(v), 10)", parseCode(i, "strconv.ParseUint(s, 10, "+size+")"), true
+	case conversions.KindFloat:
+		return "strconv.FormatFloat(float64(v), 'g', -1, " + size + ")", parseCode(i, "strconv.ParseFloat(s, "+size+")"), true
+	case conversions.KindComplex:
+		return "strconv.FormatComplex(complex128(v), 'g', -1, " + size + ")", parseCode(i, "strconv.ParseComplex(s, "+size+")"), true
+	default:
+		return "", "", false
+	}
+}
+
+// parseCode returns the code parsing a string s into a value v of the type i with the strconv call.
+func parseCode(i conversions.Info, call string) string {
+	return fmt.Sprintf("p, err := %s\nif err != nil {\n\t// s is not a valid %s.\n}\nv := %s(p)", call, i.Name, i.Name)
+}
+
+// withArticle prefixes the type name with "a" or "an" as it is read aloud, e.g. "an int" but "a uint".
+func withArticle(name string) string {
+	if strings.ContainsAny(name[:1], "aeio") {
+		return "an " + name
+	}
+	return "a " + name
+}
+
+// LoadHistory reads the History in historyPath, returning an empty History if there is none yet.
+func LoadHistory(historyPath string) (*History, error) {
+	b, err := os.ReadFile(historyPath)
+	if os.IsNotExist(err) {
+		return &History{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading history file %q", historyPath)
+	}
+
+	var h History
+	err = json.Unmarshal(b, &h)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decoding history file %q", historyPath)
+	}
+
+	return &h, nil
+}
+
+// Record adds an entry for m, produced as described by p, to h. Nothing is recorded if
+// neither m nor p have changed since the last entry.
+func (h *History) Record(m conversions.Matrix, p Provenance, now time.Time) {
+	var previous conversions.Matrix
+	previous.Results = h.Latest
+
+	var e HistoryEntry
+	e.Generated = now.Format(time.RFC3339)
+	e.Provenance = p
+	e.Total = len(m.Results)
+	for _, r := range m.Results {
+		if r.Convertible {
+			e.Convertible++
+		}
+	}
+	gained, lost := conversions.Compare(previous, m)
+	e.Gained = pairNames(gained)
+	e.Lost = pairNames(lost)
+
+	if n := len(h.Entries); n > 0 {
+		last := h.Entries[n-1]
+		if last.Provenance == p && last.Total == e.Total && len(e.Gained) == 0 && len(e.Lost) == 0 {
+			return
+		}
+	}
+
+	h.Entries = append(h.Entries, e)
+	h.Latest = m.Results
+}
+
+// pairNames names the pair of every result, e.g. int64 -> int32.
+func pairNames(results []conversions.Result) []string {
+	var names []string
+	for _, r := range results {
+		names = append(names, r.From+" "+conversions.PairSeparator+" "+r.To)
+	}
+	return names
+}
+
+// Save writes h to historyPath.
+func (h *History) Save(historyPath string) error {
+	b, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encoding history")
+	}
+
+	err = conversions.WriteFileAtomic(historyPath, b, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "writing history file %q", historyPath)
+	}
+
+	return nil
+}
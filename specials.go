@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"strings"
+)
+
+// Specials converts the special values of every float type, NaN, the infinities, negative zero,
+// and subnormals, to every other float and integer type and reports what they turn into on each
+// platform, since Go leaves most of these conversions implementation defined.
+func Specials(ctx context.Context, args []string) error {
+	var platforms string
+	fs := flag.NewFlagSet("specials", flag.ContinueOnError)
+	fs.StringVar(&platforms, "platforms", "", "comma separated GOOS/GOARCH platforms to run the probes on, which this machine must be able to run, e.g. linux/amd64,linux/386 (default this platform)")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	results, err := conversions.AnalyzeSpecialValues(ctx, opts, splitList(platforms))
+	if err != nil {
+		return errors.Wrap(err, "analyzing special values")
+	}
+
+	p, err := ProvenanceFor(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "determining provenance")
+	}
+	for _, line := range p.Lines() {
+		logrus.Info(line)
+	}
+
+	type key struct {
+		from, value, to string
+	}
+	var order []key
+	outcomes := make(map[key][]string)
+	for _, result := range results {
+		k := key{from: result.From, value: result.Value, to: result.To}
+		if _, ok := outcomes[k]; !ok {
+			order = append(order, k)
+		}
+		outcome := result.Result
+		if result.Panic != "" {
+			outcome = "panics: " + result.Panic
+		}
+		outcomes[k] = append(outcomes[k], fmt.Sprintf("%s=%s", result.Platform, outcome))
+	}
+
+	var from string
+	var differ int
+	for _, k := range order {
+		if k.from != from {
+			from = k.from
+			logrus.Infof("---------- converting %s special values ----------\n", from)
+		}
+
+		var results []string
+		seen := make(map[string]bool)
+		for _, outcome := range outcomes[k] {
+			_, result, _ := strings.Cut(outcome, "=")
+			if !seen[result] {
+				seen[result] = true
+				results = append(results, result)
+			}
+		}
+		if len(results) == 1 {
+			logrus.Infof("%10s %-18s -> %-10s %s", k.from, k.value, k.to, results[0])
+			continue
+		}
+		differ++
+		logrus.Infof("%10s %-18s -> %-10s ⚠️ differs by platform: %s", k.from, k.value, k.to, strings.Join(outcomes[k], ", "))
+	}
+
+	logrus.Infof("%d conversions of special values differ by platform", differ)
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// Stdin reads a go snippet from stdin and writes it back to stdout with every conversion in it
+// annotated inline as legal, lossy, or illegal, for quick checks and editor integrations.
+func Stdin(_ context.Context, args []string) error {
+	var asJSON bool
+	fs := flag.NewFlagSet("stdin", flag.ContinueOnError)
+	fs.BoolVar(&asJSON, "json", false, "write the conversions as JSON rather than annotating the snippet")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	src, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return errors.Wrap(err, "reading stdin")
+	}
+
+	cs, err := conversions.Snippet(src)
+	if err != nil {
+		return errors.Wrap(err, "analyzing snippet")
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(cs)
+		if err != nil {
+			return errors.Wrap(err, "encoding conversions")
+		}
+		return nil
+	}
+
+	annotations := make(map[int][]string)
+	for _, c := range cs {
+		annotations[c.Pos.Line] = append(annotations[c.Pos.Line], annotation(c))
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	for i, line := range strings.Split(strings.TrimSuffix(string(src), "\n"), "\n") {
+		_, _ = w.WriteString(line)
+		if as, ok := annotations[i+1]; ok {
+			_, _ = fmt.Fprintf(w, " // %s", strings.Join(as, "; "))
+		}
+		_ = w.WriteByte('\n')
+	}
+	err = w.Flush()
+	if err != nil {
+		return errors.Wrap(err, "writing annotated snippet")
+	}
+
+	return nil
+}
+
+// annotation describes c in the form it is written after the line c is on.
+func annotation(c conversions.Conversion) string {
+	verdict := "✅"
+	switch {
+	case !c.Legal:
+		verdict = "❌ illegal"
+	case c.Lossy:
+		verdict = "⚠️ lossy"
+	}
+	return fmt.Sprintf("%s %s -> %s %s", c.Expr, c.From, c.To, verdict)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"io"
+	"os"
+	"strings"
+)
+
+// Strings reports which primitives, and which of each other, every string-backed type in a user
+// package, e.g. `type Color string`, can be converted to and from, and what fmt prints for a
+// value of it with each of conversions.FmtVerbs, warning about those which don't print what
+// they're for, e.g. %d, which doesn't format strings. With -format markdown the same is written
+// to stdout as an advisory table instead, and with -format json everything is.
+func Strings(_ context.Context, args []string) error {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	switch *reportFormat {
+	case "", "json", "markdown":
+	default:
+		return errors.Errorf("string type reports can only be logged or rendered as json or markdown, not %q", *reportFormat)
+	}
+
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	sts, err := conversions.StringTypes(dir, opts.Types)
+	if err != nil {
+		return errors.Wrapf(err, "finding string types in %q", dir)
+	}
+	if len(sts) == 0 {
+		logrus.Infof("no string types found in %s", dir)
+		return nil
+	}
+
+	switch *reportFormat {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(sts)
+		if err != nil {
+			return errors.Wrap(err, "encoding string types")
+		}
+		return nil
+	case "markdown":
+		err = RenderMarkdownStringTypes(sts, os.Stdout)
+		if err != nil {
+			return errors.Wrap(err, "writing table")
+		}
+		return nil
+	}
+
+	logrus.Infof("formatting a value of %q, whose String method, if it has one, returns %q", conversions.VerbSample, conversions.VerbStringer)
+	var unexpected int
+	for _, st := range sts {
+		logrus.Infof("---------- %s (string, %s) ----------\n", st.Name, stringMethod(st))
+		logrus.Infof("converts from: %s", strings.Join(st.ConvertsFrom, ", "))
+		logrus.Infof("converts to:   %s", strings.Join(st.ConvertsTo, ", "))
+		for _, va := range st.Verbs {
+			if va.Expected {
+				logrus.Infof("✅ %s prints %s", va.Verb, va.Output)
+				continue
+			}
+			unexpected++
+			logrus.Warnf("❌ %s prints %s: %s", va.Verb, va.Output, va.Advice)
+		}
+	}
+	logrus.Infof("%d of %d verbs don't print what they're for across %d string types", unexpected, len(sts)*len(conversions.FmtVerbs), len(sts))
+
+	return nil
+}
+
+// stringMethod describes the String method st has, if any.
+func stringMethod(st conversions.StringType) string {
+	switch {
+	case st.Stringer:
+		return "a String method"
+	case st.PointerStringer:
+		return "a String method on *" + st.Name + " only"
+	}
+	return "no String method"
+}
+
+// RenderMarkdownStringTypes writes sts to w as markdown: a table of what fmt prints for each of
+// them with every one of conversions.FmtVerbs, a row per type, followed by what to do about those
+// which don't print what they're for, and a table of what each of them converts from and to.
+func RenderMarkdownStringTypes(sts []conversions.StringType, w io.Writer) error {
+	var b strings.Builder
+	header := []string{"type", "String"}
+	for _, verb := range conversions.FmtVerbs {
+		header = append(header, "`"+verb+"`")
+	}
+	_, _ = fmt.Fprintf(&b, "| %s |\n%s|\n", strings.Join(header, " | "), strings.Repeat("|---", len(header)))
+
+	var advice []string
+	for _, st := range sts {
+		method := "no"
+		switch {
+		case st.Stringer:
+			method = "yes"
+		case st.PointerStringer:
+			method = "pointer only"
+		}
+		cells := []string{st.Name, method}
+		for _, va := range st.Verbs {
+			mark := "✅"
+			if !va.Expected {
+				mark = "❌"
+				advice = append(advice, fmt.Sprintf("- `%s` with `%s`: %s", st.Name, va.Verb, va.Advice))
+			}
+			// NOTE: Pipes would otherwise end the cell early.
+			cells = append(cells, fmt.Sprintf("%s `%s`", mark, strings.ReplaceAll(va.Output, "|", `\|`)))
+		}
+		_, _ = fmt.Fprintf(&b, "| %s |\n", strings.Join(cells, " | "))
+	}
+	_, _ = fmt.Fprintf(&b, "\nEach type formats a value of %q, whose String method, if it has one, returns %q.\n", conversions.VerbSample, conversions.VerbStringer)
+	if len(advice) > 0 {
+		_, _ = fmt.Fprintf(&b, "\n%s\n", strings.Join(advice, "\n"))
+	}
+
+	b.WriteString("\n| type | converts from | converts to |\n|---|---|---|\n")
+	for _, st := range sts {
+		_, _ = fmt.Fprintf(&b, "| %s | %s | %s |\n", st.Name, codeList(st.ConvertsFrom), codeList(st.ConvertsTo))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// codeList formats every one of types as code, separated by commas.
+func codeList(types []string) string {
+	var code []string
+	for _, t := range types {
+		code = append(code, "`"+t+"`")
+	}
+	return strings.Join(code, ", ")
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/Insulince/go-conversions/helpers"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"os"
+)
+
+// Times reports the conversions between time.Duration, time.Time, and the integers and floats
+// they're stored as which compile but get the unit wrong or overflow, along with the helper the
+// helpers command generates to do each one with an explicit unit.
+func Times(_ context.Context, args []string) error {
+	var asJSON bool
+	fs := flag.NewFlagSet("times", flag.ContinueOnError)
+	fs.BoolVar(&asJSON, "json", false, "write the advisories as JSON rather than logging them")
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	advisories := conversions.TimeAdvisories()
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(advisories)
+		if err != nil {
+			return errors.Wrap(err, "encoding advisories")
+		}
+		return nil
+	}
+
+	for _, a := range advisories {
+		logrus.Warnf("⚠️ %s -> %s: %s", a.From, a.To, a.Risk)
+		logrus.Infof("   use %s.%s, generated by the helpers command, to state the unit", helpers.DefaultPackage, a.Helper)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"sort"
+)
+
+const (
+	// usagesPerPair is how many of the conversions found under -usages a type page shows for each pair.
+	usagesPerPair = 3
+	// usagesLogged is how many of the most common pairs found under -usages are logged.
+	usagesLogged = 10
+)
+
+// RegisterUsages finds every conversion between the analyzed types made by the Go code under
+// dir, and registers a conversions.UsagesClassifier counting them, so every report shows how
+// common each conversion is in a usages column. They're kept for the HTML type pages to show a
+// few of, and the most common pairs are logged.
+func RegisterUsages(ctx context.Context, dir string) error {
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	usages, err := conversions.FindUsages(ctx, dir, opts.Types)
+	if err != nil {
+		return err
+	}
+	err = conversions.RegisterClassifier(conversions.UsagesColumn, conversions.UsagesClassifier(usages))
+	if err != nil {
+		return errors.Wrap(err, "registering classifier")
+	}
+	// NOTE: Kept non-nil even when none were found, so the type pages say as much.
+	corpusUsages = append([]conversions.Usage{}, usages...)
+
+	if len(usages) == 0 {
+		logrus.Infof("found no conversions between the analyzed types under %s", dir)
+		return nil
+	}
+	type count struct {
+		pair string
+		n    int
+	}
+	var counts []count
+	for _, u := range usages {
+		pair := u.From + " -> " + u.To
+		if len(counts) == 0 || counts[len(counts)-1].pair != pair {
+			counts = append(counts, count{pair: pair})
+		}
+		counts[len(counts)-1].n++
+	}
+	sort.SliceStable(counts, func(i, j int) bool { return counts[i].n > counts[j].n })
+	logrus.Infof("found %d conversions of %d pairs under %s, most commonly:", len(usages), len(counts), dir)
+	for i, c := range counts {
+		if i == usagesLogged {
+			break
+		}
+		logrus.Infof("  %s: %d", c.pair, c.n)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"strings"
+)
+
+// Values runs every value -values generates of every integer and float through a conversion
+// to every other, and reports which of the conversions that can lose information actually do
+// for those values.
+func Values(ctx context.Context) error {
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	results, err := conversions.AnalyzeValueLoss(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "analyzing value loss")
+	}
+
+	p, err := ProvenanceFor(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "determining provenance")
+	}
+	for _, line := range p.Lines() {
+		logrus.Info(line)
+	}
+
+	type Pair struct {
+		From  string
+		To    string
+		Total int
+		Lost  []string
+	}
+	var pairs []*Pair
+	index := make(map[[2]string]*Pair)
+	for _, result := range results {
+		key := [2]string{result.From, result.To}
+		pair, ok := index[key]
+		if !ok {
+			pair = &Pair{From: result.From, To: result.To}
+			index[key] = pair
+			pairs = append(pairs, pair)
+		}
+		pair.Total++
+		if !result.Exact {
+			pair.Lost = append(pair.Lost, result.Value+" becomes "+result.Result)
+		}
+	}
+
+	var lossy, losing int
+	for _, pair := range pairs {
+		from, _ := conversions.Lookup(pair.From)
+		to, _ := conversions.Lookup(pair.To)
+		if conversions.Exact(from, to) {
+			continue
+		}
+		lossy++
+		if len(pair.Lost) == 0 {
+			logrus.Infof("%10s -> %-10s ✅ may lose information, but none of the %d values do", pair.From, pair.To, pair.Total)
+			continue
+		}
+		losing++
+		logrus.Infof("%10s -> %-10s ⚠️ %d of %d values lose information: %s", pair.From, pair.To, len(pair.Lost), pair.Total, strings.Join(pair.Lost, ", "))
+	}
+	logrus.Infof("%d of the %d conversions which may lose information do for these values", losing, lossy)
+
+	return nil
+}
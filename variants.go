@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Variants type checks a user package under several build tag combinations and reports every
+// conversion whose legality or involved types differ between them.
+func Variants(ctx context.Context, args []string) error {
+	var tags string
+	fs := flag.NewFlagSet("variants", flag.ContinueOnError)
+	fs.StringVar(&tags, "tags", "", `semicolon separated build tag sets, each a comma separated list of tags or a GOOS or GOARCH, e.g. "a,b;c" or "linux;windows,386"`)
+	err := fs.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "parsing flags")
+	}
+
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+
+	tagSets := conversions.ParseTagSets(tags)
+	if len(tagSets) < 2 {
+		return errors.New("at least two tag sets are needed to compare, e.g. -tags \"a;b\"")
+	}
+
+	diffs, err := conversions.AnalyzeVariants(ctx, dir, tagSets)
+	if err != nil {
+		return errors.Wrapf(err, "analyzing variants of %q", dir)
+	}
+
+	for _, d := range diffs {
+		logrus.Infof("---------- %s %s ----------\n", d.Pos, d.Expr)
+		for _, ts := range tagSets {
+			c, ok := d.Variants[ts.String()]
+			if !ok {
+				logrus.Infof("%20s: not compiled", ts)
+				continue
+			}
+			legal := "❌"
+			if c.Legal {
+				legal = "✅"
+			}
+			logrus.Infof("%20s: %s -> %s %s", ts, c.From, c.To, legal)
+		}
+	}
+
+	logrus.Infof("%d conversions differ across %d tag sets", len(diffs), len(tagSets))
+
+	return nil
+}
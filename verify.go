@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/Insulince/go-conversions/assertions"
+	"github.com/Insulince/go-conversions/helpers"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type (
+	// generator is a command whose output can be committed, and so verified.
+	generator struct {
+		// run runs the command with args.
+		run func(ctx context.Context, args []string) error
+		// defaultOut is where the command writes to when -out is not set, empty when -out is required.
+		defaultOut string
+		// file is whether -out names a single file rather than a directory.
+		file bool
+		// seed are files, relative to -out, copied from the committed output before regenerating,
+		// for commands which build on their own previous output.
+		seed []string
+	}
+)
+
+var (
+	// generators are the commands verify can check the committed output of, by name.
+	generators = map[string]generator{
+		"helpers":    {run: Helpers, defaultOut: helpers.DefaultOutputDir},
+		"assertions": {run: Assertions, defaultOut: assertions.DefaultOutputDir},
+		"examples":   {run: Examples, defaultOut: DefaultExamplesDir},
+		"export":     {run: Export, file: true},
+		"html":       {run: HTML, defaultOut: DefaultHTMLDir},
+		"site":       {run: Site, defaultOut: DefaultSiteDir, seed: []string{historyFile}},
+	}
+
+	// generatedOnRegexp matches the time stamped into generated files, which changes on every run.
+	generatedOnRegexp = regexp.MustCompile(`Generated on \d{4}-\d{2}-\d{2}T[0-9:.+\-Z]+`)
+	// generatedByRegexp matches the binary stamped into generated files, which is wherever it was
+	// built to, and so somewhere new on every go run.
+	generatedByRegexp = regexp.MustCompile(`(?m)^// Generated by .*$`)
+)
+
+// Verify regenerates the output of another command, given with its arguments, into a temporary
+// location and compares it against the output already committed at its -out, failing if any
+// file has drifted. Files are compared ignoring the time they were generated at, and the path of
+// the binary which generated them.
+func Verify(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.Errorf("usage: verify <command> [args], where command is one of %s", strings.Join(generatorNames(), ", "))
+	}
+	command := args[0]
+	g, ok := generators[command]
+	if !ok {
+		return errors.Errorf("%q can't be verified, only %s can", command, strings.Join(generatorNames(), ", "))
+	}
+
+	tmp, err := os.MkdirTemp("", "go-conversions-verify")
+	if err != nil {
+		return errors.Wrap(err, "creating temporary directory")
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	committed, regenerated, regenArgs, err := redirectOut(args[1:], g, tmp)
+	if err != nil {
+		return errors.Wrapf(err, "redirecting %s", command)
+	}
+
+	for _, name := range g.seed {
+		b, err := os.ReadFile(filepath.Join(committed, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "reading %q", name)
+		}
+		err = os.MkdirAll(regenerated, 0o755)
+		if err != nil {
+			return errors.Wrapf(err, "creating %q", regenerated)
+		}
+		err = os.WriteFile(filepath.Join(regenerated, name), b, 0o644)
+		if err != nil {
+			return errors.Wrapf(err, "seeding %q", name)
+		}
+	}
+
+	err = g.run(ctx, regenArgs)
+	if err != nil {
+		return errors.Wrapf(err, "regenerating %s", command)
+	}
+
+	var drifted int
+	if g.file {
+		drifted, err = compareFile(committed, regenerated, committed)
+	} else {
+		drifted, err = compareDirs(committed, regenerated)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "comparing %q", committed)
+	}
+	if drifted > 0 {
+		return errors.Errorf("%d generated files in %s are out of date, rerun %s", drifted, committed, command)
+	}
+
+	logrus.Infof("%s is up to date", committed)
+
+	return nil
+}
+
+// redirectOut returns where the command g, run with args, writes its committed output to, along
+// with where it is regenerated to instead under tmp and the args which regenerate it there.
+func redirectOut(args []string, g generator, tmp string) (string, string, []string, error) {
+	out := g.defaultOut
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		name := strings.TrimLeft(args[i], "-")
+		switch {
+		case args[i] == "--" || !strings.HasPrefix(args[i], "-"):
+			rest = append(rest, args[i:]...)
+			i = len(args)
+		case name == "out":
+			if i+1 >= len(args) {
+				return "", "", nil, errors.New("flag needs an argument: -out")
+			}
+			out = args[i+1]
+			i++
+		case strings.HasPrefix(name, "out="):
+			out = strings.TrimPrefix(name, "out=")
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	if out == "" {
+		return "", "", nil, errors.New("-out must be set")
+	}
+
+	regenerated := filepath.Join(tmp, filepath.Base(filepath.Clean(out)))
+	// NOTE: -out goes first, so it is parsed before any positional arguments in rest.
+	return out, regenerated, append([]string{"-out", regenerated}, rest...), nil
+}
+
+// compareDirs compares every file regenerated into regenerated against its counterpart in
+// committed, logging and counting each which is missing, changed, or no longer generated.
+func compareDirs(committed, regenerated string) (int, error) {
+	var drifted int
+	seen := make(map[string]bool)
+	err := filepath.WalkDir(regenerated, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(regenerated, path)
+		if err != nil {
+			return err
+		}
+		seen[rel] = true
+		n, err := compareFile(filepath.Join(committed, rel), path, filepath.Join(committed, rel))
+		drifted += n
+		return err
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "walking regenerated files")
+	}
+
+	err = filepath.WalkDir(committed, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(committed, path)
+		if err != nil || seen[rel] {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		// NOTE: Only files which say they're generated are stale, anything else was put there by hand.
+		if bytes.Contains(b, []byte("DO NOT EDIT")) {
+			logrus.Warnf("%s is no longer generated", path)
+			drifted++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "walking committed files")
+	}
+
+	return drifted, nil
+}
+
+// compareFile compares the committed file against the regenerated one, logging it by name and
+// returning 1 when it is missing or changed, and 0 when it is up to date.
+func compareFile(committed, regenerated, name string) (int, error) {
+	want, err := os.ReadFile(regenerated)
+	if err != nil {
+		return 0, errors.Wrapf(err, "reading regenerated %q", name)
+	}
+	got, err := os.ReadFile(committed)
+	if os.IsNotExist(err) {
+		logrus.Warnf("%s is missing", name)
+		return 1, nil
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "reading %q", name)
+	}
+
+	wantLines := strings.Split(unstamped(want), "\n")
+	gotLines := strings.Split(unstamped(got), "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g || i >= len(wantLines) || i >= len(gotLines) {
+			logrus.Warnf("%s is out of date, line %d is %q but should be %q", name, i+1, g, w)
+			return 1, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// unstamped is the generated file b without the time and binary it was generated with.
+func unstamped(b []byte) string {
+	s := generatedOnRegexp.ReplaceAllString(string(b), "Generated on")
+	return generatedByRegexp.ReplaceAllString(s, "// Generated by")
+}
+
+// generatorNames lists the names of every command in generators, sorted.
+func generatorNames() []string {
+	var names []string
+	for name := range generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
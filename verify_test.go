@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"github.com/Insulince/go-conversions/conversions"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestVerifyBinaryPath generates helpers with the binary at one path and verifies them with it at
+// another, as go run does on every run, and checks that verify doesn't take that for drift.
+func TestVerifyBinaryPath(t *testing.T) {
+	app, e, types := os.Args[0], *engine, *typeList
+	defer func() { os.Args[0], *engine, *typeList = app, e, types }()
+	*engine = conversions.EngineTypes
+	*typeList = "int8,uint8,float32,string"
+
+	ctx := context.Background()
+	out := filepath.Join(t.TempDir(), "conv")
+	os.Args[0] = filepath.Join(t.TempDir(), "go-build1", "exe", "go-conversions")
+	err := Helpers(ctx, []string{"-out", out})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Args[0] = filepath.Join(t.TempDir(), "go-build2", "exe", "go-conversions")
+	err = Verify(ctx, []string{"helpers", "-out", out})
+	if err != nil {
+		t.Fatalf("verifying with the binary somewhere else: %v", err)
+	}
+}
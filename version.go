@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+const (
+	// develVersion is the module version reported by binaries built from a local checkout.
+	develVersion = "(devel)"
+)
+
+type (
+	// Provenance records what produced a set of results, so a report can be traced back to the
+	// exact build of this program and go toolchain it came from.
+	Provenance struct {
+		// Version is the module version of this program, e.g. v1.2.3 or (devel).
+		Version string `json:"version"`
+		// Revision is the VCS revision this program was built from, if known.
+		Revision string `json:"revision,omitempty"`
+		// RevisionTime is when Revision was committed, if known.
+		RevisionTime string `json:"revisionTime,omitempty"`
+		// Modified is whether this program was built with uncommitted changes.
+		Modified bool `json:"modified,omitempty"`
+		// BuiltWith is the go version this program was compiled with.
+		BuiltWith string `json:"builtWith"`
+		// Engine is the engine the results were analyzed with.
+		Engine string `json:"engine"`
+		// AnalyzedWith is the go version whose rules the results reflect. This is the go toolchain
+		// on PATH for the build engine, BuiltWith for the types engine, and the build service for
+		// the remote engine.
+		AnalyzedWith string `json:"analyzedWith"`
+		// LangVersion is the Go language version the results were analyzed under, if not the
+		// one AnalyzedWith defaults to.
+		LangVersion string `json:"langVersion,omitempty"`
+	}
+)
+
+// Version logs the Provenance of results this program would produce as configured.
+func Version(ctx context.Context) error {
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	p, err := ProvenanceFor(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "determining provenance")
+	}
+
+	for _, line := range p.Lines() {
+		logrus.Info(line)
+	}
+
+	return nil
+}
+
+// ProvenanceFor determines the Provenance of results analyzed with opts by this build of the program.
+func ProvenanceFor(ctx context.Context, opts conversions.Options) (Provenance, error) {
+	var p Provenance
+	p.Version = develVersion
+	p.BuiltWith = runtime.Version()
+	p.Engine = opts.WithDefaults().Engine
+	p.LangVersion = opts.LangVersion
+
+	bi, ok := debug.ReadBuildInfo()
+	if ok {
+		if bi.Main.Version != "" {
+			p.Version = bi.Main.Version
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				p.Revision = s.Value
+			case "vcs.time":
+				p.RevisionTime = s.Value
+			case "vcs.modified":
+				p.Modified = s.Value == "true"
+			}
+		}
+	}
+
+	switch p.Engine {
+	case conversions.EngineTypes:
+		p.AnalyzedWith = p.BuiltWith
+	case conversions.EngineRemote:
+		// NOTE: The service doesn't tell us which toolchain it builds with.
+		p.AnalyzedWith = "the build service at " + opts.RemoteURL
+	default:
+		version, err := goEnv(ctx, "GOVERSION")
+		if err != nil {
+			return Provenance{}, errors.Wrap(err, "determining go version")
+		}
+		p.AnalyzedWith = version
+	}
+
+	return p, nil
+}
+
+// Lines describes p as a block of human readable lines.
+func (p Provenance) Lines() []string {
+	revision := p.Revision
+	if revision == "" {
+		revision = "unknown"
+	}
+	if p.RevisionTime != "" {
+		revision += " (" + p.RevisionTime + ")"
+	}
+	if p.Modified {
+		revision += " with uncommitted changes"
+	}
+
+	analyzed := fmt.Sprintf("%s using the %s engine", p.AnalyzedWith, p.Engine)
+	if p.LangVersion != "" {
+		analyzed += " under " + p.LangVersion
+	}
+
+	return []string{
+		fmt.Sprintf("go-conversions %s", p.Version),
+		fmt.Sprintf("revision:      %s", revision),
+		fmt.Sprintf("built with:    %s", p.BuiltWith),
+		fmt.Sprintf("analyzed with: %s", analyzed),
+	}
+}
+
+// Comment renders p as a block of comment lines, each starting with prefix, for stamping generated files.
+func (p Provenance) Comment(prefix string) string {
+	var b strings.Builder
+	for _, line := range p.Lines() {
+		b.WriteString(prefix)
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
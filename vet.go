@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"github.com/Insulince/go-conversions/conversions"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// RegisterVet runs go vet over every conversion which compiles, as the types engine finds them,
+// and registers a conversions.VetClassifier with what it reports, so every report annotates the
+// conversions vet flags in a vet column. It logs them as a section of their own too.
+func RegisterVet(ctx context.Context) error {
+	opts, err := Options()
+	if err != nil {
+		return errors.Wrap(err, "configuring")
+	}
+
+	// NOTE: Only which pairs compile is needed to vet them, so they're type checked in memory
+	// rather than analyzed by the configured engine, and nothing is cached or resumed.
+	typesOpts := opts
+	typesOpts.Engine = conversions.EngineTypes
+	typesOpts.StateFile = ""
+	typesOpts.CacheFile = ""
+	typesOpts.CacheStore = nil
+	typesOpts.Resume = false
+	typesOpts.Events = nil
+	m, err := conversions.Analyze(ctx, typesOpts)
+	if err != nil {
+		return errors.Wrap(err, "analyzing")
+	}
+
+	findings, err := conversions.Vet(ctx, opts, m)
+	if err != nil {
+		return err
+	}
+	err = conversions.RegisterClassifier(conversions.VetColumn, conversions.VetClassifier(findings))
+	if err != nil {
+		return errors.Wrap(err, "registering classifier")
+	}
+
+	if len(findings) == 0 {
+		logrus.Info("go vet reports none of the conversions which compile")
+		return nil
+	}
+	logrus.Infof("go vet reports %d conversions which compile:", len(findings))
+	for _, f := range findings {
+		logrus.Infof("  %s -> %s: %s: %s", f.From, f.To, f.Analyzer, f.Message)
+	}
+	return nil
+}